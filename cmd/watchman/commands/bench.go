@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+// benchCmd represents the bench command.
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Benchmark check performance",
+	Long: `Run a full check of all enabled servers several times and report
+the total check duration and per-server query latency (min, avg, max
+and p95). Use it to compare parallelism and batching settings.
+
+Notifications, the result sink and incremental state are not used.`,
+	Example: `  # Benchmark 10 checks
+  watchmen bench --runs 10
+
+  # JSON output
+  watchmen bench --output json`,
+	Hidden: true,
+	RunE:   runBench,
+}
+
+var (
+	benchRuns int
+)
+
+// benchStats is the JSON form of jobs.DurationStats in milliseconds.
+type benchStats struct {
+	Count int     `json:"count"`
+	MinMs float64 `json:"min_ms"`
+	AvgMs float64 `json:"avg_ms"`
+	MaxMs float64 `json:"max_ms"`
+	P95Ms float64 `json:"p95_ms"`
+}
+
+// benchReport is the output of the bench command.
+type benchReport struct {
+	Runs          int                   `json:"runs"`
+	Total         benchStats            `json:"total"`
+	ServerLatency map[string]benchStats `json:"server_latency"`
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+
+	benchCmd.Flags().IntVar(&benchRuns, "runs", 5, "number of checks to run")
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	if benchRuns <= 0 {
+		return withExitCode(exitConfigError, fmt.Errorf("--runs must be positive"))
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	monitor := jobs.NewMonitor(cfg)
+
+	var totals []time.Duration
+	latencies := make(map[string][]time.Duration)
+	for i := 0; i < benchRuns; i++ {
+		result, err := monitor.CheckAll(ctx)
+		if err != nil {
+			return withExitCode(exitConfigError, err)
+		}
+		totals = append(totals, result.Duration)
+		for server, ms := range result.ServerQueryLatencyMs {
+			latencies[server] = append(latencies[server], time.Duration(ms)*time.Millisecond)
+		}
+	}
+
+	report := benchReport{
+		Runs:          benchRuns,
+		Total:         toBenchStats(jobs.SummarizeDurations(totals)),
+		ServerLatency: make(map[string]benchStats, len(latencies)),
+	}
+	for server, ds := range latencies {
+		report.ServerLatency[server] = toBenchStats(jobs.SummarizeDurations(ds))
+	}
+
+	if getOutput() == OutputJSON {
+		printJSON(report)
+		return nil
+	}
+	if !isQuiet() {
+		printBenchReport(report)
+	}
+	return nil
+}
+
+// toBenchStats converts duration stats to milliseconds.
+func toBenchStats(s jobs.DurationStats) benchStats {
+	ms := func(d time.Duration) float64 {
+		return float64(d.Microseconds()) / 1000
+	}
+	return benchStats{Count: s.Count, MinMs: ms(s.Min), AvgMs: ms(s.Avg), MaxMs: ms(s.Max), P95Ms: ms(s.P95)}
+}
+
+// printBenchReport prints a bench report in human-readable format.
+func printBenchReport(report benchReport) {
+	fmt.Printf("⏱️ %d checks\n\n", report.Runs)
+	fmt.Printf("%-24s %6s %10s %10s %10s %10s\n", "", "runs", "min", "avg", "max", "p95")
+	printBenchRow("total", report.Total)
+	for _, server := range slices.Sorted(maps.Keys(report.ServerLatency)) {
+		printBenchRow(server, report.ServerLatency[server])
+	}
+}
+
+// printBenchRow prints one row of the bench table.
+func printBenchRow(name string, s benchStats) {
+	fmt.Printf("%-24s %6d %8.1fms %8.1fms %8.1fms %8.1fms\n", name, s.Count, s.MinMs, s.AvgMs, s.MaxMs, s.P95Ms)
+}
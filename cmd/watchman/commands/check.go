@@ -1,9 +1,25 @@
 package commands
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/hook"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+	"github.com/hoangtran1411/watchman/internal/metrics"
+	"github.com/hoangtran1411/watchman/internal/notification"
+	"github.com/hoangtran1411/watchman/internal/sink"
+	"github.com/hoangtran1411/watchman/internal/state"
 )
 
 // checkCmd represents the check command.
@@ -14,7 +30,44 @@ var checkCmd = &cobra.Command{
 
 Queries all configured and enabled SQL Server instances for failed 
 jobs within the lookback period. By default, shows results in 
-human-readable format. Use --output json for machine-readable output.`,
+human-readable format. Use --output json for machine-readable output.
+
+When monitoring.cache_ttl is set, a result younger than the TTL is
+reused instead of querying the servers again and is marked cached.
+
+--since-last looks back to the last successful check recorded by the
+service (monitoring.incremental), falling back to lookback_hours.
+
+--connect-timeout and --query-timeout override the connection_timeout
+and query_timeout of every server for this run, e.g. to debug a slow
+server without editing the configuration.
+
+Only failed jobs with a status in monitoring.fail_on_statuses (failed
+by default) make the check exit with 1; jobs of other reported statuses,
+such as cancelled, are informational. --fail-on overrides the list.
+
+--prom-out writes failed_jobs per server, server_up and the check time
+in Prometheus exposition format, for node_exporter's textfile collector.
+The file is replaced atomically.
+
+--json-array checks each configuration given with a repeated --config
+in turn and prints a single JSON array with one result per
+configuration, in order. A configuration that cannot be loaded or
+checked is reported by an error object in its place. The exit code is
+the highest of the individual checks. Results are never cached.
+
+--assert replaces the exit code with the outcome of simple assertions
+on the result, for health checks of Watchman itself: 0 if all hold,
+1 if any fails, each failed assertion being printed to stderr. An
+assertion compares a field with a number using >=, <=, ==, !=, > or <.
+The fields are servers (checked), available, unavailable, skipped,
+failures (failed jobs) and warnings (warn-only jobs). Quote the
+assertions, since > and < redirect output in the shell.
+
+With monitoring.long_running enabled, jobs still running past their
+threshold are listed as running long (long_running_jobs in JSON) and,
+with --notify, alerted once per execution. They never change the exit
+code.`,
 	Example: `  # Check all servers
   watchmen check
 
@@ -24,6 +77,9 @@ human-readable format. Use --output json for machine-readable output.`,
   # Check and send notification
   watchmen check --notify
 
+  # Notify with a different toast identity
+  watchmen check --notify --app-id Contoso.Watchman --icon branding\icon.png
+
   # JSON output for scripting/AI Agents
   watchmen check --output json
 
@@ -33,6 +89,33 @@ human-readable format. Use --output json for machine-readable output.`,
   # Check with custom lookback period
   watchmen check --lookback 48
 
+  # Cover everything since the service last checked
+  watchmen check --since-last
+
+  # Retry up to 3 times, 30s apart, if no server is reachable
+  watchmen check --retries 3 --retry-delay 30s
+
+  # Also fail on retried jobs; cancelled jobs stay informational
+  watchmen check --fail-on failed,retried
+
+  # Give a slow server more time
+  watchmen check --server PROD-SQL01 --connect-timeout 60s --query-timeout 5m
+
+  # Review the SQL without connecting
+  watchmen check --print-query --server PROD-SQL01
+
+  # Export metrics for node_exporter's textfile collector
+  watchmen check --prom-out C:\metrics\watchman.prom
+
+  # Check several installations and print one JSON array
+  watchmen check --json-array --config site-a.yaml --config site-b.yaml
+
+  # Health check: at least 2 servers reachable and no failed jobs
+  watchmen check --quiet --assert "available>=2,failures==0"
+
+  # Bypass the result cache (monitoring.cache_ttl)
+  watchmen check --no-cache
+
   # Quiet mode for scripts (check exit code only)
   watchmen check --quiet && echo "No failures" || echo "Has failures"`,
 	RunE: runCheck,
@@ -43,6 +126,20 @@ var (
 	checkLookback int
 	checkNotify   bool
 	checkNoColor  bool
+	checkTheme    = themeFlag(themeDefault)
+
+	checkAllowNoServers bool
+	checkRetries        int
+	checkRetryDelay     time.Duration
+	checkNoCache        bool
+	checkPrintQuery     bool
+	checkSinceLast      bool
+	checkPromOut        string
+	checkPushgateway    string
+	checkFailFast       bool
+	checkFailOn         []string
+	checkConnectTimeout time.Duration
+	checkQueryTimeout   time.Duration
 )
 
 func init() {
@@ -55,43 +152,487 @@ func init() {
 	checkCmd.Flags().BoolVar(&checkNotify, "notify", false,
 		"send notification if failures found")
 	checkCmd.Flags().BoolVar(&checkNoColor, "no-color", false,
-		"disable colored output")
+		"disable colored output (also disabled by NO_COLOR or when output is not a terminal)")
+	checkCmd.Flags().Var(&checkTheme, "theme",
+		"color theme for text output: "+strings.Join(themeNames(), ", "))
+	checkCmd.Flags().BoolVar(&checkAllowNoServers, "allow-no-servers", false,
+		"warn instead of failing with a config error when no servers are enabled")
+	checkCmd.Flags().IntVar(&checkRetries, "retries", 0,
+		"retry the check up to N times if no server is reachable (overrides scheduler.retry)")
+	checkCmd.Flags().DurationVar(&checkRetryDelay, "retry-delay", 0,
+		"delay between retries, e.g. 30s (default: from config)")
+	checkCmd.Flags().BoolVar(&checkNoCache, "no-cache", false,
+		"ignore a cached result and query the servers (see monitoring.cache_ttl)")
+	checkCmd.Flags().BoolVar(&checkPrintQuery, "print-query", false,
+		"print the SQL that would be run for each server and exit without connecting")
+	checkCmd.Flags().BoolVar(&checkSinceLast, "since-last", false,
+		"look back to the last successful scheduled check (default: from config if none recorded)")
+	checkCmd.Flags().DurationVar(&checkConnectTimeout, "connect-timeout", 0,
+		"connection timeout for every server, e.g. 60s (default: from config)")
+	checkCmd.Flags().DurationVar(&checkQueryTimeout, "query-timeout", 0,
+		"query timeout for every server, e.g. 5m (default: from config)")
+	checkCmd.Flags().StringVar(&checkPromOut, "prom-out", "",
+		"write metrics in Prometheus exposition format to this file (textfile collector)")
+	checkCmd.Flags().StringVar(&checkPushgateway, "pushgateway", "",
+		"push metrics to this Prometheus Pushgateway URL, under a job label from notification.app_id")
+	checkCmd.Flags().BoolVar(&checkFailFast, "fail-fast", false,
+		"stop at the first server with failed jobs or that cannot be checked (checks servers sequentially)")
+	checkCmd.Flags().StringSliceVar(&checkFailOn, "fail-on", nil,
+		"job statuses that fail the check with exit code 1, e.g. failed,retried (default: monitoring.fail_on_statuses)")
+	checkCmd.MarkFlagsMutuallyExclusive("lookback", "since-last")
 }
 
 func runCheck(cmd *cobra.Command, args []string) error {
-	// TODO: Implement check logic
-	// This is a placeholder that will be implemented in Phase 2
+	if checkJSONArray {
+		return runCheckArray(cmd)
+	}
 
-	if isQuiet() {
-		return nil
+	assertions, err := parseCheckAssertions()
+	if err != nil {
+		return err
+	}
+	cfg, err := loadCheckConfig()
+	if err != nil {
+		return err
+	}
+	if checkPrintQuery {
+		return printCheckQueries(cfg)
 	}
+	result, err := checkConfig(cmd, cfg)
+	if err != nil {
+		return err
+	}
+	writeCheckMetrics(cfg, result)
+	pushCheckMetrics(cmd.Context(), cfg, result)
 
-	if getOutput() == OutputJSON {
-		result := map[string]interface{}{
-			"status":              "success",
-			"message":             "Check command not yet implemented",
-			"servers_checked":     0,
-			"servers_available":   0,
-			"servers_unavailable": []string{},
-			"failed_jobs":         []interface{}{},
-			"summary":             "Not implemented",
+	if !isQuiet() {
+		if getOutput() == OutputJSON {
+			printVersionedJSONWithMeta(result, newCheckMeta(cfg, result))
+		} else {
+			printCheckResult(result, outputPalette(string(checkTheme), checkNoColor))
 		}
-		printJSON(result)
-		return nil
 	}
 
-	fmt.Println("Check command not yet implemented")
-	fmt.Println("This will query SQL Server Agent jobs for failures")
+	code := checkExitCode(cfg, result)
+	if len(assertions) > 0 {
+		code = assertExitCode(os.Stderr, assertions, result)
+	}
+	if code != exitSuccess {
+		return withExitCode(code, nil)
+	}
+	return nil
+}
 
-	if checkServer != "" {
-		fmt.Printf("Server filter: %s\n", checkServer)
+// loadCheckConfig loads the configuration and applies --lookback and --since-last.
+func loadCheckConfig() (*config.Config, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
 	}
+
 	if checkLookback > 0 {
-		fmt.Printf("Lookback: %d hours\n", checkLookback)
+		cfg.Monitoring.LookbackHours = checkLookback
+	}
+	if checkSinceLast {
+		lookback, err := sinceLastLookback(cfg, state.NewStore(state.DefaultPath()), time.Now())
+		if err != nil {
+			return nil, withExitCode(exitInternalError, err)
+		}
+		cfg.Monitoring.LookbackHours = lookback
+	}
+	return cfg, nil
+}
+
+// checkConfig checks the servers of cfg, reusing a cached result unless
+// --no-cache is given, and publishes a fresh result.
+func checkConfig(cmd *cobra.Command, cfg *config.Config) (*jobs.CheckResult, error) {
+	retry, err := checkRetryConfig(cmd, cfg.Scheduler.Retry)
+	if err != nil {
+		return nil, withExitCode(exitConfigError, err)
+	}
+	if err := applyCheckOverrides(cfg); err != nil {
+		return nil, withExitCode(exitConfigError, err)
+	}
+
+	cache := jobs.NewResultCache(jobs.DefaultCachePath(), time.Duration(cfg.Monitoring.CacheTTL)*time.Second)
+	cacheKey := jobs.CacheKey(checkServer, cfg.Monitoring.LookbackHours)
+
+	result, cached := cache.Get(cacheKey)
+	if !cached || checkNoCache {
+		ctx := cmd.Context()
+		if result, err = runFreshCheck(ctx, cfg, retry); err != nil {
+			return nil, withExitCode(exitConfigError, err)
+		}
+		cacheCheckResult(cache, cacheKey, result)
+		publishCheckResult(ctx, cfg, result)
+	}
+	return result, nil
+}
+
+// checkMeta is the run context in the check JSON output, so output pasted
+// into an issue shows how it was produced.
+type checkMeta struct {
+	ConfigPath    string `json:"config_path"`
+	Hostname      string `json:"hostname"`
+	Version       string `json:"version"`
+	LookbackHours int    `json:"lookback_hours"`
+	Cached        bool   `json:"cached"`
+
+	// Filtered is true when --server limited the check to one server.
+	Filtered     bool   `json:"filtered"`
+	ServerFilter string `json:"server_filter,omitempty"`
+}
+
+// newCheckMeta describes the check that produced result.
+func newCheckMeta(cfg *config.Config, result *jobs.CheckResult) checkMeta {
+	hostname, _ := os.Hostname()
+	return checkMeta{
+		ConfigPath:    configSource(),
+		Hostname:      hostname,
+		Version:       version,
+		LookbackHours: cfg.Monitoring.LookbackHours,
+		Cached:        result.Cached,
+		Filtered:      checkServer != "",
+		ServerFilter:  checkServer,
+	}
+}
+
+// configSource returns the absolute path of the config file or directory
+// loaded by loadConfig.
+func configSource() string {
+	path := getConfigDir()
+	if path == "" {
+		path = config.ResolvePath(getConfigFile())
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// printCheckQueries prints the queries for the servers selected by --server.
+func printCheckQueries(cfg *config.Config) error {
+	servers := cfg.GetEnabledServers()
+	if checkServer != "" {
+		servers = nil
+		for _, srv := range cfg.Servers {
+			if srv.Name == checkServer {
+				servers = append(servers, srv)
+			}
+		}
+		if len(servers) == 0 {
+			return withExitCode(exitConfigError, fmt.Errorf("server not found: %s", checkServer))
+		}
+	}
+
+	printQueries(servers, cfg.Monitoring.LookbackHours, cfg.Monitoring.QueryStatuses())
+	return nil
+}
+
+// sinceLastLookback returns the lookback hours covering the time since the last
+// successful check of the servers selected by --server, as recorded in store
+// by the service's incremental scans.
+func sinceLastLookback(cfg *config.Config, store *state.Store, now time.Time) (int, error) {
+	st, err := store.Load()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load last check times: %w", err)
+	}
+
+	return jobs.SinceLastLookback(now, st.LastChecks, checkedServers(cfg), cfg.Monitoring.LookbackHours), nil
+}
+
+// writeCheckMetrics writes the result to the --prom-out file, if given.
+// Failures are printed as warnings and never change the exit code.
+func writeCheckMetrics(cfg *config.Config, result *jobs.CheckResult) {
+	if checkPromOut == "" {
+		return
+	}
+	if err := metrics.WriteFile(checkPromOut, result, metricServers(cfg, result)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write metrics: %v\n", err)
+	}
+}
+
+// pushTimeout bounds a --pushgateway push so an unreachable gateway cannot
+// stall a scheduled task.
+const pushTimeout = 10 * time.Second
+
+// pushCheckMetrics pushes the result to the --pushgateway URL, if given.
+// Like --prom-out, failures are printed as warnings and never change the
+// exit code.
+func pushCheckMetrics(ctx context.Context, cfg *config.Config, result *jobs.CheckResult) {
+	if checkPushgateway == "" {
+		return
+	}
+	client, err := notification.NewHTTPClient(cfg.Notification, pushTimeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to push metrics: %v\n", err)
+		return
+	}
+	err = metrics.Push(ctx, client, checkPushgateway, pushgatewayJob(cfg), result, metricServers(cfg, result))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to push metrics: %v\n", err)
+	}
+}
+
+// pushgatewayJob returns the job label for pushed metrics, so several
+// Watchman installations can share a gateway by giving each its own app_id.
+func pushgatewayJob(cfg *config.Config) string {
+	if cfg.Notification.AppID != "" {
+		return cfg.Notification.AppID
+	}
+	return "watchman"
+}
+
+// metricServers returns the checked servers to export metrics for. Servers
+// skipped by --fail-fast have no result, so they are left out rather than
+// reported as up.
+func metricServers(cfg *config.Config, result *jobs.CheckResult) []string {
+	return slices.DeleteFunc(checkedServers(cfg), func(name string) bool {
+		return slices.Contains(result.ServersSkipped, name)
+	})
+}
+
+// cacheCheckResult stores a fresh result for monitoring.cache_ttl. A result
+// cut short by --fail-fast is not cached, since it lacks the skipped servers.
+func cacheCheckResult(cache *jobs.ResultCache, key string, result *jobs.CheckResult) {
+	if len(result.ServersSkipped) > 0 {
+		return
+	}
+	if err := cache.Put(key, result); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// checkedServers returns the names of the servers selected by --server.
+func checkedServers(cfg *config.Config) []string {
+	if checkServer != "" {
+		return []string{checkServer}
+	}
+	return enabledServerNames(cfg)
+}
+
+// runFreshCheck queries the servers selected by --server, retrying as configured,
+// and records the check in the event trace.
+func runFreshCheck(ctx context.Context, cfg *config.Config, retry config.RetryConfig) (*jobs.CheckResult, error) {
+	monitor := jobs.NewMonitor(cfg)
+	monitor.SetFailFast(checkFailFast)
+	servers := checkedServers(cfg)
+	check := monitor.CheckAll
+	if checkServer != "" {
+		check = func(ctx context.Context) (*jobs.CheckResult, error) {
+			return monitor.CheckServer(ctx, checkServer)
+		}
 	}
+
+	start := time.Now()
+	result, err := jobs.RetryCheck(ctx, retry, check)
+	if recErr := newCheckRecorder(cfg, servers).Record(start, result, err); recErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record check event: %v\n", recErr)
+	}
+	return result, err
+}
+
+// publishCheckResult sends notifications, if requested, posts the result to the sink
+// and runs the post-check hook. All are best effort: failures are printed as
+// warnings and never change the exit code.
+func publishCheckResult(ctx context.Context, cfg *config.Config, result *jobs.CheckResult) {
 	if checkNotify {
-		fmt.Println("Notification: enabled")
+		dispatcher := notification.NewDispatcher(cfg, state.NewStore(state.DefaultPath()))
+		defer func() { _ = dispatcher.Close() }()
+		if _, err := dispatcher.RetryOutbox(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to retry queued notifications: %v\n", err)
+		}
+		if result.HasFailedJobs() {
+			if err := dispatcher.Dispatch(ctx, result); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to send notification: %v\n", err)
+			}
+		}
+		if err := dispatcher.NotifyServersDown(ctx, result); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to send server down notification: %v\n", err)
+		}
+		if err := dispatcher.NotifyLongRunning(ctx, result); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to send long-running job notification: %v\n", err)
+		}
 	}
 
+	if resultSink, err := sink.NewHTTPSink(cfg.Monitoring.ResultSink, cfg.Notification); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	} else if resultSink != nil {
+		if err := resultSink.Post(ctx, result); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	if postCheck := hook.NewPostCheck(cfg.Monitoring.PostCheckHook); postCheck != nil {
+		if output, err := postCheck.Run(ctx, result); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			if output = strings.TrimRight(output, "\r\n"); output != "" {
+				fmt.Fprintln(os.Stderr, output)
+			}
+		}
+	}
+}
+
+// applyCheckOverrides applies the timeout, fail-fast, fail-on and notification flags to cfg.
+func applyCheckOverrides(cfg *config.Config) error {
+	if err := applyTimeoutOverrides(cfg); err != nil {
+		return err
+	}
+	if len(checkFailOn) > 0 {
+		for _, name := range checkFailOn {
+			if _, ok := config.RunStatus(name); !ok {
+				return fmt.Errorf("--fail-on: unknown status %q", name)
+			}
+		}
+		cfg.Monitoring.FailOnStatuses = checkFailOn
+	}
+	if checkFailFast {
+		// Parallel checks cannot stop early
+		cfg.Monitoring.Parallel.Enabled = false
+	}
+	return applyNotificationOverrides(cfg)
+}
+
+// applyTimeoutOverrides sets the connection and query timeouts of every server
+// from --connect-timeout and --query-timeout. Timeouts are whole seconds, so
+// a fraction of a second is rounded up.
+func applyTimeoutOverrides(cfg *config.Config) error {
+	if checkConnectTimeout < 0 || checkQueryTimeout < 0 {
+		return fmt.Errorf("--connect-timeout and --query-timeout must not be negative")
+	}
+
+	connect := timeoutSeconds(checkConnectTimeout)
+	query := timeoutSeconds(checkQueryTimeout)
+	for i := range cfg.Servers {
+		if connect > 0 {
+			cfg.Servers[i].Options.ConnectionTimeout = connect
+		}
+		if query > 0 {
+			cfg.Servers[i].Options.QueryTimeout = query
+		}
+	}
 	return nil
 }
+
+// timeoutSeconds converts d to whole seconds, rounding up.
+func timeoutSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// checkRetryConfig returns the retry policy for a manual check.
+// Manual checks run once unless --retries or --retry-delay is given; flags
+// that are not given fall back to scheduler.retry.
+func checkRetryConfig(cmd *cobra.Command, retry config.RetryConfig) (config.RetryConfig, error) {
+	retriesSet := cmd.Flags().Changed("retries")
+	delaySet := cmd.Flags().Changed("retry-delay")
+	if !retriesSet && !delaySet {
+		return config.RetryConfig{}, nil
+	}
+
+	if retriesSet {
+		if checkRetries < 0 {
+			return retry, fmt.Errorf("--retries must not be negative")
+		}
+		retry.MaxAttempts = checkRetries + 1
+	}
+	if delaySet {
+		if checkRetryDelay < 0 {
+			return retry, fmt.Errorf("--retry-delay must not be negative")
+		}
+		retry.DelaySeconds = int(checkRetryDelay.Round(time.Second) / time.Second)
+	}
+	retry.Enabled = retry.MaxAttempts > 1
+	return retry, nil
+}
+
+// checkExitCode returns the exit code for result.
+// Only failed jobs with a status in monitoring.fail_on_statuses fail the check.
+// With --allow-no-servers, having no enabled servers is only a warning.
+// With --fail-fast, any unreachable server is a connection error.
+func checkExitCode(cfg *config.Config, result *jobs.CheckResult) int {
+	if result.Status == "no_servers" && checkAllowNoServers {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", result.Summary)
+		return exitSuccess
+	}
+	code := result.GetExitCode(cfg.Monitoring.FailOnStatusCodes())
+	if checkFailFast && code == exitSuccess && len(result.ServersUnavailable) > 0 {
+		// Any unreachable server fails a fail-fast gate, not only a total outage
+		return exitConnectionError
+	}
+	return code
+}
+
+// severityTag returns the job's severity as a " [level]" suffix, or "" if
+// it has none.
+func severityTag(job database.FailedJob) string {
+	if job.Severity == "" {
+		return ""
+	}
+	return " [" + job.Severity + "]"
+}
+
+// printCheckResult prints a check result in human-readable format, colored with p.
+func printCheckResult(result *jobs.CheckResult, p palette) {
+	if result.Status == "no_servers" {
+		fmt.Println(p.paint(roleWarning, "⚠️ "+result.Summary))
+		return
+	}
+	if result.Cached {
+		fmt.Println(p.paint(roleDetail, fmt.Sprintf("(cached result from %s, use --no-cache to refresh)", result.Timestamp.Format("15:04:05"))))
+	}
+	if result.Status == "error" || result.HasFailedJobs() {
+		fmt.Println(p.paint(roleFailure, "❌ "+result.Summary))
+	} else {
+		fmt.Println(p.paint(roleSuccess, "✅ "+result.Summary))
+	}
+
+	currentServer := ""
+	for _, job := range result.FailedJobs {
+		if job.ServerName != currentServer {
+			currentServer = job.ServerName
+			fmt.Printf("\n%s\n", p.paint(roleServer, "🖥️ "+job.ServerLabel()))
+		}
+		failedAt := job.FailedAt.Format("2006-01-02 15:04:05")
+		if d := job.RunDuration(); d > 0 {
+			failedAt += fmt.Sprintf(", after %s", d)
+		}
+		if job.StepID > 0 {
+			fmt.Printf("  • %s%s [step %d: %s] (%s)\n", p.paint(roleFailure, job.JobName), severityTag(job), job.StepID, job.StepName, failedAt)
+		} else {
+			fmt.Printf("  • %s%s (%s)\n", p.paint(roleFailure, job.JobName), severityTag(job), failedAt)
+		}
+		if job.ErrorMessage != "" {
+			fmt.Printf("    %s\n", p.paint(roleDetail, job.ErrorMessage))
+		}
+	}
+
+	if len(result.WarnJobs) > 0 {
+		fmt.Printf("\n%s\n", p.paint(roleWarning, fmt.Sprintf("⚠️ %d warn-only (not notified):", len(result.WarnJobs))))
+		for _, job := range result.WarnJobs {
+			fmt.Printf("  • %s/%s [status %d] (%s)\n", job.ServerLabel(), p.paint(roleWarning, job.JobName), job.Status, job.FailedAt.Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	printLongRunningJobs(result.LongRunningJobs, p)
+
+	if len(result.ServersUnavailable) > 0 {
+		fmt.Printf("\n%s\n", p.paint(roleWarning, "⚠️ Unavailable servers: "+strings.Join(result.ServersUnavailable, ", ")))
+	}
+	for _, server := range slices.Sorted(maps.Keys(result.ServerErrors)) {
+		fmt.Printf("  %s: %s\n", server, p.paint(roleDetail, result.ServerErrors[server]))
+	}
+
+	fmt.Printf("\n%s\n", p.paint(roleDetail, fmt.Sprintf("Checked %d servers in %s", result.ServersChecked, result.Duration.Round(time.Millisecond))))
+}
+
+// printLongRunningJobs prints the jobs running past their threshold, if any.
+func printLongRunningJobs(running []database.LongRunningJob, p palette) {
+	if len(running) == 0 {
+		return
+	}
+	fmt.Printf("\n%s\n", p.paint(roleWarning, fmt.Sprintf("⏳ %d running long:", len(running))))
+	for _, job := range running {
+		fmt.Printf("  • %s/%s (started %s, running %s, threshold %dm)\n", job.ServerLabel(), p.paint(roleWarning, job.JobName),
+			job.StartedAt.Format("2006-01-02 15:04:05"), job.RunningFor().Round(time.Minute), job.ThresholdMinutes)
+	}
+}
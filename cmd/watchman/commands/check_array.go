@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// checkJSONArray is set by check --json-array.
+var checkJSONArray bool
+
+func init() {
+	checkCmd.Flags().BoolVar(&checkJSONArray, "json-array", false,
+		"check each --config file in turn and print one JSON array of the results")
+	checkCmd.MarkFlagsMutuallyExclusive("json-array", "print-query")
+	checkCmd.MarkFlagsMutuallyExclusive("json-array", "prom-out")
+	checkCmd.MarkFlagsMutuallyExclusive("json-array", "pushgateway")
+}
+
+// runCheckArray checks every configuration given with --config and prints
+// one JSON array with the result of each, in order. A configuration that
+// cannot be checked is reported by an error envelope in its place. The exit
+// code is the highest of the individual checks.
+func runCheckArray(cmd *cobra.Command) error {
+	paths := configFiles
+	if len(paths) == 0 {
+		// The default location or --config-dir
+		paths = []string{cfgFile}
+	}
+
+	last := cfgFile
+	defer func() { cfgFile = last }()
+
+	docs := make([]json.RawMessage, 0, len(paths))
+	worst := exitSuccess
+	for _, path := range paths {
+		cfgFile = path
+		doc, code := checkArrayElement(cmd)
+		docs = append(docs, doc)
+		worst = max(worst, code)
+	}
+
+	if !isQuiet() {
+		printJSON(docs)
+	}
+	if worst != exitSuccess {
+		return withExitCode(worst, nil)
+	}
+	return nil
+}
+
+// checkArrayElement checks the configuration in cfgFile and returns its
+// element of the --json-array output and its exit code.
+func checkArrayElement(cmd *cobra.Command) (json.RawMessage, int) {
+	cfg, err := loadCheckConfig()
+	if err != nil {
+		return checkArrayError(err), ExitCode(err)
+	}
+	// The cache is keyed by server and lookback only, so results of
+	// different configurations would be mixed up
+	cfg.Monitoring.CacheTTL = 0
+
+	result, err := checkConfig(cmd, cfg)
+	if err != nil {
+		return checkArrayError(err), ExitCode(err)
+	}
+	return versionedJSONWithMeta(result, newCheckMeta(cfg, result)), checkExitCode(cfg, result)
+}
+
+// checkArrayError returns the --json-array element for a configuration
+// that could not be checked.
+func checkArrayError(err error) json.RawMessage {
+	hostname, _ := os.Hostname()
+	meta := checkMeta{ConfigPath: configSource(), Hostname: hostname, Version: version}
+	return versionedJSONWithMeta(errorEnvelope{
+		Status: "error",
+		Error:  errorDetail{Code: ExitCode(err), Message: err.Error()},
+	}, meta)
+}
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck_JSONArray(t *testing.T) {
+	t.Setenv("ProgramData", t.TempDir())
+	dir := t.TempDir()
+	siteA := filepath.Join(dir, "site-a.yaml")
+	siteB := filepath.Join(dir, "site-b.yaml")
+	missing := filepath.Join(dir, "missing.yaml")
+	require.NoError(t, os.WriteFile(siteA, []byte(unreachableConfig), 0o600))
+	require.NoError(t, os.WriteFile(siteB, []byte(unreachableConfig), 0o600))
+
+	stdout, err := executeArgs(t, "check", "--json-array",
+		"--config", siteA, "--config", siteB, "--config", missing)
+	require.Error(t, err)
+	assert.Equal(t, exitConnectionError, ExitCode(err), "the highest exit code of the checks")
+
+	var docs []struct {
+		SchemaVersion int               `json:"schema_version"`
+		Meta          checkMeta         `json:"meta"`
+		Status        string            `json:"status"`
+		ServerErrors  map[string]string `json:"server_errors"`
+		Error         struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &docs), "stdout: %s", stdout)
+	require.Len(t, docs, 3, "one element per config")
+
+	for i, path := range []string{siteA, siteB} {
+		assert.Equal(t, 1, docs[i].SchemaVersion)
+		assert.Equal(t, path, docs[i].Meta.ConfigPath)
+		assert.False(t, docs[i].Meta.Cached)
+		assert.NotEmpty(t, docs[i].ServerErrors)
+	}
+
+	assert.Equal(t, missing, docs[2].Meta.ConfigPath)
+	assert.Equal(t, "error", docs[2].Status)
+	assert.Equal(t, exitConfigError, docs[2].Error.Code)
+	assert.Contains(t, docs[2].Error.Message, "config file not found")
+}
+
+func TestCheck_RepeatedConfigNeedsJSONArray(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(unreachableConfig), 0o600))
+
+	_, err := executeArgs(t, "check", "--config", path, "--config", path, "--quiet")
+	require.Error(t, err)
+	assert.Equal(t, exitConfigError, ExitCode(err))
+	assert.Contains(t, err.Error(), "--json-array")
+}
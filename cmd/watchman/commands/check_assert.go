@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+// checkAssert is set by check --assert.
+var checkAssert []string
+
+func init() {
+	checkCmd.Flags().StringSliceVar(&checkAssert, "assert", nil,
+		"exit 0 only if the result satisfies these assertions, e.g. servers>=2,failures==0")
+	checkCmd.MarkFlagsMutuallyExclusive("assert", "json-array")
+	checkCmd.MarkFlagsMutuallyExclusive("assert", "print-query")
+}
+
+// parseCheckAssertions parses the --assert assertions.
+func parseCheckAssertions() ([]jobs.Assertion, error) {
+	assertions, err := jobs.ParseAssertions(checkAssert)
+	if err != nil {
+		return nil, withExitCode(exitConfigError, fmt.Errorf("--assert: %w", err))
+	}
+	return assertions, nil
+}
+
+// assertExitCode evaluates assertions against result, printing each one
+// that fails to w, which is stderr. It returns exitFailedJobs if any
+// failed, and exitSuccess otherwise, whatever the result itself contains.
+func assertExitCode(w io.Writer, assertions []jobs.Assertion, result *jobs.CheckResult) int {
+	code := exitSuccess
+	for _, a := range assertions {
+		if ok, got := a.Eval(result); !ok {
+			fmt.Fprintf(w, "Assertion failed: %s (%s is %d)\n", a, a.Field, got)
+			code = exitFailedJobs
+		}
+	}
+	return code
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+func TestCheck_Assert(t *testing.T) {
+	t.Setenv("ProgramData", t.TempDir())
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(unreachableConfig), 0o600))
+
+	// Both servers are unreachable, which the assertions expect
+	_, err := executeArgs(t, "check", "--config", path, "--no-cache", "--quiet",
+		"--assert", "servers==2,unavailable==2,failures==0")
+	require.NoError(t, err)
+
+	_, err = executeArgs(t, "check", "--config", path, "--no-cache", "--quiet",
+		"--assert", "available>=1", "--assert", "failures==0")
+	require.Error(t, err)
+	assert.Equal(t, exitFailedJobs, ExitCode(err))
+
+	_, err = executeArgs(t, "check", "--config", path, "--assert", "servers=>1")
+	require.Error(t, err)
+	assert.Equal(t, exitConfigError, ExitCode(err))
+	assert.Contains(t, err.Error(), "--assert")
+}
+
+func TestAssertExitCode(t *testing.T) {
+	result := &jobs.CheckResult{ServersChecked: 2, ServersAvailable: 1}
+	assertions, err := jobs.ParseAssertions([]string{"servers>=2", "available==2", "failures==0", "available>1"})
+	require.NoError(t, err)
+
+	var stderr bytes.Buffer
+	assert.Equal(t, exitFailedJobs, assertExitCode(&stderr, assertions, result))
+	assert.Equal(t, "Assertion failed: available==2 (available is 1)\n"+
+		"Assertion failed: available>1 (available is 1)\n", stderr.String())
+
+	stderr.Reset()
+	assert.Equal(t, exitSuccess, assertExitCode(&stderr, assertions[:1], result))
+	assert.Empty(t, stderr.String())
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+// serverQuery is the JSON output of check --print-query for one server.
+type serverQuery struct {
+	Server       string   `json:"server"`
+	Query        string   `json:"query"`
+	Include      []string `json:"include,omitempty"`
+	Exclude      []string `json:"exclude,omitempty"`
+	IncludeSteps bool     `json:"include_steps"`
+}
+
+// printQueries prints the failed jobs query for each server without connecting.
+func printQueries(servers []config.ServerConfig, lookbackHours int, statuses []int) {
+	queries := make([]serverQuery, 0, len(servers))
+	for _, srv := range servers {
+		queries = append(queries, newServerQuery(srv, lookbackHours, statuses))
+	}
+
+	if getOutput() == OutputJSON {
+		printJSON(queries)
+		return
+	}
+	for _, q := range queries {
+		fmt.Print(formatServerQuery(q))
+	}
+}
+
+// newServerQuery returns the query and result filters for srv.
+func newServerQuery(srv config.ServerConfig, lookbackHours int, statuses []int) serverQuery {
+	return serverQuery{
+		Server:       srv.Name,
+		Query:        database.FailedJobsQuery(srv, lookbackHours, statuses),
+		Include:      srv.Jobs.Include,
+		Exclude:      srv.Jobs.Exclude,
+		IncludeSteps: srv.Jobs.IncludeSteps,
+	}
+}
+
+// formatServerQuery formats a server's query as a runnable SQL batch.
+// Settings that are applied outside the SQL are listed as comments.
+func formatServerQuery(q serverQuery) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- Server: %s\n", q.Server)
+	if len(q.Include) > 0 {
+		fmt.Fprintf(&b, "-- Include jobs matching (applied to results): %s\n", strings.Join(q.Include, ", "))
+	}
+	if len(q.Exclude) > 0 {
+		fmt.Fprintf(&b, "-- Exclude jobs matching (applied to results): %s\n", strings.Join(q.Exclude, ", "))
+	}
+	if q.IncludeSteps {
+		b.WriteString("-- Step outcomes: included\n")
+	} else {
+		b.WriteString("-- Step outcomes: excluded\n")
+	}
+	b.WriteString(strings.TrimSpace(q.Query))
+	b.WriteString("\nGO\n\n")
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+func TestFormatServerQuery(t *testing.T) {
+	tests := []struct {
+		name         string
+		server       config.ServerConfig
+		wantContains []string
+		wantMissing  []string
+	}{
+		{
+			name:   "defaults",
+			server: config.ServerConfig{Name: "SQL01"},
+			wantContains: []string{
+				"-- Server: SQL01",
+				"-- Step outcomes: excluded",
+				"h.run_status IN (0)",
+				"DATEADD(second, -172800, GETDATE())",
+				"WHERE h.step_id = 0",
+			},
+			wantMissing: []string{"@LookbackSeconds", "h.step_id > 0", "-- Include", "-- Exclude"},
+		},
+		{
+			name: "filters and steps",
+			server: config.ServerConfig{Name: "SQL02", Jobs: config.JobsFilter{
+				Include:      []string{"ETL_*"},
+				Exclude:      []string{"*_Test", "Maintenance*"},
+				IncludeSteps: true,
+			}},
+			wantContains: []string{
+				"-- Include jobs matching (applied to results): ETL_*",
+				"-- Exclude jobs matching (applied to results): *_Test, Maintenance*",
+				"-- Step outcomes: included",
+				"WHERE h.step_id > 0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatServerQuery(newServerQuery(tt.server, 48, nil))
+			for _, want := range tt.wantContains {
+				assert.Contains(t, got, want)
+			}
+			for _, missing := range tt.wantMissing {
+				assert.NotContains(t, got, missing)
+			}
+		})
+	}
+}
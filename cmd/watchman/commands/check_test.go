@@ -0,0 +1,325 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+func TestSinceLastLookback(t *testing.T) {
+	now := time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC)
+	cfg := &config.Config{
+		Servers: []config.ServerConfig{
+			{Name: "SQL01", Enabled: true},
+			{Name: "SQL02", Enabled: true},
+		},
+		Monitoring: config.MonitoringConfig{LookbackHours: 24},
+	}
+
+	tests := []struct {
+		name       string
+		lastChecks map[string]time.Time
+		server     string
+		want       int
+	}{
+		{
+			name: "oldest enabled server",
+			lastChecks: map[string]time.Time{
+				"SQL01": now.Add(-5 * time.Hour),
+				"SQL02": now.Add(-2 * time.Hour),
+			},
+			want: 5,
+		},
+		{
+			name: "selected server only",
+			lastChecks: map[string]time.Time{
+				"SQL01": now.Add(-5 * time.Hour),
+				"SQL02": now.Add(-2 * time.Hour),
+			},
+			server: "SQL02",
+			want:   2,
+		},
+		{
+			name: "longer than configured lookback",
+			lastChecks: map[string]time.Time{
+				"SQL01": now.Add(-50 * time.Hour),
+				"SQL02": now.Add(-50 * time.Hour),
+			},
+			want: 50,
+		},
+		{
+			name: "no state falls back to config",
+			want: 24,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+			if tt.lastChecks != nil {
+				require.NoError(t, store.Save(&state.State{LastChecks: tt.lastChecks}))
+			}
+			checkServer = tt.server
+			t.Cleanup(func() { checkServer = "" })
+
+			got, err := sinceLastLookback(cfg, store, now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCheck_SinceLastConflictsWithLookback(t *testing.T) {
+	t.Cleanup(func() {
+		checkLookback = 0
+		// Cobra keeps Changed across executions of the shared command tree
+		for _, name := range []string{"lookback", "since-last"} {
+			checkCmd.Flags().Lookup(name).Changed = false
+		}
+	})
+
+	_, err := executeArgs(t, "check", "--lookback", "48", "--since-last")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "none of the others can be")
+}
+
+func TestWriteCheckMetrics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watchman.prom")
+	checkPromOut = path
+	t.Cleanup(func() { checkPromOut = "" })
+
+	cfg := &config.Config{Servers: []config.ServerConfig{
+		{Name: "SQL01", Enabled: true},
+		{Name: "SQL02", Enabled: true},
+		{Name: "SQL03", Enabled: false},
+	}}
+	result := &jobs.CheckResult{
+		Timestamp:  time.Unix(1770105600, 0),
+		FailedJobs: []database.FailedJob{{ServerName: "SQL02", JobName: "Backup"}},
+	}
+
+	writeCheckMetrics(cfg, result)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `watchman_failed_jobs{server="SQL01"} 0`)
+	assert.Contains(t, string(data), `watchman_failed_jobs{server="SQL02"} 1`)
+	assert.NotContains(t, string(data), "SQL03")
+	assert.Contains(t, string(data), "watchman_last_check_timestamp_seconds 1770105600")
+}
+
+func TestPushCheckMetrics(t *testing.T) {
+	var path, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer srv.Close()
+	checkPushgateway = srv.URL
+	t.Cleanup(func() { checkPushgateway = "" })
+
+	cfg := &config.Config{
+		Servers:      []config.ServerConfig{{Name: "SQL01", Enabled: true}},
+		Notification: config.NotificationConfig{AppID: "Watchman"},
+	}
+	result := &jobs.CheckResult{Timestamp: time.Unix(1770105600, 0)}
+
+	pushCheckMetrics(context.Background(), cfg, result)
+
+	assert.Equal(t, "/metrics/job/Watchman", path)
+	assert.Contains(t, body, `watchman_server_up{server="SQL01"} 1`)
+}
+
+func TestPushCheckMetrics_UsesProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+	checkPushgateway = "http://pushgateway.example.invalid:9091"
+	t.Cleanup(func() { checkPushgateway = "" })
+
+	cfg := &config.Config{Notification: config.NotificationConfig{AppID: "Watchman", ProxyURL: proxy.URL}}
+	pushCheckMetrics(context.Background(), cfg, &jobs.CheckResult{})
+
+	assert.Equal(t, "http://pushgateway.example.invalid:9091/metrics/job/Watchman", proxied)
+}
+
+func TestPushCheckMetrics_FailureIsNonFatal(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	checkPushgateway = srv.URL
+	t.Cleanup(func() { checkPushgateway = "" })
+
+	// Must only warn; the check result and exit code are unaffected.
+	pushCheckMetrics(context.Background(), &config.Config{}, &jobs.CheckResult{})
+}
+
+func TestPushgatewayJob(t *testing.T) {
+	assert.Equal(t, "watchman", pushgatewayJob(&config.Config{}))
+	cfg := &config.Config{Notification: config.NotificationConfig{AppID: "Watchman-Prod"}}
+	assert.Equal(t, "Watchman-Prod", pushgatewayJob(cfg))
+}
+
+func TestCheckExitCode_FailFast(t *testing.T) {
+	cfg := &config.Config{}
+	partialOutage := &jobs.CheckResult{Status: "success", ServersChecked: 2, ServersAvailable: 1, ServersUnavailable: []string{"SQL02"}}
+	assert.Equal(t, exitSuccess, checkExitCode(cfg, partialOutage))
+
+	checkFailFast = true
+	t.Cleanup(func() { checkFailFast = false })
+	assert.Equal(t, exitConnectionError, checkExitCode(cfg, partialOutage))
+
+	failed := &jobs.CheckResult{
+		Status:             "failed_jobs",
+		ServersUnavailable: []string{"SQL02"},
+		FailedJobs:         []database.FailedJob{{ServerName: "SQL01", JobName: "Backup"}},
+	}
+	assert.Equal(t, exitFailedJobs, checkExitCode(cfg, failed))
+}
+
+func TestCheckExitCode_FailOnStatuses(t *testing.T) {
+	failed := database.FailedJob{ServerName: "SQL01", JobName: "Backup", Status: 0}
+	retried := database.FailedJob{ServerName: "SQL01", JobName: "ETL", Status: 2}
+	cancelled := database.FailedJob{ServerName: "SQL01", JobName: "Reindex", Status: 3}
+
+	tests := []struct {
+		name   string
+		failOn []string
+		jobs   []database.FailedJob
+		want   int
+	}{
+		{name: "failed by default", jobs: []database.FailedJob{failed, cancelled}, want: exitFailedJobs},
+		{name: "cancelled only is informational", jobs: []database.FailedJob{cancelled}, want: exitSuccess},
+		{name: "retried not in list", failOn: []string{"failed"}, jobs: []database.FailedJob{retried, cancelled}, want: exitSuccess},
+		{name: "retried in list", failOn: []string{"failed", "retried"}, jobs: []database.FailedJob{retried}, want: exitFailedJobs},
+		{name: "cancelled in list", failOn: []string{"canceled"}, jobs: []database.FailedJob{failed, cancelled}, want: exitFailedJobs},
+		{name: "failed not in list", failOn: []string{"cancelled"}, jobs: []database.FailedJob{failed}, want: exitSuccess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Monitoring: config.MonitoringConfig{FailOnStatuses: tt.failOn}}
+			result := &jobs.CheckResult{Status: "failed_jobs", FailedJobs: tt.jobs}
+			assert.Equal(t, tt.want, checkExitCode(cfg, result))
+		})
+	}
+}
+
+func TestApplyCheckOverrides_FailOn(t *testing.T) {
+	t.Cleanup(func() { checkFailOn = nil })
+
+	cfg := &config.Config{Monitoring: config.MonitoringConfig{FailOnStatuses: []string{"failed"}}}
+	checkFailOn = []string{"failed", "retried"}
+	require.NoError(t, applyCheckOverrides(cfg))
+	assert.Equal(t, []string{"failed", "retried"}, cfg.Monitoring.FailOnStatuses)
+
+	checkFailOn = []string{"aborted"}
+	assert.ErrorContains(t, applyCheckOverrides(cfg), `--fail-on: unknown status "aborted"`)
+}
+
+func TestMetricServers_OmitsSkipped(t *testing.T) {
+	cfg := &config.Config{Servers: []config.ServerConfig{
+		{Name: "SQL01", Enabled: true},
+		{Name: "SQL02", Enabled: true},
+		{Name: "SQL03", Enabled: true},
+	}}
+	result := &jobs.CheckResult{ServersSkipped: []string{"SQL03"}}
+	assert.Equal(t, []string{"SQL01", "SQL02"}, metricServers(cfg, result))
+}
+
+func TestApplyCheckOverrides_FailFastIsSequential(t *testing.T) {
+	checkFailFast = true
+	t.Cleanup(func() { checkFailFast = false })
+
+	cfg := config.DefaultConfig()
+	cfg.Monitoring.Parallel.Enabled = true
+	require.NoError(t, applyCheckOverrides(cfg))
+	assert.False(t, cfg.Monitoring.Parallel.Enabled)
+}
+
+func TestApplyTimeoutOverrides(t *testing.T) {
+	t.Cleanup(func() { checkConnectTimeout, checkQueryTimeout = 0, 0 })
+
+	newConfig := func() *config.Config {
+		return &config.Config{Servers: []config.ServerConfig{
+			{Name: "SQL01", Options: config.DBOptions{ConnectionTimeout: 30, QueryTimeout: 60}},
+			{Name: "SQL02", Options: config.DBOptions{ConnectionTimeout: 15, QueryTimeout: 30}},
+		}}
+	}
+
+	tests := []struct {
+		name        string
+		connect     time.Duration
+		query       time.Duration
+		wantConnect []int
+		wantQuery   []int
+		wantErr     bool
+	}{
+		{name: "no flags", wantConnect: []int{30, 15}, wantQuery: []int{60, 30}},
+		{name: "connect only", connect: time.Minute, wantConnect: []int{60, 60}, wantQuery: []int{60, 30}},
+		{name: "query rounds up", query: 1500 * time.Millisecond, wantConnect: []int{30, 15}, wantQuery: []int{2, 2}},
+		{name: "negative", query: -time.Second, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkConnectTimeout, checkQueryTimeout = tt.connect, tt.query
+			cfg := newConfig()
+
+			err := applyTimeoutOverrides(cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for i, srv := range cfg.Servers {
+				assert.Equal(t, tt.wantConnect[i], srv.Options.ConnectionTimeout, srv.Name)
+				assert.Equal(t, tt.wantQuery[i], srv.Options.QueryTimeout, srv.Name)
+			}
+		})
+	}
+}
+
+func TestCheck_JSONMeta(t *testing.T) {
+	t.Setenv("ProgramData", t.TempDir())
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(unreachableConfig), 0o600))
+
+	stdout, err := executeArgs(t, "check", "--config", path, "--server", "SQL01", "--no-cache", "--output", "json")
+	require.Error(t, err, "the server is unreachable")
+
+	var doc struct {
+		SchemaVersion int       `json:"schema_version"`
+		Meta          checkMeta `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &doc), "stdout: %s", stdout)
+	hostname, _ := os.Hostname()
+	assert.Equal(t, checkMeta{
+		ConfigPath:    path,
+		Hostname:      hostname,
+		Version:       version,
+		LookbackHours: 24,
+		Filtered:      true,
+		ServerFilter:  "SQL01",
+	}, doc.Meta)
+	assert.True(t, strings.HasPrefix(stdout, "{\n  \"schema_version\": 1,\n  \"meta\": {"), stdout)
+}
@@ -1,9 +1,17 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/diagnostics"
+	"github.com/hoangtran1411/watchman/internal/state"
+	"github.com/hoangtran1411/watchman/internal/warnings"
 )
 
 // configCmd represents the config command.
@@ -37,7 +45,18 @@ var configValidateCmd = &cobra.Command{
 This command will:
 1. Parse and validate the YAML configuration
 2. Test connectivity to each enabled server
-3. Report any errors or warnings`,
+3. Report any errors or warnings
+
+A server that cannot be checked is reported with the likely cause:
+  dns         Host name does not resolve
+  tcp         Port is not reachable (firewall, service stopped)
+  auth        Login failed
+  permission  Login cannot read the msdb job tables
+  timeout     Connection or query timed out
+  unknown     See the error message
+
+Exits with code 2 if the configuration is invalid and 3 if a server
+cannot be checked.`,
 	Example: `  # Validate configuration
   watchmen config validate
 
@@ -46,10 +65,31 @@ This command will:
 	RunE: runConfigValidate,
 }
 
+// configDiffCmd represents the config diff command.
+var configDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show changes since the service started or reloaded",
+	Long: `Compare the configuration on disk with the one the running service
+applied, as recorded in the state file when the service starts or
+reloads its configuration.
+
+Each changed field is printed with its old and new value. Servers are
+matched by name. Passwords, DSNs, result sink headers and credentials in
+the proxy and result sink URLs are masked, so a change to a secret value
+is not shown.`,
+	Example: `  # Show what changed since the service started or reloaded
+  watchmen config diff
+
+  # JSON output
+  watchmen config diff --output json`,
+	RunE: runConfigDiff,
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(configShowCmd)
 	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configDiffCmd)
 }
 
 func runConfigShow(cmd *cobra.Command, args []string) error {
@@ -61,7 +101,7 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 			"message": "Config show not yet implemented",
 			"config":  map[string]interface{}{},
 		}
-		printJSON(result)
+		printVersionedJSON(result)
 		return nil
 	}
 
@@ -72,23 +112,157 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// serverValidation is the connectivity result of one server.
+type serverValidation struct {
+	Name     string               `json:"name"`
+	OK       bool                 `json:"ok"`
+	Step     diagnostics.Step     `json:"step,omitempty"`
+	Category diagnostics.Category `json:"category,omitempty"`
+	Error    string               `json:"error,omitempty"`
+}
+
+// validationResult is the output of config validate.
+type validationResult struct {
+	Valid    bool               `json:"valid"`
+	Servers  []serverValidation `json:"servers"`
+	Warnings []string           `json:"warnings"`
+	Errors   []string           `json:"errors"`
+}
+
 func runConfigValidate(cmd *cobra.Command, args []string) error {
-	// TODO: Implement config validation logic
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	matrix := diagnostics.NewRunner().BuildMatrix(cmd.Context(), cfg.GetEnabledServers())
+	result := newValidationResult(matrix)
+	// Report problems found while loading, such as unknown keys, with the others
+	for _, w := range warnings.Take() {
+		result.Warnings = append(result.Warnings, w.String())
+	}
 
 	if getOutput() == OutputJSON {
-		result := map[string]interface{}{
-			"valid":    true,
-			"message":  "Config validation not yet implemented",
-			"servers":  []interface{}{},
-			"warnings": []string{},
-			"errors":   []string{},
+		printVersionedJSON(result)
+	} else if !isQuiet() {
+		writeValidationResult(cmd.OutOrStdout(), result)
+	}
+
+	if !result.Valid {
+		return withExitCode(exitConnectionError, nil)
+	}
+	return nil
+}
+
+// newValidationResult summarizes the connectivity of each server in m.
+func newValidationResult(m *diagnostics.Matrix) *validationResult {
+	result := &validationResult{
+		Valid:    true,
+		Servers:  make([]serverValidation, 0, len(m.Rows)),
+		Warnings: []string{},
+		Errors:   []string{},
+	}
+	if len(m.Rows) == 0 {
+		result.Warnings = append(result.Warnings, "no servers are enabled")
+	}
+
+	for _, row := range m.Rows {
+		sv := serverValidation{Name: row.Server, OK: row.OK()}
+		for _, step := range row.Steps {
+			if !step.OK && !step.Skipped {
+				sv.Step, sv.Category, sv.Error = step.Step, step.Category, step.Error
+				break
+			}
+		}
+		if !sv.OK {
+			result.Valid = false
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s: %s", sv.Name, sv.Category, sv.Error))
 		}
-		printJSON(result)
+		result.Servers = append(result.Servers, sv)
+	}
+	return result
+}
+
+// writeValidationResult prints one line per server followed by any warnings.
+func writeValidationResult(w io.Writer, r *validationResult) {
+	_, _ = fmt.Fprintln(w, "✓ Configuration is valid")
+	for _, sv := range r.Servers {
+		if sv.OK {
+			_, _ = fmt.Fprintf(w, "✓ %s\n", sv.Name)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "✗ %s [%s] %s: %s\n", sv.Name, sv.Category, sv.Step, sv.Error)
+	}
+	for _, warning := range r.Warnings {
+		_, _ = fmt.Fprintf(w, "Warning: %s\n", warning)
+	}
+}
+
+func runConfigDiff(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	st, err := state.NewStore(state.DefaultPath()).Load()
+	if err != nil {
+		return withExitCode(exitInternalError, err)
+	}
+	if st.AppliedConfig == nil {
+		return errors.New("no applied configuration recorded; start the service to record one")
+	}
+
+	changes := config.Diff(st.AppliedConfig, cfg.Masked())
+
+	if getOutput() == OutputJSON {
+		if changes == nil {
+			changes = []config.Change{}
+		}
+		printVersionedJSON(map[string]interface{}{
+			"applied_at": st.AppliedAt,
+			"changed":    len(changes) > 0,
+			"changes":    changes,
+		})
 		return nil
 	}
 
 	if !isQuiet() {
-		fmt.Println("Config validation not yet implemented")
+		writeConfigDiff(cmd.OutOrStdout(), st.AppliedAt, changes)
 	}
 	return nil
 }
+
+// writeConfigDiff prints changes as one line per field: "+" for added,
+// "-" for removed and "~" for changed values.
+func writeConfigDiff(w io.Writer, appliedAt time.Time, changes []config.Change) {
+	since := "the service started"
+	if !appliedAt.IsZero() {
+		since = appliedAt.Format("2006-01-02 15:04:05")
+	}
+
+	if len(changes) == 0 {
+		_, _ = fmt.Fprintf(w, "No changes since %s\n", since)
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "%d change(s) since %s:\n", len(changes), since)
+	for _, c := range changes {
+		switch c.Kind {
+		case config.ChangeAdded:
+			_, _ = fmt.Fprintf(w, "  + %s%s\n", c.Path, diffValue(c.New))
+		case config.ChangeRemoved:
+			_, _ = fmt.Fprintf(w, "  - %s%s\n", c.Path, diffValue(c.Old))
+		default:
+			_, _ = fmt.Fprintf(w, "  ~ %s: %s -> %s\n", c.Path, c.Old, c.New)
+		}
+	}
+}
+
+// diffValue formats the value of an added or removed field. List entries
+// such as servers have no value of their own.
+func diffValue(v string) string {
+	if v == "" {
+		return ""
+	}
+	return ": " + v
+}
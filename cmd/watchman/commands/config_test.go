@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/diagnostics"
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+func TestWriteConfigDiff(t *testing.T) {
+	at := time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	writeConfigDiff(&buf, at, []config.Change{
+		{Path: "scheduler.check_times", Kind: config.ChangeChanged, Old: "[08:00]", New: "[08:00, 17:00]"},
+		{Path: "servers[QA]", Kind: config.ChangeAdded},
+		{Path: "servers[DR]", Kind: config.ChangeRemoved},
+		{Path: "monitoring.result_sink.headers.X-Team", Kind: config.ChangeAdded, New: "dba"},
+	})
+
+	assert.Equal(t, `4 change(s) since 2026-02-03 08:00:00:
+  ~ scheduler.check_times: [08:00] -> [08:00, 17:00]
+  + servers[QA]
+  - servers[DR]
+  + monitoring.result_sink.headers.X-Team: dba
+`, buf.String())
+
+	buf.Reset()
+	writeConfigDiff(&buf, at, nil)
+	assert.Equal(t, "No changes since 2026-02-03 08:00:00\n", buf.String())
+}
+
+func TestConfigDiff(t *testing.T) {
+	programData := t.TempDir()
+	t.Setenv("ProgramData", programData)
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(testConfig), 0o600))
+
+	_, err := executeArgs(t, "config", "diff", "--config", configPath)
+	require.Error(t, err, "nothing recorded yet")
+
+	applied, err := config.Load(configPath)
+	require.NoError(t, err)
+	applied.Servers[0].Port = 14330
+	applied.Servers = append(applied.Servers, config.ServerConfig{Name: "OLD", Host: "old", Port: 1433})
+	require.NoError(t, state.NewStore(state.DefaultPath()).Update(func(st *state.State) error {
+		st.RecordAppliedConfig(applied, time.Now())
+		return nil
+	}))
+
+	stdout, err := executeArgs(t, "config", "diff", "--config", configPath, "--output", "json")
+	require.NoError(t, err)
+
+	var result struct {
+		Changed bool            `json:"changed"`
+		Changes []config.Change `json:"changes"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.True(t, result.Changed)
+	assert.Equal(t, []config.Change{
+		{Path: "servers[OLD]", Kind: config.ChangeRemoved},
+		{Path: "servers[SQL01].port", Kind: config.ChangeChanged, Old: "14330", New: "1433"},
+	}, result.Changes)
+}
+
+func TestConfigValidate_CategorizesFailures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(unreachableConfig), 0o600))
+
+	stdout, err := executeArgs(t, "config", "validate", "--config", path, "--output", "json")
+	require.Error(t, err)
+	assert.Equal(t, exitConnectionError, ExitCode(err))
+
+	var result validationResult
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.False(t, result.Valid)
+	require.Len(t, result.Servers, 2)
+	for _, sv := range result.Servers {
+		assert.False(t, sv.OK)
+		assert.Equal(t, diagnostics.StepTCP, sv.Step)
+		assert.Equal(t, diagnostics.CategoryTCP, sv.Category)
+		assert.NotEmpty(t, sv.Error)
+	}
+	assert.Len(t, result.Errors, 2)
+}
+
+func TestNewValidationResult(t *testing.T) {
+	m := &diagnostics.Matrix{Rows: []diagnostics.ServerRow{
+		{Server: "SQL01", Steps: []diagnostics.StepResult{{Step: diagnostics.StepDNS, OK: true}, {Step: diagnostics.StepPing, OK: true}}},
+		{Server: "SQL02", Steps: []diagnostics.StepResult{
+			{Step: diagnostics.StepDNS, OK: true},
+			{Step: diagnostics.StepPing, Error: "login failed", Category: diagnostics.CategoryAuth},
+			{Step: diagnostics.StepMsdb, Skipped: true},
+		}},
+	}}
+
+	result := newValidationResult(m)
+	assert.False(t, result.Valid)
+	assert.Equal(t, serverValidation{Name: "SQL01", OK: true}, result.Servers[0])
+	assert.Equal(t, serverValidation{
+		Name: "SQL02", Step: diagnostics.StepPing, Category: diagnostics.CategoryAuth, Error: "login failed",
+	}, result.Servers[1])
+	assert.Equal(t, []string{"SQL02: auth: login failed"}, result.Errors)
+
+	var buf bytes.Buffer
+	writeValidationResult(&buf, result)
+	assert.Equal(t, "✓ Configuration is valid\n✓ SQL01\n✗ SQL02 [auth] ping: login failed\n", buf.String())
+
+	empty := newValidationResult(&diagnostics.Matrix{})
+	assert.True(t, empty.Valid)
+	assert.Equal(t, []string{"no servers are enabled"}, empty.Warnings)
+}
@@ -0,0 +1,336 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/events"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+	"github.com/hoangtran1411/watchman/internal/notification"
+)
+
+// debugCmd represents the debug command.
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Troubleshooting tools",
+	Long:  `Tools for diagnosing Watchmen behavior.`,
+}
+
+// debugEventsCmd represents the debug events command.
+var debugEventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Show recent check events",
+	Long: `Show recent checks recorded in the event trace
+(%ProgramData%\Watchman\events.jsonl).
+
+Each event records a check's lookback and servers along with the
+resulting counts, durations and errors. Enable recording with
+logging.check_events.enabled. Attach the JSON output when reporting
+an intermittent issue such as a missed failure.`,
+	Example: `  # Show recorded events
+  watchmen debug events
+
+  # Show the last 10 events
+  watchmen debug events --limit 10
+
+  # JSON output for bug reports
+  watchmen debug events --output json`,
+	RunE: runDebugEvents,
+}
+
+// debugServerCmd represents the debug server command.
+var debugServerCmd = &cobra.Command{
+	Use:   "server NAME",
+	Short: "Trace one server's check stage by stage",
+	Long: `Run the full check pipeline for one server and print every stage
+with its timing and the data it produced:
+
+  connect      Open a connection (connection string, driver)
+  ping         Log in (authentication)
+  permissions  Read the msdb job tables
+  query        Query failed jobs in the lookback window (raw rows)
+  filter       Apply consecutive_failures and warn_statuses
+  notify       Build the notifications that would be sent (nothing is shown)
+
+Once a stage fails, the remaining stages are skipped. No state is
+recorded, so the next scheduled check is unaffected.`,
+	Example: `  # Trace a server
+  watchmen debug server SQL01
+
+  # JSON output for bug reports
+  watchmen debug server SQL01 --output json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDebugServer,
+}
+
+// debugConnstrCmd represents the debug connstr command.
+var debugConnstrCmd = &cobra.Command{
+	Use:   "connstr NAME",
+	Short: "Print the connection string used for a server",
+	Long: `Print the connection string Watchman would use for the configured
+server, with the password replaced by ***. Nothing is connected.
+
+Substitute the password and pass the string to sqlcmd or another tool
+to reproduce a driver problem outside Watchman.`,
+	Example: `  # Show the connection string of a server
+  watchmen debug connstr SQL01`,
+	Args:   cobra.ExactArgs(1),
+	Hidden: true,
+	RunE:   runDebugConnstr,
+}
+
+var (
+	debugEventsLimit int
+)
+
+func init() {
+	rootCmd.AddCommand(debugCmd)
+	debugCmd.AddCommand(debugEventsCmd)
+	debugCmd.AddCommand(debugServerCmd)
+	debugCmd.AddCommand(debugConnstrCmd)
+
+	debugEventsCmd.Flags().IntVar(&debugEventsLimit, "limit", 0,
+		"show only the most recent N events (default: all)")
+}
+
+func runDebugEvents(cmd *cobra.Command, args []string) error {
+	evs, err := events.NewLog(events.DefaultPath(), 0).Recent(debugEventsLimit)
+	if err != nil {
+		return withExitCode(exitInternalError, err)
+	}
+
+	if getOutput() == OutputJSON {
+		printJSON(evs)
+		return nil
+	}
+	if isQuiet() {
+		return nil
+	}
+
+	if len(evs) == 0 {
+		fmt.Println("No check events recorded")
+		return nil
+	}
+	for _, ev := range evs {
+		line := fmt.Sprintf("%s  lookback=%dh servers=%d available=%d failed_jobs=%d duration=%s",
+			ev.Time.Format("2006-01-02 15:04:05"), ev.LookbackHours, len(ev.Servers),
+			ev.ServersAvailable, ev.FailedJobs, time.Duration(ev.DurationMs)*time.Millisecond)
+		if len(ev.ServersUnavailable) > 0 {
+			line += " unavailable=" + strings.Join(ev.ServersUnavailable, ",")
+		}
+		if ev.Error != "" {
+			line += fmt.Sprintf(" error=%q", ev.Error)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// connstrResult is the JSON output of debug connstr.
+type connstrResult struct {
+	Server           string `json:"server"`
+	ConnectionString string `json:"connection_string"`
+}
+
+func runDebugConnstr(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	connStr, err := debugConnstr(cfg, args[0])
+	if err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+
+	if getOutput() == OutputJSON {
+		printJSON(connstrResult{Server: args[0], ConnectionString: connStr})
+		return nil
+	}
+	_, _ = fmt.Fprintln(cmd.OutOrStdout(), connStr)
+	return nil
+}
+
+// debugConnstr returns the redacted connection string of the named server.
+func debugConnstr(cfg *config.Config, name string) (string, error) {
+	for _, server := range cfg.Servers {
+		if server.Name == name {
+			return database.RedactedConnectionString(server), nil
+		}
+	}
+	return "", fmt.Errorf("server not found: %s", name)
+}
+
+// stageNotify is the simulated notification stage that follows the check stages.
+const stageNotify jobs.Stage = "notify"
+
+// serverTracer traces a single server check.
+type serverTracer interface {
+	TraceServer(ctx context.Context, serverName string) (*jobs.ServerTrace, error)
+}
+
+// serverDebugReport is the output of debug server.
+type serverDebugReport struct {
+	*jobs.ServerTrace
+
+	// Notification is what would be sent for the filtered jobs, nil if an
+	// earlier stage failed.
+	Notification *notification.Simulation `json:"notification"`
+}
+
+func runDebugServer(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	report, err := debugServer(cmd.Context(), cfg, jobs.NewMonitor(cfg), args[0])
+	if err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+
+	if getOutput() == OutputJSON {
+		printJSON(report)
+	} else if !isQuiet() {
+		writeServerDebugReport(cmd.OutOrStdout(), report)
+	}
+
+	if !report.OK() {
+		return withExitCode(exitConnectionError, nil)
+	}
+	return nil
+}
+
+// debugServer traces the named server and simulates the notification for
+// the jobs that survive filtering.
+func debugServer(ctx context.Context, cfg *config.Config, tracer serverTracer, name string) (*serverDebugReport, error) {
+	trace, err := tracer.TraceServer(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	report := &serverDebugReport{ServerTrace: trace}
+
+	if !trace.OK() {
+		trace.Stages = append(trace.Stages, jobs.StageResult{Stage: stageNotify, Skipped: true})
+		return report, nil
+	}
+
+	start := time.Now()
+	// The trace applied consecutive_failures with the real job history
+	simCfg := *cfg
+	simCfg.Monitoring.ConsecutiveFailures = 0
+	sim, err := notification.Simulate(&simCfg, currentState(), trace.FailedJobs)
+	stage := jobs.StageResult{Stage: stageNotify, OK: err == nil, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		stage.Error = err.Error()
+	}
+	trace.Stages = append(trace.Stages, stage)
+	report.Notification = sim
+	return report, nil
+}
+
+// writeServerDebugReport prints each stage followed by the data it produced.
+func writeServerDebugReport(w io.Writer, r *serverDebugReport) {
+	_, _ = fmt.Fprintf(w, "Server %s, lookback %dh\n", r.Server, r.LookbackHours)
+	for _, s := range r.Stages {
+		_, _ = fmt.Fprintf(w, "\n%-12s %s\n", s.Stage, formatStage(s))
+		if s.Error != "" {
+			_, _ = fmt.Fprintf(w, "  error: %s\n", s.Error)
+		}
+		if !s.OK {
+			continue
+		}
+		switch s.Stage {
+		case jobs.StageQuery:
+			writeDebugJobs(w, "rows", r.Rows)
+		case jobs.StageFilter:
+			writeDebugJobs(w, "failed", r.FailedJobs)
+			writeDebugJobs(w, "warn", r.WarnJobs)
+		case stageNotify:
+			writeDebugNotifications(w, r.Notification)
+		}
+	}
+}
+
+// formatStage formats a stage outcome with its timing.
+func formatStage(s jobs.StageResult) string {
+	switch {
+	case s.Skipped:
+		return "- skipped"
+	case s.OK:
+		return fmt.Sprintf("✓ %s", s.Duration())
+	default:
+		return fmt.Sprintf("✗ %s", s.Duration())
+	}
+}
+
+// writeDebugJobs prints a labelled list of jobs.
+func writeDebugJobs(w io.Writer, label string, list []database.FailedJob) {
+	_, _ = fmt.Fprintf(w, "  %s: %d\n", label, len(list))
+	for _, job := range list {
+		_, _ = fmt.Fprintf(w, "    • %s [status %d, step %d] %s\n",
+			job.JobName, job.Status, job.StepID, job.FailedAt.Format("2006-01-02 15:04:05"))
+	}
+}
+
+// writeDebugNotifications prints the notifications that would be sent.
+func writeDebugNotifications(w io.Writer, sim *notification.Simulation) {
+	if sim != nil && len(sim.Deferred) > 0 {
+		_, _ = fmt.Fprintf(w, "  %d failed jobs would be queued\n", len(sim.Deferred))
+	}
+	if sim == nil || len(sim.Notifications) == 0 {
+		_, _ = fmt.Fprintln(w, "  no notifications would be sent")
+		return
+	}
+	for _, n := range sim.Notifications {
+		_, _ = fmt.Fprintf(w, "  [%s] %s\n", n.Backend, n.Title)
+		for _, line := range strings.Split(n.Message, "\n") {
+			_, _ = fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+}
+
+// checkRecorder records checks in the event trace.
+// A nil recorder records nothing.
+type checkRecorder struct {
+	log           *events.Log
+	lookbackHours int
+	servers       []string
+}
+
+// newCheckRecorder returns a recorder for checks of servers, or nil if the event trace is disabled.
+func newCheckRecorder(cfg *config.Config, servers []string) *checkRecorder {
+	if !cfg.Logging.CheckEvents.Enabled {
+		return nil
+	}
+	return &checkRecorder{
+		log:           events.NewLog(events.DefaultPath(), cfg.Logging.CheckEvents.MaxEntries),
+		lookbackHours: cfg.Monitoring.LookbackHours,
+		servers:       servers,
+	}
+}
+
+// Record appends a check that started at start to the event trace.
+func (r *checkRecorder) Record(start time.Time, result *jobs.CheckResult, checkErr error) error {
+	if r == nil {
+		return nil
+	}
+	return r.log.Append(events.NewEvent(start, r.lookbackHours, r.servers, result, checkErr))
+}
+
+// enabledServerNames returns the names of the enabled servers.
+func enabledServerNames(cfg *config.Config) []string {
+	servers := cfg.GetEnabledServers()
+	names := make([]string, 0, len(servers))
+	for _, server := range servers {
+		names = append(names, server.Name)
+	}
+	return names
+}
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+// fakeTracer returns a fixed trace.
+type fakeTracer struct {
+	trace *jobs.ServerTrace
+	err   error
+}
+
+func (f fakeTracer) TraceServer(context.Context, string) (*jobs.ServerTrace, error) {
+	return f.trace, f.err
+}
+
+func debugTrace(failedStage jobs.Stage) *jobs.ServerTrace {
+	failedAt := time.Date(2026, 2, 3, 8, 30, 0, 0, time.UTC)
+	trace := &jobs.ServerTrace{
+		Server:        "SQL01",
+		LookbackHours: 24,
+		Rows: []database.FailedJob{
+			{ServerName: "SQL01", JobName: "Backup", FailedAt: failedAt},
+			{ServerName: "SQL01", JobName: "Flaky", FailedAt: failedAt},
+		},
+		FailedJobs: []database.FailedJob{{ServerName: "SQL01", JobName: "Backup", FailedAt: failedAt}},
+	}
+	failed := false
+	for _, stage := range jobs.TraceStages {
+		switch {
+		case failed:
+			trace.Stages = append(trace.Stages, jobs.StageResult{Stage: stage, Skipped: true})
+		case stage == failedStage:
+			trace.Stages = append(trace.Stages, jobs.StageResult{Stage: stage, Error: "login failed"})
+			failed = true
+		default:
+			trace.Stages = append(trace.Stages, jobs.StageResult{Stage: stage, OK: true, DurationMs: 1})
+		}
+	}
+	return trace
+}
+
+func debugConfig() *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Servers = []config.ServerConfig{{Name: "SQL01", Enabled: true}}
+	cfg.Notification.Grouping.Enabled = false
+	return cfg
+}
+
+func TestDebugServer(t *testing.T) {
+	useTempCacheDir(t)
+	report, err := debugServer(context.Background(), debugConfig(), fakeTracer{trace: debugTrace("")}, "SQL01")
+	require.NoError(t, err)
+
+	require.Len(t, report.Stages, len(jobs.TraceStages)+1)
+	notify := report.Stages[len(report.Stages)-1]
+	assert.Equal(t, stageNotify, notify.Stage)
+	assert.True(t, notify.OK)
+	require.NotNil(t, report.Notification)
+	require.Len(t, report.Notification.Notifications, 1)
+
+	var buf bytes.Buffer
+	writeServerDebugReport(&buf, report)
+	out := buf.String()
+	for _, stage := range append(jobs.TraceStages, stageNotify) {
+		assert.Contains(t, out, string(stage))
+	}
+	assert.Contains(t, out, "rows: 2")
+	assert.Contains(t, out, "failed: 1")
+	assert.Contains(t, out, "• Flaky")
+	assert.Contains(t, out, "Job Failed on SQL01")
+}
+
+func TestDebugServer_StageFailure(t *testing.T) {
+	report, err := debugServer(context.Background(), debugConfig(), fakeTracer{trace: debugTrace(jobs.StagePing)}, "SQL01")
+	require.NoError(t, err)
+
+	assert.False(t, report.OK())
+	assert.True(t, report.Stages[len(report.Stages)-1].Skipped, "notify is skipped after a failure")
+	assert.Nil(t, report.Notification)
+
+	var buf bytes.Buffer
+	writeServerDebugReport(&buf, report)
+	assert.Contains(t, buf.String(), "error: login failed")
+	assert.Contains(t, buf.String(), "- skipped")
+	assert.NotContains(t, buf.String(), "rows:")
+}
+
+func TestDebugServer_UnknownServer(t *testing.T) {
+	_, err := debugServer(context.Background(), debugConfig(), fakeTracer{err: errors.New("server not found: NOPE")}, "NOPE")
+	assert.Error(t, err)
+}
+
+func TestDebugConnstr(t *testing.T) {
+	cfg := &config.Config{Servers: []config.ServerConfig{{
+		Name:     "SQL01",
+		Host:     "sql01",
+		Port:     1433,
+		Database: "msdb",
+		Auth:     config.AuthConfig{Type: "sql", Username: "watchman", Password: "s3cret"},
+		Options:  config.DBOptions{TrustServerCertificate: true, ConnectionTimeout: 30},
+	}}}
+
+	connStr, err := debugConnstr(cfg, "SQL01")
+	require.NoError(t, err)
+	assert.NotContains(t, connStr, "s3cret")
+	assert.Contains(t, connStr, "watchman:***@sql01:1433")
+	assert.Contains(t, connStr, "TrustServerCertificate=true")
+	assert.Contains(t, connStr, "connection+timeout=30")
+
+	_, err = debugConnstr(cfg, "NOPE")
+	assert.EqualError(t, err, "server not found: NOPE")
+}
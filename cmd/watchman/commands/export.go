@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/export"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+// exportCmd represents the export command.
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export recent failures to a CSV or JSON file",
+	Long: `Export failed SQL Server Agent jobs to a file for reporting.
+
+Runs a check across all enabled servers and writes the full failure
+detail (status, duration, error message) to the --file path. Nothing
+is sent as a notification.`,
+	Example: `  # Weekly report as CSV
+  watchmen export --format csv --lookback 168h --file report.csv
+
+  # Full result as JSON
+  watchmen export --format json -f report.json`,
+	RunE: runExport,
+}
+
+var (
+	exportFormat   string
+	exportLookback time.Duration
+	exportFile     string
+)
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVar(&exportFormat, "format", "csv",
+		"export format: csv, json")
+	exportCmd.Flags().DurationVar(&exportLookback, "lookback", 0,
+		"how far to look back for failures, e.g. 168h (default: from config)")
+	exportCmd.Flags().StringVarP(&exportFile, "file", "f", "",
+		"output file path (required)")
+	_ = exportCmd.MarkFlagRequired("file")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	format, err := export.ParseFormat(exportFormat)
+	if err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if exportLookback > 0 {
+		cfg.Monitoring.LookbackHours = int(math.Ceil(exportLookback.Hours()))
+	}
+
+	result, err := export.ToFile(cmd.Context(), jobs.NewMonitor(cfg), format, exportFile)
+	if err != nil {
+		return withExitCode(exitInternalError, err)
+	}
+
+	if len(result.ServersUnavailable) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: unavailable servers: %s\n", strings.Join(result.ServersUnavailable, ", "))
+	}
+
+	if !isQuiet() {
+		fmt.Printf("Exported %d failed jobs to %s\n", len(result.FailedJobs), exportFile)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCmd_FileFlag(t *testing.T) {
+	file := exportCmd.Flags().Lookup("file")
+	require.NotNil(t, file)
+	assert.Equal(t, "f", file.Shorthand)
+	assert.Nil(t, exportCmd.LocalNonPersistentFlags().Lookup("output"), "the global --output format flag must not be shadowed")
+
+	// -o is the global output format, so the file is still missing
+	_, err := executeArgs(t, "export", "-o", "json")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `required flag(s) "file" not set`)
+}
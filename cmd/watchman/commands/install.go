@@ -13,7 +13,8 @@ var installCmd = &cobra.Command{
 	Long: `Install Watchmen as a Windows Service.
 
 The service will be configured to start automatically (delayed start)
-and will run under the LocalSystem account.`,
+and will run under the LocalSystem account. The notification app ID
+is registered machine-wide (see register-toast).`,
 	Example: `  # Install with default settings
   watchmen install
 
@@ -37,7 +38,17 @@ func init() {
 }
 
 func runInstall(cmd *cobra.Command, args []string) error {
-	// TODO: Implement install logic
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	// Toasts from the service must carry a registered app ID to be shown reliably
+	if _, err := registerToast(cfg, true); err != nil {
+		return withExitCode(exitInternalError, err)
+	}
+
+	// TODO: Implement service install logic
 	if !isQuiet() {
 		fmt.Println("Install command not yet implemented")
 		fmt.Println("Use scripts/install.ps1 for now")
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/notification"
+)
+
+// muteCmd represents the mute command.
+var muteCmd = &cobra.Command{
+	Use:   "mute",
+	Short: "Suppress all notifications",
+	Long: `Suppress all notifications until 'watchman unmute' is run.
+
+Creates the mute file (%ProgramData%\Watchman\mute). While it exists,
+the service keeps checking and logging failed jobs but sends no
+notifications. Useful during maintenance windows.`,
+	Example: `  # Mute during maintenance
+  watchmen mute
+
+  # Resume notifications
+  watchmen unmute`,
+	RunE: runMute,
+}
+
+// unmuteCmd represents the unmute command.
+var unmuteCmd = &cobra.Command{
+	Use:   "unmute",
+	Short: "Resume notifications",
+	Long:  `Resume notifications by removing the mute file created by 'watchman mute'.`,
+	Example: `  # Resume notifications
+  watchmen unmute`,
+	RunE: runUnmute,
+}
+
+// muteStatus is the JSON output of the mute and unmute commands.
+type muteStatus struct {
+	Muted bool   `json:"muted"`
+	Path  string `json:"path"`
+}
+
+func init() {
+	rootCmd.AddCommand(muteCmd)
+	rootCmd.AddCommand(unmuteCmd)
+}
+
+func runMute(cmd *cobra.Command, args []string) error {
+	path := notification.DefaultMutePath()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return withExitCode(exitInternalError, fmt.Errorf("failed to create mute directory: %w", err))
+	}
+	content := []byte("muted at " + time.Now().Format(time.RFC3339) + "\n")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return withExitCode(exitInternalError, fmt.Errorf("failed to create mute file: %w", err))
+	}
+
+	printMuteStatus(muteStatus{Muted: true, Path: path})
+	return nil
+}
+
+func runUnmute(cmd *cobra.Command, args []string) error {
+	path := notification.DefaultMutePath()
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return withExitCode(exitInternalError, fmt.Errorf("failed to remove mute file: %w", err))
+	}
+
+	printMuteStatus(muteStatus{Muted: false, Path: path})
+	return nil
+}
+
+// printMuteStatus prints the mute state.
+func printMuteStatus(status muteStatus) {
+	if getOutput() == OutputJSON {
+		printJSON(status)
+		return
+	}
+	if isQuiet() {
+		return
+	}
+	if status.Muted {
+		fmt.Println("🔇 Notifications muted")
+	} else {
+		fmt.Println("🔔 Notifications resumed")
+	}
+}
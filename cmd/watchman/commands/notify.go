@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/notification"
+)
+
+// notifyCmd represents the notify command.
+var notifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Manage notification backends",
+	Long:  `Check the notification backends configured under notification.`,
+}
+
+// notifyValidateCmd represents the notify validate command.
+var notifyValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check notification backends without sending",
+	Long: `Check each enabled notification backend without delivering an alert.
+
+  toast  notification.app_id is registered with Windows and
+         notification.icon_path exists
+  pipe   notification.pipe.name can be created; this fails while the
+         service holds the pipe
+
+Run it after changing notification settings so a broken backend is
+found before an incident. Exits with code 3 if any backend fails.`,
+	Example: `  # Check all enabled backends
+  watchmen notify validate
+
+  # JSON output
+  watchmen notify validate --output json`,
+	RunE: runNotifyValidate,
+}
+
+// notifyValidation is the output of notify validate.
+type notifyValidation struct {
+	Valid    bool                             `json:"valid"`
+	Backends []notification.BackendValidation `json:"backends"`
+}
+
+func init() {
+	rootCmd.AddCommand(notifyCmd)
+	notifyCmd.AddCommand(notifyValidateCmd)
+}
+
+func runNotifyValidate(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	backends := notification.NewDispatcher(cfg, nil).Backends()
+	result := newNotifyValidation(notification.ValidateBackends(cmd.Context(), backends))
+
+	if getOutput() == OutputJSON {
+		printVersionedJSON(result)
+	} else if !isQuiet() {
+		writeNotifyValidation(cmd.OutOrStdout(), result)
+	}
+
+	if !result.Valid {
+		return withExitCode(exitConnectionError, nil)
+	}
+	return nil
+}
+
+// newNotifyValidation summarizes the backend results.
+func newNotifyValidation(backends []notification.BackendValidation) *notifyValidation {
+	result := &notifyValidation{Valid: true, Backends: backends}
+	for _, b := range backends {
+		if !b.OK {
+			result.Valid = false
+		}
+	}
+	return result
+}
+
+// writeNotifyValidation prints one line per backend.
+func writeNotifyValidation(w io.Writer, r *notifyValidation) {
+	if len(r.Backends) == 0 {
+		_, _ = fmt.Fprintln(w, "Warning: no notification backends are enabled")
+		return
+	}
+	for _, b := range r.Backends {
+		switch {
+		case b.Skipped:
+			_, _ = fmt.Fprintf(w, "- %s (no check available)\n", b.Backend)
+		case b.OK:
+			_, _ = fmt.Fprintf(w, "✓ %s\n", b.Backend)
+		default:
+			_, _ = fmt.Fprintf(w, "✗ %s: %s\n", b.Backend, b.Error)
+		}
+	}
+}
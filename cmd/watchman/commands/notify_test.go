@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hoangtran1411/watchman/internal/notification"
+)
+
+func TestNotifyValidation(t *testing.T) {
+	result := newNotifyValidation([]notification.BackendValidation{
+		{Backend: "toast", OK: true},
+		{Backend: "pipe", Error: "pipe is in use"},
+		{Backend: "legacy", OK: true, Skipped: true},
+	})
+	assert.False(t, result.Valid)
+
+	var buf bytes.Buffer
+	writeNotifyValidation(&buf, result)
+	assert.Equal(t, "✓ toast\n✗ pipe: pipe is in use\n- legacy (no check available)\n", buf.String())
+
+	assert.True(t, newNotifyValidation([]notification.BackendValidation{{Backend: "toast", OK: true}}).Valid)
+}
+
+func TestNotifyValidate_NoBackends(t *testing.T) {
+	var buf bytes.Buffer
+	writeNotifyValidation(&buf, newNotifyValidation(nil))
+	assert.Equal(t, "Warning: no notification backends are enabled\n", buf.String())
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/notification"
+)
+
+// registerToastCmd represents the register-toast command.
+var registerToastCmd = &cobra.Command{
+	Use:   "register-toast",
+	Short: "Register the notification app ID with Windows",
+	Long: `Register notification.app_id as an App User Model ID (AUMID).
+
+Creates the registry entry and Start Menu shortcut that bind the
+app ID to Watchman, so toasts show Watchman's name and icon and are
+not attributed to "Windows PowerShell". Run once after install or
+after changing notification.app_id.`,
+	Example: `  # Register for the current user
+  watchmen register-toast
+
+  # Register machine-wide (requires Administrator)
+  watchmen register-toast --all-users`,
+	RunE: runRegisterToast,
+}
+
+var (
+	registerToastAllUsers bool
+)
+
+func init() {
+	rootCmd.AddCommand(registerToastCmd)
+
+	registerToastCmd.Flags().BoolVar(&registerToastAllUsers, "all-users", false,
+		"register machine-wide instead of for the current user")
+}
+
+func runRegisterToast(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	reg, err := registerToast(cfg, registerToastAllUsers)
+	if err != nil {
+		return withExitCode(exitInternalError, err)
+	}
+
+	if getOutput() == OutputJSON {
+		printJSON(reg)
+		return nil
+	}
+	if !isQuiet() {
+		fmt.Printf("✅ Registered app ID %q\n", reg.AppID)
+		fmt.Printf("   Registry: %s\n", reg.RegistryKey)
+		fmt.Printf("   Shortcut: %s\n", reg.ShortcutPath)
+	}
+	return nil
+}
+
+// registerToast registers the configured notification app ID for this executable.
+func registerToast(cfg *config.Config, allUsers bool) (*notification.ToastRegistration, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	registrar := notification.NewToastRegistrar()
+	reg, err := registrar.Plan(cfg.Notification, exe, allUsers)
+	if err != nil {
+		return nil, err
+	}
+	if err := registrar.Register(reg); err != nil {
+		return nil, err
+	}
+	return reg, nil
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/service"
 )
 
 // reloadCmd represents the reload command.
@@ -13,7 +15,11 @@ var reloadCmd = &cobra.Command{
 	Long: `Reload configuration file without restarting the service.
 
 This command sends a signal to the running Watchmen service to
-reload its configuration. Useful after editing config.yaml.`,
+reload its configuration. Useful after editing config.yaml.
+
+The service keeps its current configuration if the file fails to
+load, and logs the error. Logging settings only apply after a
+restart. Use 'watchmen config diff' to check what was applied.`,
 	Example: `  # Reload configuration
   watchmen reload
 
@@ -27,20 +33,21 @@ func init() {
 }
 
 func runReload(cmd *cobra.Command, args []string) error {
-	// TODO: Implement reload logic (signal to service)
+	if err := service.Reload(); err != nil {
+		return withExitCode(exitInternalError, err)
+	}
 
 	if getOutput() == OutputJSON {
 		result := map[string]interface{}{
 			"status":  "success",
-			"message": "Reload not yet implemented",
+			"message": "Reload requested",
 		}
 		printJSON(result)
 		return nil
 	}
 
 	if !isQuiet() {
-		fmt.Println("Reload command not yet implemented")
-		fmt.Println("For now, restart the service to apply configuration changes")
+		fmt.Println("🔄 Reload requested")
 	}
 	return nil
 }
@@ -2,7 +2,17 @@
 package commands
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
 	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/warnings"
+	"github.com/hoangtran1411/watchman/pkg/logger"
 )
 
 // Build info (set by main.go).
@@ -14,12 +24,61 @@ var (
 
 // Global flags.
 var (
-	cfgFile string
-	output  string
-	quiet   bool
-	verbose bool
+	cfgFile     string
+	configFiles []string
+	cfgDir      string
+	serverFile  string
+	output      string
+	quiet       bool
+	verbose     bool
+
+	jsonCompact bool
 )
 
+// Exit codes (see usage template).
+const (
+	exitSuccess         = 0
+	exitFailedJobs      = 1
+	exitConfigError     = 2
+	exitConnectionError = 3
+	exitInternalError   = 4
+)
+
+// exitError carries a process exit code through Cobra's error handling.
+// A nil err means the code is the only outcome and nothing is printed.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode attaches an exit code to err.
+func withExitCode(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// ExitCode returns the process exit code for an error returned by Execute.
+func ExitCode(err error) int {
+	if err == nil {
+		return exitSuccess
+	}
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	return exitInternalError
+}
+
 // SetBuildInfo sets build information from main package.
 func SetBuildInfo(v, c, d string) {
 	version = v
@@ -57,18 +116,106 @@ Features:
 	SilenceErrors: true,
 }
 
+// outputEnv is the environment variable setting the default --output.
+const outputEnv = "WATCHMAN_OUTPUT"
+
+// effectiveOutput returns the output format. An explicit --output wins, then
+// WATCHMAN_OUTPUT, then output.default from the config, then text.
+func effectiveOutput(flag string, flagSet bool, env, configured string) string {
+	switch {
+	case flagSet:
+		return flag
+	case env != "":
+		return env
+	case configured != "":
+		return configured
+	default:
+		return OutputText
+	}
+}
+
+// applyOutputDefault resolves --output unless it was passed explicitly.
+// configured is output.default, empty before the config is loaded.
+func applyOutputDefault(configured string) error {
+	env := os.Getenv(outputEnv)
+	switch env {
+	case "", OutputText, OutputJSON, OutputNDJSON:
+	default:
+		return withExitCode(exitConfigError, fmt.Errorf("invalid %s %q (expected text, json, ndjson)", outputEnv, env))
+	}
+
+	flagSet := rootCmd.PersistentFlags().Changed("output")
+	output = effectiveOutput(output, flagSet, env, configured)
+	return nil
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	if err != nil {
+		printError(err)
+	}
+	printWarnings(os.Stderr)
+	return err
+}
+
+// printWarnings prints the warnings not already included in JSON output to
+// w, which is stderr. Warnings never change the exit code.
+func printWarnings(w io.Writer) {
+	for _, warning := range warnings.Take() {
+		fmt.Fprintf(w, "Warning: %s\n", warning)
+	}
+}
+
+// errorEnvelope is the JSON output for a failed command.
+type errorEnvelope struct {
+	Status string      `json:"status"`
+	Error  errorDetail `json:"error"`
+}
+
+// errorDetail describes the error in an errorEnvelope.
+type errorDetail struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// printError prints err unless it only carries an exit code.
+// In JSON mode the error is written to stdout as an errorEnvelope so
+// automation always gets parseable output; otherwise it goes to stderr.
+func printError(err error) {
+	var ee *exitError
+	if errors.As(err, &ee) && ee.err == nil {
+		return
+	}
+	if getOutput() == OutputJSON {
+		printVersionedJSON(errorEnvelope{
+			Status: "error",
+			Error:  errorDetail{Code: ExitCode(err), Message: err.Error()},
+		})
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 }
 
 func init() {
+	// Resolve the --output default before any command runs; commands that
+	// load the config apply output.default again in loadConfig
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return applyOutputDefault("")
+	}
+
 	// Global flags
-	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "",
-		"config file path (default \"%ProgramData%\\Watchmen\\config.yaml\")")
+	rootCmd.PersistentFlags().VarP(configFileFlag{}, "config", "c",
+		"config file path (default \"%ProgramData%\\Watchmen\\config.yaml\"); repeat with check --json-array")
+	rootCmd.PersistentFlags().StringVar(&cfgDir, "config-dir", "",
+		"load and merge all *.yaml files in this directory instead of --config")
+	rootCmd.PersistentFlags().StringVar(&serverFile, "server-file", "",
+		"merge the servers listed in this YAML or JSON file by name (overrides servers_file)")
 	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", "text",
-		"output format: text, json")
+		"output format: text, json, ndjson ("+outputEnv+" or output.default change the default)")
+	rootCmd.PersistentFlags().BoolVar(&jsonCompact, "json-compact", false,
+		"print JSON on a single line (implies --output json)")
 	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false,
 		"suppress all output except errors")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false,
@@ -79,23 +226,120 @@ func init() {
 Exit Codes:
   0  Success / No failed jobs
   1  Failed jobs found (check completed successfully)
-  2  Configuration error
+  2  Configuration error (including no enabled servers)
   3  Connection error (all servers unreachable)
   4  Internal error
 `)
 }
 
 // getOutput returns the current output format.
+// NDJSON is reported as JSON so commands without line output still print
+// JSON; commands that stream lines check isNDJSON first.
 func getOutput() string {
+	if jsonCompact || output == OutputNDJSON {
+		return OutputJSON
+	}
 	return output
 }
 
+// isNDJSON returns whether --output ndjson is set.
+func isNDJSON() bool {
+	return output == OutputNDJSON
+}
+
 // isQuiet returns whether quiet mode is enabled.
 func isQuiet() bool {
 	return quiet
 }
 
+// isVerbose returns whether verbose mode is enabled.
+func isVerbose() bool {
+	return verbose
+}
+
+// effectiveLogLevel returns the log level for this run.
+// Precedence is --quiet (error) > --verbose (debug) > configured level.
+func effectiveLogLevel(configured string) string {
+	switch {
+	case isQuiet():
+		return "error"
+	case isVerbose():
+		return "debug"
+	default:
+		return configured
+	}
+}
+
+// newLogger creates a logger honoring the --quiet and --verbose flags.
+func newLogger(cfg config.LoggingConfig) (*logger.Logger, error) {
+	cfg.Level = effectiveLogLevel(cfg.Level)
+	log, err := logger.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create logger: %w", err)
+	}
+	return log, nil
+}
+
+// configFileFlag is the --config value. The flag may be repeated for
+// check --json-array: cfgFile holds the last path given and configFiles
+// all of them.
+type configFileFlag struct{}
+
+// String implements pflag.Value.
+func (configFileFlag) String() string { return cfgFile }
+
+// Set implements pflag.Value.
+func (configFileFlag) Set(path string) error {
+	cfgFile = path
+	configFiles = append(configFiles, path)
+	return nil
+}
+
+// Type implements pflag.Value.
+func (configFileFlag) Type() string { return "string" }
+
 // getConfigFile returns the config file path.
 func getConfigFile() string {
 	return cfgFile
 }
+
+// getConfigDir returns the config directory path.
+func getConfigDir() string {
+	return cfgDir
+}
+
+// loadConfig loads the configuration from --config-dir, the --config path
+// or the default location, merging servers from --server-file.
+func loadConfig() (*config.Config, error) {
+	if getConfigDir() != "" && getConfigFile() != "" {
+		return nil, withExitCode(exitConfigError, errors.New("--config and --config-dir cannot be used together"))
+	}
+	if len(configFiles) > 1 && !checkJSONArray {
+		return nil, withExitCode(exitConfigError, errors.New("--config can only be repeated with check --json-array"))
+	}
+
+	var opts config.LoadOptions
+	if serverFile != "" {
+		// Relative to the working directory rather than the config file
+		path, err := filepath.Abs(serverFile)
+		if err != nil {
+			return nil, withExitCode(exitConfigError, fmt.Errorf("invalid --server-file: %w", err))
+		}
+		opts.ServersFile = path
+	}
+
+	var cfg *config.Config
+	var err error
+	if dir := getConfigDir(); dir != "" {
+		cfg, err = config.LoadDirWithOptions(dir, opts)
+	} else {
+		cfg, err = config.LoadWithOptions(getConfigFile(), opts)
+	}
+	if err != nil {
+		return nil, withExitCode(exitConfigError, err)
+	}
+	if err := applyOutputDefault(cfg.Output.Default); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,367 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/jobs"
+	"github.com/hoangtran1411/watchman/internal/warnings"
+)
+
+// testConfig is a minimal valid configuration.
+const testConfig = `
+servers:
+  - name: "SQL01"
+    enabled: true
+    host: "localhost"
+    port: 1433
+    auth:
+      type: "sql"
+scheduler:
+  check_times: ["08:00"]
+`
+
+// executeArgs runs the root command with args and returns stdout and the error.
+// Global flag state is reset afterwards.
+func executeArgs(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetArgs(args)
+	t.Cleanup(func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetArgs(nil)
+		cfgFile, cfgDir, output, quiet, verbose = "", "", "text", false, false
+		serverFile, configFiles = "", nil
+		rootCmd.PersistentFlags().Lookup("output").Changed = false
+		// Mutually exclusive check flags must not see the previous run's flags
+		checkCmd.Flags().VisitAll(func(f *pflag.Flag) { f.Changed = false })
+		checkServer, checkAllowNoServers = "", false
+		checkRetries, checkRetryDelay, checkNoCache = 0, 0, false
+		notifyAppID, notifyIcon = "", ""
+		checkPrintQuery, checkSinceLast = false, false
+		jsonCompact = false
+		checkPromOut = ""
+		checkPushgateway = ""
+		checkFailFast, checkFailOn = false, nil
+		checkConnectTimeout, checkQueryTimeout = 0, 0
+		checkNoColor, checkTheme = false, themeFlag(themeDefault)
+		versionCheckUpdate = false
+		checkJSONArray = false
+		checkAssert = nil
+		warnings.Take()
+	})
+
+	// --config appends, so a second run in a test must not see the first's paths
+	configFiles = nil
+	err := Execute()
+	return stdout.String(), err
+}
+
+func TestExecute_JSONErrorEnvelope(t *testing.T) {
+	validConfig := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(validConfig, []byte(testConfig), 0o600))
+	missingConfig := filepath.Join(t.TempDir(), "missing.yaml")
+
+	tests := []struct {
+		name        string
+		args        []string
+		wantCode    int
+		wantMessage string
+	}{
+		{
+			name:        "check with missing config",
+			args:        []string{"check", "--config", missingConfig, "--output", "json"},
+			wantCode:    exitConfigError,
+			wantMessage: "config file not found",
+		},
+		{
+			name:        "servers matrix with missing config",
+			args:        []string{"servers", "matrix", "--config", missingConfig, "--output", "json"},
+			wantCode:    exitConfigError,
+			wantMessage: "config file not found",
+		},
+		{
+			name:        "check unknown server",
+			args:        []string{"check", "--config", validConfig, "--server", "NOPE", "--output", "json"},
+			wantCode:    exitConfigError,
+			wantMessage: "server not found: NOPE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, err := executeArgs(t, tt.args...)
+			require.Error(t, err)
+			assert.Equal(t, tt.wantCode, ExitCode(err))
+
+			var envelope errorEnvelope
+			require.NoError(t, json.Unmarshal([]byte(stdout), &envelope), "stdout must be a JSON envelope: %q", stdout)
+			assert.Equal(t, "error", envelope.Status)
+			assert.Equal(t, tt.wantCode, envelope.Error.Code)
+			assert.Contains(t, envelope.Error.Message, tt.wantMessage)
+		})
+	}
+}
+
+func TestExecute_JSONCompact(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantCompact bool
+	}{
+		{name: "indented by default", args: []string{"version", "--output", "json"}},
+		{name: "compact", args: []string{"version", "--json-compact"}, wantCompact: true},
+		{name: "compact with explicit output", args: []string{"version", "--output", "json", "--json-compact"}, wantCompact: true},
+		{name: "compact error envelope", args: []string{"check", "--config", "missing.yaml", "--json-compact"}, wantCompact: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, _ := executeArgs(t, tt.args...)
+
+			var doc map[string]any
+			require.NoError(t, json.Unmarshal([]byte(stdout), &doc), "stdout: %s", stdout)
+			if tt.wantCompact {
+				assert.Equal(t, 1, strings.Count(stdout, "\n"), "compact JSON is one line")
+				assert.True(t, strings.HasSuffix(stdout, "}\n"))
+				assert.NotContains(t, stdout, "  ")
+			} else {
+				assert.Greater(t, strings.Count(stdout, "\n"), 1)
+				assert.Contains(t, stdout, "\n  \"")
+			}
+		})
+	}
+}
+
+func TestExecute_JSONSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "config show", args: []string{"config", "show", "--output", "json"}},
+		{name: "config validate", args: []string{"config", "validate", "--output", "json"}},
+		{name: "check error envelope", args: []string{"check", "--config", "missing.yaml", "--output", "json"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, _ := executeArgs(t, tt.args...)
+
+			var doc map[string]any
+			require.NoError(t, json.Unmarshal([]byte(stdout), &doc), "stdout: %s", stdout)
+			assert.EqualValues(t, jsonSchemaVersion, doc["schema_version"])
+			assert.True(t, strings.HasPrefix(stdout, "{\n  \"schema_version\": "), "schema_version comes first")
+		})
+	}
+}
+
+func TestVersionedJSON(t *testing.T) {
+	result := &jobs.CheckResult{Status: "success", Summary: "ok"}
+	data := string(versionedJSON(result))
+	assert.True(t, strings.HasPrefix(data, `{"schema_version":1,"status":"success",`), data)
+
+	assert.JSONEq(t, `{"schema_version":1}`, string(versionedJSON(struct{}{})))
+	assert.JSONEq(t, `["a"]`, string(versionedJSON([]string{"a"})), "non-objects are unchanged")
+
+	data = string(versionedJSONWithMeta(result, map[string]int{"lookback_hours": 24}))
+	assert.True(t, strings.HasPrefix(data, `{"schema_version":1,"meta":{"lookback_hours":24},"status":"success",`), data)
+}
+
+func TestExecute_TextErrorNotOnStdout(t *testing.T) {
+	stdout, err := executeArgs(t, "check", "--config", filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+	assert.Equal(t, exitConfigError, ExitCode(err))
+	assert.Empty(t, stdout)
+}
+
+func TestCheck_SinkFailureKeepsExitCode(t *testing.T) {
+	var posted []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	// Nothing listens on port 1, so the only server is unavailable
+	content := fmt.Sprintf(`
+servers:
+  - name: "DOWN"
+    enabled: true
+    host: "127.0.0.1"
+    port: 1
+    auth:
+      type: "sql"
+    options:
+      connection_timeout: 2
+scheduler:
+  check_times: ["08:00"]
+monitoring:
+  result_sink:
+    url: %q
+    retries: 0
+`, srv.URL)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0o600))
+
+	stdout, err := executeArgs(t, "check", "--config", configPath, "--output", "json")
+	require.Error(t, err)
+	assert.Equal(t, exitConnectionError, ExitCode(err), "sink failure must not change the exit code")
+
+	var result struct {
+		Status string `json:"status"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, "error", result.Status)
+	assert.Contains(t, string(posted), `"servers_unavailable":["DOWN"]`)
+}
+
+func TestEffectiveLogLevel(t *testing.T) {
+	tests := []struct {
+		name       string
+		quiet      bool
+		verbose    bool
+		configured string
+		want       string
+	}{
+		{name: "config level by default", configured: "warn", want: "warn"},
+		{name: "verbose forces debug", verbose: true, configured: "warn", want: "debug"},
+		{name: "quiet forces error", quiet: true, configured: "info", want: "error"},
+		{name: "quiet wins over verbose", quiet: true, verbose: true, configured: "info", want: "error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quiet, verbose = tt.quiet, tt.verbose
+			t.Cleanup(func() { quiet, verbose = false, false })
+
+			assert.Equal(t, tt.want, effectiveLogLevel(tt.configured))
+		})
+	}
+}
+
+func TestCheck_NoEnabledServers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	disabled := strings.Replace(testConfig, "enabled: true", "enabled: false", 1)
+	require.NoError(t, os.WriteFile(path, []byte(disabled), 0o600))
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+	}{
+		{name: "config error by default", args: []string{"check", "--config", path, "--quiet"}, wantCode: exitConfigError},
+		{name: "warning when allowed", args: []string{"check", "--config", path, "--quiet", "--allow-no-servers"}, wantCode: exitSuccess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := executeArgs(t, tt.args...)
+			assert.Equal(t, tt.wantCode, ExitCode(err))
+		})
+	}
+}
+
+func TestEffectiveOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		flag       string
+		flagSet    bool
+		env        string
+		configured string
+		want       string
+	}{
+		{name: "nothing set", flag: "text", want: "text"},
+		{name: "config", flag: "text", configured: "json", want: "json"},
+		{name: "env beats config", flag: "text", env: "ndjson", configured: "json", want: "ndjson"},
+		{name: "flag beats env", flag: "text", flagSet: true, env: "json", configured: "json", want: "text"},
+		{name: "flag alone", flag: "json", flagSet: true, want: "json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, effectiveOutput(tt.flag, tt.flagSet, tt.env, tt.configured))
+		})
+	}
+}
+
+func TestExecute_OutputDefault(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := testConfig + `
+output:
+  default: "json"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0o600))
+	t.Setenv("ProgramData", t.TempDir())
+
+	isJSON := func(stdout string) bool {
+		var v map[string]any
+		return json.Unmarshal([]byte(stdout), &v) == nil
+	}
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv(outputEnv, "json")
+		stdout, err := executeArgs(t, "version")
+		require.NoError(t, err)
+		assert.True(t, isJSON(stdout), "WATCHMAN_OUTPUT=json: %s", stdout)
+	})
+
+	t.Run("flag beats env", func(t *testing.T) {
+		t.Setenv(outputEnv, "json")
+		stdout, err := executeArgs(t, "version", "--output", "text")
+		require.NoError(t, err)
+		assert.False(t, isJSON(stdout))
+	})
+
+	t.Run("config", func(t *testing.T) {
+		_, err := executeArgs(t, "config", "diff", "--config", configPath)
+		require.Error(t, err, "nothing recorded yet")
+		// The error envelope is printed by Execute on stdout in JSON mode
+		assert.Equal(t, "json", getOutput())
+	})
+
+	t.Run("invalid env", func(t *testing.T) {
+		t.Setenv(outputEnv, "yaml")
+		_, err := executeArgs(t, "version")
+		require.Error(t, err)
+		assert.Equal(t, exitConfigError, ExitCode(err))
+	})
+}
+
+func TestExecute_Warnings(t *testing.T) {
+	t.Setenv("ProgramData", t.TempDir())
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := unreachableConfig + "monitoring:\n  lookback_hour: 48\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	stdout, err := executeArgs(t, "check", "--config", path, "--server", "SQL01", "--output", "json")
+	assert.Equal(t, exitConnectionError, ExitCode(err), "warnings do not change the exit code")
+
+	var doc struct {
+		Warnings []warnings.Warning `json:"warnings"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &doc), "stdout: %s", stdout)
+	assert.Equal(t, []warnings.Warning{
+		{Source: warnings.SourceConfig, Message: `config.yaml: unknown key "monitoring.lookback_hour" is ignored`},
+	}, doc.Warnings)
+	assert.Empty(t, warnings.Take(), "warnings in the JSON output are not printed again")
+
+	// Warnings left out of the output go to stderr
+	warnings.Add(warnings.SourceMonitor, "servers not checked: SQL02")
+	var stderr bytes.Buffer
+	printWarnings(&stderr)
+	assert.Equal(t, "Warning: monitor: servers not checked: SQL02\n", stderr.String())
+}
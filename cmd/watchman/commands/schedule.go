@@ -0,0 +1,256 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/scheduler"
+)
+
+// scheduleCmd represents the schedule command.
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Show the check schedule",
+	Long:  `Inspect when scheduled checks run, from scheduler.check_times.`,
+}
+
+// scheduleNextCmd represents the schedule next command.
+var scheduleNextCmd = &cobra.Command{
+	Use:   "next",
+	Short: "Show when the next scheduled check runs",
+	Long: `Show when the service runs its next scheduled check, from
+scheduler.check_times in scheduler.timezone.
+
+Use --output json for dashboards showing "next check in X".`,
+	Example: `  # Show the next check
+  watchmen schedule next
+
+  # JSON output
+  watchmen schedule next --output json`,
+	RunE: runScheduleNext,
+}
+
+// scheduleVerifyCmd represents the schedule verify command.
+var scheduleVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Show the UTC instants scheduled checks fire at",
+	Long: `Show the next runs of every scheduler.check_times entry in
+scheduler.timezone, with the UTC instant each one fires at, to confirm
+how the timezone is interpreted.
+
+If the timezone's UTC offset changes within a year, e.g. for daylight
+saving time, the runs on the day before, of and after the change are
+shown too. A check time keeps its local time across the change, so its
+UTC instant moves by the change.`,
+	Example: `  # Show the next 5 runs of each check time
+  watchmen schedule verify
+
+  # Show the next 10 runs
+  watchmen schedule verify --count 10
+
+  # JSON output
+  watchmen schedule verify --output json`,
+	RunE: runScheduleVerify,
+}
+
+var scheduleVerifyCount int
+
+// scheduleNext is the output of schedule next.
+type scheduleNext struct {
+	NextRun  time.Time `json:"next_run"`
+	In       string    `json:"in"`
+	Timezone string    `json:"timezone"`
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+	scheduleCmd.AddCommand(scheduleNextCmd)
+	scheduleCmd.AddCommand(scheduleVerifyCmd)
+
+	scheduleVerifyCmd.Flags().IntVar(&scheduleVerifyCount, "count", 5,
+		"number of upcoming runs to show for each check time")
+}
+
+func runScheduleNext(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	loc, err := cfg.GetLocation()
+	if err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+
+	next, err := nextScheduledCheck(cmd.Context(), cfg)
+	if err != nil {
+		return withExitCode(exitInternalError, err)
+	}
+	result := newScheduleNext(next, time.Now(), loc)
+
+	if getOutput() == OutputJSON {
+		printJSON(result)
+		return nil
+	}
+	if !isQuiet() {
+		fmt.Printf("Next check at %s (%s), in %s\n", result.NextRun.Format("2006-01-02 15:04"), result.Timezone, result.In)
+	}
+	return nil
+}
+
+// nextScheduledCheck schedules the configured check times without running
+// them and returns the earliest upcoming one.
+func nextScheduledCheck(ctx context.Context, cfg *config.Config) (time.Time, error) {
+	sched, err := scheduler.NewScheduler(cfg, func(context.Context) error { return nil }, zerolog.Nop())
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := sched.Start(ctx); err != nil {
+		return time.Time{}, err
+	}
+	defer func() {
+		_ = sched.Stop()
+	}()
+	return sched.NextRun()
+}
+
+// newScheduleNext describes a check at next as seen at now.
+func newScheduleNext(next, now time.Time, loc *time.Location) scheduleNext {
+	return scheduleNext{
+		NextRun:  next.In(loc),
+		In:       formatUntil(next.Sub(now)),
+		Timezone: loc.String(),
+	}
+}
+
+// formatUntil formats d rounded to the minute, e.g. "2h15m" or "40m".
+func formatUntil(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d <= 0 {
+		return "0m"
+	}
+	hours, minutes := int(d/time.Hour), int(d%time.Hour/time.Minute)
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh%dm", hours, minutes)
+}
+
+// dstChangeRuns is how many daily runs are shown around a UTC offset change:
+// the day before, of and after it.
+const dstChangeRuns = 3
+
+// scheduleVerify is the output of schedule verify.
+type scheduleVerify struct {
+	Timezone  string           `json:"timezone"`
+	Checks    []scheduledCheck `json:"checks"`
+	DSTChange *dstChange       `json:"dst_change,omitempty"`
+}
+
+// dstChange is the next UTC offset change of the timezone and the runs around it.
+type dstChange struct {
+	At     time.Time        `json:"at"`
+	Checks []scheduledCheck `json:"checks"`
+}
+
+// scheduledCheck lists the runs of one check time.
+type scheduledCheck struct {
+	CheckTime string         `json:"check_time"`
+	Runs      []scheduledRun `json:"runs"`
+}
+
+// scheduledRun is one run in the configured timezone and in UTC.
+type scheduledRun struct {
+	Local time.Time `json:"local"`
+	UTC   time.Time `json:"utc"`
+}
+
+func runScheduleVerify(cmd *cobra.Command, args []string) error {
+	if scheduleVerifyCount < 1 {
+		return withExitCode(exitConfigError, fmt.Errorf("--count must be at least 1"))
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	result, err := newScheduleVerify(cfg, time.Now(), scheduleVerifyCount)
+	if err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+
+	if getOutput() == OutputJSON {
+		printJSON(result)
+		return nil
+	}
+	if !isQuiet() {
+		writeScheduleVerify(cmd.OutOrStdout(), result)
+	}
+	return nil
+}
+
+// newScheduleVerify computes the next count runs of cfg's check times after
+// now and the runs around the next UTC offset change, if any.
+func newScheduleVerify(cfg *config.Config, now time.Time, count int) (*scheduleVerify, error) {
+	loc, err := cfg.GetLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err := scheduler.UpcomingRuns(cfg, now, count)
+	if err != nil {
+		return nil, err
+	}
+	result := &scheduleVerify{Timezone: loc.String(), Checks: scheduledChecks(runs, loc)}
+
+	if change, ok := scheduler.NextOffsetChange(loc, now); ok {
+		runs, err := scheduler.UpcomingRuns(cfg, change.Add(-24*time.Hour), dstChangeRuns)
+		if err != nil {
+			return nil, err
+		}
+		result.DSTChange = &dstChange{At: change, Checks: scheduledChecks(runs, loc)}
+	}
+	return result, nil
+}
+
+// scheduledChecks converts runs to the output in loc and UTC.
+func scheduledChecks(runs []scheduler.CheckRuns, loc *time.Location) []scheduledCheck {
+	checks := make([]scheduledCheck, 0, len(runs))
+	for _, r := range runs {
+		check := scheduledCheck{CheckTime: r.CheckTime, Runs: make([]scheduledRun, 0, len(r.Runs))}
+		for _, run := range r.Runs {
+			check.Runs = append(check.Runs, scheduledRun{Local: run.In(loc), UTC: run.UTC()})
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// writeScheduleVerify writes result in human-readable format.
+func writeScheduleVerify(w io.Writer, result *scheduleVerify) {
+	fmt.Fprintf(w, "Timezone: %s\n\nNext runs:\n", result.Timezone)
+	writeScheduledChecks(w, result.Checks)
+
+	if result.DSTChange != nil {
+		fmt.Fprintf(w, "\nUTC offset changes at %s; runs around it:\n", result.DSTChange.At.Format("2006-01-02 15:04 MST"))
+		writeScheduledChecks(w, result.DSTChange.Checks)
+	}
+}
+
+// writeScheduledChecks writes one line per run, e.g.
+// "  08:00  2026-03-08 08:00 EDT = 2026-03-08 12:00 UTC".
+func writeScheduledChecks(w io.Writer, checks []scheduledCheck) {
+	const layout = "2006-01-02 15:04 MST"
+	for _, check := range checks {
+		label := check.CheckTime
+		for _, run := range check.Runs {
+			fmt.Fprintf(w, "  %-5s  %s = %s\n", label, run.Local.Format(layout), run.UTC.Format(layout))
+			label = ""
+		}
+	}
+}
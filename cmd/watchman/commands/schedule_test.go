@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+func TestNewScheduleNext(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Ho_Chi_Minh")
+	require.NoError(t, err)
+	now := time.Date(2026, 2, 3, 5, 45, 0, 0, loc)
+
+	tests := []struct {
+		name string
+		next time.Time
+		want string
+	}{
+		{name: "hours and minutes", next: time.Date(2026, 2, 3, 8, 0, 0, 0, loc), want: "2h15m"},
+		{name: "whole hours", next: time.Date(2026, 2, 3, 7, 45, 0, 0, loc), want: "2h0m"},
+		{name: "minutes", next: time.Date(2026, 2, 3, 6, 25, 0, 0, loc), want: "40m"},
+		{name: "rounded to the minute", next: now.Add(90 * time.Second), want: "2m"},
+		{name: "tomorrow", next: time.Date(2026, 2, 4, 5, 0, 0, 0, loc), want: "23h15m"},
+		{name: "due", next: now.Add(10 * time.Second), want: "0m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newScheduleNext(tt.next.UTC(), now, loc)
+			assert.Equal(t, tt.want, got.In)
+			assert.Equal(t, "Asia/Ho_Chi_Minh", got.Timezone)
+			assert.Equal(t, loc, got.NextRun.Location())
+			assert.True(t, tt.next.Equal(got.NextRun))
+		})
+	}
+}
+
+func TestNextScheduledCheck(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Scheduler.Timezone = "UTC"
+	now := time.Now().UTC()
+	cfg.Scheduler.CheckTimes = []string{now.Add(2 * time.Hour).Format("15:04"), now.Add(5 * time.Hour).Format("15:04")}
+
+	next, err := nextScheduledCheck(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.WithinDuration(t, now.Add(2*time.Hour), next, time.Minute)
+}
+
+func TestNewScheduleVerify(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Scheduler.Timezone = "America/New_York"
+	cfg.Scheduler.CheckTimes = []string{"08:00"}
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	result, err := newScheduleVerify(cfg, now, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "America/New_York", result.Timezone)
+	require.Len(t, result.Checks, 1)
+	require.Len(t, result.Checks[0].Runs, 2)
+	assert.Equal(t, "2026-03-01T08:00:00-05:00", result.Checks[0].Runs[0].Local.Format(time.RFC3339))
+	assert.Equal(t, "2026-03-01T13:00:00Z", result.Checks[0].Runs[0].UTC.Format(time.RFC3339))
+
+	// The check keeps firing at 08:00 local time, an hour earlier in UTC
+	require.NotNil(t, result.DSTChange)
+	assert.Equal(t, "2026-03-08T03:00:00-04:00", result.DSTChange.At.Format(time.RFC3339))
+	var utcRuns []string
+	for _, run := range result.DSTChange.Checks[0].Runs {
+		assert.Equal(t, 8, run.Local.Hour())
+		utcRuns = append(utcRuns, run.UTC.Format(time.RFC3339))
+	}
+	assert.Equal(t, []string{"2026-03-07T13:00:00Z", "2026-03-08T12:00:00Z", "2026-03-09T12:00:00Z"}, utcRuns)
+
+	var out bytes.Buffer
+	writeScheduleVerify(&out, result)
+	assert.Contains(t, out.String(), "UTC offset changes at 2026-03-08 03:00 EDT; runs around it:\n")
+	assert.Contains(t, out.String(), "  08:00  2026-03-07 08:00 EST = 2026-03-07 13:00 UTC\n         2026-03-08 08:00 EDT = 2026-03-08 12:00 UTC\n")
+}
+
+func TestNewScheduleVerify_NoOffsetChange(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Scheduler.Timezone = "UTC"
+
+	result, err := newScheduleVerify(cfg, time.Now(), 3)
+	require.NoError(t, err)
+	assert.Nil(t, result.DSTChange)
+	require.Len(t, result.Checks, 1)
+	assert.Len(t, result.Checks[0].Runs, 3)
+}
@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/diagnostics"
+)
+
+// serversCmd represents the servers command.
+var serversCmd = &cobra.Command{
+	Use:   "servers",
+	Short: "Inspect configured servers",
+	Long:  `Inspect the effective server list and diagnose connectivity.`,
+}
+
+// serversMatrixCmd represents the servers matrix command.
+var serversMatrixCmd = &cobra.Command{
+	Use:   "matrix",
+	Short: "Show a connectivity matrix for enabled servers",
+	Long: `Show a connectivity matrix for each enabled server.
+
+Each server is checked step by step and every step is timed:
+  DNS   Host name resolves
+  TCP   Port is reachable (firewall)
+  Ping  Login succeeds (authentication)
+  msdb  Job tables are readable (permissions)
+
+Once a step fails, the remaining steps are skipped. This pinpoints
+whether a failure is caused by DNS, firewall, auth or permissions.
+
+The CLOCK column shows how far each server's clock is ahead of (+) or
+behind (-) this host's. Skew beyond monitoring.max_clock_skew_seconds
+is flagged, since it makes the lookback window miss or repeat failures.`,
+	Example: `  # Show connectivity matrix
+  watchmen servers matrix
+
+  # JSON output
+  watchmen servers matrix --output json
+
+  # One JSON line per server plus a summary line, for streaming
+  watchmen servers matrix --output ndjson`,
+	RunE: runServersMatrix,
+}
+
+func init() {
+	rootCmd.AddCommand(serversCmd)
+	serversCmd.AddCommand(serversMatrixCmd)
+}
+
+func runServersMatrix(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	matrix := diagnostics.NewRunner().BuildMatrix(context.Background(), cfg.GetEnabledServers())
+
+	if isNDJSON() {
+		return writeMatrixNDJSON(cmd.OutOrStdout(), matrix)
+	}
+	if getOutput() == OutputJSON {
+		printJSON(matrix)
+		return nil
+	}
+
+	if !isQuiet() {
+		printMatrix(matrix, cfg.Monitoring)
+	}
+	return nil
+}
+
+// matrixServerLine is an NDJSON line with one server's health.
+type matrixServerLine struct {
+	Type string `json:"type"`
+	diagnostics.ServerRow
+	OK bool `json:"ok"`
+}
+
+// matrixSummaryLine is the final NDJSON line of the matrix.
+type matrixSummaryLine struct {
+	Type      string `json:"type"`
+	Servers   int    `json:"servers"`
+	Healthy   int    `json:"healthy"`
+	Unhealthy int    `json:"unhealthy"`
+}
+
+// writeMatrixNDJSON writes one JSON line per server followed by a summary line.
+func writeMatrixNDJSON(w io.Writer, m *diagnostics.Matrix) error {
+	encoder := json.NewEncoder(w)
+	summary := matrixSummaryLine{Type: "summary", Servers: len(m.Rows)}
+	for _, row := range m.Rows {
+		line := matrixServerLine{Type: "server", ServerRow: row, OK: row.OK()}
+		if line.OK {
+			summary.Healthy++
+		} else {
+			summary.Unhealthy++
+		}
+		if err := encoder.Encode(line); err != nil {
+			return withExitCode(exitInternalError, fmt.Errorf("failed to write server line: %w", err))
+		}
+	}
+	if err := encoder.Encode(summary); err != nil {
+		return withExitCode(exitInternalError, fmt.Errorf("failed to write summary line: %w", err))
+	}
+	return nil
+}
+
+// printMatrix prints the connectivity matrix as a table.
+func printMatrix(m *diagnostics.Matrix, monitoring config.MonitoringConfig) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	header := []string{"SERVER", "ADDRESS"}
+	for _, step := range m.Steps {
+		header = append(header, strings.ToUpper(string(step)))
+	}
+	header = append(header, "CLOCK")
+	_, _ = fmt.Fprintln(w, strings.Join(header, "\t"))
+
+	for _, row := range m.Rows {
+		cells := []string{row.Server, fmt.Sprintf("%s:%d", row.Host, row.Port)}
+		for _, s := range row.Steps {
+			cells = append(cells, formatStep(s))
+		}
+		cells = append(cells, formatClockSkew(row, monitoring))
+		_, _ = fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	_ = w.Flush()
+
+	// Print errors below the table so the grid stays readable
+	for _, row := range m.Rows {
+		for _, s := range row.Steps {
+			if s.Error != "" {
+				fmt.Printf("\n%s (%s): %s", row.Server, s.Step, s.Error)
+			}
+		}
+		if skew, ok := row.ClockSkew(); ok && monitoring.ClockSkewExceeded(skew) {
+			fmt.Printf("\n%s (clock): skew %s exceeds max_clock_skew_seconds (%d)", row.Server, formatSkew(skew), monitoring.MaxClockSkewSeconds)
+		}
+	}
+	fmt.Println()
+}
+
+// formatStep formats a single matrix cell.
+func formatStep(s diagnostics.StepResult) string {
+	switch {
+	case s.Skipped:
+		return "-"
+	case s.OK:
+		return fmt.Sprintf("✓ %s", s.Duration())
+	default:
+		return fmt.Sprintf("✗ %s", s.Duration())
+	}
+}
+
+// formatClockSkew formats the clock skew cell, flagging skew beyond the limit.
+func formatClockSkew(row diagnostics.ServerRow, monitoring config.MonitoringConfig) string {
+	skew, ok := row.ClockSkew()
+	if !ok {
+		return "-"
+	}
+	if monitoring.ClockSkewExceeded(skew) {
+		return "✗ " + formatSkew(skew)
+	}
+	return formatSkew(skew)
+}
+
+// formatSkew formats skew with an explicit sign, e.g. "+1.2s".
+func formatSkew(skew time.Duration) string {
+	if skew < 0 {
+		return skew.Round(100 * time.Millisecond).String()
+	}
+	return "+" + skew.Round(100*time.Millisecond).String()
+}
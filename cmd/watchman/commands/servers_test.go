@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/diagnostics"
+)
+
+// unreachableConfig has two servers on a closed local port.
+const unreachableConfig = `
+servers:
+  - name: "SQL01"
+    enabled: true
+    host: "127.0.0.1"
+    port: 1
+    auth:
+      type: "sql"
+  - name: "SQL02"
+    enabled: true
+    host: "127.0.0.1"
+    port: 1
+    auth:
+      type: "sql"
+scheduler:
+  check_times: ["08:00"]
+`
+
+func TestServersMatrix_NDJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(unreachableConfig), 0o600))
+
+	stdout, err := executeArgs(t, "servers", "matrix", "--config", path, "--output", "ndjson")
+	require.NoError(t, err)
+
+	var lines []map[string]any
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line), "line is not JSON: %s", scanner.Text())
+		lines = append(lines, line)
+	}
+
+	require.Len(t, lines, 3, "one line per server plus a summary")
+	for i, name := range []string{"SQL01", "SQL02"} {
+		assert.Equal(t, "server", lines[i]["type"])
+		assert.Equal(t, name, lines[i]["server"])
+		assert.Equal(t, false, lines[i]["ok"])
+		assert.NotEmpty(t, lines[i]["steps"])
+	}
+	assert.Equal(t, map[string]any{"type": "summary", "servers": 2.0, "healthy": 0.0, "unhealthy": 2.0}, lines[2])
+}
+
+func TestExecute_NDJSONFallsBackToCompactJSON(t *testing.T) {
+	stdout, err := executeArgs(t, "version", "--output", "ndjson")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, strings.Count(stdout, "\n"))
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal([]byte(stdout), &doc))
+	assert.Contains(t, doc, "version")
+}
+
+func TestFormatClockSkew(t *testing.T) {
+	monitoring := config.MonitoringConfig{MaxClockSkewSeconds: 60}
+	skewMs := func(ms int64) diagnostics.ServerRow {
+		return diagnostics.ServerRow{ClockSkewMs: &ms}
+	}
+
+	assert.Equal(t, "-", formatClockSkew(diagnostics.ServerRow{}, monitoring))
+	assert.Equal(t, "+1.2s", formatClockSkew(skewMs(1234), monitoring))
+	assert.Equal(t, "-400ms", formatClockSkew(skewMs(-420), monitoring))
+	assert.Equal(t, "✗ +2m0s", formatClockSkew(skewMs(120000), monitoring))
+	assert.Equal(t, "+2m0s", formatClockSkew(skewMs(120000), config.MonitoringConfig{}), "disabled check never flags")
+}
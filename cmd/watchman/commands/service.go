@@ -1,9 +1,27 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/hook"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+	"github.com/hoangtran1411/watchman/internal/notification"
+	"github.com/hoangtran1411/watchman/internal/perfcounter"
+	"github.com/hoangtran1411/watchman/internal/scheduler"
+	"github.com/hoangtran1411/watchman/internal/service"
+	"github.com/hoangtran1411/watchman/internal/sink"
+	"github.com/hoangtran1411/watchman/internal/state"
+	"github.com/hoangtran1411/watchman/internal/updater"
+	"github.com/hoangtran1411/watchman/pkg/logger"
 )
 
 // serviceCmd represents the service command (internal).
@@ -40,15 +58,459 @@ func init() {
 }
 
 func runService(cmd *cobra.Command, args []string) error {
-	// TODO: Implement Windows Service handler
-	// This is called when Windows SCM starts the service
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
 
-	if !isQuiet() {
-		fmt.Println("Service mode not yet implemented")
+	log, err := newLogger(cfg.Logging)
+	if err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+
+	store := state.NewStore(state.DefaultPath())
+	rt, err := newServiceRuntime(cfg, store, log)
+	if err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+	host := &serviceHost{store: store, log: log, current: rt}
+
+	start := func(ctx context.Context) error {
+		if err := host.start(ctx); err != nil {
+			return err
+		}
+		log.LogServiceStart(version)
+		recordAppliedConfig(cfg, store, log)
+		logStateCompaction(cfg, store, log)
+		announceUpdateApplied(cfg, store, notification.NewNotifier(cfg.Notification), log)
+		if cfg.Monitoring.PreflightOnStart {
+			go runPreflight(ctx, cfg, rt.monitor, rt.dispatcher, log)
+		}
+		if cfg.Update.CheckOnStartup {
+			go notifyUpdateAvailable(ctx, cfg, store, log)
+		}
+		<-ctx.Done()
+		return nil
+	}
+	stop := func() error {
+		defer log.LogServiceStop()
+		return host.stop()
+	}
+
+	isService, err := service.IsInteractive()
+	if err != nil {
+		return err
+	}
+
+	svcHandler := service.NewService(cfg, start, stop, log.Logger)
+	svcHandler.SetReloadHandler(host.reload)
+	return svcHandler.Run(!isService)
+}
+
+// serviceRuntime is the scheduled check with the components it uses, built
+// from one configuration. A configuration reload replaces it.
+type serviceRuntime struct {
+	cfg        *config.Config
+	monitor    *jobs.Monitor
+	dispatcher *notification.Dispatcher
+	sched      *scheduler.Scheduler
+	counter    *perfcounter.Counter
+
+	// cancel stops the background tasks started with the runtime.
+	cancel context.CancelFunc
+}
+
+// newServiceRuntime builds the monitor, notification dispatcher, result
+// sink, post-check hook and scheduler for cfg.
+func newServiceRuntime(cfg *config.Config, store *state.Store, log *logger.Logger) (*serviceRuntime, error) {
+	monitor := jobs.NewMonitor(cfg)
+	monitor.SetStateStore(store)
+	monitor.SetLogger(log.Logger)
+	dispatcher := notification.NewDispatcher(cfg, store)
+	resultSink, err := sink.NewHTTPSink(cfg.Monitoring.ResultSink, cfg.Notification)
+	if err != nil {
+		return nil, err
+	}
+	postCheck := hook.NewPostCheck(cfg.Monitoring.PostCheckHook)
+	recorder := newCheckRecorder(cfg, enabledServerNames(cfg))
+	counter, err := perfcounter.New(cfg.Monitoring.PerfCounter)
+	if err != nil {
+		log.Warn().Err(err).Msg("performance counter disabled")
+	}
+
+	sched, err := scheduler.NewScheduler(cfg, newCheckHandler(monitor, dispatcher, resultSink, postCheck, recorder, counter, log), log.Logger)
+	if err != nil {
+		_ = counter.Close()
+		return nil, err
+	}
+	sched.SetStateStore(store)
+
+	return &serviceRuntime{
+		cfg:        cfg,
+		monitor:    monitor,
+		dispatcher: dispatcher,
+		sched:      sched,
+		counter:    counter,
+	}, nil
+}
+
+// start starts the scheduled checks, the daily state compaction and digest,
+// and the delivery of notifications deferred by quiet hours.
+func (rt *serviceRuntime) start(ctx context.Context, store *state.Store, log *logger.Logger) error {
+	ctx, rt.cancel = context.WithCancel(ctx)
+
+	if err := rt.sched.Start(ctx); err != nil {
+		return err
+	}
+	if err := rt.sched.ScheduleDaily(ctx, "state_compaction", stateCompactTime, func(context.Context) error {
+		logStateCompaction(rt.cfg, store, log)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if rt.dispatcher.DigestMode() {
+		if err := rt.sched.ScheduleDaily(ctx, "notification_digest", rt.cfg.Notification.DigestTime, rt.dispatcher.SendDigest); err != nil {
+			return err
+		}
+	}
+	if rt.dispatcher.DefersSends() {
+		go flushDeferredNotifications(ctx, rt.dispatcher, log)
 	}
 	return nil
 }
 
+// stop stops the scheduler and background tasks and closes the performance
+// counter and notification backends.
+func (rt *serviceRuntime) stop(log *logger.Logger) error {
+	if rt.cancel != nil {
+		rt.cancel()
+	}
+	defer func() {
+		if err := rt.counter.Close(); err != nil {
+			log.Warn().Err(err).Msg("failed to close performance counter")
+		}
+		if err := rt.dispatcher.Close(); err != nil {
+			log.Warn().Err(err).Msg("failed to close notification backends")
+		}
+	}()
+	return rt.sched.Stop()
+}
+
+// serviceHost runs the service runtime and replaces it when the
+// configuration is reloaded.
+type serviceHost struct {
+	store *state.Store
+	log   *logger.Logger
+
+	mu      sync.Mutex
+	ctx     context.Context // service context, set by start
+	current *serviceRuntime
+}
+
+// start starts the current runtime for the lifetime of ctx.
+func (h *serviceHost) start(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.ctx = ctx
+	return h.current.start(ctx, h.store, h.log)
+}
+
+// stop stops the current runtime.
+func (h *serviceHost) stop() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.current.stop(h.log)
+}
+
+// reload loads the configuration file again, replaces the current runtime
+// with one built from it and records it as the applied configuration.
+// A file that fails to load leaves the current runtime running; a
+// configuration that cannot be started falls back to the previous one.
+// Logging settings only apply after a restart.
+func (h *serviceHost) reload() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.ctx == nil || h.ctx.Err() != nil {
+		return errors.New("service is not running")
+	}
+
+	previous := h.current.cfg
+	if err := h.current.stop(h.log); err != nil {
+		h.log.Warn().Err(err).Msg("failed to stop scheduler for reload")
+	}
+	if err := h.replace(cfg); err != nil {
+		if restoreErr := h.replace(previous); restoreErr != nil {
+			return fmt.Errorf("failed to apply reloaded configuration: %w; failed to restore the previous one: %w", err, restoreErr)
+		}
+		return fmt.Errorf("failed to apply reloaded configuration, kept the previous one: %w", err)
+	}
+
+	h.log.LogConfigReload()
+	recordAppliedConfig(cfg, h.store, h.log)
+	return nil
+}
+
+// replace builds a runtime from cfg, starts it and makes it the current one.
+func (h *serviceHost) replace(cfg *config.Config) error {
+	rt, err := newServiceRuntime(cfg, h.store, h.log)
+	if err != nil {
+		return err
+	}
+	if err := rt.start(h.ctx, h.store, h.log); err != nil {
+		_ = rt.stop(h.log)
+		return err
+	}
+	h.current = rt
+	return nil
+}
+
+// runPreflight pings every enabled server once at startup and reports the
+// unreachable ones and those with a skewed clock, so misconfiguration shows
+// up before the first scheduled check.
+func runPreflight(ctx context.Context, cfg *config.Config, monitor *jobs.Monitor, dispatcher *notification.Dispatcher, log *logger.Logger) {
+	result := monitor.Preflight(ctx)
+	for _, server := range result.ServersUnavailable {
+		log.Warn().Str("server", server).Str("error", result.ServerErrors[server]).Msg("preflight: server unavailable")
+	}
+	for _, server := range slices.Sorted(maps.Keys(result.ServerClockSkewMs)) {
+		skew := time.Duration(result.ServerClockSkewMs[server]) * time.Millisecond
+		if cfg.Monitoring.ClockSkewExceeded(skew) {
+			log.Warn().
+				Str("server", server).
+				Dur("clock_skew", skew).
+				Int("max_clock_skew_seconds", cfg.Monitoring.MaxClockSkewSeconds).
+				Msg("preflight: server clock differs from host clock, lookback window may miss or repeat failures")
+		}
+	}
+	log.Info().
+		Int("servers_checked", result.ServersChecked).
+		Int("servers_available", result.ServersAvailable).
+		Msg("preflight completed")
+
+	if err := dispatcher.NotifyServersDown(ctx, result); err != nil {
+		log.Warn().Err(err).Msg("failed to send server down notification")
+	}
+}
+
+// recordAppliedConfig stores the configuration the service started or last
+// reloaded with, so 'config diff' can show edits made since.
+func recordAppliedConfig(cfg *config.Config, store *state.Store, log *logger.Logger) {
+	err := store.Update(func(st *state.State) error {
+		st.RecordAppliedConfig(cfg, time.Now())
+		return nil
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to record applied configuration")
+	}
+}
+
+// announceUpdateApplied records the running version and, if it differs from
+// the version that last started the service, logs the update and confirms it
+// with a notification when update.notify_applied is set.
+func announceUpdateApplied(cfg *config.Config, store *state.Store, notifier *notification.Notifier, log *logger.Logger) {
+	var previous string
+	var changed bool
+	err := store.Update(func(st *state.State) error {
+		previous, changed = st.RecordVersion(version)
+		return nil
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to record running version")
+		return
+	}
+	if !changed {
+		return
+	}
+
+	log.LogUpdateApplied(previous, version)
+	if !cfg.Update.NotifyApplied {
+		return
+	}
+	if err := notifier.NotifyUpdateApplied(previous, version); err != nil {
+		log.Warn().Err(err).Msg("failed to send update applied notification")
+	}
+}
+
+// notifyUpdateAvailable sends an update notification unless the version was dismissed.
+func notifyUpdateAvailable(ctx context.Context, cfg *config.Config, store *state.Store, log *logger.Logger) {
+	result, err := updater.NewUpdater(cfg.Update, version).CheckForUpdate(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("update check failed")
+		return
+	}
+	if !result.UpdateAvailable {
+		return
+	}
+
+	log.LogUpdateAvailable(result.CurrentVersion, result.LatestVersion)
+
+	st, err := store.Load()
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to load state")
+		return
+	}
+	if st.IsUpdateDismissed(result.LatestVersion) {
+		log.Debug().Str("version", result.LatestVersion).Msg("update notification dismissed")
+		return
+	}
+
+	if err := notification.NewNotifier(cfg.Notification).NotifyUpdateAvailable(result.CurrentVersion, result.LatestVersion); err != nil {
+		log.Warn().Err(err).Msg("failed to send update notification")
+	}
+}
+
+// logStateCompaction removes stale entries from the state file and logs the outcome.
+func logStateCompaction(cfg *config.Config, store *state.Store, log *logger.Logger) {
+	removed, err := compactState(cfg, store, time.Now())
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to compact state file")
+		return
+	}
+	if removed > 0 {
+		log.Info().Int("removed", removed).Msg("compacted state file")
+	}
+}
+
+// flushDeferredNotifications periodically sends notifications held during quiet
+// hours or by the send throttle.
+func flushDeferredNotifications(ctx context.Context, dispatcher *notification.Dispatcher, log *logger.Logger) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := dispatcher.FlushPending(ctx); err != nil {
+				log.Warn().Err(err).Msg("failed to send deferred notifications")
+			}
+		}
+	}
+}
+
+// newCheckHandler returns the scheduled check handler.
+// It checks all servers and dispatches notifications for failed jobs.
+// The result is recorded in the event trace and posted to the result sink.
+// The post-check hook runs if configured.
+// An error is returned when no server was checked or none could be reached,
+// so the scheduler retries.
+func newCheckHandler(monitor *jobs.Monitor, dispatcher *notification.Dispatcher, resultSink *sink.HTTPSink, postCheck *hook.PostCheck, recorder *checkRecorder, counter *perfcounter.Counter, log *logger.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		start := time.Now()
+		result, err := monitor.CheckAll(ctx)
+		if recErr := recorder.Record(start, result, err); recErr != nil {
+			log.Warn().Err(recErr).Msg("failed to record check event")
+		}
+		if err != nil {
+			return fmt.Errorf("check failed: %w", err)
+		}
+
+		if result.Status == "no_servers" {
+			log.Warn().Msg(result.Summary)
+			return nil
+		}
+
+		log.LogCheckResult(result.ServersChecked, result.ServersAvailable, len(result.FailedJobs), result.Duration)
+		for server, ms := range result.ServerQueryLatencyMs {
+			log.LogQueryLatency(server, time.Duration(ms)*time.Millisecond)
+		}
+		for _, job := range result.FailedJobs {
+			log.LogFailedJob(job.ServerName, job.JobName, job.FailedAt)
+		}
+		for _, job := range result.WarnJobs {
+			log.LogWarnJob(job.ServerName, job.JobName, job.Status, job.FailedAt)
+		}
+		for _, job := range result.LongRunningJobs {
+			log.LogLongRunningJob(job.ServerName, job.JobName, job.StartedAt, job.RunningFor())
+		}
+		if err := counter.Update(result); err != nil {
+			log.Warn().Err(err).Msg("failed to update performance counter")
+		}
+
+		dispatchCheckResult(ctx, dispatcher, result, log)
+
+		if resultSink != nil {
+			if err := resultSink.Post(ctx, result); err != nil {
+				log.Warn().Err(err).Msg("failed to post result to sink")
+			}
+		}
+		if postCheck != nil {
+			runPostCheckHook(ctx, postCheck, result, log)
+		}
+
+		if result.Status == "error" {
+			if result.ServersChecked == 0 {
+				return fmt.Errorf("no servers checked: %s", result.Summary)
+			}
+			return fmt.Errorf("all %d servers unavailable", result.ServersChecked)
+		}
+		return nil
+	}
+}
+
+// runPostCheckHook runs the post-check hook and logs its output.
+// A failed hook is logged and never fails the check.
+func runPostCheckHook(ctx context.Context, postCheck *hook.PostCheck, result *jobs.CheckResult, log *logger.Logger) {
+	output, err := postCheck.Run(ctx, result)
+	if err != nil {
+		log.Warn().Err(err).Str("output", output).Msg("post-check hook failed")
+		return
+	}
+	log.Info().Str("output", output).Msg("post-check hook ran")
+}
+
+// dispatchCheckResult retries queued sends, then sends notifications for a
+// scheduled check result.
+// Failures are logged and never fail the check.
+func dispatchCheckResult(ctx context.Context, dispatcher *notification.Dispatcher, result *jobs.CheckResult, log *logger.Logger) {
+	if sent, err := dispatcher.RetryOutbox(ctx); err != nil {
+		log.Warn().Err(err).Msg("failed to retry queued notifications")
+	} else if sent > 0 {
+		log.Info().Int("notifications", sent).Msg("sent queued notifications")
+	}
+	if result.HasFailedJobs() {
+		dispatchFailedJobs(ctx, dispatcher, result, log)
+	}
+	if err := dispatcher.NotifyServersDown(ctx, result); err != nil {
+		log.Warn().Err(err).Msg("failed to send server down notification")
+	}
+	if err := dispatcher.NotifyLongRunning(ctx, result); err != nil {
+		log.Warn().Err(err).Msg("failed to send long-running job notification")
+	}
+}
+
+// dispatchFailedJobs sends or queues the failed jobs of result and logs which.
+func dispatchFailedJobs(ctx context.Context, dispatcher *notification.Dispatcher, result *jobs.CheckResult, log *logger.Logger) {
+	count := len(result.FailedJobs)
+	if dispatcher.Muted() {
+		log.Info().Int("failed_jobs", count).Msg("notifications muted, not sending")
+		return
+	}
+
+	deferring := dispatcher.Deferring()
+	if err := dispatcher.Dispatch(ctx, result); err != nil {
+		log.Warn().Err(err).Msg("failed to send notification")
+		return
+	}
+	switch {
+	case dispatcher.DigestMode():
+		log.Info().Int("failed_jobs", count).Msg("failed jobs queued for digest")
+	case deferring:
+		log.Info().Int("failed_jobs", count).Msg("notification deferred by quiet hours or send throttle")
+	default:
+		log.LogNotificationSent(count)
+	}
+}
+
 func runStart(cmd *cobra.Command, args []string) error {
 	// TODO: Implement start command (call sc.exe start)
 
@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+	"github.com/hoangtran1411/watchman/internal/notification"
+	"github.com/hoangtran1411/watchman/internal/state"
+	"github.com/hoangtran1411/watchman/pkg/logger"
+)
+
+// useTempCacheDir points the user cache directory, where the default toast
+// icon is extracted, at a temporary directory.
+func useTempCacheDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("LocalAppData", dir)
+	t.Setenv("XDG_CACHE_HOME", dir)
+}
+
+func TestAnnounceUpdateApplied(t *testing.T) {
+	useTempCacheDir(t)
+	oldVersion := version
+	t.Cleanup(func() { version = oldVersion })
+
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	cfg := &config.Config{Update: config.UpdateConfig{NotifyApplied: true}}
+	pusher := &recordingPusher{}
+	notifier := notification.NewNotifier(config.NotificationConfig{AppID: "Watchman"})
+	notifier.SetPusher(pusher)
+	log := &logger.Logger{Logger: zerolog.Nop()}
+
+	start := func(v string) {
+		version = v
+		announceUpdateApplied(cfg, store, notifier, log)
+	}
+
+	start("v1.1.0")
+	assert.Empty(t, pusher.sent, "first start is not an update")
+
+	start("v1.2.0")
+	require.Len(t, pusher.sent, 1)
+	assert.Equal(t, "Updated to v1.2.0 (was v1.1.0)", pusher.sent[0].Message)
+
+	start("v1.2.0")
+	assert.Len(t, pusher.sent, 1, "the update is announced once")
+
+	cfg.Update.NotifyApplied = false
+	start("v1.3.0")
+	assert.Len(t, pusher.sent, 1, "update.notify_applied off only logs")
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.3.0", st.LastVersion)
+}
+
+func TestServiceHost_Reload(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(testConfig), 0o600))
+	cfgFile = configPath
+	t.Cleanup(func() { cfgFile = "" })
+
+	cfg, err := loadConfig()
+	require.NoError(t, err)
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	log := &logger.Logger{Logger: zerolog.Nop()}
+	rt, err := newServiceRuntime(cfg, store, log)
+	require.NoError(t, err)
+	host := &serviceHost{store: store, log: log, current: rt}
+
+	require.Error(t, host.reload(), "the service has not started")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, host.start(ctx))
+	defer func() { _ = host.stop() }()
+
+	edited := strings.Replace(testConfig, `"08:00"`, `"09:00"`, 1)
+	require.NoError(t, os.WriteFile(configPath, []byte(edited), 0o600))
+	require.NoError(t, host.reload())
+	assert.Equal(t, []string{"09:00"}, host.current.cfg.Scheduler.CheckTimes)
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	require.NotNil(t, st.AppliedConfig, "the reloaded configuration is recorded")
+	assert.Equal(t, []string{"09:00"}, st.AppliedConfig.Scheduler.CheckTimes)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("servers: ["), 0o600))
+	require.Error(t, host.reload())
+	assert.Equal(t, []string{"09:00"}, host.current.cfg.Scheduler.CheckTimes, "an invalid file keeps the current configuration")
+}
+
+func TestDispatchFailedJobs_LogsThrottledSend(t *testing.T) {
+	t.Setenv("ProgramData", t.TempDir())
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	cfg := &config.Config{Notification: config.NotificationConfig{MinIntervalBetweenSends: 300}}
+	dispatcher := notification.NewDispatcher(cfg, store)
+
+	var logs bytes.Buffer
+	log := &logger.Logger{Logger: zerolog.New(&logs)}
+	result := &jobs.CheckResult{FailedJobs: []database.FailedJob{{ServerName: "SQL01", JobName: "Backup"}}}
+
+	dispatchFailedJobs(context.Background(), dispatcher, result, log)
+	assert.Contains(t, logs.String(), "notification sent")
+
+	logs.Reset()
+	dispatchFailedJobs(context.Background(), dispatcher, result, log)
+	assert.Contains(t, logs.String(), "notification deferred")
+	assert.NotContains(t, logs.String(), "notification sent")
+}
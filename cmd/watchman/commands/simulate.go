@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/notification"
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+// simulateCmd represents the simulate command.
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Preview notifications for sample failed jobs",
+	Long: `Run sample failed jobs through the configured job filters and
+the notification dispatcher without a database, and print what would
+be sent.
+
+The input is a JSON array of failed jobs in the same shape as the
+failed_jobs field of 'watchmen check --output json'. Jobs are matched
+to servers by name. Each job's runs in the input stand in for its
+history when applying monitoring.consecutive_failures.
+
+Warn statuses, monitor-only servers, mute, quiet hours, digest mode
+and the send throttle are applied as the service would right now. No
+notification is shown, nothing is written to the pipe and no state is
+changed. Useful for tuning filters, grouping and throttling.`,
+	Example: `  # Preview notifications for sample failures
+  watchmen simulate --input failures.json
+
+  # Reuse a real check as input
+  watchmen check --output json | jq '.failed_jobs' > failures.json
+  watchmen simulate --input failures.json --output json`,
+	RunE: runSimulate,
+}
+
+var (
+	simulateInput string
+)
+
+func init() {
+	rootCmd.AddCommand(simulateCmd)
+
+	simulateCmd.Flags().StringVarP(&simulateInput, "input", "i", "",
+		"JSON file with an array of failed jobs (required)")
+	_ = simulateCmd.MarkFlagRequired("input")
+}
+
+func runSimulate(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	failed, err := readFailedJobs(simulateInput)
+	if err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+
+	sim, err := notification.Simulate(cfg, currentState(), failed)
+	if err != nil {
+		return withExitCode(exitInternalError, err)
+	}
+
+	if getOutput() == OutputJSON {
+		printJSON(sim)
+		return nil
+	}
+	if !isQuiet() {
+		printSimulation(sim)
+	}
+	return nil
+}
+
+// currentState returns the persisted state for simulations, so quiet hours
+// and the send throttle start from where the service is. An unreadable state
+// is treated as empty.
+func currentState() *state.State {
+	st, err := state.NewStore(state.DefaultPath()).Load()
+	if err != nil {
+		return nil
+	}
+	return st
+}
+
+// readFailedJobs reads a JSON array of failed jobs from path.
+func readFailedJobs(path string) ([]database.FailedJob, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is chosen by the user
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	var failed []database.FailedJob
+	if err := json.Unmarshal(data, &failed); err != nil {
+		return nil, fmt.Errorf("failed to parse input: %w", err)
+	}
+	return failed, nil
+}
+
+// printSimulation prints a simulation in human-readable format.
+func printSimulation(sim *notification.Simulation) {
+	fmt.Printf("📥 %d failed jobs in input, %d reported, %d dropped\n",
+		sim.Input, len(sim.Reported), len(sim.Dropped))
+
+	if len(sim.Dropped) > 0 {
+		fmt.Println("\nDropped:")
+		for _, d := range sim.Dropped {
+			fmt.Printf("  • %s / %s: %s\n", d.Job.ServerName, d.Job.JobName, d.Reason)
+		}
+	}
+
+	if sim.Muted {
+		fmt.Println("\n🔇 Notifications are muted")
+	}
+	if len(sim.Deferred) > 0 {
+		fmt.Printf("\n⏸️ %d failed jobs would be queued by quiet hours, digest mode or the send throttle\n",
+			len(sim.Deferred))
+	}
+
+	if len(sim.Notifications) == 0 {
+		fmt.Println("\n🔕 No notifications would be sent")
+		return
+	}
+
+	fmt.Printf("\n🔔 %d notifications would be sent:\n", len(sim.Notifications))
+	for _, n := range sim.Notifications {
+		fmt.Printf("\n  [%s] %s\n", n.Backend, n.Title)
+		for _, line := range strings.Split(n.Message, "\n") {
+			fmt.Printf("    %s\n", line)
+		}
+	}
+}
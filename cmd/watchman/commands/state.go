@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+// stateCmd represents the state command.
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Manage the runtime state file",
+	Long:  `Maintain the state file (%ProgramData%\Watchman\state.json).`,
+}
+
+// stateCompactCmd represents the state compact command.
+var stateCompactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Remove stale entries from the state file",
+	Long: `Remove stale entries from the state file and rewrite it atomically.
+
+Entries older than the longest window they are used for are removed:
+monitoring.lookback_hours, notification.min_interval_between_sends, or
+a day when digest mode or quiet hours can hold failed jobs back. Last
+check times and outages of servers no longer configured are removed
+too.
+
+The service compacts the state file when it starts and every day at
+03:00, so running this by hand is rarely needed.`,
+	Example: `  # Compact the state file
+  watchmen state compact
+
+  # JSON output
+  watchmen state compact --output json`,
+	RunE: runStateCompact,
+}
+
+// stateCompactTime is when the service compacts the state file each day.
+const stateCompactTime = "03:00"
+
+// compactResult is the JSON output of state compact.
+type compactResult struct {
+	Path    string `json:"path"`
+	Removed int    `json:"removed"`
+}
+
+func init() {
+	rootCmd.AddCommand(stateCmd)
+	stateCmd.AddCommand(stateCompactCmd)
+}
+
+func runStateCompact(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	store := state.NewStore(state.DefaultPath())
+	removed, err := compactState(cfg, store, time.Now())
+	if err != nil {
+		return withExitCode(exitInternalError, err)
+	}
+
+	if getOutput() == OutputJSON {
+		printJSON(compactResult{Path: store.Path(), Removed: removed})
+		return nil
+	}
+	if !isQuiet() {
+		fmt.Printf("Removed %d stale entries from %s\n", removed, store.Path())
+	}
+	return nil
+}
+
+// compactState removes state entries older than the retention window of cfg
+// and those of servers no longer configured.
+func compactState(cfg *config.Config, store *state.Store, now time.Time) (int, error) {
+	servers := make([]string, 0, len(cfg.Servers))
+	for _, server := range cfg.Servers {
+		servers = append(servers, server.Name)
+	}
+	return store.Compact(now, state.RetentionWindow(cfg), servers)
+}
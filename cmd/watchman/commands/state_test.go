@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+func TestStateCompact(t *testing.T) {
+	t.Setenv("ProgramData", t.TempDir())
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(testConfig), 0o600))
+
+	store := state.NewStore(state.DefaultPath())
+	now := time.Now()
+	require.NoError(t, store.Save(&state.State{
+		LastChecks: map[string]time.Time{
+			"SQL01": now.Add(-time.Hour),
+			"OLD":   now.Add(-time.Hour),
+		},
+	}))
+
+	stdout, err := executeArgs(t, "state", "compact", "--config", configPath, "--output", "json")
+	require.NoError(t, err)
+
+	var result compactResult
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, 1, result.Removed)
+	assert.Equal(t, store.Path(), result.Path)
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Contains(t, st.LastChecks, "SQL01")
+	assert.NotContains(t, st.LastChecks, "OLD")
+}
@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/notification"
+)
+
+// testNotificationCmd represents the test-notification command.
+var testNotificationCmd = &cobra.Command{
+	Use:   "test-notification",
+	Short: "Show a sample failed job notification",
+	Long: `Show a sample failed job toast using the notification settings.
+
+Use --app-id and --icon to try a different toast identity without
+editing the configuration. The app ID must be registered with
+'watchmen register-toast' for Windows to show its name and icon.`,
+	Example: `  # Show a sample notification
+  watchmen test-notification
+
+  # Try another identity and icon
+  watchmen test-notification --app-id Contoso.Watchman --icon C:\branding\icon.png`,
+	RunE: runTestNotification,
+}
+
+var (
+	notifyAppID string
+	notifyIcon  string
+)
+
+func init() {
+	rootCmd.AddCommand(testNotificationCmd)
+
+	for _, cmd := range []*cobra.Command{testNotificationCmd, checkCmd} {
+		cmd.Flags().StringVar(&notifyAppID, "app-id", "",
+			"override notification.app_id for this run")
+		cmd.Flags().StringVar(&notifyIcon, "icon", "",
+			"override notification.icon_path for this run")
+	}
+}
+
+func runTestNotification(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if err := applyNotificationOverrides(cfg); err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+
+	sample := database.FailedJob{
+		ServerName:   "WATCHMAN-TEST",
+		JobName:      "Sample_Job",
+		FailedAt:     time.Now(),
+		ErrorMessage: "This is a test notification from Watchman.",
+	}
+	if err := notification.NewNotifier(cfg.Notification).NotifyFailedJobs([]database.FailedJob{sample}); err != nil {
+		return withExitCode(exitInternalError, err)
+	}
+
+	if getOutput() == OutputJSON {
+		printJSON(map[string]interface{}{
+			"status":    "success",
+			"app_id":    cfg.Notification.AppID,
+			"icon_path": cfg.Notification.IconPath,
+		})
+		return nil
+	}
+	if !isQuiet() {
+		fmt.Printf("✅ Test notification sent (app ID %q)\n", cfg.Notification.AppID)
+	}
+	return nil
+}
+
+// applyNotificationOverrides applies --app-id and --icon to the notification settings.
+// The icon is made absolute since Windows cannot resolve relative toast image paths.
+func applyNotificationOverrides(cfg *config.Config) error {
+	if notifyAppID != "" {
+		cfg.Notification.AppID = notifyAppID
+	}
+	if notifyIcon != "" {
+		icon, err := filepath.Abs(notifyIcon)
+		if err != nil {
+			return fmt.Errorf("failed to resolve icon path: %w", err)
+		}
+		cfg.Notification.IconPath = icon
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/go-toast/toast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/notification"
+)
+
+// recordingPusher records pushed toasts.
+type recordingPusher struct {
+	sent []toast.Notification
+}
+
+func (p *recordingPusher) Push(n toast.Notification) error {
+	p.sent = append(p.sent, n)
+	return nil
+}
+
+func TestApplyNotificationOverrides(t *testing.T) {
+	t.Cleanup(func() { notifyAppID, notifyIcon = "", "" })
+
+	tests := []struct {
+		name      string
+		appID     string
+		icon      string
+		wantAppID string
+		wantIcon  string
+	}{
+		{name: "no overrides", wantAppID: "Watchman", wantIcon: `C:\watchman\icon.png`},
+		{name: "app id", appID: "Contoso.Watchman", wantAppID: "Contoso.Watchman", wantIcon: `C:\watchman\icon.png`},
+		{name: "icon made absolute", icon: "branding.png", wantAppID: "Watchman", wantIcon: mustAbs(t, "branding.png")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifyAppID, notifyIcon = tt.appID, tt.icon
+			cfg := config.DefaultConfig()
+			cfg.Notification.IconPath = `C:\watchman\icon.png`
+			require.NoError(t, applyNotificationOverrides(cfg))
+
+			pusher := &recordingPusher{}
+			notifier := notification.NewNotifier(cfg.Notification)
+			notifier.SetPusher(pusher)
+			require.NoError(t, notifier.NotifyFailedJobs([]database.FailedJob{{ServerName: "SQL01", JobName: "Backup"}}))
+
+			require.Len(t, pusher.sent, 1)
+			assert.Equal(t, tt.wantAppID, pusher.sent[0].AppID)
+			assert.Equal(t, tt.wantIcon, pusher.sent[0].Icon)
+		})
+	}
+}
+
+func mustAbs(t *testing.T, path string) string {
+	t.Helper()
+	abs, err := filepath.Abs(path)
+	require.NoError(t, err)
+	return abs
+}
@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// colorRole is a semantic part of the colored text output.
+type colorRole int
+
+const (
+	roleSuccess colorRole = iota // No failures
+	roleFailure                  // Failed jobs and error summaries
+	roleWarning                  // Warn-only jobs and unavailable servers
+	roleServer                   // Server headings
+	roleDetail                   // Error messages and timings
+)
+
+// Theme names accepted by --theme.
+const (
+	themeDefault      = "default"
+	themeHighContrast = "high-contrast"
+	themeMonochrome   = "monochrome"
+)
+
+// themes maps a theme name to the ANSI SGR parameters of each role.
+// The high-contrast theme avoids telling success and failure apart by
+// red and green alone; the monochrome theme uses only bold, dim and
+// underline.
+var themes = map[string]map[colorRole]string{
+	themeDefault: {
+		roleSuccess: "32",
+		roleFailure: "31",
+		roleWarning: "33",
+		roleServer:  "1;36",
+		roleDetail:  "2",
+	},
+	themeHighContrast: {
+		roleSuccess: "1;94",
+		roleFailure: "1;97;41",
+		roleWarning: "1;30;43",
+		roleServer:  "1;97",
+		roleDetail:  "97",
+	},
+	themeMonochrome: {
+		roleSuccess: "1",
+		roleFailure: "1;4",
+		roleWarning: "1",
+		roleServer:  "1",
+		roleDetail:  "2",
+	},
+}
+
+// themeNames returns the registered theme names, sorted.
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// themeFlag is a --theme value restricted to the registered themes.
+type themeFlag string
+
+// String implements pflag.Value.
+func (f *themeFlag) String() string { return string(*f) }
+
+// Set implements pflag.Value.
+func (f *themeFlag) Set(name string) error {
+	if _, ok := themes[name]; !ok {
+		return fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(themeNames(), ", "))
+	}
+	*f = themeFlag(name)
+	return nil
+}
+
+// Type implements pflag.Value.
+func (f *themeFlag) Type() string { return "string" }
+
+// palette colors text by role. The zero palette leaves text unchanged.
+type palette struct {
+	codes map[colorRole]string
+}
+
+// newPalette returns the palette of the named theme, or the zero palette
+// when plain is set.
+func newPalette(theme string, plain bool) palette {
+	if plain {
+		return palette{}
+	}
+	return palette{codes: themes[theme]}
+}
+
+// outputPalette returns the palette for text written to stdout. Color is
+// disabled by --no-color, by the NO_COLOR environment variable and when
+// stdout is not a terminal.
+func outputPalette(theme string, noColor bool) palette {
+	plain := noColor || os.Getenv("NO_COLOR") != "" || !colorSupported(os.Stdout)
+	return newPalette(theme, plain)
+}
+
+// paint wraps s in the escape codes of role.
+func (p palette) paint(role colorRole, s string) string {
+	code := p.codes[role]
+	if code == "" {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
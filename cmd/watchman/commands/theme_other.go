@@ -0,0 +1,14 @@
+//go:build !windows
+
+package commands
+
+import (
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// colorSupported reports whether f is a terminal.
+func colorSupported(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd())
+}
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// colorCode matches SGR foreground and background color parameters.
+var colorCode = regexp.MustCompile(`\x1b\[[0-9;]*\b(3[0-7]|4[0-7]|9[0-7]|10[0-7])\b[0-9;]*m`)
+
+func TestPalette_ThemeChangesCodes(t *testing.T) {
+	def := newPalette(themeDefault, false)
+	high := newPalette(themeHighContrast, false)
+
+	assert.Equal(t, "\x1b[31mfailed\x1b[0m", def.paint(roleFailure, "failed"))
+	assert.Equal(t, "\x1b[1;97;41mfailed\x1b[0m", high.paint(roleFailure, "failed"))
+
+	for _, role := range []colorRole{roleSuccess, roleFailure, roleWarning, roleServer, roleDetail} {
+		assert.NotEqual(t, def.paint(role, "x"), high.paint(role, "x"), "role %d", role)
+	}
+}
+
+func TestPalette_Monochrome(t *testing.T) {
+	p := newPalette(themeMonochrome, false)
+
+	for _, role := range []colorRole{roleSuccess, roleFailure, roleWarning, roleServer, roleDetail} {
+		painted := p.paint(role, "x")
+		assert.Contains(t, painted, "\x1b[", "role %d keeps emphasis", role)
+		assert.False(t, colorCode.MatchString(painted), "role %d uses a color: %q", role, painted)
+	}
+}
+
+func TestPalette_Plain(t *testing.T) {
+	for _, name := range themeNames() {
+		p := newPalette(name, true)
+		assert.Equal(t, "failed", p.paint(roleFailure, "failed"), name)
+	}
+	assert.Equal(t, "ok", palette{}.paint(roleSuccess, "ok"))
+}
+
+func TestOutputPalette_NoColorEnv(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	assert.Equal(t, "failed", outputPalette(themeDefault, false).paint(roleFailure, "failed"))
+}
+
+func TestThemeFlag(t *testing.T) {
+	var f themeFlag
+	require.NoError(t, f.Set(themeHighContrast))
+	assert.Equal(t, themeHighContrast, f.String())
+
+	err := f.Set("neon")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "default, high-contrast, monochrome")
+	assert.Equal(t, themeHighContrast, f.String(), "rejected value leaves the flag unchanged")
+}
+
+func TestCheck_UnknownTheme(t *testing.T) {
+	_, err := executeArgs(t, "check", "--theme", "neon")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown theme")
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package commands
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// colorSupported reports whether f is a console that accepts ANSI escape
+// codes, enabling virtual terminal processing on it if needed.
+func colorSupported(f *os.File) bool {
+	handle := windows.Handle(f.Fd())
+
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		return false
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return true
+	}
+	return windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil
+}
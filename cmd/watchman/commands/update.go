@@ -1,9 +1,17 @@
 package commands
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/state"
+	"github.com/hoangtran1411/watchman/internal/updater"
 )
 
 // updateCmd represents the update command.
@@ -21,13 +29,17 @@ an update. Use --yes to skip the prompt.`,
   watchmen update --yes
 
   # Check only (don't apply)
-  watchmen update --check-only`,
+  watchmen update --check-only
+
+  # Skip the available version; notify again when a newer one is released
+  watchmen update --remind-later`,
 	RunE: runUpdate,
 }
 
 var (
-	updateYes       bool
-	updateCheckOnly bool
+	updateYes         bool
+	updateCheckOnly   bool
+	updateRemindLater bool
 )
 
 func init() {
@@ -37,26 +49,79 @@ func init() {
 		"auto-apply update without confirmation")
 	updateCmd.Flags().BoolVar(&updateCheckOnly, "check-only", false,
 		"check for updates without applying")
+	updateCmd.Flags().BoolVar(&updateRemindLater, "remind-later", false,
+		"stop notifying about the available version until a newer one is released")
 }
 
 func runUpdate(cmd *cobra.Command, args []string) error {
-	// TODO: Implement update logic using selfupdate library
+	// Updating must keep working even when the server config is broken
+	cfg, err := loadConfig()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
 
-	if getOutput() == OutputJSON {
-		result := map[string]interface{}{
-			"current_version":  version,
-			"latest_version":   "unknown",
-			"update_available": false,
-			"message":          "Update check not yet implemented",
+	u := updater.NewUpdater(cfg.Update, version)
+	result, err := u.CheckForUpdate(cmd.Context())
+	if err != nil {
+		return withExitCode(exitConnectionError, err)
+	}
+
+	switch {
+	case !result.UpdateAvailable || updateCheckOnly:
+		// Nothing to apply
+	case updateRemindLater:
+		err = state.NewStore(state.DefaultPath()).Update(func(st *state.State) error {
+			st.DismissUpdate(result.LatestVersion, time.Now())
+			return nil
+		})
+		if err != nil {
+			return withExitCode(exitInternalError, err)
+		}
+		if !isQuiet() && getOutput() != OutputJSON {
+			fmt.Printf("Notifications for version %s dismissed until a newer release is available\n", result.LatestVersion)
+		}
+	case updateYes || confirmUpdate(result.LatestVersion):
+		result, err = u.Update(cmd.Context())
+		if err != nil {
+			return withExitCode(exitInternalError, err)
 		}
+	}
+
+	if getOutput() == OutputJSON {
 		printJSON(result)
 		return nil
 	}
 
 	if !isQuiet() {
-		fmt.Printf("Current version: %s\n", version)
-		fmt.Println("Update check not yet implemented")
-		fmt.Println("Check https://github.com/hoangtran1411/watchman/releases for latest version")
+		printUpdateResult(result)
 	}
 	return nil
 }
+
+// confirmUpdate asks the user to confirm applying the update.
+func confirmUpdate(newVersion string) bool {
+	if getOutput() == OutputJSON {
+		return false
+	}
+	fmt.Printf("Update to version %s? [y/N]: ", newVersion)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// printUpdateResult prints an update result in human-readable format.
+func printUpdateResult(result *updater.UpdateResult) {
+	fmt.Printf("Current version: %s\n", result.CurrentVersion)
+	if result.LatestVersion != "" {
+		fmt.Printf("Latest version:  %s\n", result.LatestVersion)
+	}
+
+	switch {
+	case result.Applied:
+		fmt.Println("Update applied. Restart the service to use the new version.")
+	case result.UpdateAvailable:
+		fmt.Println("An update is available. Run 'watchmen update --yes' to upgrade.")
+	default:
+		fmt.Println("You are running the latest version.")
+	}
+}
@@ -3,9 +3,14 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"runtime"
 
 	"github.com/spf13/cobra"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/updater"
+	"github.com/hoangtran1411/watchman/internal/warnings"
 )
 
 // VersionInfo holds version information.
@@ -16,11 +21,21 @@ type VersionInfo struct {
 	GoVersion string `json:"go_version"`
 	OS        string `json:"os"`
 	Arch      string `json:"arch"`
+
+	// Set by --check-update.
+	LatestVersion   string `json:"latest_version,omitempty"`
+	UpdateAvailable *bool  `json:"update_available,omitempty"`
+	UpdateError     string `json:"update_error,omitempty"`
 }
 
 const (
+	// OutputText is the human-readable output format.
+	OutputText = "text"
 	// OutputJSON is the JSON output format.
 	OutputJSON = "json"
+	// OutputNDJSON streams one JSON document per line where a command
+	// supports it (servers matrix); other commands print compact JSON.
+	OutputNDJSON = "ndjson"
 )
 
 // versionCmd represents the version command.
@@ -29,20 +44,29 @@ var versionCmd = &cobra.Command{
 	Short: "Show version information",
 	Long: `Show version information including build details.
 
-Use --output json for machine-readable output.`,
+Use --output json for machine-readable output. With --check-update the
+latest release is looked up too, as 'watchmen update --check-only' does.`,
 	Example: `  # Show version
   watchmen version
 
   # JSON output
-  watchmen version --output json`,
-	Run: runVersion,
+  watchmen version --output json
+
+  # Am I current?
+  watchmen version --check-update`,
+	RunE: runVersion,
 }
 
+var versionCheckUpdate bool
+
 func init() {
 	rootCmd.AddCommand(versionCmd)
+
+	versionCmd.Flags().BoolVar(&versionCheckUpdate, "check-update", false,
+		"also check for a newer release")
 }
 
-func runVersion(cmd *cobra.Command, args []string) {
+func runVersion(cmd *cobra.Command, args []string) error {
 	info := VersionInfo{
 		Version:   version,
 		Commit:    commit,
@@ -52,25 +76,123 @@ func runVersion(cmd *cobra.Command, args []string) {
 		Arch:      runtime.GOARCH,
 	}
 
+	var checkErr error
+	if versionCheckUpdate {
+		// Like update, checking must keep working when the server config is broken
+		cfg, err := loadConfig()
+		if err != nil {
+			cfg = config.DefaultConfig()
+		}
+		result, err := updater.NewUpdater(cfg.Update, version).CheckForUpdate(cmd.Context())
+		info = withUpdateResult(info, result, err)
+		checkErr = err
+	}
+
 	if getOutput() == OutputJSON {
 		printJSON(info)
+	} else {
+		writeVersionInfo(cmd.OutOrStdout(), info, versionCheckUpdate)
+	}
+
+	if checkErr != nil {
+		return withExitCode(exitConnectionError, nil)
+	}
+	return nil
+}
+
+// withUpdateResult adds the outcome of an update check to info.
+func withUpdateResult(info VersionInfo, result *updater.UpdateResult, err error) VersionInfo {
+	if err != nil {
+		info.UpdateError = err.Error()
+		return info
+	}
+	available := result.UpdateAvailable
+	info.LatestVersion = result.LatestVersion
+	info.UpdateAvailable = &available
+	return info
+}
+
+// writeVersionInfo prints info, including the update check if checked.
+func writeVersionInfo(w io.Writer, info VersionInfo, checked bool) {
+	_, _ = fmt.Fprintf(w, "Watchmen %s\n", info.Version)
+	_, _ = fmt.Fprintf(w, "  Commit:     %s\n", info.Commit)
+	_, _ = fmt.Fprintf(w, "  Built:      %s\n", info.BuildDate)
+	_, _ = fmt.Fprintf(w, "  Go version: %s\n", info.GoVersion)
+	_, _ = fmt.Fprintf(w, "  OS/Arch:    %s/%s\n", info.OS, info.Arch)
+	if !checked {
 		return
 	}
 
-	// Text output
-	fmt.Printf("Watchmen %s\n", info.Version)
-	fmt.Printf("  Commit:     %s\n", info.Commit)
-	fmt.Printf("  Built:      %s\n", info.BuildDate)
-	fmt.Printf("  Go version: %s\n", info.GoVersion)
-	fmt.Printf("  OS/Arch:    %s/%s\n", info.OS, info.Arch)
+	switch {
+	case info.UpdateError != "":
+		_, _ = fmt.Fprintf(w, "  Latest:     unknown (%s)\n", info.UpdateError)
+	case info.LatestVersion == "":
+		_, _ = fmt.Fprintln(w, "  Latest:     no release found")
+	case *info.UpdateAvailable:
+		_, _ = fmt.Fprintf(w, "  Latest:     %s (update available, run 'watchmen update')\n", info.LatestVersion)
+	default:
+		_, _ = fmt.Fprintf(w, "  Latest:     %s (up to date)\n", info.LatestVersion)
+	}
 }
 
-// printJSON prints data as JSON.
+// printJSON prints data as JSON, indented unless --json-compact or
+// --output ndjson is set.
 func printJSON(v interface{}) {
 	encoder := json.NewEncoder(cmd.OutOrStdout())
-	encoder.SetIndent("", "  ")
+	if !jsonCompact && !isNDJSON() {
+		encoder.SetIndent("", "  ")
+	}
 	_ = encoder.Encode(v)
 }
 
+// jsonSchemaVersion is the schema_version of the check, config and error
+// JSON output. Bump it when a field is renamed, removed or changes meaning;
+// adding a field is not a breaking change.
+const jsonSchemaVersion = 1
+
+// printVersionedJSON prints v, which must encode as a JSON object, like
+// printJSON with a leading schema_version field.
+func printVersionedJSON(v interface{}) {
+	printJSON(versionedJSON(v))
+}
+
+// printVersionedJSONWithMeta prints v like printVersionedJSON, followed by
+// meta as a "meta" field describing the run.
+func printVersionedJSONWithMeta(v, meta interface{}) {
+	printJSON(versionedJSONWithMeta(v, meta))
+}
+
+// versionedJSON encodes v with schema_version as its first field. The other
+// fields keep their order. Values that are not JSON objects are returned as is.
+func versionedJSON(v interface{}) json.RawMessage {
+	return versionedJSONWithMeta(v, nil)
+}
+
+// versionedJSONWithMeta encodes v like versionedJSON with meta, if not nil,
+// as a "meta" field right after schema_version. The warnings collected so
+// far follow as a "warnings" array and are not printed to stderr again.
+func versionedJSONWithMeta(v, meta interface{}) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil || len(data) < 2 || data[0] != '{' {
+		return data
+	}
+
+	prefix := fmt.Sprintf(`{"schema_version":%d`, jsonSchemaVersion)
+	if meta != nil {
+		if m, err := json.Marshal(meta); err == nil {
+			prefix += `,"meta":` + string(m)
+		}
+	}
+	if ws := warnings.Take(); len(ws) > 0 {
+		if w, err := json.Marshal(ws); err == nil {
+			prefix += `,"warnings":` + string(w)
+		}
+	}
+	if string(data) == "{}" {
+		return json.RawMessage(prefix + "}")
+	}
+	return json.RawMessage(prefix + "," + string(data[1:]))
+}
+
 // cmd is a reference to access stdout (used by printJSON).
 var cmd = rootCmd
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/updater"
+)
+
+func TestWithUpdateResult(t *testing.T) {
+	info := VersionInfo{Version: "1.2.0", Commit: "abc123", OS: "windows", Arch: "amd64"}
+
+	tests := []struct {
+		name     string
+		result   *updater.UpdateResult
+		err      error
+		wantJSON string
+		wantText string
+	}{
+		{
+			name:     "update available",
+			result:   &updater.UpdateResult{CurrentVersion: "1.2.0", LatestVersion: "1.3.0", UpdateAvailable: true},
+			wantJSON: `"latest_version":"1.3.0","update_available":true`,
+			wantText: "  Latest:     1.3.0 (update available, run 'watchmen update')\n",
+		},
+		{
+			name:     "up to date",
+			result:   &updater.UpdateResult{CurrentVersion: "1.2.0", LatestVersion: "1.2.0"},
+			wantJSON: `"latest_version":"1.2.0","update_available":false`,
+			wantText: "  Latest:     1.2.0 (up to date)\n",
+		},
+		{
+			name:     "no release",
+			result:   &updater.UpdateResult{CurrentVersion: "1.2.0"},
+			wantJSON: `"update_available":false`,
+			wantText: "  Latest:     no release found\n",
+		},
+		{
+			name:     "check failed",
+			err:      errors.New("rate limited"),
+			wantJSON: `"update_error":"rate limited"`,
+			wantText: "  Latest:     unknown (rate limited)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := withUpdateResult(info, tt.result, tt.err)
+			assert.Equal(t, "1.2.0", got.Version, "version info is kept")
+			assert.Equal(t, "abc123", got.Commit)
+
+			data, err := json.Marshal(got)
+			require.NoError(t, err)
+			assert.Contains(t, string(data), tt.wantJSON)
+
+			var buf bytes.Buffer
+			writeVersionInfo(&buf, got, true)
+			assert.Contains(t, buf.String(), "Watchmen 1.2.0\n")
+			assert.Contains(t, buf.String(), tt.wantText)
+		})
+	}
+}
+
+func TestVersion_WithoutUpdateCheck(t *testing.T) {
+	stdout, err := executeArgs(t, "version", "--output", "json")
+	require.NoError(t, err)
+	assert.NotContains(t, stdout, "update_available")
+	assert.NotContains(t, stdout, "latest_version")
+
+	var buf bytes.Buffer
+	writeVersionInfo(&buf, VersionInfo{Version: "1.2.0"}, false)
+	assert.NotContains(t, buf.String(), "Latest")
+}
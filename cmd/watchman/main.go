@@ -22,6 +22,6 @@ func main() {
 
 	// Execute root command
 	if err := commands.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(commands.ExitCode(err))
 	}
 }
@@ -4,22 +4,52 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
+
+	"github.com/hoangtran1411/watchman/internal/warnings"
 )
 
+// envPrefix marks a value that must be read from an environment variable.
+const envPrefix = "env:"
+
 // Config represents the complete application configuration.
 type Config struct {
 	Servers      []ServerConfig     `mapstructure:"servers"`
+	ServersFile  string             `mapstructure:"servers_file"` // Extra servers, merged by name
 	Scheduler    SchedulerConfig    `mapstructure:"scheduler"`
 	Notification NotificationConfig `mapstructure:"notification"`
 	Logging      LoggingConfig      `mapstructure:"logging"`
 	Monitoring   MonitoringConfig   `mapstructure:"monitoring"`
 	Update       UpdateConfig       `mapstructure:"update"`
+	Output       OutputConfig       `mapstructure:"output"`
+}
+
+// Output formats accepted by --output and output.default.
+var outputFormats = []string{"text", "json", "ndjson"}
+
+// OutputConfig represents CLI output configuration.
+type OutputConfig struct {
+	// Default is the --output format used when neither the flag nor the
+	// WATCHMAN_OUTPUT environment variable is set.
+	Default string `mapstructure:"default"`
+}
+
+// validate checks that the default output format is known.
+func (o OutputConfig) validate() error {
+	if o.Default != "" && !slices.Contains(outputFormats, o.Default) {
+		return fmt.Errorf("invalid output.default %q (expected %s)", o.Default, strings.Join(outputFormats, ", "))
+	}
+	return nil
 }
 
 // ServerConfig represents a SQL Server instance configuration.
@@ -32,6 +62,37 @@ type ServerConfig struct {
 	Auth     AuthConfig `mapstructure:"auth"`
 	Options  DBOptions  `mapstructure:"options"`
 	Jobs     JobsFilter `mapstructure:"jobs"`
+
+	// MsdbDatabase is the database holding SQL Server Agent job history.
+	// Empty means msdb.
+	MsdbDatabase string `mapstructure:"msdb_database"`
+
+	// DSN is a complete driver connection string, used verbatim instead of
+	// host, port, database, auth and the connection options.
+	DSN string `mapstructure:"dsn"`
+
+	// ReadOnlyIntent connects with ApplicationIntent=ReadOnly, which an
+	// availability group listener routes to a readable secondary. Job
+	// history in msdb is local to each instance, so this only reports the
+	// primary's failures if history is replicated to the secondary.
+	ReadOnlyIntent bool `mapstructure:"read_only_intent"`
+
+	// MonitorOnly checks and logs the server but never notifies about its
+	// failed jobs or outages, e.g. for pre-production servers. Unlike
+	// Enabled: false, the server is still checked and reported.
+	MonitorOnly bool `mapstructure:"monitor_only"`
+
+	// DisplayName is shown instead of the server name in notifications and
+	// check output, e.g. "Finance Prod DB". Empty shows the server name.
+	DisplayName string `mapstructure:"display_name"`
+}
+
+// Label returns the server's display name, or its name if none is configured.
+func (s ServerConfig) Label() string {
+	if s.DisplayName != "" {
+		return s.DisplayName
+	}
+	return s.Name
 }
 
 // AuthConfig represents authentication configuration.
@@ -49,17 +110,77 @@ type DBOptions struct {
 	QueryTimeout           int  `mapstructure:"query_timeout"`
 }
 
+// Timeouts in seconds used when a server's options leave them unset, e.g.
+// for a server configured only with a dsn.
+const (
+	DefaultConnectionTimeout = 30
+	DefaultQueryTimeout      = 60
+)
+
+// WithDefaults returns the options with unset timeouts set to
+// DefaultConnectionTimeout and DefaultQueryTimeout.
+func (o DBOptions) WithDefaults() DBOptions {
+	if o.ConnectionTimeout <= 0 {
+		o.ConnectionTimeout = DefaultConnectionTimeout
+	}
+	if o.QueryTimeout <= 0 {
+		o.QueryTimeout = DefaultQueryTimeout
+	}
+	return o
+}
+
 // JobsFilter represents job filtering configuration.
 type JobsFilter struct {
 	Include []string `mapstructure:"include"`
 	Exclude []string `mapstructure:"exclude"`
+
+	// Only lists the exact job names to monitor. The names are sent to the
+	// server so only those jobs are read, which is cheaper than filtering
+	// every failure with include patterns. It cannot be combined with
+	// include or exclude.
+	Only []string `mapstructure:"only"`
+
+	// IncludeSteps also reports failed job steps, even when the job itself
+	// succeeded (e.g. after a retry). By default only job outcomes alert.
+	IncludeSteps bool `mapstructure:"include_steps"`
 }
 
 // SchedulerConfig represents scheduler configuration.
 type SchedulerConfig struct {
-	CheckTimes []string    `mapstructure:"check_times"`
-	Timezone   string      `mapstructure:"timezone"`
-	Retry      RetryConfig `mapstructure:"retry"`
+	CheckTimes []string      `mapstructure:"check_times"`
+	Timezone   string        `mapstructure:"timezone"`
+	Retry      RetryConfig   `mapstructure:"retry"`
+	Backoff    BackoffConfig `mapstructure:"backoff"`
+}
+
+// validate checks the check times and the backoff settings.
+func (s SchedulerConfig) validate() error {
+	if len(s.CheckTimes) == 0 {
+		return fmt.Errorf("no check times configured")
+	}
+	for _, t := range s.CheckTimes {
+		if _, err := time.Parse("15:04", t); err != nil {
+			return fmt.Errorf("invalid check time format: %s (expected HH:MM)", t)
+		}
+	}
+	if s.Backoff.AfterFailures < 0 {
+		return fmt.Errorf("backoff after_failures must not be negative")
+	}
+	if s.Backoff.AfterFailures > 0 && s.Backoff.MaxHours <= 0 {
+		return fmt.Errorf("backoff max_hours must be positive")
+	}
+	return nil
+}
+
+// BackoffConfig represents the backoff of scheduled checks while every
+// check fails, e.g. during a network outage. After AfterFailures
+// consecutive checks that reached no server, scheduled checks are skipped
+// for an hour, doubling with each further failed check up to MaxHours.
+// The first successful check resets the backoff.
+type BackoffConfig struct {
+	// AfterFailures is how many failed checks in a row start the backoff. 0 disables it.
+	AfterFailures int `mapstructure:"after_failures"`
+	MaxHours      int `mapstructure:"max_hours"`
 }
 
 // RetryConfig represents retry configuration.
@@ -71,16 +192,217 @@ type RetryConfig struct {
 
 // NotificationConfig represents notification configuration.
 type NotificationConfig struct {
-	AppID    string         `mapstructure:"app_id"`
-	IconPath string         `mapstructure:"icon_path"`
-	Grouping GroupingConfig `mapstructure:"grouping"`
-	Sound    SoundConfig    `mapstructure:"sound"`
+	AppID      string           `mapstructure:"app_id"`
+	IconPath   string           `mapstructure:"icon_path"`
+	Grouping   GroupingConfig   `mapstructure:"grouping"`
+	Sound      SoundConfig      `mapstructure:"sound"`
+	QuietHours QuietHoursConfig `mapstructure:"quiet_hours"`
+	Toast      BackendConfig    `mapstructure:"toast"`
+
+	// NotifyServerDown alerts once when a server becomes unreachable.
+	NotifyServerDown bool `mapstructure:"notify_server_down"`
+
+	// MaxConcurrentSends bounds how many backends are sent to at once.
+	MaxConcurrentSends int `mapstructure:"max_concurrent_sends"`
+
+	// MinIntervalBetweenSends is the minimum number of seconds between two
+	// failed job notifications, across all jobs. Failures reported sooner
+	// are coalesced into the next notification. 0 disables the throttle.
+	MinIntervalBetweenSends int `mapstructure:"min_interval_between_sends"`
+
+	// Mode is "immediate" (default) or "digest". In digest mode failures are
+	// accumulated and sent as one summary at DigestTime (HH:MM) each day.
+	Mode       string `mapstructure:"mode"`
+	DigestTime string `mapstructure:"digest_time"`
+
+	// Outbox keeps failed sends of remote backends for retry.
+	Outbox OutboxConfig `mapstructure:"outbox"`
+
+	// Pipe writes each notification as a JSON line to a local named pipe.
+	Pipe PipeConfig `mapstructure:"pipe"`
+
+	// ProxyURL is the proxy HTTP-based backends, the result sink and
+	// metrics pushes connect through, e.g. "http://proxy.corp:8080". Empty
+	// uses the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+	ProxyURL string `mapstructure:"proxy_url"`
+
+	// TLS configures certificate verification of the same requests.
+	TLS TLSConfig `mapstructure:"tls"`
+
+	// UseEmoji prefixes titles and lines with emoji. Disable it for consoles
+	// and logs that render emoji as boxes; ASCII markers such as "[FAIL]" are
+	// used instead.
+	UseEmoji bool `mapstructure:"use_emoji"`
+
+	// MaxJobNameLength shortens longer job names in notification text with
+	// an ellipsis in the middle, keeping the start and end. Full names are
+	// kept in JSON output and exports. 0 disables shortening.
+	MaxJobNameLength int `mapstructure:"max_job_name_length"`
+
+	// TopN lists grouped failures by severity, most severe first, showing
+	// at most TopN jobs and summarizing the rest by severity. 0 lists them
+	// by server, up to grouping.max_jobs_per_notification.
+	TopN int `mapstructure:"top_n"`
+
+	// TimeFormat is how job failure times are shown: a Go time layout or one
+	// of the presets "iso", "us" and "relative" (e.g. "5 minutes ago").
+	TimeFormat string `mapstructure:"time_format"`
+}
+
+// Notification modes.
+const (
+	NotificationModeImmediate = "immediate"
+	NotificationModeDigest    = "digest"
+)
+
+// Notification time format presets.
+const (
+	TimeFormatDefault  = "2006-01-02 15:04:05"
+	TimeFormatISO      = "iso"
+	TimeFormatUS       = "us"
+	TimeFormatRelative = "relative"
+)
+
+// OutboxConfig represents the notification outbox configuration.
+// Sends that fail, e.g. while the machine is offline, are queued in the
+// state directory and retried on the next check. Toasts are local and are
+// never queued.
+type OutboxConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// MaxAgeHours drops queued sends older than this. 0 uses 24 hours.
+	MaxAgeHours int `mapstructure:"max_age_hours"`
+}
+
+// PipeConfig represents the named pipe notification backend (Windows only).
+// Each notification is written as one JSON line to \\.\pipe\<Name> so local
+// processes can subscribe to events. Events are dropped while the buffer is
+// full, so a slow or absent reader never stalls checks.
+type PipeConfig struct {
+	// Name is the pipe name without the \\.\pipe\ prefix. Empty disables the backend.
+	Name string `mapstructure:"name"`
+
+	// BufferSize is how many events are held for a slow reader. 0 uses 100.
+	BufferSize int `mapstructure:"buffer_size"`
+}
+
+// maxPipeNameLength is the longest pipe name Windows accepts after the
+// \\.\pipe\ prefix.
+const maxPipeNameLength = 247
+
+// validate checks the pipe name is usable.
+func (p PipeConfig) validate() error {
+	if p.BufferSize < 0 {
+		return fmt.Errorf("pipe buffer_size must not be negative")
+	}
+	if strings.Contains(p.Name, `\`) {
+		return fmt.Errorf("invalid pipe name %q: must not contain a backslash", p.Name)
+	}
+	if len(p.Name) > maxPipeNameLength {
+		return fmt.Errorf("invalid pipe name: longer than %d characters", maxPipeNameLength)
+	}
+	return nil
+}
+
+// TLSConfig represents certificate verification of outgoing HTTPS requests.
+type TLSConfig struct {
+	// CAFile is a PEM bundle of extra CAs to trust, e.g. an internal CA.
+	CAFile string `mapstructure:"ca_file"`
+
+	// InsecureSkipVerify disables certificate verification entirely.
+	// Prefer CAFile; this is an explicit opt-in for testing.
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+}
+
+// validateProxyURL checks that a non-empty proxy URL is absolute and uses a
+// scheme Go's HTTP client supports.
+func validateProxyURL(proxy string) error {
+	if proxy == "" {
+		return nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return fmt.Errorf("invalid proxy_url: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("invalid proxy_url scheme %q (expected http, https or socks5)", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid proxy_url %q: missing host", proxy)
+	}
+	return nil
+}
+
+// BackendConfig represents settings shared by every notification backend.
+type BackendConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Retries is how many more times a send failing with a transient error
+	// is attempted, RetryDelayMs milliseconds apart. Only the toast backend
+	// retries; its failures are often shell hiccups.
+	Retries      int `mapstructure:"retries"`
+	RetryDelayMs int `mapstructure:"retry_delay_ms"`
+}
+
+// validate checks the retry settings.
+func (b BackendConfig) validate() error {
+	if b.Retries < 0 || b.RetryDelayMs < 0 {
+		return fmt.Errorf("retries and retry_delay_ms must not be negative")
+	}
+	return nil
+}
+
+// QuietHoursConfig represents a daily window during which notifications are
+// deferred and sent as a single digest once the window ends.
+type QuietHoursConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Start   string `mapstructure:"start"` // HH:MM
+	End     string `mapstructure:"end"`   // HH:MM, may be earlier than Start to span midnight
+}
+
+// Covers returns true if the time of day clock (HH:MM) falls within quiet
+// hours. It returns false if quiet hours are disabled or a time is invalid.
+func (q QuietHoursConfig) Covers(clock string) bool {
+	if !q.Enabled {
+		return false
+	}
+	at, errAt := time.Parse("15:04", clock)
+	start, errStart := time.Parse("15:04", q.Start)
+	end, errEnd := time.Parse("15:04", q.End)
+	if errAt != nil || errStart != nil || errEnd != nil {
+		return false
+	}
+
+	minute := at.Hour()*60 + at.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	if startMinute <= endMinute {
+		return minute >= startMinute && minute < endMinute
+	}
+	// Window spans midnight (e.g. 22:00-07:00)
+	return minute >= startMinute || minute < endMinute
 }
 
 // GroupingConfig represents notification grouping configuration.
 type GroupingConfig struct {
 	Enabled                bool `mapstructure:"enabled"`
 	MaxJobsPerNotification int  `mapstructure:"max_jobs_per_notification"`
+
+	// AutoGroupThreshold applies while grouping is disabled: a server with
+	// more failed jobs than this gets one grouped notification instead of
+	// one per job. 0 always sends one per job.
+	AutoGroupThreshold int `mapstructure:"auto_group_threshold"`
+}
+
+// validate checks the grouping limits.
+func (g GroupingConfig) validate() error {
+	if g.AutoGroupThreshold < 0 {
+		return fmt.Errorf("grouping auto_group_threshold must not be negative")
+	}
+	return nil
 }
 
 // SoundConfig represents notification sound configuration.
@@ -91,12 +413,21 @@ type SoundConfig struct {
 
 // LoggingConfig represents logging configuration.
 type LoggingConfig struct {
-	Level    string         `mapstructure:"level"`
-	Format   string         `mapstructure:"format"`
-	File     FileLogConfig  `mapstructure:"file"`
-	EventLog EventLogConfig `mapstructure:"event_log"`
+	Level       string            `mapstructure:"level"`
+	Format      string            `mapstructure:"format"`
+	File        FileLogConfig     `mapstructure:"file"`
+	EventLog    EventLogConfig    `mapstructure:"event_log"`
+	CheckEvents CheckEventsConfig `mapstructure:"check_events"`
+
+	// RedactFields lists the names of log fields, compared case-insensitively,
+	// whose values are written as "***" to every log output. Setting it
+	// replaces the defaults, so keep the password-like names in the list.
+	RedactFields []string `mapstructure:"redact_fields"`
 }
 
+// defaultRedactFields are the password-like log fields redacted by default.
+var defaultRedactFields = []string{"password", "pwd", "secret", "token"}
+
 // FileLogConfig represents file logging configuration.
 type FileLogConfig struct {
 	Enabled    bool   `mapstructure:"enabled"`
@@ -105,6 +436,7 @@ type FileLogConfig struct {
 	MaxBackups int    `mapstructure:"max_backups"`
 	MaxAgeDays int    `mapstructure:"max_age_days"`
 	Compress   bool   `mapstructure:"compress"`
+	MinFreeMB  int    `mapstructure:"min_free_mb"` // Below this, compress and keep one day of logs; 0 disables
 }
 
 // EventLogConfig represents Windows Event Log configuration.
@@ -113,11 +445,147 @@ type EventLogConfig struct {
 	Source  string `mapstructure:"source"`
 }
 
+// CheckEventsConfig represents the check event trace used for debugging.
+type CheckEventsConfig struct {
+	Enabled    bool `mapstructure:"enabled"`
+	MaxEntries int  `mapstructure:"max_entries"`
+}
+
 // MonitoringConfig represents monitoring configuration.
 type MonitoringConfig struct {
-	LookbackHours  int            `mapstructure:"lookback_hours"`
-	ReportStatuses []string       `mapstructure:"report_statuses"`
-	Parallel       ParallelConfig `mapstructure:"parallel"`
+	LookbackHours    int               `mapstructure:"lookback_hours"`
+	ReportStatuses   []string          `mapstructure:"report_statuses"`
+	WarnStatuses     []string          `mapstructure:"warn_statuses"`    // Logged and reported, never notified
+	FailOnStatuses   []string          `mapstructure:"fail_on_statuses"` // Statuses that make check exit with 1
+	Parallel         ParallelConfig    `mapstructure:"parallel"`
+	BatchSharedHosts bool              `mapstructure:"batch_shared_hosts"`
+	Incremental      IncrementalConfig `mapstructure:"incremental"`
+	ResultSink       ResultSinkConfig  `mapstructure:"result_sink"`
+	PostCheckHook    PostCheckHook     `mapstructure:"post_check_hook"`
+	CacheTTL         int               `mapstructure:"cache_ttl"` // seconds, 0 disables the result cache
+	PerfCounter      PerfCounterConfig `mapstructure:"perf_counter"`
+	PreflightOnStart bool              `mapstructure:"preflight_on_start"` // Ping every server when the service starts
+
+	// ConsecutiveFailures reports a job only once its latest runs failed this
+	// many times in a row. 0 or 1 reports every failure.
+	ConsecutiveFailures int `mapstructure:"consecutive_failures"`
+
+	// ClassifyFailures tags each failed job as transient (its latest run
+	// failed once) or persistent (failed several runs in a row), using the
+	// job's recent run history.
+	ClassifyFailures bool `mapstructure:"classify_failures"`
+
+	// MaxClockSkewSeconds is how far a server's clock may drift from this
+	// host's before preflight and 'servers matrix' warn. 0 disables the check.
+	MaxClockSkewSeconds int `mapstructure:"max_clock_skew_seconds"`
+
+	// Severities tags failed jobs with a severity by job name pattern.
+	// The first matching rule wins; unmatched jobs have no severity.
+	Severities []SeverityRule `mapstructure:"severities"`
+
+	// LongRunning reports jobs that are still running past a threshold.
+	LongRunning LongRunningConfig `mapstructure:"long_running"`
+}
+
+// LongRunningConfig reports executions of jobs running longer than a
+// threshold. Jobs whose name matches a rule in Jobs use its threshold, the
+// first matching rule winning; other jobs use ThresholdMinutes.
+type LongRunningConfig struct {
+	Enabled          bool              `mapstructure:"enabled"`
+	ThresholdMinutes int               `mapstructure:"threshold_minutes"`
+	Jobs             []LongRunningRule `mapstructure:"jobs"`
+}
+
+// LongRunningRule sets the long-running threshold of jobs whose name
+// matches Pattern. Patterns are exact names or use a leading or trailing
+// "*", as in job filters.
+type LongRunningRule struct {
+	Pattern          string `mapstructure:"pattern"`
+	ThresholdMinutes int    `mapstructure:"threshold_minutes"`
+}
+
+// MinThreshold returns the lowest threshold in minutes of any job, which
+// bounds the executions read from the server.
+func (l LongRunningConfig) MinThreshold() int {
+	threshold := l.ThresholdMinutes
+	for _, rule := range l.Jobs {
+		threshold = min(threshold, rule.ThresholdMinutes)
+	}
+	return threshold
+}
+
+// validate checks the thresholds when detection is enabled.
+func (l LongRunningConfig) validate() error {
+	if !l.Enabled {
+		return nil
+	}
+	if l.ThresholdMinutes <= 0 {
+		return fmt.Errorf("long_running threshold_minutes must be positive")
+	}
+	for i, rule := range l.Jobs {
+		if rule.Pattern == "" {
+			return fmt.Errorf("long_running jobs[%d]: pattern must not be empty", i)
+		}
+		if rule.ThresholdMinutes <= 0 {
+			return fmt.Errorf("long_running jobs[%d]: threshold_minutes must be positive", i)
+		}
+	}
+	return nil
+}
+
+// SeverityRule assigns a severity level to jobs whose name matches Pattern.
+// Patterns are exact names or use a leading or trailing "*", as in job filters.
+type SeverityRule struct {
+	Pattern string `mapstructure:"pattern"`
+	Level   string `mapstructure:"level"`
+}
+
+// Severity levels.
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityLow      = "low"
+)
+
+// ClockSkewExceeded reports whether skew, in either direction, is beyond
+// MaxClockSkewSeconds. It is always false when the check is disabled.
+func (m MonitoringConfig) ClockSkewExceeded(skew time.Duration) bool {
+	if m.MaxClockSkewSeconds <= 0 {
+		return false
+	}
+	return skew.Abs() > time.Duration(m.MaxClockSkewSeconds)*time.Second
+}
+
+// PerfCounterConfig represents the Windows performance counter publishing the
+// failed job count of each scheduled check.
+type PerfCounterConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// ResultSinkConfig represents an HTTP endpoint receiving every check result as JSON.
+// The sink is disabled when URL is empty.
+type ResultSinkConfig struct {
+	URL     string            `mapstructure:"url"`
+	Method  string            `mapstructure:"method"`
+	Headers map[string]string `mapstructure:"headers"`
+	Timeout int               `mapstructure:"timeout"` // seconds, per attempt
+	Retries int               `mapstructure:"retries"`
+}
+
+// PostCheckHook represents a command run after each check with the check
+// result as JSON on stdin, e.g. to restart a failed job or open a ticket.
+type PostCheckHook struct {
+	Command string   `mapstructure:"command"` // Absolute path of the executable or script; empty = disabled
+	Args    []string `mapstructure:"args"`
+	Timeout int      `mapstructure:"timeout"` // seconds, the hook is killed after it
+}
+
+// IncrementalConfig represents incremental scanning configuration.
+// Scheduled checks only look back to the last successful check of each
+// server, but never less than MinLookbackHours.
+type IncrementalConfig struct {
+	Enabled          bool `mapstructure:"enabled"`
+	MinLookbackHours int  `mapstructure:"min_lookback_hours"`
 }
 
 // ParallelConfig represents parallel checking configuration.
@@ -131,6 +599,10 @@ type UpdateConfig struct {
 	CheckOnStartup    bool   `mapstructure:"check_on_startup"`
 	GithubRepo        string `mapstructure:"github_repo"`
 	IncludePrerelease bool   `mapstructure:"include_prerelease"`
+
+	// NotifyApplied confirms with a notification on the first start after
+	// the version changed, e.g. after an update was applied.
+	NotifyApplied bool `mapstructure:"notify_applied"`
 }
 
 // DefaultConfig returns the default configuration.
@@ -145,17 +617,39 @@ func DefaultConfig() *Config {
 				MaxAttempts:  3,
 				DelaySeconds: 60,
 			},
+			Backoff: BackoffConfig{
+				MaxHours: 24,
+			},
 		},
 		Notification: NotificationConfig{
 			AppID: "Watchman",
 			Grouping: GroupingConfig{
 				Enabled:                true,
 				MaxJobsPerNotification: 5,
+				AutoGroupThreshold:     10,
 			},
 			Sound: SoundConfig{
 				Enabled: true,
 				Type:    "default",
 			},
+			Toast: BackendConfig{
+				Enabled:      true,
+				Retries:      2,
+				RetryDelayMs: 500,
+			},
+			MaxConcurrentSends: 4,
+			Mode:               NotificationModeImmediate,
+			DigestTime:         "17:00",
+			UseEmoji:           true,
+			TimeFormat:         TimeFormatDefault,
+			MaxJobNameLength:   60,
+			Outbox: OutboxConfig{
+				Enabled:     true,
+				MaxAgeHours: 24,
+			},
+			Pipe: PipeConfig{
+				BufferSize: 100,
+			},
 		},
 		Logging: LoggingConfig{
 			Level:  "info",
@@ -167,39 +661,62 @@ func DefaultConfig() *Config {
 				MaxBackups: 5,
 				MaxAgeDays: 30,
 				Compress:   true,
+				MinFreeMB:  100,
 			},
 			EventLog: EventLogConfig{
 				Enabled: true,
 				Source:  "Watchman",
 			},
+			CheckEvents: CheckEventsConfig{
+				MaxEntries: 100,
+			},
+			RedactFields: slices.Clone(defaultRedactFields),
 		},
 		Monitoring: MonitoringConfig{
 			LookbackHours:  24,
 			ReportStatuses: []string{"failed"},
+			FailOnStatuses: []string{"failed"},
 			Parallel: ParallelConfig{
 				Enabled:       true,
 				MaxConcurrent: 5,
 			},
+			MaxClockSkewSeconds: 60,
+			ClassifyFailures:    true,
+			LongRunning: LongRunningConfig{
+				ThresholdMinutes: 120,
+			},
+		},
+		Output: OutputConfig{
+			Default: "text",
 		},
 		Update: UpdateConfig{
 			CheckOnStartup:    true,
 			GithubRepo:        "hoangtran1411/watchman",
 			IncludePrerelease: false,
+			NotifyApplied:     true,
 		},
 	}
 }
 
+// LoadOptions adjusts how a configuration is loaded.
+type LoadOptions struct {
+	// ServersFile overrides the servers_file setting.
+	ServersFile string
+}
+
 // Load loads configuration from file.
 func Load(configPath string) (*Config, error) {
+	return LoadWithOptions(configPath, LoadOptions{})
+}
+
+// LoadWithOptions loads configuration from file, applying opts.
+func LoadWithOptions(configPath string, opts LoadOptions) (*Config, error) {
 	v := viper.New()
 
 	// Set defaults
 	setDefaults(v)
 
-	// Determine config path
-	if configPath == "" {
-		configPath = getDefaultConfigPath()
-	}
+	configPath = ResolvePath(configPath)
 
 	// Check if config file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
@@ -221,21 +738,224 @@ func Load(configPath string) (*Config, error) {
 
 	// Unmarshal to struct
 	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
+	if err := unmarshal(v, &cfg, filepath.Base(configPath), nil); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	if err := cfg.mergeServersFile(opts.ServersFile, filepath.Dir(configPath)); err != nil {
+		return nil, err
+	}
+	return finalize(&cfg)
+}
+
+// LoadDir loads and merges every *.yaml file in dir, in file name order.
+// Server lists are concatenated; all other settings are merged with the
+// last file winning. The merged configuration is validated as a whole.
+func LoadDir(dir string) (*Config, error) {
+	return LoadDirWithOptions(dir, LoadOptions{})
+}
+
+// LoadDirWithOptions loads a configuration directory like LoadDir, applying opts.
+func LoadDirWithOptions(dir string, opts LoadOptions) (*Config, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list config directory: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no config files found in: %s", dir)
+	}
+	sort.Strings(files)
+
+	v := viper.New()
+	setDefaults(v)
+	v.AutomaticEnv()
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+
+	var servers []ServerConfig
+	for _, file := range files {
+		fv := viper.New()
+		fv.SetConfigFile(file)
+		fv.SetConfigType("yaml")
+		if err := fv.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read config %s: %w", filepath.Base(file), err)
+		}
+
+		var fragment struct {
+			Servers []ServerConfig `mapstructure:"servers"`
+		}
+		if err := unmarshal(fv, &fragment, filepath.Base(file), isServerKey); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal config %s: %w", filepath.Base(file), err)
+		}
+		servers = append(servers, fragment.Servers...)
+
+		if err := v.MergeConfigMap(fv.AllSettings()); err != nil {
+			return nil, fmt.Errorf("failed to merge config %s: %w", filepath.Base(file), err)
+		}
+	}
+
+	// Server keys were checked per file above
+	var cfg Config
+	if err := unmarshal(v, &cfg, filepath.Base(dir), func(key string) bool { return !isServerKey(key) }); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	cfg.Servers = servers
+
+	if err := cfg.mergeServersFile(opts.ServersFile, dir); err != nil {
+		return nil, err
+	}
+	return finalize(&cfg)
+}
+
+// unmarshal decodes the settings of v into out and warns about keys of file
+// that match no setting, e.g. a misspelled or removed key, which would
+// otherwise be ignored silently. If report is not nil, only the keys it
+// returns true for are warned about.
+func unmarshal(v *viper.Viper, out interface{}, file string, report func(key string) bool) error {
+	var md mapstructure.Metadata
+	if err := v.Unmarshal(out, func(dc *mapstructure.DecoderConfig) { dc.Metadata = &md }); err != nil {
+		return err
+	}
+
+	slices.Sort(md.Unused)
+	for _, key := range md.Unused {
+		if report == nil || report(key) {
+			warnings.Add(warnings.SourceConfig, "%s: unknown key %q is ignored", file, key)
+		}
+	}
+	return nil
+}
+
+// isServerKey returns true if key is a setting of a server in the servers list.
+func isServerKey(key string) bool {
+	return strings.HasPrefix(key, "servers[")
+}
+
+// mergeServersFile merges the servers listed in the servers file into c.
+// The file is override, or servers_file if override is empty; a relative
+// path is relative to baseDir. It is YAML or JSON with a top-level servers
+// list. A server replaces a configured server of the same name and is
+// appended otherwise.
+func (c *Config) mergeServersFile(override, baseDir string) error {
+	if override != "" {
+		c.ServersFile = override
+	}
+	if c.ServersFile == "" {
+		return nil
+	}
+
+	path := c.ServersFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	servers, err := readServersFile(path)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(servers))
+	for _, srv := range servers {
+		key := strings.ToLower(strings.TrimSpace(srv.Name))
+		if key == "" {
+			return fmt.Errorf("servers file %s: server name is required", filepath.Base(path))
+		}
+		if seen[key] {
+			return fmt.Errorf("servers file %s: duplicate server name %s", filepath.Base(path), srv.Name)
+		}
+		seen[key] = true
+
+		i := slices.IndexFunc(c.Servers, func(existing ServerConfig) bool {
+			return strings.EqualFold(strings.TrimSpace(existing.Name), strings.TrimSpace(srv.Name))
+		})
+		if i >= 0 {
+			c.Servers[i] = srv
+		} else {
+			c.Servers = append(c.Servers, srv)
+		}
+	}
+	return nil
+}
+
+// readServersFile reads the servers list of a YAML or JSON servers file.
+func readServersFile(path string) ([]ServerConfig, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("servers file not found: %s", path)
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+		return nil, fmt.Errorf("servers file %s: unsupported format (use .yaml, .yml or .json)", filepath.Base(path))
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read servers file %s: %w", filepath.Base(path), err)
+	}
+	if !v.IsSet("servers") {
+		return nil, fmt.Errorf("servers file %s: missing servers list", filepath.Base(path))
+	}
+
+	var fragment struct {
+		Servers []ServerConfig `mapstructure:"servers"`
+	}
+	if err := unmarshal(v, &fragment, filepath.Base(path), isServerKey); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal servers file %s: %w", filepath.Base(path), err)
+	}
+	return fragment.Servers, nil
+}
+
+// finalize resolves credentials and validates a loaded configuration.
+func finalize(cfg *Config) (*Config, error) {
+	// Resolve environment variables in credentials
+	if err := cfg.resolveSecrets(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
 
-	// Expand environment variables in passwords
+	// A server without options, e.g. one with only a dsn, would time out at once
 	for i := range cfg.Servers {
-		cfg.Servers[i].Auth.Password = expandEnvVar(cfg.Servers[i].Auth.Password)
+		cfg.Servers[i].Options = cfg.Servers[i].Options.WithDefaults()
 	}
 
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
+	cfg.warnPatterns()
+
+	return cfg, nil
+}
+
+// warnPatterns warns about job filter and severity patterns with a * that
+// is neither leading nor trailing, or on both ends. Only one leading or one
+// trailing * is a wildcard, so such a pattern is matched literally and
+// usually matches no job.
+func (c *Config) warnPatterns() {
+	for _, srv := range c.Servers {
+		for _, pattern := range srv.Jobs.Include {
+			warnPattern(fmt.Sprintf("server %s: jobs.include", srv.Name), pattern)
+		}
+		for _, pattern := range srv.Jobs.Exclude {
+			warnPattern(fmt.Sprintf("server %s: jobs.exclude", srv.Name), pattern)
+		}
+	}
+	for _, rule := range c.Monitoring.Severities {
+		warnPattern("monitoring.severities", rule.Pattern)
+	}
+	for _, rule := range c.Monitoring.LongRunning.Jobs {
+		warnPattern("monitoring.long_running.jobs", rule.Pattern)
+	}
+}
 
-	return &cfg, nil
+// warnPattern warns if pattern, set at where, is matched literally despite containing a *.
+func warnPattern(where, pattern string) {
+	if pattern == "*" || !strings.Contains(pattern, "*") {
+		return
+	}
+	if inner := strings.Trim(pattern, "*"); len(pattern)-len(inner) == 1 && !strings.Contains(inner, "*") {
+		return
+	}
+	warnings.Add(warnings.SourceFilter,
+		"%s: pattern %q is matched literally, only one leading or trailing * is a wildcard", where, pattern)
 }
 
 // Validate validates the configuration.
@@ -246,36 +966,365 @@ func (c *Config) Validate() error {
 	}
 
 	// Validate servers
+	if err := c.validateServers(); err != nil {
+		return err
+	}
+
+	// Validate scheduler
+	if err := c.Scheduler.validate(); err != nil {
+		return err
+	}
+
+	// Validate monitoring
+	if c.Monitoring.LookbackHours <= 0 {
+		return fmt.Errorf("lookback_hours must be positive")
+	}
+	if c.Monitoring.CacheTTL < 0 {
+		return fmt.Errorf("cache_ttl must not be negative")
+	}
+	if c.Monitoring.ConsecutiveFailures < 0 {
+		return fmt.Errorf("consecutive_failures must not be negative")
+	}
+	if c.Monitoring.MaxClockSkewSeconds < 0 {
+		return fmt.Errorf("max_clock_skew_seconds must not be negative")
+	}
+	if err := c.Monitoring.validateJobRules(); err != nil {
+		return err
+	}
+
+	// Validate logging
+	if c.Logging.File.MinFreeMB < 0 {
+		return fmt.Errorf("logging.file.min_free_mb must not be negative")
+	}
+
+	// Validate result sink and post-check hook
+	if err := c.Monitoring.ResultSink.validate(); err != nil {
+		return err
+	}
+	if err := c.Monitoring.PostCheckHook.validate(); err != nil {
+		return err
+	}
+
+	// Validate output
+	if err := c.Output.validate(); err != nil {
+		return err
+	}
+
+	// Validate notification
+	return c.Notification.validate()
+}
+
+// runStatuses maps job status names to msdb sysjobhistory run_status values.
+var runStatuses = map[string]int{
+	"failed":    0,
+	"succeeded": 1,
+	"retried":   2,
+	"cancelled": 3,
+	"canceled":  3,
+}
+
+// RunStatus returns the msdb run_status value of a job status name such as
+// failed or retried, and false if the name is unknown.
+func RunStatus(name string) (int, bool) {
+	code, ok := runStatuses[strings.ToLower(name)]
+	return code, ok
+}
+
+// validateJobRules checks the job statuses, severities and long-running
+// thresholds.
+func (m MonitoringConfig) validateJobRules() error {
+	if err := m.validateStatuses(); err != nil {
+		return err
+	}
+	if err := m.validateSeverities(); err != nil {
+		return err
+	}
+	return m.LongRunning.validate()
+}
+
+// validateStatuses checks report_statuses and warn_statuses name known statuses
+// and that no status is both alerted on and warn-only.
+func (m MonitoringConfig) validateStatuses() error {
+	report := make(map[int]bool, len(m.ReportStatuses))
+	for _, name := range m.ReportStatuses {
+		code, ok := runStatuses[strings.ToLower(name)]
+		if !ok {
+			return fmt.Errorf("report_statuses: unknown status %q", name)
+		}
+		report[code] = true
+	}
+	for _, name := range m.WarnStatuses {
+		code, ok := runStatuses[strings.ToLower(name)]
+		if !ok {
+			return fmt.Errorf("warn_statuses: unknown status %q", name)
+		}
+		if report[code] {
+			return fmt.Errorf("warn_statuses: %q is also in report_statuses", name)
+		}
+	}
+	for _, name := range m.FailOnStatuses {
+		if _, ok := runStatuses[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("fail_on_statuses: unknown status %q", name)
+		}
+	}
+	return nil
+}
+
+// validateSeverities checks that every severity rule has a pattern and a
+// known level.
+func (m MonitoringConfig) validateSeverities() error {
+	for i, rule := range m.Severities {
+		if rule.Pattern == "" {
+			return fmt.Errorf("severities[%d]: pattern must not be empty", i)
+		}
+		switch rule.Level {
+		case SeverityCritical, SeverityHigh, SeverityLow:
+		default:
+			return fmt.Errorf("severities[%d]: invalid level %q (expected critical, high or low)", i, rule.Level)
+		}
+	}
+	return nil
+}
+
+// QueryStatuses returns the run_status values to read from job history:
+// report_statuses (failed if empty) followed by warn_statuses, without duplicates.
+// Unknown names are skipped; Validate rejects them.
+func (m MonitoringConfig) QueryStatuses() []int {
+	report := m.ReportStatuses
+	if len(report) == 0 {
+		report = []string{"failed"}
+	}
+
+	var codes []int
+	for _, name := range append(slices.Clone(report), m.WarnStatuses...) {
+		code, ok := runStatuses[strings.ToLower(name)]
+		if ok && !slices.Contains(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// FailOnStatusCodes returns the run_status values of fail_on_statuses
+// (failed if empty): only failed jobs with these statuses fail a check,
+// the others are informational. Unknown names are skipped; Validate rejects them.
+func (m MonitoringConfig) FailOnStatusCodes() []int {
+	names := m.FailOnStatuses
+	if len(names) == 0 {
+		names = []string{"failed"}
+	}
+
+	codes := []int{}
+	for _, name := range names {
+		code, ok := runStatuses[strings.ToLower(name)]
+		if ok && !slices.Contains(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// IsWarnStatus returns true if the run_status value is listed in warn_statuses.
+func (m MonitoringConfig) IsWarnStatus(code int) bool {
+	for _, name := range m.WarnStatuses {
+		if c, ok := runStatuses[strings.ToLower(name)]; ok && c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlIdentifier matches a SQL Server regular identifier. Names outside this
+// set are rejected rather than escaped so configuration cannot smuggle SQL
+// into the query text.
+var sqlIdentifier = regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}_@#$]{0,127}$`)
+
+// validateServers checks each server and rejects duplicate names.
+// Names identify servers in state, routing and --server, so they are
+// compared case-insensitively.
+func (c *Config) validateServers() error {
+	seen := make(map[string]int, len(c.Servers))
 	for i, srv := range c.Servers {
-		if srv.Name == "" {
+		name := strings.TrimSpace(srv.Name)
+		if name == "" {
 			return fmt.Errorf("server[%d]: name is required", i)
 		}
-		if srv.Host == "" {
-			return fmt.Errorf("server[%d] (%s): host is required", i, srv.Name)
+		if first, ok := seen[strings.ToLower(name)]; ok {
+			return fmt.Errorf("server[%d] (%s): duplicate server name (also used by server[%d])", i, srv.Name, first)
+		}
+		seen[strings.ToLower(name)] = i
+
+		if err := srv.validateConnection(); err != nil {
+			return fmt.Errorf("server[%d] (%s): %w", i, srv.Name, err)
+		}
+		if srv.MsdbDatabase != "" && !sqlIdentifier.MatchString(srv.MsdbDatabase) {
+			return fmt.Errorf("server[%d] (%s): invalid msdb_database %q (letters, digits and _@#$ only)", i, srv.Name, srv.MsdbDatabase)
+		}
+		if err := srv.Jobs.validate(); err != nil {
+			return fmt.Errorf("server[%d] (%s): %w", i, srv.Name, err)
 		}
-		if srv.Port <= 0 || srv.Port > 65535 {
-			return fmt.Errorf("server[%d] (%s): invalid port: %d", i, srv.Name, srv.Port)
+	}
+	return nil
+}
+
+// validateConnection checks that the server has either a DSN or a host,
+// port and auth type, but not both.
+func (s ServerConfig) validateConnection() error {
+	if s.DSN != "" {
+		if s.Host != "" || s.Auth != (AuthConfig{}) {
+			return fmt.Errorf("dsn cannot be combined with host or auth")
 		}
-		if srv.Auth.Type != "sql" && srv.Auth.Type != "windows" {
-			return fmt.Errorf("server[%d] (%s): auth type must be 'sql' or 'windows'", i, srv.Name)
+		if s.ReadOnlyIntent {
+			return fmt.Errorf("read_only_intent cannot be combined with dsn; add ApplicationIntent=ReadOnly to the dsn instead")
 		}
+		return nil
 	}
 
-	// Validate scheduler
-	if len(c.Scheduler.CheckTimes) == 0 {
-		return fmt.Errorf("no check times configured")
+	if s.Host == "" {
+		return fmt.Errorf("host is required")
 	}
-	for _, t := range c.Scheduler.CheckTimes {
-		if _, err := time.Parse("15:04", t); err != nil {
-			return fmt.Errorf("invalid check time format: %s (expected HH:MM)", t)
+	if s.Port <= 0 || s.Port > 65535 {
+		return fmt.Errorf("invalid port: %d", s.Port)
+	}
+	if s.Auth.Type != "sql" && s.Auth.Type != "windows" {
+		return fmt.Errorf("auth type must be 'sql' or 'windows'")
+	}
+	return nil
+}
+
+// validate checks that jobs.only is not mixed with patterns and names no
+// job twice.
+func (f JobsFilter) validate() error {
+	if len(f.Only) == 0 {
+		return nil
+	}
+	if len(f.Include) > 0 || len(f.Exclude) > 0 {
+		return fmt.Errorf("jobs.only cannot be combined with jobs.include or jobs.exclude")
+	}
+
+	seen := make(map[string]bool, len(f.Only))
+	for _, name := range f.Only {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if key == "" {
+			return fmt.Errorf("jobs.only: job name must not be empty")
 		}
+		if seen[key] {
+			return fmt.Errorf("jobs.only: duplicate job name %q", name)
+		}
+		seen[key] = true
 	}
+	return nil
+}
 
-	// Validate monitoring
-	if c.Monitoring.LookbackHours <= 0 {
-		return fmt.Errorf("lookback_hours must be positive")
+// validate checks the notification configuration.
+func (n NotificationConfig) validate() error {
+	if err := n.validateLimits(); err != nil {
+		return err
+	}
+	if err := n.Toast.validate(); err != nil {
+		return err
+	}
+	if err := n.Grouping.validate(); err != nil {
+		return err
+	}
+	if err := n.Pipe.validate(); err != nil {
+		return err
+	}
+	if err := validateProxyURL(n.ProxyURL); err != nil {
+		return err
+	}
+	if err := n.validateSchedule(); err != nil {
+		return err
+	}
+	return validateTimeFormat(n.TimeFormat)
+}
+
+// validateSchedule checks the mode, digest time and quiet hours. A digest
+// time within quiet hours is rejected: the digest would never be sent.
+func (n NotificationConfig) validateSchedule() error {
+	if q := n.QuietHours; q.Enabled {
+		for _, t := range []string{q.Start, q.End} {
+			if _, err := time.Parse("15:04", t); err != nil {
+				return fmt.Errorf("invalid quiet hours time format: %s (expected HH:MM)", t)
+			}
+		}
+	}
+	switch n.Mode {
+	case "", NotificationModeImmediate:
+	case NotificationModeDigest:
+		if _, err := time.Parse("15:04", n.DigestTime); err != nil {
+			return fmt.Errorf("invalid digest_time format: %s (expected HH:MM)", n.DigestTime)
+		}
+		if n.QuietHours.Covers(n.DigestTime) {
+			return fmt.Errorf("digest_time %s falls within quiet hours (%s-%s)", n.DigestTime, n.QuietHours.Start, n.QuietHours.End)
+		}
+	default:
+		return fmt.Errorf("invalid notification mode: %s (expected immediate or digest)", n.Mode)
 	}
+	return nil
+}
+
+// validateLimits checks that the numeric limits are not negative.
+func (n NotificationConfig) validateLimits() error {
+	if n.MaxConcurrentSends < 0 {
+		return fmt.Errorf("max_concurrent_sends must not be negative")
+	}
+	if n.Outbox.MaxAgeHours < 0 {
+		return fmt.Errorf("outbox max_age_hours must not be negative")
+	}
+	if n.MinIntervalBetweenSends < 0 {
+		return fmt.Errorf("min_interval_between_sends must not be negative")
+	}
+	if n.MaxJobNameLength < 0 {
+		return fmt.Errorf("max_job_name_length must not be negative")
+	}
+	if n.TopN < 0 {
+		return fmt.Errorf("top_n must not be negative")
+	}
+	return nil
+}
+
+// validateTimeFormat accepts an empty format, a preset or a Go time layout.
+// A layout is recognised by formatting differently from its own text; a
+// string such as "short" contains no layout elements and is a typo.
+func validateTimeFormat(format string) error {
+	switch format {
+	case "", TimeFormatISO, TimeFormatUS, TimeFormatRelative:
+		return nil
+	}
+	if time.Unix(0, 0).UTC().Format(format) == format {
+		return fmt.Errorf("invalid time_format: %q (expected a Go time layout, iso, us or relative)", format)
+	}
+	return nil
+}
+
+// validate checks the result sink configuration. An empty URL disables the sink.
+func (r ResultSinkConfig) validate() error {
+	if r.URL == "" {
+		return nil
+	}
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid result sink url: %s (expected http or https)", r.URL)
+	}
+	if r.Timeout < 0 || r.Retries < 0 {
+		return fmt.Errorf("result sink timeout and retries must not be negative")
+	}
+	return nil
+}
 
+// validate checks that the hook command is an absolute path, so it cannot be
+// resolved from PATH or the working directory to another executable.
+func (h PostCheckHook) validate() error {
+	if h.Command == "" {
+		return nil
+	}
+	if !filepath.IsAbs(h.Command) {
+		return fmt.Errorf("post_check_hook command must be an absolute path: %s", h.Command)
+	}
+	if h.Timeout < 0 {
+		return fmt.Errorf("post_check_hook timeout must not be negative")
+	}
 	return nil
 }
 
@@ -309,12 +1358,35 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("scheduler.retry.enabled", true)
 	v.SetDefault("scheduler.retry.max_attempts", 3)
 	v.SetDefault("scheduler.retry.delay_seconds", 60)
+	v.SetDefault("scheduler.backoff.after_failures", 0)
+	v.SetDefault("scheduler.backoff.max_hours", 24)
 
 	v.SetDefault("notification.app_id", "Watchman")
 	v.SetDefault("notification.grouping.enabled", true)
 	v.SetDefault("notification.grouping.max_jobs_per_notification", 5)
+	v.SetDefault("notification.grouping.auto_group_threshold", 10)
 	v.SetDefault("notification.sound.enabled", true)
 	v.SetDefault("notification.sound.type", "default")
+	v.SetDefault("notification.quiet_hours.enabled", false)
+	v.SetDefault("notification.toast.enabled", true)
+	v.SetDefault("notification.toast.retries", 2)
+	v.SetDefault("notification.toast.retry_delay_ms", 500)
+	v.SetDefault("notification.notify_server_down", false)
+	v.SetDefault("notification.max_concurrent_sends", 4)
+	v.SetDefault("notification.min_interval_between_sends", 0)
+	v.SetDefault("notification.mode", NotificationModeImmediate)
+	v.SetDefault("notification.digest_time", "17:00")
+	v.SetDefault("notification.use_emoji", true)
+	v.SetDefault("notification.time_format", TimeFormatDefault)
+	v.SetDefault("notification.max_job_name_length", 60)
+	v.SetDefault("notification.top_n", 0)
+	v.SetDefault("notification.outbox.enabled", true)
+	v.SetDefault("notification.outbox.max_age_hours", 24)
+	v.SetDefault("notification.pipe.name", "")
+	v.SetDefault("notification.pipe.buffer_size", 100)
+	v.SetDefault("notification.proxy_url", "")
+	v.SetDefault("notification.tls.ca_file", "")
+	v.SetDefault("notification.tls.insecure_skip_verify", false)
 
 	v.SetDefault("logging.level", "info")
 	v.SetDefault("logging.format", "json")
@@ -324,17 +1396,47 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("logging.file.max_backups", 5)
 	v.SetDefault("logging.file.max_age_days", 30)
 	v.SetDefault("logging.file.compress", true)
+	v.SetDefault("logging.file.min_free_mb", 100)
+	v.SetDefault("logging.redact_fields", slices.Clone(defaultRedactFields))
 	v.SetDefault("logging.event_log.enabled", true)
 	v.SetDefault("logging.event_log.source", "Watchman")
+	v.SetDefault("logging.check_events.enabled", false)
+	v.SetDefault("logging.check_events.max_entries", 100)
 
 	v.SetDefault("monitoring.lookback_hours", 24)
 	v.SetDefault("monitoring.report_statuses", []string{"failed"})
+	v.SetDefault("monitoring.fail_on_statuses", []string{"failed"})
 	v.SetDefault("monitoring.parallel.enabled", true)
 	v.SetDefault("monitoring.parallel.max_concurrent", 5)
+	v.SetDefault("monitoring.batch_shared_hosts", false)
+	v.SetDefault("monitoring.incremental.enabled", false)
+	v.SetDefault("monitoring.incremental.min_lookback_hours", 1)
+	v.SetDefault("monitoring.result_sink.method", "POST")
+	v.SetDefault("monitoring.result_sink.timeout", 10)
+	v.SetDefault("monitoring.result_sink.retries", 2)
+	v.SetDefault("monitoring.post_check_hook.timeout", 30)
+	v.SetDefault("monitoring.cache_ttl", 0)
+	v.SetDefault("monitoring.perf_counter.enabled", false)
+	v.SetDefault("monitoring.preflight_on_start", false)
+	v.SetDefault("monitoring.consecutive_failures", 0)
+	v.SetDefault("monitoring.classify_failures", true)
+	v.SetDefault("monitoring.max_clock_skew_seconds", 60)
+	v.SetDefault("monitoring.long_running.enabled", false)
+	v.SetDefault("monitoring.long_running.threshold_minutes", 120)
 
+	v.SetDefault("output.default", "text")
 	v.SetDefault("update.check_on_startup", true)
 	v.SetDefault("update.github_repo", "hoangtran1411/watchman")
 	v.SetDefault("update.include_prerelease", false)
+	v.SetDefault("update.notify_applied", true)
+}
+
+// ResolvePath returns configPath, or the default config file path if it is empty.
+func ResolvePath(configPath string) string {
+	if configPath != "" {
+		return configPath
+	}
+	return getDefaultConfigPath()
 }
 
 // getDefaultConfigPath returns the default config file path.
@@ -361,6 +1463,61 @@ func getDefaultConfigPath() string {
 	return "config.yaml"
 }
 
+// resolveSecrets resolves environment variable references in server credentials.
+func (c *Config) resolveSecrets() error {
+	for i := range c.Servers {
+		srv := &c.Servers[i]
+
+		username, err := resolveSecret(srv.Auth.Username)
+		if err != nil {
+			return fmt.Errorf("server[%d] (%s): username: %w", i, srv.Name, err)
+		}
+		srv.Auth.Username = username
+
+		password, err := resolveSecret(srv.Auth.Password)
+		if err != nil {
+			return fmt.Errorf("server[%d] (%s): password: %w", i, srv.Name, err)
+		}
+		srv.Auth.Password = password
+
+		dsn, err := resolveSecret(srv.DSN)
+		if err != nil {
+			return fmt.Errorf("server[%d] (%s): dsn: %w", i, srv.Name, err)
+		}
+		srv.DSN = dsn
+	}
+
+	for name, value := range c.Monitoring.ResultSink.Headers {
+		resolved, err := resolveSecret(value)
+		if err != nil {
+			return fmt.Errorf("result sink header %s: %w", name, err)
+		}
+		c.Monitoring.ResultSink.Headers[name] = resolved
+	}
+	return nil
+}
+
+// resolveSecret resolves a sensitive value.
+// Values in format env:VAR are read from the environment and must be set;
+// other values are expanded with expandEnvVar.
+func resolveSecret(s string) (string, error) {
+	if !strings.HasPrefix(s, envPrefix) {
+		return expandEnvVar(s), nil
+	}
+
+	varName := strings.TrimPrefix(s, envPrefix)
+	if varName == "" {
+		return "", fmt.Errorf("missing environment variable name in %q", s)
+	}
+
+	value, ok := os.LookupEnv(varName)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", varName)
+	}
+
+	return value, nil
+}
+
 // expandEnvVar expands environment variables in format ${VAR} or ${VAR:default}.
 func expandEnvVar(s string) string {
 	if !strings.HasPrefix(s, "${") || !strings.HasSuffix(s, "}") {
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// maskedSecret replaces secret values in masked configurations.
+const maskedSecret = "***"
+
+// Change kinds reported by Diff.
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// Change is one field-level difference between two configurations.
+// Paths use the YAML key names, e.g. "servers[PROD].port".
+type Change struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// Masked returns a copy of c with passwords, DSNs, result sink header values
+// and the credentials in the proxy and result sink URLs replaced, so it can
+// be stored or printed.
+func (c *Config) Masked() *Config {
+	masked := *c
+
+	masked.Servers = make([]ServerConfig, len(c.Servers))
+	for i, srv := range c.Servers {
+		srv.Auth.Password = maskSecret(srv.Auth.Password)
+		srv.DSN = maskSecret(srv.DSN)
+		masked.Servers[i] = srv
+	}
+
+	if c.Monitoring.ResultSink.Headers != nil {
+		headers := make(map[string]string, len(c.Monitoring.ResultSink.Headers))
+		for name, value := range c.Monitoring.ResultSink.Headers {
+			headers[name] = maskSecret(value)
+		}
+		masked.Monitoring.ResultSink.Headers = headers
+	}
+	masked.Monitoring.ResultSink.URL = maskURL(c.Monitoring.ResultSink.URL)
+	masked.Notification.ProxyURL = maskURL(c.Notification.ProxyURL)
+	return &masked
+}
+
+// maskSecret masks a non-empty secret value.
+func maskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return maskedSecret
+}
+
+// maskURL masks the userinfo, e.g. "user:password@", of a URL. A value that
+// does not parse as a URL is masked entirely.
+func maskURL(s string) string {
+	if s == "" {
+		return ""
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return maskedSecret
+	}
+	if u.User == nil {
+		return s
+	}
+	u.User = nil
+	return strings.Replace(u.String(), "//", "//"+maskedSecret+"@", 1)
+}
+
+// Diff returns the field-level differences from old to cur, sorted by path.
+// List entries with a name, such as servers, are matched by name; an added
+// or removed entry is reported once rather than field by field.
+func Diff(old, cur *Config) []Change {
+	before := flatten(old)
+	after := flatten(cur)
+
+	paths := make([]string, 0, len(before)+len(after))
+	for path := range before {
+		paths = append(paths, path)
+	}
+	for path := range after {
+		if _, ok := before[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var changes []Change
+	var entry string // path of the last added or removed list entry
+	for _, path := range paths {
+		if entry != "" && strings.HasPrefix(path, entry+".") {
+			continue
+		}
+		oldValue, inOld := before[path]
+		newValue, inNew := after[path]
+
+		switch {
+		case !inOld:
+			changes = append(changes, Change{Path: path, Kind: ChangeAdded, New: newValue})
+			entry = path
+		case !inNew:
+			changes = append(changes, Change{Path: path, Kind: ChangeRemoved, Old: oldValue})
+			entry = path
+		case oldValue != newValue:
+			changes = append(changes, Change{Path: path, Kind: ChangeChanged, Old: oldValue, New: newValue})
+		}
+	}
+	return changes
+}
+
+// flatten maps every configuration field path to its formatted value.
+// List entries also get a path of their own so additions and removals
+// show up even when their fields match.
+func flatten(c *Config) map[string]string {
+	fields := make(map[string]string)
+	if c != nil {
+		flattenValue(fields, "", reflect.ValueOf(*c))
+	}
+	return fields
+}
+
+// flattenValue adds v and its nested fields to fields under prefix.
+func flattenValue(fields map[string]string, prefix string, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			key := t.Field(i).Tag.Get("mapstructure")
+			if key == "" || !t.Field(i).IsExported() {
+				continue
+			}
+			flattenValue(fields, joinPath(prefix, key), v.Field(i))
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Struct {
+			fields[prefix] = formatList(v)
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			path := fmt.Sprintf("%s[%s]", prefix, entryKey(v.Index(i), i))
+			fields[path] = ""
+			flattenValue(fields, path, v.Index(i))
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			flattenValue(fields, joinPath(prefix, fmt.Sprint(key.Interface())), v.MapIndex(key))
+		}
+	default:
+		fields[prefix] = fmt.Sprint(v.Interface())
+	}
+}
+
+// entryKey identifies a list entry by its name, or by index if it has none.
+func entryKey(v reflect.Value, index int) string {
+	if name := v.FieldByName("Name"); name.IsValid() && name.Kind() == reflect.String && name.String() != "" {
+		return name.String()
+	}
+	return fmt.Sprint(index)
+}
+
+// formatList formats a list of scalar values.
+func formatList(v reflect.Value) string {
+	items := make([]string, v.Len())
+	for i := range items {
+		items[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// joinPath appends key to a dotted path.
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
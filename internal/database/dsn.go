@@ -0,0 +1,87 @@
+package database
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/microsoft/go-mssqldb/msdsn"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+// defaultPort is the SQL Server port used when a DSN does not name one.
+const defaultPort = 1433
+
+// redactedPassword replaces passwords in a redacted DSN.
+const redactedPassword = "***"
+
+// ConnectionString returns the server's DSN verbatim when set, otherwise the
+// connection string built from the structured fields.
+func ConnectionString(server config.ServerConfig) string {
+	if server.DSN != "" {
+		return server.DSN
+	}
+	return buildConnectionString(server)
+}
+
+// RedactedConnectionString returns the connection string used for server
+// with its password replaced, for troubleshooting.
+func RedactedConnectionString(server config.ServerConfig) string {
+	if server.DSN != "" {
+		return RedactDSN(server.DSN)
+	}
+	if server.Auth.Password == "" {
+		return buildConnectionString(server)
+	}
+	server.Auth.Password = redactedPassword
+	// "*" is valid in the userinfo, so show it unescaped
+	escaped := ":" + url.QueryEscape(redactedPassword) + "@"
+	return strings.Replace(buildConnectionString(server), escaped, ":"+redactedPassword+"@", 1)
+}
+
+// Address returns the host and port the server connects to. For a DSN they
+// are read from it; a DSN that cannot be parsed yields the configured values.
+func Address(server config.ServerConfig) (string, int) {
+	if server.DSN == "" {
+		return server.Host, server.Port
+	}
+
+	p, err := msdsn.Parse(server.DSN)
+	if err != nil || p.Host == "" {
+		return server.Host, server.Port
+	}
+	port := int(p.Port) // #nosec G115 -- msdsn rejects ports above 65535
+	if port == 0 {
+		port = defaultPort
+	}
+	return p.Host, port
+}
+
+// RedactDSN returns dsn with its password replaced, so it can be logged.
+// Both the literal and the URL-escaped forms of the password are replaced.
+func RedactDSN(dsn string) string {
+	for _, form := range PasswordForms(DSNPassword(dsn)) {
+		dsn = strings.ReplaceAll(dsn, form, redactedPassword)
+	}
+	return dsn
+}
+
+// DSNPassword returns the password set in dsn, or "" if it sets none or
+// cannot be parsed.
+func DSNPassword(dsn string) string {
+	p, err := msdsn.Parse(dsn)
+	if err != nil {
+		return ""
+	}
+	return p.Password
+}
+
+// PasswordForms returns password as written verbatim and URL-escaped, the
+// ways it can appear in a DSN or an error quoting one. An empty password
+// has no forms.
+func PasswordForms(password string) []string {
+	if password == "" {
+		return nil
+	}
+	return []string{password, url.QueryEscape(password), url.PathEscape(password)}
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+// LongRunningJob is an execution of a SQL Server Agent job that is still
+// running past its long-running threshold.
+type LongRunningJob struct {
+	ServerName       string    `json:"server"`
+	JobName          string    `json:"job_name"`
+	StartedAt        time.Time `json:"started_at"`
+	RunningSeconds   int       `json:"running_seconds"`        // At the time of the check
+	ThresholdMinutes int       `json:"threshold_minutes"`      // From monitoring.long_running
+	DisplayName      string    `json:"display_name,omitempty"` // The server's display_name, if configured
+	ConfigName       string    `json:"config_name,omitempty"`  // Name of the configured server queried
+}
+
+// ServerLabel returns the server's display name, or its name if none is configured.
+func (j LongRunningJob) ServerLabel() string {
+	if j.DisplayName != "" {
+		return j.DisplayName
+	}
+	return j.ServerName
+}
+
+// ConfiguredServer returns the name of the configured server the job was
+// found on, or ServerName for jobs not tagged with a configured server.
+func (j LongRunningJob) ConfiguredServer() string {
+	if j.ConfigName != "" {
+		return j.ConfigName
+	}
+	return j.ServerName
+}
+
+// RunningFor returns how long the job had been running when it was checked.
+func (j LongRunningJob) RunningFor() time.Duration {
+	return time.Duration(j.RunningSeconds) * time.Second
+}
+
+// longRunningJobsQuery selects the executions started in the current SQL
+// Agent session that have not stopped and started at least
+// @ThresholdMinutes ago. Rows of earlier sessions are left over from an
+// Agent restart and never stop. %[1]s is the quoted history database
+// identifier.
+const longRunningJobsQuery = `
+SELECT
+    ISNULL(@@SERVERNAME, CONVERT(nvarchar(128), SERVERPROPERTY('ServerName'))) AS ServerName,
+    j.name AS JobName,
+    a.start_execution_date AS StartedAt,
+    DATEDIFF(second, a.start_execution_date, GETDATE()) AS RunningSeconds
+FROM %[1]s.dbo.sysjobactivity a
+INNER JOIN %[1]s.dbo.sysjobs j
+    ON j.job_id = a.job_id
+WHERE a.session_id = (SELECT MAX(session_id) FROM %[1]s.dbo.syssessions)
+    AND a.start_execution_date IS NOT NULL
+    AND a.stop_execution_date IS NULL
+    AND a.start_execution_date <= DATEADD(minute, -@ThresholdMinutes, GETDATE())
+ORDER BY a.start_execution_date
+`
+
+// QueryLongRunningJobs returns the jobs passing the server's job filters
+// that have been running for at least thresholdMinutes, longest first.
+// ThresholdMinutes is left for the caller to set.
+func (db *DB) QueryLongRunningJobs(ctx context.Context, thresholdMinutes int) ([]LongRunningJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(db.server.Options.QueryTimeout)*time.Second)
+	defer cancel()
+
+	loc := db.serverLocation(ctx)
+	// #nosec G201 -- the identifier is escaped by quoteIdentifier
+	query := fmt.Sprintf(longRunningJobsQuery, quoteIdentifier(HistoryDatabase(db.server)))
+
+	var rows *sql.Rows
+	err := db.retryStale(ctx, func() error {
+		var err error
+		rows, err = db.conn.QueryContext(ctx, query, sql.Named("ThresholdMinutes", thresholdMinutes))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query long-running jobs: %w", err)
+	}
+	defer func() {
+		_ = rows.Close() // Ignore validation error on close
+	}()
+
+	var jobs []LongRunningJob
+	for rows.Next() {
+		var job LongRunningJob
+		var serverName sql.NullString
+		var startedAt time.Time
+		if err := rows.Scan(&serverName, &job.JobName, &startedAt, &job.RunningSeconds); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if !db.matchesFilter(job.JobName) {
+			continue
+		}
+		job.ServerName = resolveServerName(db.server.Name, serverName)
+		job.StartedAt = inLocation(startedAt, loc)
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return jobs, nil
+}
+
+// inLocation returns the wall clock time of t in loc. datetime values carry
+// no time zone and are read as UTC; they are recorded in the server's.
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// LongRunningThreshold returns the threshold in minutes of jobName: that
+// of the first rule whose pattern matches, or the default threshold.
+func LongRunningThreshold(cfg config.LongRunningConfig, jobName string) int {
+	for _, rule := range cfg.Jobs {
+		if matchPattern(jobName, rule.Pattern) {
+			return rule.ThresholdMinutes
+		}
+	}
+	return cfg.ThresholdMinutes
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+// longRunningColumns are the columns returned by the long-running jobs query.
+var longRunningColumns = []string{"ServerName", "JobName", "StartedAt", "RunningSeconds"}
+
+func TestQueryLongRunningJobs(t *testing.T) {
+	db, mock := newMockDB(t, config.ServerConfig{
+		Name:         "SQL01",
+		MsdbDatabase: "msdb_restored",
+		Jobs:         config.JobsFilter{Exclude: []string{"test_*"}},
+	})
+	expectServerOffset(mock, -5*time.Hour)
+
+	rows := sqlmock.NewRows(longRunningColumns).
+		AddRow("SQL01\\PROD", "ETL_Nightly", time.Date(2026, 2, 3, 1, 0, 0, 0, time.UTC), 3*3600).
+		AddRow(nil, "Index_Rebuild", time.Date(2026, 2, 3, 2, 30, 0, 0, time.UTC), 90*60).
+		AddRow("SQL01\\PROD", "test_Load", time.Date(2026, 2, 3, 2, 0, 0, 0, time.UTC), 2*3600)
+	mock.ExpectQuery(`FROM \[msdb_restored\]\.dbo\.sysjobactivity[\s\S]*syssessions[\s\S]*stop_execution_date IS NULL[\s\S]*@ThresholdMinutes`).
+		WithArgs(sql.Named("ThresholdMinutes", 60)).
+		WillReturnRows(rows)
+
+	jobs, err := db.QueryLongRunningJobs(context.Background(), 60)
+	if err != nil {
+		t.Fatalf("QueryLongRunningJobs() unexpected error: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("QueryLongRunningJobs() returned %d jobs, want 2 (test_* excluded): %+v", len(jobs), jobs)
+	}
+
+	etl := jobs[0]
+	if etl.ServerName != "SQL01\\PROD" || etl.JobName != "ETL_Nightly" {
+		t.Errorf("jobs[0] = %s/%s, want SQL01\\PROD/ETL_Nightly", etl.ServerName, etl.JobName)
+	}
+	wantStart := time.Date(2026, 2, 3, 1, 0, 0, 0, time.FixedZone("", -5*3600))
+	if !etl.StartedAt.Equal(wantStart) {
+		t.Errorf("StartedAt = %v, want %v (server local time)", etl.StartedAt, wantStart)
+	}
+	if etl.RunningFor() != 3*time.Hour {
+		t.Errorf("RunningFor() = %v, want 3h", etl.RunningFor())
+	}
+	if jobs[1].ServerName != "SQL01" {
+		t.Errorf("ServerName without @@SERVERNAME = %q, want configured name", jobs[1].ServerName)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestQueryLongRunningJobs_None(t *testing.T) {
+	db, mock := newMockDB(t, config.ServerConfig{Name: "SQL01"})
+	expectServerOffset(mock, 0)
+	mock.ExpectQuery(`FROM \[msdb\]\.dbo\.sysjobactivity`).
+		WithArgs(sql.Named("ThresholdMinutes", 120)).
+		WillReturnRows(sqlmock.NewRows(longRunningColumns))
+
+	jobs, err := db.QueryLongRunningJobs(context.Background(), 120)
+	if err != nil {
+		t.Fatalf("QueryLongRunningJobs() unexpected error: %v", err)
+	}
+	if len(jobs) != 0 {
+		t.Errorf("QueryLongRunningJobs() = %+v, want none", jobs)
+	}
+}
+
+func TestQueryLongRunningJobs_Error(t *testing.T) {
+	db, mock := newMockDB(t, config.ServerConfig{Name: "SQL01"})
+	expectServerOffset(mock, 0)
+	mock.ExpectQuery(`sysjobactivity`).WillReturnError(errors.New("SELECT permission was denied"))
+
+	_, err := db.QueryLongRunningJobs(context.Background(), 60)
+	if err == nil || err.Error() != "failed to query long-running jobs: SELECT permission was denied" {
+		t.Errorf("QueryLongRunningJobs() error = %v, want wrapped query error", err)
+	}
+}
+
+func TestLongRunningThreshold(t *testing.T) {
+	cfg := config.LongRunningConfig{
+		ThresholdMinutes: 120,
+		Jobs: []config.LongRunningRule{
+			{Pattern: "ETL_*", ThresholdMinutes: 240},
+			{Pattern: "ETL_Hourly", ThresholdMinutes: 30},
+		},
+	}
+
+	tests := []struct {
+		jobName string
+		want    int
+	}{
+		{"ETL_Nightly", 240},
+		{"ETL_Hourly", 240}, // First match wins over the exact rule
+		{"Backup", 120},
+	}
+	for _, tt := range tests {
+		if got := LongRunningThreshold(cfg, tt.jobName); got != tt.want {
+			t.Errorf("LongRunningThreshold(%q) = %d, want %d", tt.jobName, got, tt.want)
+		}
+	}
+}
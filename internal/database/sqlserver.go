@@ -5,9 +5,12 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/microsoft/go-mssqldb" // SQL Server driver
@@ -15,10 +18,51 @@ import (
 	"github.com/hoangtran1411/watchman/internal/config"
 )
 
+// ErrPermissionDenied is returned when the login cannot read SQL Agent job history.
+var ErrPermissionDenied = errors.New("insufficient permissions on msdb job tables")
+
 // DB represents a SQL Server database connection.
 type DB struct {
 	conn   *sql.DB
 	server config.ServerConfig
+
+	// location is the server's time zone offset, looked up once per connection.
+	location *time.Location
+
+	// statuses are the run_status values queried; failed only when empty.
+	statuses []int
+
+	// dropIdle closes idle pooled connections before a stale connection is retried.
+	dropIdle func()
+}
+
+// JobQuerier is the set of job history queries a monitor runs against one
+// server. It is implemented by *DB and can be replaced in tests.
+type JobQuerier interface {
+	Ping(ctx context.Context) error
+	Close() error
+	QueryFailedJobs(ctx context.Context, lookback time.Duration) ([]FailedJob, error)
+	QueryFailedJobsShared(ctx context.Context, lookback time.Duration, servers []config.ServerConfig) (map[string][]FailedJob, error)
+	QueryRecentOutcomes(ctx context.Context, count int) (map[string][]int, error)
+	QueryLongRunningJobs(ctx context.Context, thresholdMinutes int) ([]LongRunningJob, error)
+	GetClockSkew(ctx context.Context) (time.Duration, error)
+	ValidatePermissions(ctx context.Context) error
+}
+
+// Factory creates a JobQuerier connected to server.
+type Factory func(server config.ServerConfig) (JobQuerier, error)
+
+// NewFactory returns a Factory that opens a *DB querying the given
+// run_status values, failed only when statuses is empty.
+func NewFactory(statuses []int) Factory {
+	return func(server config.ServerConfig) (JobQuerier, error) {
+		db, err := New(server)
+		if err != nil {
+			return nil, err
+		}
+		db.SetStatuses(statuses)
+		return db, nil
+	}
 }
 
 // FailedJob represents a failed SQL Server Agent job.
@@ -30,12 +74,47 @@ type FailedJob struct {
 	FailedAt     time.Time `json:"failed_at"`
 	Status       int       `json:"status"`
 	ErrorMessage string    `json:"error_message"`
-	Duration     int       `json:"duration_seconds"`
+	Duration     int       `json:"duration_seconds"`    // Decoded from run_duration (HHMMSS)
+	StepID       int       `json:"step_id,omitempty"`   // 0 for the job outcome
+	StepName     string    `json:"step_name,omitempty"` // Empty for the job outcome
+	Owner        string    `json:"owner,omitempty"`     // Notify operator, else owner login
+	Category     string    `json:"category,omitempty"`
+	Severity     string    `json:"severity,omitempty"`     // From monitoring.severities; empty if no rule matches
+	Persistence  string    `json:"persistence,omitempty"`  // Transient or persistent; empty if unknown
+	DisplayName  string    `json:"display_name,omitempty"` // The server's display_name, if configured
+	ConfigName   string    `json:"config_name,omitempty"`  // Name of the configured server queried
+}
+
+// ServerLabel returns the server's display name, or its name if none is configured.
+func (j FailedJob) ServerLabel() string {
+	if j.DisplayName != "" {
+		return j.DisplayName
+	}
+	return j.ServerName
+}
+
+// ConfiguredServer returns the name of the configured server the job was
+// found on. ServerName is the instance's own @@SERVERNAME, which may differ;
+// it is used only for jobs not tagged with a configured server.
+func (j FailedJob) ConfiguredServer() string {
+	if j.ConfigName != "" {
+		return j.ConfigName
+	}
+	return j.ServerName
 }
 
+// Failure persistence, from the job's recent run history.
+const (
+	PersistenceTransient  = "transient"  // The latest run failed, the one before did not
+	PersistencePersistent = "persistent" // Several runs in a row failed
+)
+
 // New creates a new database connection.
+// A configured DSN is used verbatim; otherwise the connection string is built
+// from the structured fields.
 func New(server config.ServerConfig) (*DB, error) {
-	connStr := buildConnectionString(server)
+	server.Options = server.Options.WithDefaults()
+	connStr := ConnectionString(server)
 
 	conn, err := sql.Open("sqlserver", connStr)
 	if err != nil {
@@ -44,22 +123,31 @@ func New(server config.ServerConfig) (*DB, error) {
 
 	// Set connection pool settings
 	conn.SetMaxOpenConns(5)
-	conn.SetMaxIdleConns(2)
+	conn.SetMaxIdleConns(maxIdleConns)
 	conn.SetConnMaxLifetime(time.Duration(server.Options.ConnectionTimeout) * time.Second * 2)
 
 	return &DB{
 		conn:   conn,
 		server: server,
+		dropIdle: func() {
+			// Dropping the idle limit closes every idle connection
+			conn.SetMaxIdleConns(0)
+			conn.SetMaxIdleConns(maxIdleConns)
+		},
 	}, nil
 }
 
 // Ping tests the database connection.
 // Ping tests the database connection.
+// A stale pooled connection, e.g. after a server restart, is retried once.
 func (db *DB) Ping(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(db.server.Options.ConnectionTimeout)*time.Second)
 	defer cancel()
 
-	if err := db.conn.PingContext(ctx); err != nil {
+	err := db.retryStale(ctx, func() error {
+		return db.conn.PingContext(ctx)
+	})
+	if err != nil {
 		return fmt.Errorf("ping failed: %w", err)
 	}
 	return nil
@@ -76,47 +164,244 @@ func (db *DB) Close() error {
 	return nil
 }
 
-// GetServerName returns the SQL Server name using @@SERVERNAME.
+// GetServerName returns the SQL Server name.
+// It falls back from @@SERVERNAME to SERVERPROPERTY('ServerName') and finally
+// to the configured server name, since @@SERVERNAME is NULL on misconfigured instances.
 func (db *DB) GetServerName(ctx context.Context) (string, error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(db.server.Options.QueryTimeout)*time.Second)
 	defer cancel()
 
-	var serverName string
-	err := db.conn.QueryRowContext(ctx, "SELECT @@SERVERNAME").Scan(&serverName)
+	query := "SELECT @@SERVERNAME, CONVERT(nvarchar(128), SERVERPROPERTY('ServerName'))"
+
+	var serverName, propertyName sql.NullString
+	err := db.retryStale(ctx, func() error {
+		return db.conn.QueryRowContext(ctx, query).Scan(&serverName, &propertyName)
+	})
 	if err != nil {
 		return "", fmt.Errorf("failed to get server name: %w", err)
 	}
 
-	return serverName, nil
+	return resolveServerName(db.server.Name, serverName, propertyName), nil
+}
+
+// GetServerTimeOffset returns the SQL Server instance's current UTC offset.
+// Job history times are stored in the server's local time, which may differ
+// from the machine running Watchman.
+func (db *DB) GetServerTimeOffset(ctx context.Context) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(db.server.Options.QueryTimeout)*time.Second)
+	defer cancel()
+
+	var now time.Time
+	err := db.retryStale(ctx, func() error {
+		return db.conn.QueryRowContext(ctx, "SELECT SYSDATETIMEOFFSET()").Scan(&now)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get server time offset: %w", err)
+	}
+
+	_, offset := now.Zone()
+	return time.Duration(offset) * time.Second, nil
 }
 
-// QueryFailedJobs queries for failed SQL Server Agent jobs.
-func (db *DB) QueryFailedJobs(ctx context.Context, lookbackHours int) ([]FailedJob, error) {
+// GetClockSkew returns how far the SQL Server clock is ahead of the local
+// clock, negative when it is behind. The server's time is read with its UTC
+// offset, so a different time zone is not mistaken for skew.
+func (db *DB) GetClockSkew(ctx context.Context) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(db.server.Options.QueryTimeout)*time.Second)
+	defer cancel()
+
+	var serverNow, sent time.Time
+	err := db.retryStale(ctx, func() error {
+		sent = time.Now()
+		return db.conn.QueryRowContext(ctx, "SELECT SYSDATETIMEOFFSET()").Scan(&serverNow)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get server time: %w", err)
+	}
+	return clockSkew(serverNow, sent, time.Now()), nil
+}
+
+// clockSkew returns the difference between serverNow and the local time
+// halfway between sent and received, which cancels out the round trip.
+func clockSkew(serverNow, sent, received time.Time) time.Duration {
+	local := sent.Add(received.Sub(sent) / 2)
+	return serverNow.Sub(local)
+}
+
+// serverLocation returns the time zone used to interpret job history times.
+// The offset is cached for the lifetime of the connection; if it cannot be
+// read, the local time zone is used as before.
+func (db *DB) serverLocation(ctx context.Context) *time.Location {
+	if db.location != nil {
+		return db.location
+	}
+
+	offset, err := db.GetServerTimeOffset(ctx)
+	if err != nil {
+		db.location = time.Local
+		return db.location
+	}
+	db.location = time.FixedZone("", int(offset/time.Second))
+	return db.location
+}
+
+// resolveServerName returns the first non-empty name from the candidates,
+// falling back to the configured server name.
+func resolveServerName(fallback string, candidates ...sql.NullString) string {
+	for _, c := range candidates {
+		if c.Valid && c.String != "" {
+			return c.String
+		}
+	}
+	return fallback
+}
+
+// ValidatePermissions checks that the login can read the msdb job tables.
+func (db *DB) ValidatePermissions(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(db.server.Options.QueryTimeout)*time.Second)
 	defer cancel()
 
 	query := `
+SELECT
+    HAS_PERMS_BY_NAME('msdb.dbo.sysjobs', 'OBJECT', 'SELECT') AS CanReadJobs,
+    HAS_PERMS_BY_NAME('msdb.dbo.sysjobhistory', 'OBJECT', 'SELECT') AS CanReadHistory
+`
+
+	var canReadJobs, canReadHistory sql.NullInt64
+	if err := db.conn.QueryRowContext(ctx, query).Scan(&canReadJobs, &canReadHistory); err != nil {
+		return fmt.Errorf("failed to check permissions: %w", err)
+	}
+
+	if canReadJobs.Int64 != 1 || canReadHistory.Int64 != 1 {
+		return ErrPermissionDenied
+	}
+
+	return nil
+}
+
+// failedJobsBranch selects failed history rows from one msdb database.
+// %[1]s is the quoted database identifier, %[2]s the quoted source label
+// %[3]s the outcome predicate (jobOutcome or stepOutcome) and %[4]s the
+// comma-separated run_status values, %[5]s the job name predicate (see
+// jobNamesClause).
+// Owner is the job's e-mail operator, falling back to the owning login;
+// Owner and Category may be NULL, e.g. for an orphaned owner SID.
+const failedJobsBranch = `
 SELECT 
-    @@SERVERNAME AS ServerName,
+    ISNULL(@@SERVERNAME, CONVERT(nvarchar(128), SERVERPROPERTY('ServerName'))) AS ServerName,
+    %[2]s AS SourceDatabase,
     j.name AS JobName,
     h.run_date AS RunDate,
     h.run_time AS RunTime,
     h.run_status AS Status,
     ISNULL(h.message, '') AS ErrorMessage,
-    h.run_duration AS Duration
-FROM msdb.dbo.sysjobs j
-INNER JOIN msdb.dbo.sysjobhistory h 
+    h.run_duration AS Duration,
+    h.step_id AS StepID,
+    CASE WHEN h.step_id = 0 THEN N'' ELSE ISNULL(h.step_name, N'') END AS StepName,
+    COALESCE(o.name, SUSER_SNAME(j.owner_sid)) AS Owner,
+    c.name AS Category
+FROM %[1]s.dbo.sysjobs j
+INNER JOIN %[1]s.dbo.sysjobhistory h 
     ON j.job_id = h.job_id
-WHERE h.step_id = 0
-    AND h.run_status = 0
+LEFT JOIN %[1]s.dbo.sysoperators o
+    ON o.id = j.notify_email_operator_id
+LEFT JOIN %[1]s.dbo.syscategories c
+    ON c.category_id = j.category_id
+WHERE %[3]s
+    AND h.run_status IN (%[4]s)%[5]s
     AND CONVERT(datetime, 
         CONVERT(varchar(8), h.run_date) + ' ' + 
         STUFF(STUFF(RIGHT('000000' + CONVERT(varchar(6), h.run_time), 6), 5, 0, ':'), 3, 0, ':')
-    ) >= DATEADD(hour, -@LookbackHours, GETDATE())
-ORDER BY h.run_date DESC, h.run_time DESC
-`
+    ) >= DATEADD(second, -@LookbackSeconds, GETDATE())`
+
+// History outcome predicates. Step 0 records the overall job outcome and is
+// what alerts by default. Rows with step_id > 0 record individual steps: a step
+// can fail while the job still succeeds (retry, or "go to next step" on
+// failure), so step outcomes are only reported when a server opts in.
+const (
+	jobOutcome  = "h.step_id = 0"
+	stepOutcome = "h.step_id > 0"
+)
+
+// defaultHistoryDatabase is the database holding SQL Server Agent job history.
+const defaultHistoryDatabase = "msdb"
+
+// sourcedJob is a failed job row tagged with the database it was read from.
+type sourcedJob struct {
+	FailedJob
+	rawServerName sql.NullString
+	source        string
+}
+
+// SetStatuses sets the run_status values returned by the failed jobs queries.
+// By default only failed (0) runs are returned.
+func (db *DB) SetStatuses(statuses []int) {
+	db.statuses = statuses
+}
+
+// QueryFailedJobs queries for failed SQL Server Agent jobs that ran within lookback.
+func (db *DB) QueryFailedJobs(ctx context.Context, lookback time.Duration) ([]FailedJob, error) {
+	rows, err := db.queryFailedJobs(ctx, lookback, []string{HistoryDatabase(db.server)}, db.server.Jobs.IncludeSteps, db.server.Jobs.Only)
+	if err != nil {
+		return nil, err
+	}
+	return filterJobs(db.server, rows), nil
+}
+
+// QueryFailedJobsShared queries failed jobs once for several server configs that
+// share this connection's SQL Server instance. Results are filtered per config
+// and keyed by config name. Job names are only restricted in the query when
+// every config sets jobs.only.
+func (db *DB) QueryFailedJobsShared(ctx context.Context, lookback time.Duration, servers []config.ServerConfig) (map[string][]FailedJob, error) {
+	seen := make(map[string]struct{})
+	var databases, only []string
+	includeSteps := false
+	allOnly := true
+	for _, srv := range servers {
+		includeSteps = includeSteps || srv.Jobs.IncludeSteps
+		allOnly = allOnly && len(srv.Jobs.Only) > 0
+		only = append(only, srv.Jobs.Only...)
+		name := HistoryDatabase(srv)
+		if _, ok := seen[name]; !ok {
+			seen[name] = struct{}{}
+			databases = append(databases, name)
+		}
+	}
+	if !allOnly {
+		only = nil
+	}
+
+	rows, err := db.queryFailedJobs(ctx, lookback, databases, includeSteps, only)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string][]FailedJob, len(servers))
+	for _, srv := range servers {
+		results[srv.Name] = filterJobs(srv, rows)
+	}
+	return results, nil
+}
+
+// queryFailedJobs runs the failed jobs query across the given history databases.
+// Step outcomes are queried in addition to job outcomes when includeSteps is set,
+// and only the jobs named in only are read when it is not empty.
+// The lookback is rounded up to whole seconds.
+// A stale pooled connection is retried once on a fresh connection.
+func (db *DB) queryFailedJobs(ctx context.Context, lookback time.Duration, databases []string, includeSteps bool, only []string) ([]sourcedJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(db.server.Options.QueryTimeout)*time.Second)
+	defer cancel()
+
+	loc := db.serverLocation(ctx)
+	query := buildFailedJobsQuery(databases, includeSteps, db.statuses, len(only))
+	args := append([]any{sql.Named("LookbackSeconds", lookbackSeconds(lookback))}, jobNameArgs(only)...)
 
-	rows, err := db.conn.QueryContext(ctx, query, sql.Named("LookbackHours", lookbackHours))
+	var rows *sql.Rows
+	err := db.retryStale(ctx, func() error {
+		var err error
+		rows, err = db.conn.QueryContext(ctx, query, args...)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to query failed jobs: %w", err)
 	}
@@ -124,29 +409,34 @@ ORDER BY h.run_date DESC, h.run_time DESC
 		_ = rows.Close() // Ignore validation error on close
 	}()
 
-	var jobs []FailedJob
+	var jobs []sourcedJob
 	for rows.Next() {
-		var job FailedJob
+		var job sourcedJob
+		var owner, category sql.NullString
+		var runDuration int
 		err := rows.Scan(
-			&job.ServerName,
+			&job.rawServerName,
+			&job.source,
 			&job.JobName,
 			&job.RunDate,
 			&job.RunTime,
 			&job.Status,
 			&job.ErrorMessage,
-			&job.Duration,
+			&runDuration,
+			&job.StepID,
+			&job.StepName,
+			&owner,
+			&category,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
+		job.Owner = owner.String
+		job.Category = category.String
+		job.Duration = int(parseRunDuration(runDuration) / time.Second)
 
 		// Parse FailedAt from RunDate and RunTime
-		job.FailedAt = parseDateTime(job.RunDate, job.RunTime)
-
-		// Apply job filters
-		if !db.matchesFilter(job.JobName) {
-			continue
-		}
+		job.FailedAt = parseDateTime(job.RunDate, job.RunTime, loc)
 
 		jobs = append(jobs, job)
 	}
@@ -158,9 +448,212 @@ ORDER BY h.run_date DESC, h.run_time DESC
 	return jobs, nil
 }
 
-// matchesFilter checks if a job name matches the include/exclude filters.
+// recentOutcomesQuery selects the last @Count job outcomes (step 0) of every
+// job, most recent first. %[1]s is the quoted history database identifier.
+const recentOutcomesQuery = `
+SELECT JobName, Status
+FROM (
+    SELECT
+        j.name AS JobName,
+        h.run_status AS Status,
+        ROW_NUMBER() OVER (
+            PARTITION BY h.job_id
+            ORDER BY h.run_date DESC, h.run_time DESC, h.instance_id DESC
+        ) AS RowNum
+    FROM %[1]s.dbo.sysjobs j
+    INNER JOIN %[1]s.dbo.sysjobhistory h
+        ON j.job_id = h.job_id
+    WHERE h.step_id = 0
+) recent
+WHERE RowNum <= @Count
+ORDER BY JobName, RowNum
+`
+
+// QueryRecentOutcomes returns the run_status of the last count runs of every
+// job, most recent first, keyed by job name.
+func (db *DB) QueryRecentOutcomes(ctx context.Context, count int) (map[string][]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(db.server.Options.QueryTimeout)*time.Second)
+	defer cancel()
+
+	// #nosec G201 -- the identifier is escaped by quoteIdentifier
+	query := fmt.Sprintf(recentOutcomesQuery, quoteIdentifier(HistoryDatabase(db.server)))
+
+	var rows *sql.Rows
+	err := db.retryStale(ctx, func() error {
+		var err error
+		rows, err = db.conn.QueryContext(ctx, query, sql.Named("Count", count))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recent outcomes: %w", err)
+	}
+	defer func() {
+		_ = rows.Close() // Ignore validation error on close
+	}()
+
+	outcomes := make(map[string][]int)
+	for rows.Next() {
+		var name string
+		var status int
+		if err := rows.Scan(&name, &status); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		outcomes[name] = append(outcomes[name], status)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return outcomes, nil
+}
+
+// FailedJobsQuery returns the failed jobs query run for server, with the
+// parameters substituted so it can be reviewed or run by hand.
+// Job include/exclude filters are applied to the rows afterwards and are not
+// part of the SQL; jobs.only names are.
+func FailedJobsQuery(server config.ServerConfig, lookbackHours int, statuses []int) string {
+	query := buildFailedJobsQuery([]string{HistoryDatabase(server)}, server.Jobs.IncludeSteps, statuses, len(server.Jobs.Only))
+	// Highest index first so @Job1 does not clobber @Job10
+	for i := len(server.Jobs.Only) - 1; i >= 0; i-- {
+		query = strings.ReplaceAll(query, "@"+jobNameParam(i), quoteLiteral(server.Jobs.Only[i]))
+	}
+	return strings.ReplaceAll(query, "@LookbackSeconds", strconv.FormatInt(lookbackSeconds(time.Duration(lookbackHours)*time.Hour), 10))
+}
+
+// lookbackSeconds returns lookback in seconds, rounded up.
+func lookbackSeconds(lookback time.Duration) int64 {
+	return int64((lookback + time.Second - 1) / time.Second)
+}
+
+// buildFailedJobsQuery builds the failed jobs query reading from each history
+// database in a single round trip. Identifiers are quoted, never interpolated raw.
+// Job outcomes are always selected; step outcomes only when includeSteps is set.
+// Rows with any of statuses are selected, failed (0) only when statuses is empty.
+// When jobNames is positive, only jobs named by @Job0..@Job<jobNames-1> are read.
+func buildFailedJobsQuery(databases []string, includeSteps bool, statuses []int, jobNames int) string {
+	outcomes := []string{jobOutcome}
+	if includeSteps {
+		outcomes = append(outcomes, stepOutcome)
+	}
+
+	codes := []string{"0"}
+	if len(statuses) > 0 {
+		codes = make([]string, len(statuses))
+		for i, status := range statuses {
+			codes[i] = strconv.Itoa(status)
+		}
+	}
+	statusList := strings.Join(codes, ", ")
+	names := jobNamesClause(jobNames)
+
+	branches := make([]string, 0, len(databases)*len(outcomes))
+	for _, name := range databases {
+		for _, outcome := range outcomes {
+			// #nosec G201 -- identifiers are escaped by quoteIdentifier/quoteLiteral
+			branches = append(branches, fmt.Sprintf(failedJobsBranch, quoteIdentifier(name), quoteLiteral(name), outcome, statusList, names))
+		}
+	}
+	return strings.Join(branches, "\nUNION ALL") + "\nORDER BY RunDate DESC, RunTime DESC\n"
+}
+
+// jobNamesClause returns the predicate restricting rows to count job names
+// bound as parameters, or "" when count is zero.
+func jobNamesClause(count int) string {
+	if count == 0 {
+		return ""
+	}
+	params := make([]string, count)
+	for i := range params {
+		params[i] = "@" + jobNameParam(i)
+	}
+	return "\n    AND j.name IN (" + strings.Join(params, ", ") + ")"
+}
+
+// jobNameArgs returns the query arguments binding names to jobNamesClause.
+func jobNameArgs(names []string) []any {
+	args := make([]any, len(names))
+	for i, name := range names {
+		args[i] = sql.Named(jobNameParam(i), name)
+	}
+	return args
+}
+
+// jobNameParam returns the parameter name, without "@", of the i-th job name.
+func jobNameParam(i int) string {
+	return "Job" + strconv.Itoa(i)
+}
+
+// filterJobs returns the rows belonging to server's history database that pass its job filters.
+func filterJobs(server config.ServerConfig, rows []sourcedJob) []FailedJob {
+	source := HistoryDatabase(server)
+
+	var jobs []FailedJob
+	for _, row := range rows {
+		if row.source != source || DropReason(server, row.FailedJob) != "" {
+			continue
+		}
+		job := row.FailedJob
+		job.ServerName = resolveServerName(server.Name, row.rawServerName)
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+// DropReason returns why server's job filters drop job, or "" if the job is reported.
+// Step outcomes are dropped unless the server opted in to them.
+func DropReason(server config.ServerConfig, job FailedJob) string {
+	if !filterMatches(server.Jobs, job.JobName) {
+		return "excluded by job filters"
+	}
+	if job.StepID > 0 && !server.Jobs.IncludeSteps {
+		return "step outcome (include_steps is off)"
+	}
+	return ""
+}
+
+// HistoryDatabase returns the database holding the server's job history.
+// The name is validated by the config and quoted by quoteIdentifier when used.
+func HistoryDatabase(server config.ServerConfig) string {
+	if server.MsdbDatabase != "" {
+		return server.MsdbDatabase
+	}
+	return defaultHistoryDatabase
+}
+
+// quoteIdentifier quotes a SQL Server identifier using brackets.
+func quoteIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+// quoteLiteral quotes a SQL Server Unicode string literal.
+func quoteLiteral(s string) string {
+	return "N'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// Severity returns the level of the first rule whose pattern matches
+// jobName, or "" if none does.
+func Severity(rules []config.SeverityRule, jobName string) string {
+	for _, rule := range rules {
+		if matchPattern(jobName, rule.Pattern) {
+			return rule.Level
+		}
+	}
+	return ""
+}
+
+// matchesFilter checks if a job name matches the server's include/exclude filters.
 func (db *DB) matchesFilter(jobName string) bool {
-	filter := db.server.Jobs
+	return filterMatches(db.server.Jobs, jobName)
+}
+
+// filterMatches checks if a job name matches the include/exclude filters.
+// With jobs.only, the name must be one of the listed names instead.
+func filterMatches(filter config.JobsFilter, jobName string) bool {
+	if len(filter.Only) > 0 {
+		// SQL Server compares the names with the (usually case-insensitive) server collation
+		return slices.ContainsFunc(filter.Only, func(name string) bool {
+			return strings.EqualFold(name, jobName)
+		})
+	}
 
 	// If include list is specified, job must match at least one pattern
 	if len(filter.Include) > 0 {
@@ -209,8 +702,9 @@ func matchPattern(name, pattern string) bool {
 	return name == pattern
 }
 
-// parseDateTime converts SQL Server run_date and run_time to time.Time.
-func parseDateTime(runDate, runTime int) time.Time {
+// parseDateTime converts SQL Server run_date and run_time, recorded in the
+// server's local time zone loc, to time.Time.
+func parseDateTime(runDate, runTime int, loc *time.Location) time.Time {
 	// run_date format: YYYYMMDD
 	// run_time format: HHMMSS
 
@@ -222,7 +716,22 @@ func parseDateTime(runDate, runTime int) time.Time {
 	minute := (runTime % 10000) / 100
 	second := runTime % 100
 
-	return time.Date(year, time.Month(month), day, hour, minute, second, 0, time.Local)
+	return time.Date(year, time.Month(month), day, hour, minute, second, 0, loc)
+}
+
+// parseRunDuration converts a SQL Agent run_duration to a Duration.
+// run_duration is encoded as HHMMSS; hours may exceed two digits.
+func parseRunDuration(runDuration int) time.Duration {
+	hours := runDuration / 10000
+	minutes := (runDuration % 10000) / 100
+	seconds := runDuration % 100
+
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+}
+
+// RunDuration returns how long the job ran before it failed.
+func (j FailedJob) RunDuration() time.Duration {
+	return time.Duration(j.Duration) * time.Second
 }
 
 // buildConnectionString builds a SQL Server connection string.
@@ -232,6 +741,9 @@ func buildConnectionString(server config.ServerConfig) string {
 	query.Add("encrypt", strconv.FormatBool(server.Options.Encrypt))
 	query.Add("TrustServerCertificate", strconv.FormatBool(server.Options.TrustServerCertificate))
 	query.Add("connection timeout", strconv.Itoa(server.Options.ConnectionTimeout))
+	if server.ReadOnlyIntent {
+		query.Add("ApplicationIntent", "ReadOnly")
+	}
 
 	u := &url.URL{
 		Scheme:   "sqlserver",
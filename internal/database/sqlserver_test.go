@@ -1,12 +1,46 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/microsoft/go-mssqldb/msdsn"
+
 	"github.com/hoangtran1411/watchman/internal/config"
 )
 
+// failedJobColumns are the columns returned by the failed jobs query.
+var failedJobColumns = []string{"ServerName", "SourceDatabase", "JobName", "RunDate", "RunTime", "Status", "ErrorMessage", "Duration", "StepID", "StepName", "Owner", "Category"}
+
+// expectServerOffset expects the server time offset lookup and returns offset.
+func expectServerOffset(mock sqlmock.Sqlmock, offset time.Duration) {
+	now := time.Date(2026, 2, 3, 9, 0, 0, 0, time.FixedZone("", int(offset/time.Second)))
+	mock.ExpectQuery(`SELECT SYSDATETIMEOFFSET\(\)`).WillReturnRows(sqlmock.NewRows([]string{""}).AddRow(now))
+}
+
+// newMockDB creates a DB backed by sqlmock.
+func newMockDB(t *testing.T, server config.ServerConfig) (*DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	if server.Options.QueryTimeout == 0 {
+		server.Options.QueryTimeout = 5
+	}
+	return &DB{conn: conn, server: server}, mock
+}
+
 func TestParseDateTime(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -56,7 +90,7 @@ func TestParseDateTime(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := parseDateTime(tt.runDate, tt.runTime)
+			got := parseDateTime(tt.runDate, tt.runTime, time.Local)
 
 			if got.Year() != tt.wantYear {
 				t.Errorf("Year = %d, want %d", got.Year(), tt.wantYear)
@@ -80,6 +114,49 @@ func TestParseDateTime(t *testing.T) {
 	}
 }
 
+func TestParseRunDuration(t *testing.T) {
+	tests := []struct {
+		name        string
+		runDuration int
+		want        time.Duration
+	}{
+		{name: "zero", runDuration: 0, want: 0},
+		{name: "seconds only", runDuration: 45, want: 45 * time.Second},
+		{name: "minutes and seconds", runDuration: 130, want: time.Minute + 30*time.Second},
+		{name: "one hour", runDuration: 10000, want: time.Hour},
+		{name: "hours minutes seconds", runDuration: 23005, want: 2*time.Hour + 30*time.Minute + 5*time.Second},
+		{name: "more than 99 hours", runDuration: 1000000, want: 100 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRunDuration(tt.runDuration); got != tt.want {
+				t.Errorf("parseRunDuration(%d) = %v, want %v", tt.runDuration, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryFailedJobs_RunDuration(t *testing.T) {
+	db, mock := newMockDB(t, config.ServerConfig{Name: "SQL01"})
+
+	rows := sqlmock.NewRows(failedJobColumns).
+		AddRow("SQL01", "msdb", "Backup", 20260203, 83015, 0, "failed", 13005, 0, "", nil, nil)
+	expectServerOffset(mock, 0)
+	mock.ExpectQuery("FROM \\[msdb\\]").WillReturnRows(rows)
+
+	jobs, err := db.QueryFailedJobs(context.Background(), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("QueryFailedJobs() unexpected error: %v", err)
+	}
+	if got, want := jobs[0].Duration, 3600+30*60+5; got != want {
+		t.Errorf("Duration = %d seconds, want %d", got, want)
+	}
+	if got, want := jobs[0].RunDuration(), time.Hour+30*time.Minute+5*time.Second; got != want {
+		t.Errorf("RunDuration() = %v, want %v", got, want)
+	}
+}
+
 func TestMatchPattern(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -141,6 +218,36 @@ func TestMatchPattern(t *testing.T) {
 	}
 }
 
+func TestSeverity(t *testing.T) {
+	rules := []config.SeverityRule{
+		{Pattern: "Backup_Prod*", Level: config.SeverityCritical},
+		{Pattern: "Backup_*", Level: config.SeverityHigh},
+		{Pattern: "*_Cleanup", Level: config.SeverityLow},
+		{Pattern: "Backup_Prod_Logs", Level: config.SeverityLow},
+	}
+
+	tests := []struct {
+		jobName string
+		want    string
+	}{
+		{"Backup_Prod_Full", config.SeverityCritical},
+		{"Backup_Prod_Logs", config.SeverityCritical}, // First match wins over the exact rule
+		{"Backup_QA", config.SeverityHigh},
+		{"Index_Cleanup", config.SeverityLow},
+		{"Backup_Cleanup", config.SeverityHigh},
+		{"ETL_Daily", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Severity(rules, tt.jobName); got != tt.want {
+			t.Errorf("Severity(%q) = %q, want %q", tt.jobName, got, tt.want)
+		}
+	}
+	if got := Severity(nil, "Backup_QA"); got != "" {
+		t.Errorf("Severity without rules = %q, want none", got)
+	}
+}
+
 func TestMatchesFilter(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -238,6 +345,24 @@ func TestMatchesFilter(t *testing.T) {
 	}
 }
 
+func TestNewFactory(t *testing.T) {
+	// Opening does not connect, so no server is needed
+	factory := NewFactory([]int{0, 2})
+	q, err := factory(config.ServerConfig{Name: "SQL1", Host: "localhost", Port: 1433})
+	if err != nil {
+		t.Fatalf("factory() error = %v", err)
+	}
+	t.Cleanup(func() { _ = q.Close() })
+
+	db, ok := q.(*DB)
+	if !ok {
+		t.Fatalf("factory() returned %T, want *DB", q)
+	}
+	if fmt.Sprint(db.statuses) != "[0 2]" {
+		t.Errorf("statuses = %v, want [0 2]", db.statuses)
+	}
+}
+
 func TestBuildConnectionString(t *testing.T) {
 	server := config.ServerConfig{
 		Host:     "localhost",
@@ -267,3 +392,609 @@ func TestBuildConnectionString(t *testing.T) {
 		t.Errorf("connection string should start with 'sqlserver://', got: %s", connStr)
 	}
 }
+
+func TestBuildConnectionString_ReadOnlyIntent(t *testing.T) {
+	server := config.ServerConfig{
+		Host:     "ag-listener",
+		Port:     1433,
+		Database: "msdb",
+		Auth:     config.AuthConfig{Type: "windows"},
+	}
+
+	p, err := msdsn.Parse(buildConnectionString(server))
+	if err != nil {
+		t.Fatalf("failed to parse connection string: %v", err)
+	}
+	if p.ReadOnlyIntent {
+		t.Error("ReadOnlyIntent should be off by default")
+	}
+
+	server.ReadOnlyIntent = true
+	connStr := buildConnectionString(server)
+	if !strings.Contains(connStr, "ApplicationIntent=ReadOnly") {
+		t.Errorf("connection string should set ApplicationIntent=ReadOnly, got: %s", connStr)
+	}
+	p, err = msdsn.Parse(connStr)
+	if err != nil {
+		t.Fatalf("failed to parse connection string: %v", err)
+	}
+	if !p.ReadOnlyIntent {
+		t.Error("driver should parse the read-only intent")
+	}
+}
+
+func TestGetServerName(t *testing.T) {
+	tests := []struct {
+		name         string
+		serverName   interface{}
+		propertyName interface{}
+		want         string
+	}{
+		{
+			name:         "servername populated",
+			serverName:   "SQLPROD01",
+			propertyName: "SQLPROD01-PROP",
+			want:         "SQLPROD01",
+		},
+		{
+			name:         "servername null falls back to serverproperty",
+			serverName:   nil,
+			propertyName: "SQLPROD01-PROP",
+			want:         "SQLPROD01-PROP",
+		},
+		{
+			name:         "both null falls back to configured name",
+			serverName:   nil,
+			propertyName: nil,
+			want:         "CONFIGURED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t, config.ServerConfig{Name: "CONFIGURED"})
+
+			rows := sqlmock.NewRows([]string{"", ""}).AddRow(tt.serverName, tt.propertyName)
+			mock.ExpectQuery(`SELECT @@SERVERNAME, CONVERT\(nvarchar\(128\), SERVERPROPERTY\('ServerName'\)\)`).
+				WillReturnRows(rows)
+
+			got, err := db.GetServerName(context.Background())
+			if err != nil {
+				t.Fatalf("GetServerName() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetServerName() = %q, want %q", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestGetServerName_QueryError(t *testing.T) {
+	db, mock := newMockDB(t, config.ServerConfig{Name: "CONFIGURED"})
+	mock.ExpectQuery("SELECT @@SERVERNAME").WillReturnError(sql.ErrConnDone)
+
+	if _, err := db.GetServerName(context.Background()); err == nil {
+		t.Error("GetServerName() expected error, got nil")
+	}
+}
+
+func TestQueryFailedJobs_NullServerName(t *testing.T) {
+	db, mock := newMockDB(t, config.ServerConfig{Name: "CONFIGURED"})
+
+	rows := sqlmock.NewRows(failedJobColumns).
+		AddRow(nil, "msdb", "Backup", 20260203, 83015, 0, "failed", 10, 0, "", nil, nil).
+		AddRow("SQLPROD01", "msdb", "ETL", 20260203, 70000, 0, "failed", 5, 0, "", nil, nil)
+	expectServerOffset(mock, 0)
+	mock.ExpectQuery(`FROM \[msdb\]\.dbo\.sysjobs`).WillReturnRows(rows)
+
+	jobs, err := db.QueryFailedJobs(context.Background(), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("QueryFailedJobs() unexpected error: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("QueryFailedJobs() returned %d jobs, want 2", len(jobs))
+	}
+	if jobs[0].ServerName != "CONFIGURED" {
+		t.Errorf("jobs[0].ServerName = %q, want %q", jobs[0].ServerName, "CONFIGURED")
+	}
+	if jobs[1].ServerName != "SQLPROD01" {
+		t.Errorf("jobs[1].ServerName = %q, want %q", jobs[1].ServerName, "SQLPROD01")
+	}
+}
+
+func TestBuildFailedJobsQuery(t *testing.T) {
+	tests := []struct {
+		name         string
+		databases    []string
+		includeSteps bool
+		statuses     []int
+		jobNames     int
+		wantBranches int
+		wantContains []string
+		wantMissing  []string
+	}{
+		{
+			name:         "single database",
+			databases:    []string{"msdb"},
+			wantBranches: 1,
+			wantContains: []string{"FROM [msdb].dbo.sysjobs", "N'msdb' AS SourceDatabase", "h.step_id = 0", "h.run_status IN (0)"},
+			wantMissing:  []string{"h.step_id > 0", "j.name IN"},
+		},
+		{
+			name:         "job names are parameters in every branch",
+			databases:    []string{"msdb"},
+			includeSteps: true,
+			jobNames:     2,
+			wantBranches: 2,
+			wantContains: []string{"AND j.name IN (@Job0, @Job1)\n    AND CONVERT"},
+		},
+		{
+			name:         "configured statuses",
+			databases:    []string{"msdb"},
+			statuses:     []int{0, 3},
+			wantBranches: 1,
+			wantContains: []string{"h.run_status IN (0, 3)"},
+		},
+		{
+			name:         "step outcomes are a separate branch",
+			databases:    []string{"msdb"},
+			includeSteps: true,
+			wantBranches: 2,
+			wantContains: []string{"WHERE h.step_id = 0", "WHERE h.step_id > 0"},
+		},
+		{
+			name:         "multiple databases combined with union",
+			databases:    []string{"msdb", "msdb_archive"},
+			wantBranches: 2,
+			wantContains: []string{"FROM [msdb].dbo.sysjobs", "JOIN [msdb_archive].dbo.sysjobhistory", "UNION ALL"},
+		},
+		{
+			name:         "identifier is escaped",
+			databases:    []string{"bad]; DROP TABLE x; --"},
+			wantBranches: 1,
+			wantContains: []string{"[bad]]; DROP TABLE x; --]", "N'bad]; DROP TABLE x; --'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := buildFailedJobsQuery(tt.databases, tt.includeSteps, tt.statuses, tt.jobNames)
+			if tt.jobNames > 0 {
+				if got := strings.Count(query, "j.name IN"); got != tt.wantBranches {
+					t.Errorf("query has %d job name clauses, want %d", got, tt.wantBranches)
+				}
+			}
+
+			if got := strings.Count(query, "SELECT"); got != tt.wantBranches {
+				t.Errorf("query has %d SELECT branches, want %d", got, tt.wantBranches)
+			}
+			if got := strings.Count(query, "UNION ALL"); got != tt.wantBranches-1 {
+				t.Errorf("query has %d UNION ALL, want %d", got, tt.wantBranches-1)
+			}
+			if got := strings.Count(query, "ORDER BY"); got != 1 {
+				t.Errorf("query has %d ORDER BY, want 1", got)
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(query, want) {
+					t.Errorf("query missing %q:\n%s", want, query)
+				}
+			}
+			for _, unwanted := range tt.wantMissing {
+				if strings.Contains(query, unwanted) {
+					t.Errorf("query unexpectedly contains %q:\n%s", unwanted, query)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryFailedJobsShared(t *testing.T) {
+	servers := []config.ServerConfig{
+		{Name: "ETL-VIEW", Jobs: config.JobsFilter{Include: []string{"ETL_*"}}},
+		{Name: "BACKUP-VIEW", Jobs: config.JobsFilter{Include: []string{"Backup_*"}}},
+	}
+	db, mock := newMockDB(t, servers[0])
+
+	rows := sqlmock.NewRows(failedJobColumns).
+		AddRow("SQLPROD01", "msdb", "ETL_Daily", 20260203, 83015, 0, "failed", 10, 0, "", nil, nil).
+		AddRow("SQLPROD01", "msdb", "Backup_Full", 20260203, 70000, 0, "failed", 5, 0, "", nil, nil).
+		AddRow("SQLPROD01", "msdb", "Cleanup", 20260203, 60000, 0, "failed", 5, 0, "", nil, nil)
+	// A single round trip serves both configs
+	expectServerOffset(mock, 0)
+	mock.ExpectQuery("FROM \\[msdb\\]").WillReturnRows(rows)
+
+	results, err := db.QueryFailedJobsShared(context.Background(), 24*time.Hour, servers)
+	if err != nil {
+		t.Fatalf("QueryFailedJobsShared() unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+
+	if got := results["ETL-VIEW"]; len(got) != 1 || got[0].JobName != "ETL_Daily" {
+		t.Errorf("ETL-VIEW results = %+v, want only ETL_Daily", got)
+	}
+	if got := results["BACKUP-VIEW"]; len(got) != 1 || got[0].JobName != "Backup_Full" {
+		t.Errorf("BACKUP-VIEW results = %+v, want only Backup_Full", got)
+	}
+}
+
+func TestQueryFailedJobs_StepOutcomes(t *testing.T) {
+	tests := []struct {
+		name         string
+		includeSteps bool
+		wantQuery    string
+		wantJobs     int
+	}{
+		{
+			name:      "job outcomes only by default",
+			wantQuery: `WHERE h\.step_id = 0`,
+			wantJobs:  1,
+		},
+		{
+			name:         "step outcomes when opted in",
+			includeSteps: true,
+			wantQuery:    `WHERE h\.step_id = 0(.|\n)*UNION ALL(.|\n)*WHERE h\.step_id > 0`,
+			wantJobs:     2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t, config.ServerConfig{
+				Name: "SQL01",
+				Jobs: config.JobsFilter{IncludeSteps: tt.includeSteps},
+			})
+
+			// The job succeeded after a retry, but its first step failed
+			rows := sqlmock.NewRows(failedJobColumns).
+				AddRow("SQL01", "msdb", "Backup", 20260203, 83015, 0, "failed", 10, 0, "", nil, nil).
+				AddRow("SQL01", "msdb", "ETL", 20260203, 70000, 0, "deadlock", 5, 1, "Load staging", nil, nil)
+			expectServerOffset(mock, 0)
+			mock.ExpectQuery(tt.wantQuery).WillReturnRows(rows)
+
+			jobs, err := db.QueryFailedJobs(context.Background(), 24*time.Hour)
+			if err != nil {
+				t.Fatalf("QueryFailedJobs() unexpected error: %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+			if len(jobs) != tt.wantJobs {
+				t.Fatalf("QueryFailedJobs() returned %d jobs, want %d", len(jobs), tt.wantJobs)
+			}
+			if jobs[0].StepID != 0 || jobs[0].StepName != "" {
+				t.Errorf("jobs[0] step = %d %q, want job outcome", jobs[0].StepID, jobs[0].StepName)
+			}
+			if tt.includeSteps && (jobs[1].StepID != 1 || jobs[1].StepName != "Load staging") {
+				t.Errorf("jobs[1] step = %d %q, want 1 %q", jobs[1].StepID, jobs[1].StepName, "Load staging")
+			}
+		})
+	}
+}
+
+func TestQueryFailedJobs_OwnerAndCategory(t *testing.T) {
+	db, mock := newMockDB(t, config.ServerConfig{Name: "SQL01"})
+
+	rows := sqlmock.NewRows(failedJobColumns).
+		AddRow("SQL01", "msdb", "Backup", 20260203, 83015, 0, "failed", 10, 0, "", "DBA Team", "Database Maintenance").
+		AddRow("SQL01", "msdb", "ETL", 20260203, 70000, 0, "failed", 5, 0, "", nil, nil)
+	expectServerOffset(mock, 0)
+	mock.ExpectQuery(`LEFT JOIN \[msdb\]\.dbo\.sysoperators o[\s\S]*LEFT JOIN \[msdb\]\.dbo\.syscategories c`).WillReturnRows(rows)
+
+	jobs, err := db.QueryFailedJobs(context.Background(), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("QueryFailedJobs() unexpected error: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("QueryFailedJobs() returned %d jobs, want 2", len(jobs))
+	}
+	if jobs[0].Owner != "DBA Team" || jobs[0].Category != "Database Maintenance" {
+		t.Errorf("jobs[0] owner/category = %q/%q, want %q/%q", jobs[0].Owner, jobs[0].Category, "DBA Team", "Database Maintenance")
+	}
+	if jobs[1].Owner != "" || jobs[1].Category != "" {
+		t.Errorf("jobs[1] owner/category = %q/%q, want empty for NULLs", jobs[1].Owner, jobs[1].Category)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestQueryFailedJobs_MsdbDatabase(t *testing.T) {
+	db, mock := newMockDB(t, config.ServerConfig{Name: "SQL01", MsdbDatabase: "msdb_restored"})
+
+	rows := sqlmock.NewRows(failedJobColumns).
+		AddRow("SQL01", "msdb_restored", "Backup", 20260203, 83015, 0, "failed", 10, 0, "", nil, nil)
+	expectServerOffset(mock, 0)
+	mock.ExpectQuery(`FROM \[msdb_restored\]\.dbo\.sysjobs`).WillReturnRows(rows)
+
+	jobs, err := db.QueryFailedJobs(context.Background(), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("QueryFailedJobs() unexpected error: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].JobName != "Backup" {
+		t.Errorf("QueryFailedJobs() = %+v, want only Backup", jobs)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestQueryRecentOutcomes(t *testing.T) {
+	db, mock := newMockDB(t, config.ServerConfig{Name: "SQL01", MsdbDatabase: "msdb_restored"})
+
+	rows := sqlmock.NewRows([]string{"JobName", "Status"}).
+		AddRow("Backup", 0).
+		AddRow("Backup", 0).
+		AddRow("Backup", 1).
+		AddRow("ETL", 1)
+	mock.ExpectQuery(`ROW_NUMBER\(\)[\s\S]*FROM \[msdb_restored\]\.dbo\.sysjobs[\s\S]*RowNum <= @Count`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(rows)
+
+	outcomes, err := db.QueryRecentOutcomes(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("QueryRecentOutcomes() unexpected error: %v", err)
+	}
+	if got := outcomes["Backup"]; len(got) != 3 || got[0] != 0 || got[2] != 1 {
+		t.Errorf("Backup outcomes = %v, want [0 0 1]", got)
+	}
+	if got := outcomes["ETL"]; len(got) != 1 || got[0] != 1 {
+		t.Errorf("ETL outcomes = %v, want [1]", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetServerTimeOffset(t *testing.T) {
+	db, mock := newMockDB(t, config.ServerConfig{Name: "SQL01"})
+	expectServerOffset(mock, -5*time.Hour)
+
+	got, err := db.GetServerTimeOffset(context.Background())
+	if err != nil {
+		t.Fatalf("GetServerTimeOffset() unexpected error: %v", err)
+	}
+	if got != -5*time.Hour {
+		t.Errorf("GetServerTimeOffset() = %v, want %v", got, -5*time.Hour)
+	}
+}
+
+func TestQueryFailedJobs_ServerTimeOffset(t *testing.T) {
+	db, mock := newMockDB(t, config.ServerConfig{Name: "SQL01"})
+
+	// The offset is looked up once per connection and reused
+	expectServerOffset(mock, 7*time.Hour)
+	for i := 0; i < 2; i++ {
+		rows := sqlmock.NewRows(failedJobColumns).
+			AddRow("SQL01", "msdb", "Backup", 20260203, 83015, 0, "failed", 10, 0, "", nil, nil)
+		mock.ExpectQuery(`FROM \[msdb\]\.dbo\.sysjobs`).WillReturnRows(rows)
+	}
+
+	want := time.Date(2026, 2, 3, 1, 30, 15, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		jobs, err := db.QueryFailedJobs(context.Background(), 24*time.Hour)
+		if err != nil {
+			t.Fatalf("QueryFailedJobs() unexpected error: %v", err)
+		}
+		if len(jobs) != 1 {
+			t.Fatalf("QueryFailedJobs() returned %d jobs, want 1", len(jobs))
+		}
+		if !jobs[0].FailedAt.Equal(want) {
+			t.Errorf("FailedAt = %v, want %v", jobs[0].FailedAt.UTC(), want)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestQueryFailedJobs_ServerTimeOffsetError(t *testing.T) {
+	db, mock := newMockDB(t, config.ServerConfig{Name: "SQL01"})
+
+	mock.ExpectQuery(`SELECT SYSDATETIMEOFFSET\(\)`).WillReturnError(errors.New("permission denied"))
+	rows := sqlmock.NewRows(failedJobColumns).
+		AddRow("SQL01", "msdb", "Backup", 20260203, 83015, 0, "failed", 10, 0, "", nil, nil)
+	mock.ExpectQuery(`FROM \[msdb\]\.dbo\.sysjobs`).WillReturnRows(rows)
+
+	jobs, err := db.QueryFailedJobs(context.Background(), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("QueryFailedJobs() unexpected error: %v", err)
+	}
+	want := time.Date(2026, 2, 3, 8, 30, 15, 0, time.Local)
+	if !jobs[0].FailedAt.Equal(want) {
+		t.Errorf("FailedAt = %v, want local time %v", jobs[0].FailedAt, want)
+	}
+}
+
+func TestTimeoutsBoundCalls(t *testing.T) {
+	server := config.ServerConfig{
+		Name:    "SQL01",
+		Options: config.DBOptions{ConnectionTimeout: 1, QueryTimeout: 1},
+	}
+
+	t.Run("ping uses connection timeout", func(t *testing.T) {
+		conn, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		if err != nil {
+			t.Fatalf("failed to create sqlmock: %v", err)
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+		mock.ExpectPing().WillDelayFor(10 * time.Second)
+
+		db := &DB{conn: conn, server: server}
+		start := time.Now()
+		if err := db.Ping(context.Background()); !errors.Is(err, sqlmock.ErrCancelled) {
+			t.Errorf("Ping() error = %v, want cancelled", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Ping() took %v, want about the 1s connection timeout", elapsed)
+		}
+	})
+
+	t.Run("query uses query timeout", func(t *testing.T) {
+		db, mock := newMockDB(t, server)
+		expectServerOffset(mock, 0)
+		mock.ExpectQuery(`sysjobhistory`).
+			WithArgs(sqlmock.AnyArg()).
+			WillDelayFor(10 * time.Second).
+			WillReturnRows(sqlmock.NewRows(failedJobColumns))
+
+		start := time.Now()
+		if _, err := db.QueryFailedJobs(context.Background(), 24*time.Hour); !errors.Is(err, sqlmock.ErrCancelled) {
+			t.Errorf("QueryFailedJobs() error = %v, want cancelled", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("QueryFailedJobs() took %v, want about the 1s query timeout", elapsed)
+		}
+	})
+}
+
+func TestQueryFailedJobs_OnlyJobs(t *testing.T) {
+	db, mock := newMockDB(t, config.ServerConfig{
+		Name: "SQL01",
+		Jobs: config.JobsFilter{Only: []string{"Backup_Full", "ETL_Daily"}},
+	})
+	expectServerOffset(mock, 0)
+
+	rows := sqlmock.NewRows(failedJobColumns).
+		AddRow("SQL01", "msdb", "backup_full", 20260203, 83000, 0, "Disk full", 0, 0, "", nil, nil)
+	mock.ExpectQuery(`h\.run_status IN \(0\)\s+AND j\.name IN \(@Job0, @Job1\)`).
+		WithArgs(
+			sql.Named("LookbackSeconds", int64(86400)),
+			sql.Named("Job0", "Backup_Full"),
+			sql.Named("Job1", "ETL_Daily"),
+		).
+		WillReturnRows(rows)
+
+	jobs, err := db.QueryFailedJobs(context.Background(), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("QueryFailedJobs() unexpected error: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].JobName != "backup_full" {
+		t.Errorf("QueryFailedJobs() = %+v, want backup_full (names compare like a case-insensitive collation)", jobs)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestQueryFailedJobsShared_OnlyJobs(t *testing.T) {
+	tests := []struct {
+		name     string
+		servers  []config.ServerConfig
+		wantArgs int
+	}{
+		{
+			name: "every config lists jobs",
+			servers: []config.ServerConfig{
+				{Name: "A", Jobs: config.JobsFilter{Only: []string{"Backup"}}},
+				{Name: "B", Jobs: config.JobsFilter{Only: []string{"ETL"}}},
+			},
+			wantArgs: 2,
+		},
+		{
+			name: "one config monitors all jobs",
+			servers: []config.ServerConfig{
+				{Name: "A", Jobs: config.JobsFilter{Only: []string{"Backup"}}},
+				{Name: "B"},
+			},
+			wantArgs: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t, config.ServerConfig{Name: "A"})
+			expectServerOffset(mock, 0)
+
+			args := []driver.Value{sqlmock.AnyArg()}
+			for range tt.wantArgs {
+				args = append(args, sqlmock.AnyArg())
+			}
+			query := mock.ExpectQuery(`sysjobhistory`).WithArgs(args...)
+			query.WillReturnRows(sqlmock.NewRows(failedJobColumns))
+
+			if _, err := db.QueryFailedJobsShared(context.Background(), 24*time.Hour, tt.servers); err != nil {
+				t.Fatalf("QueryFailedJobsShared() unexpected error: %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestFailedJobsQuery_OnlyJobs(t *testing.T) {
+	names := make([]string, 11)
+	for i := range names {
+		names[i] = fmt.Sprintf("Job_%d", i)
+	}
+	names[10] = "O'Brien"
+	query := FailedJobsQuery(config.ServerConfig{Jobs: config.JobsFilter{Only: names}}, 24, nil)
+
+	if strings.Contains(query, "@Job") {
+		t.Errorf("query still has job parameters:\n%s", query)
+	}
+	if !strings.Contains(query, "N'Job_1', N'Job_2'") || !strings.Contains(query, "N'O''Brien')") {
+		t.Errorf("query does not list the job names:\n%s", query)
+	}
+}
+
+func TestClockSkew(t *testing.T) {
+	sent := time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC)
+	received := sent.Add(200 * time.Millisecond)
+
+	tests := []struct {
+		name      string
+		serverNow time.Time
+		want      time.Duration
+	}{
+		{name: "in sync", serverNow: sent.Add(100 * time.Millisecond), want: 0},
+		{name: "server ahead", serverNow: sent.Add(2*time.Minute + 100*time.Millisecond), want: 2 * time.Minute},
+		{name: "server behind", serverNow: sent.Add(-30 * time.Second), want: -30*time.Second - 100*time.Millisecond},
+		{
+			name:      "other time zone is not skew",
+			serverNow: sent.Add(100 * time.Millisecond).In(time.FixedZone("", 7*3600)),
+			want:      0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clockSkew(tt.serverNow, sent, received); got != tt.want {
+				t.Errorf("clockSkew() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetClockSkew(t *testing.T) {
+	db, mock := newMockDB(t, config.ServerConfig{Name: "SQL01"})
+
+	serverNow := time.Now().Add(10 * time.Minute).In(time.FixedZone("", 7*3600))
+	mock.ExpectQuery(`SELECT SYSDATETIMEOFFSET\(\)`).WillReturnRows(sqlmock.NewRows([]string{""}).AddRow(serverNow))
+
+	skew, err := db.GetClockSkew(context.Background())
+	if err != nil {
+		t.Fatalf("GetClockSkew() unexpected error: %v", err)
+	}
+	if skew < 9*time.Minute || skew > 11*time.Minute {
+		t.Errorf("GetClockSkew() = %v, want about 10m", skew)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetClockSkew_Error(t *testing.T) {
+	db, mock := newMockDB(t, config.ServerConfig{Name: "SQL01"})
+
+	mock.ExpectQuery(`SELECT SYSDATETIMEOFFSET\(\)`).WillReturnError(errors.New("permission denied"))
+
+	if _, err := db.GetClockSkew(context.Background()); err == nil {
+		t.Fatal("GetClockSkew() expected error, got nil")
+	}
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"syscall"
+)
+
+// maxIdleConns is the number of idle pooled connections kept per server.
+const maxIdleConns = 2
+
+// staleConnMessages are driver error texts for pooled connections that the
+// server closed, e.g. because it restarted. go-mssqldb does not always wrap
+// these as driver.ErrBadConn, so database/sql does not retry them itself.
+var staleConnMessages = []string{
+	"connection reset",
+	"broken pipe",
+	"forcibly closed",
+	"use of closed network connection",
+}
+
+// isStaleConnError returns true if err means the connection was closed under us
+// and a fresh connection is likely to succeed.
+func isStaleConnError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, s := range staleConnMessages {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryStale runs fn and, if it fails on a stale connection, closes the idle
+// pool and runs fn once more so it dials a fresh connection.
+func (db *DB) retryStale(ctx context.Context, fn func() error) error {
+	err := fn()
+	if !isStaleConnError(err) || ctx.Err() != nil {
+		return err
+	}
+
+	if db.dropIdle != nil {
+		db.dropIdle()
+	}
+	return fn()
+}
@@ -0,0 +1,107 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+// Category is the likely cause of a connectivity failure.
+type Category string
+
+// Failure categories.
+const (
+	CategoryDNS        Category = "dns"
+	CategoryTCP        Category = "tcp"
+	CategoryAuth       Category = "auth"
+	CategoryPermission Category = "permission"
+	CategoryTimeout    Category = "timeout"
+	CategoryUnknown    Category = "unknown"
+)
+
+// SQL Server error numbers that identify a category.
+var sqlErrorCategories = map[int32]Category{
+	18456: CategoryAuth,       // Login failed for user
+	18452: CategoryAuth,       // Login is from an untrusted domain (Windows auth)
+	18486: CategoryAuth,       // Account is locked out
+	18487: CategoryAuth,       // Password expired
+	18488: CategoryAuth,       // Password must be changed
+	229:   CategoryPermission, // Permission denied on object
+	916:   CategoryPermission, // Not able to access the database under the current security context
+	4060:  CategoryPermission, // Cannot open database requested by the login
+}
+
+// messageCategories maps error message fragments to categories, for errors
+// that are only available as text, such as diagnostics step errors. They are
+// matched in order, case-insensitively.
+var messageCategories = []struct {
+	category  Category
+	fragments []string
+}{
+	{CategoryDNS, []string{"no such host", "dns lookup", "server misbehaving"}},
+	{CategoryTimeout, []string{"i/o timeout", "deadline exceeded", "timed out", "timeout"}},
+	{CategoryTCP, []string{"connection refused", "actively refused", "no route to host", "network is unreachable", "tcp connect failed", "connection reset"}},
+	{CategoryAuth, []string{"login failed", "login error", "password expired", "untrusted domain"}},
+	{CategoryPermission, []string{"permission", "insufficient permissions", "not able to access the database", "cannot open database"}},
+}
+
+// Classify returns the likely cause of err, or CategoryUnknown.
+// Typed errors from the network stack, the context and the SQL Server
+// driver are inspected first; otherwise the message is matched.
+func Classify(err error) Category {
+	if err == nil {
+		return ""
+	}
+	if c := classifyTyped(err); c != "" {
+		return c
+	}
+	return ClassifyMessage(err.Error())
+}
+
+// ClassifyMessage returns the likely cause of an error message, or
+// CategoryUnknown.
+func ClassifyMessage(msg string) Category {
+	msg = strings.ToLower(msg)
+	for _, mc := range messageCategories {
+		for _, fragment := range mc.fragments {
+			if strings.Contains(msg, fragment) {
+				return mc.category
+			}
+		}
+	}
+	return CategoryUnknown
+}
+
+// classifyTyped classifies err by its type, or returns "" if no type matches.
+func classifyTyped(err error) Category {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return CategoryDNS
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return CategoryTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return CategoryTimeout
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return CategoryTCP
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return CategoryTCP
+	}
+	if errors.Is(err, database.ErrPermissionDenied) {
+		return CategoryPermission
+	}
+	var sqlErr interface{ SQLErrorNumber() int32 }
+	if errors.As(err, &sqlErr) {
+		return sqlErrorCategories[sqlErr.SQLErrorNumber()]
+	}
+	return ""
+}
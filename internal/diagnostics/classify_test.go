@@ -0,0 +1,57 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+// timeoutError is a net.Error that timed out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "operation failed" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Category
+	}{
+		{"nil", nil, ""},
+		{"dns error", &net.DNSError{Err: "no such host", Name: "sql01", IsNotFound: true}, CategoryDNS},
+		{"wrapped dns error", fmt.Errorf("dns lookup failed: %w", &net.DNSError{Err: "server misbehaving", Name: "sql01"}), CategoryDNS},
+		{"context deadline", fmt.Errorf("ping: %w", context.DeadlineExceeded), CategoryTimeout},
+		{"net timeout", &net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}}, CategoryTimeout},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, CategoryTCP},
+		{"dial failure", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("host is down")}, CategoryTCP},
+		{"login failed", mssql.Error{Number: 18456, Message: "Login failed for user 'watchman'."}, CategoryAuth},
+		{"wrapped login failed", fmt.Errorf("failed to ping: %w", mssql.Error{Number: 18456}), CategoryAuth},
+		{"object permission", mssql.Error{Number: 229, Message: "The SELECT permission was denied"}, CategoryPermission},
+		{"database access", mssql.Error{Number: 916}, CategoryPermission},
+		{"msdb permissions", fmt.Errorf("check: %w", database.ErrPermissionDenied), CategoryPermission},
+		{"other sql error", mssql.Error{Number: 208, Message: "Invalid object name"}, CategoryUnknown},
+		{"dns message", errors.New("lookup sql01: no such host"), CategoryDNS},
+		{"timeout message", errors.New("dial tcp 10.0.0.5:1433: i/o timeout"), CategoryTimeout},
+		{"refused message", errors.New("No connection could be made because the target machine actively refused it."), CategoryTCP},
+		{"login message", errors.New("mssql: login error: Login failed for user 'sa'."), CategoryAuth},
+		{"permission message", errors.New("The SELECT permission was denied on the object 'sysjobs'"), CategoryPermission},
+		{"unrecognised", errors.New("bad connection string"), CategoryUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Classify(tt.err))
+		})
+	}
+}
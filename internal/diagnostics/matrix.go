@@ -0,0 +1,262 @@
+// Package diagnostics provides connectivity diagnostics for Watchman.
+// It breaks a server check into individual steps so failures can be
+// attributed to DNS, firewall, authentication or permissions.
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+// Step identifies a single diagnostic step.
+type Step string
+
+// Diagnostic steps in execution order.
+const (
+	StepDNS  Step = "dns"
+	StepTCP  Step = "tcp"
+	StepPing Step = "ping"
+	StepMsdb Step = "msdb"
+)
+
+// defaultDialTimeout is used when a server has no connection timeout configured.
+const defaultDialTimeout = 5 * time.Second
+
+// Steps lists all diagnostic steps in the order they are executed.
+var Steps = []Step{StepDNS, StepTCP, StepPing, StepMsdb}
+
+// StepResult represents the outcome of a single diagnostic step.
+type StepResult struct {
+	Step       Step   `json:"step"`
+	OK         bool   `json:"ok"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+
+	// Category is the likely cause of a failure, empty if the step passed.
+	Category Category `json:"category,omitempty"`
+}
+
+// Duration returns how long the step took.
+func (s StepResult) Duration() time.Duration {
+	return time.Duration(s.DurationMs) * time.Millisecond
+}
+
+// stepCategories is the category of a failed step whose error does not
+// identify a cause; a failed msdb step can only be a permission problem.
+var stepCategories = map[Step]Category{
+	StepDNS:  CategoryDNS,
+	StepTCP:  CategoryTCP,
+	StepMsdb: CategoryPermission,
+}
+
+// ServerRow represents the diagnostic results for a single server.
+type ServerRow struct {
+	Server string       `json:"server"`
+	Host   string       `json:"host"`
+	Port   int          `json:"port"`
+	Steps  []StepResult `json:"steps"`
+
+	// ClockSkewMs is how far the server clock is ahead of this host's, in
+	// milliseconds. It is nil if the server could not be reached or its
+	// time could not be read.
+	ClockSkewMs *int64 `json:"clock_skew_ms,omitempty"`
+}
+
+// ClockSkew returns the measured clock skew and whether it is known.
+func (r ServerRow) ClockSkew() (time.Duration, bool) {
+	if r.ClockSkewMs == nil {
+		return 0, false
+	}
+	return time.Duration(*r.ClockSkewMs) * time.Millisecond, true
+}
+
+// stepOK returns true if step ran and succeeded.
+func (r ServerRow) stepOK(step Step) bool {
+	for _, s := range r.Steps {
+		if s.Step == step {
+			return s.OK
+		}
+	}
+	return false
+}
+
+// Category returns the category of the failed step, or "" if every step
+// succeeded.
+func (r ServerRow) Category() Category {
+	for _, s := range r.Steps {
+		if !s.OK && !s.Skipped {
+			return s.Category
+		}
+	}
+	return ""
+}
+
+// OK returns true if every step succeeded.
+func (r ServerRow) OK() bool {
+	for _, s := range r.Steps {
+		if !s.OK {
+			return false
+		}
+	}
+	return true
+}
+
+// Matrix represents the diagnostic results for all servers.
+type Matrix struct {
+	Steps []Step      `json:"steps"`
+	Rows  []ServerRow `json:"servers"`
+}
+
+// Checker defines the database operations needed by the diagnostics.
+type Checker interface {
+	Ping(ctx context.Context) error
+	ValidatePermissions(ctx context.Context) error
+	GetClockSkew(ctx context.Context) (time.Duration, error)
+	Close() error
+}
+
+// CheckerFactory is a function that creates a Checker.
+type CheckerFactory func(config.ServerConfig) (Checker, error)
+
+// Runner runs connectivity diagnostics against servers.
+type Runner struct {
+	resolve   func(ctx context.Context, host string) error
+	dial      func(ctx context.Context, address string, timeout time.Duration) error
+	dbFactory CheckerFactory
+}
+
+// NewRunner creates a new diagnostics runner.
+func NewRunner() *Runner {
+	return &Runner{
+		resolve: resolveHost,
+		dial:    dialTCP,
+		dbFactory: func(cfg config.ServerConfig) (Checker, error) {
+			return database.New(cfg)
+		},
+	}
+}
+
+// BuildMatrix runs all diagnostic steps for each server.
+func (r *Runner) BuildMatrix(ctx context.Context, servers []config.ServerConfig) *Matrix {
+	m := &Matrix{
+		Steps: Steps,
+		Rows:  make([]ServerRow, 0, len(servers)),
+	}
+
+	for _, srv := range servers {
+		m.Rows = append(m.Rows, r.checkServer(ctx, srv))
+	}
+
+	return m
+}
+
+// checkServer runs the diagnostic steps for a single server.
+// Once a step fails, the remaining steps are marked as skipped.
+func (r *Runner) checkServer(ctx context.Context, server config.ServerConfig) ServerRow {
+	host, port := database.Address(server)
+	row := ServerRow{
+		Server: server.Name,
+		Host:   host,
+		Port:   port,
+	}
+
+	timeout := time.Duration(server.Options.ConnectionTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultDialTimeout
+	}
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+
+	var db Checker
+	defer func() {
+		if db != nil {
+			_ = db.Close()
+		}
+	}()
+
+	steps := []struct {
+		step Step
+		run  func() error
+	}{
+		{StepDNS, func() error { return r.resolve(ctx, host) }},
+		{StepTCP, func() error { return r.dial(ctx, address, timeout) }},
+		{StepPing, func() error {
+			var err error
+			db, err = r.dbFactory(server)
+			if err != nil {
+				return err
+			}
+			return db.Ping(ctx)
+		}},
+		{StepMsdb, func() error { return db.ValidatePermissions(ctx) }},
+	}
+
+	failed := false
+	for _, s := range steps {
+		if failed {
+			row.Steps = append(row.Steps, StepResult{Step: s.step, Skipped: true})
+			continue
+		}
+		result := runStep(s.step, s.run)
+		failed = !result.OK
+		row.Steps = append(row.Steps, result)
+	}
+
+	// Skew is informational, so it is not a step and never fails the row
+	if row.stepOK(StepPing) {
+		if skew, err := db.GetClockSkew(ctx); err == nil {
+			ms := skew.Milliseconds()
+			row.ClockSkewMs = &ms
+		}
+	}
+
+	return row
+}
+
+// runStep runs and times a single diagnostic step.
+func runStep(step Step, run func() error) StepResult {
+	start := time.Now()
+	err := run()
+	result := StepResult{
+		Step:       step,
+		OK:         err == nil,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.Category = Classify(err)
+		if c, ok := stepCategories[step]; ok && result.Category == CategoryUnknown {
+			result.Category = c
+		}
+	}
+	return result
+}
+
+// resolveHost checks that a host name resolves to at least one address.
+func resolveHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return fmt.Errorf("dns lookup failed: %w", err)
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("dns lookup returned no addresses for %s", host)
+	}
+	return nil
+}
+
+// dialTCP checks that a TCP connection can be established.
+func dialTCP(ctx context.Context, address string, timeout time.Duration) error {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("tcp connect failed: %w", err)
+	}
+	_ = conn.Close()
+	return nil
+}
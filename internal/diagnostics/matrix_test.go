@@ -0,0 +1,208 @@
+package diagnostics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+// fakeChecker is a Checker returning preset errors.
+type fakeChecker struct {
+	pingErr  error
+	permsErr error
+	skew     time.Duration
+	skewErr  error
+	closed   bool
+}
+
+func (f *fakeChecker) Ping(ctx context.Context) error                { return f.pingErr }
+func (f *fakeChecker) ValidatePermissions(ctx context.Context) error { return f.permsErr }
+func (f *fakeChecker) Close() error                                  { f.closed = true; return nil }
+func (f *fakeChecker) GetClockSkew(ctx context.Context) (time.Duration, error) {
+	return f.skew, f.skewErr
+}
+
+func TestBuildMatrix(t *testing.T) {
+	tests := []struct {
+		name       string
+		dnsErr     error
+		tcpErr     error
+		factoryErr error
+		pingErr    error
+		permsErr   error
+		wantOK     []bool
+		wantSkip   []bool
+		wantCat    Category
+	}{
+		{
+			name:     "all steps pass",
+			wantOK:   []bool{true, true, true, true},
+			wantSkip: []bool{false, false, false, false},
+		},
+		{
+			name:     "dns failure skips remaining steps",
+			dnsErr:   errors.New("no such host"),
+			wantOK:   []bool{false, false, false, false},
+			wantSkip: []bool{false, true, true, true},
+			wantCat:  CategoryDNS,
+		},
+		{
+			name:     "tcp failure",
+			tcpErr:   errors.New("connection refused"),
+			wantOK:   []bool{true, false, false, false},
+			wantSkip: []bool{false, false, true, true},
+			wantCat:  CategoryTCP,
+		},
+		{
+			name:       "connection open failure",
+			factoryErr: errors.New("bad connection string"),
+			wantOK:     []bool{true, true, false, false},
+			wantSkip:   []bool{false, false, false, true},
+			wantCat:    CategoryUnknown,
+		},
+		{
+			name:     "login failure",
+			pingErr:  errors.New("login failed for user"),
+			wantOK:   []bool{true, true, false, false},
+			wantSkip: []bool{false, false, false, true},
+			wantCat:  CategoryAuth,
+		},
+		{
+			name:     "permission failure",
+			permsErr: errors.New("permission denied"),
+			wantOK:   []bool{true, true, true, false},
+			wantSkip: []bool{false, false, false, false},
+			wantCat:  CategoryPermission,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := &fakeChecker{pingErr: tt.pingErr, permsErr: tt.permsErr}
+			r := NewRunner()
+			r.resolve = func(ctx context.Context, host string) error { return tt.dnsErr }
+			r.dial = func(ctx context.Context, address string, timeout time.Duration) error { return tt.tcpErr }
+			r.dbFactory = func(config.ServerConfig) (Checker, error) {
+				if tt.factoryErr != nil {
+					return nil, tt.factoryErr
+				}
+				return checker, nil
+			}
+
+			servers := []config.ServerConfig{{Name: "SQL01", Host: "sql01", Port: 1433}}
+			m := r.BuildMatrix(context.Background(), servers)
+
+			assert.Equal(t, Steps, m.Steps)
+			assert.Len(t, m.Rows, 1)
+
+			row := m.Rows[0]
+			assert.Equal(t, "SQL01", row.Server)
+			assert.Len(t, row.Steps, len(Steps))
+			for i, s := range row.Steps {
+				assert.Equal(t, Steps[i], s.Step)
+				assert.Equal(t, tt.wantOK[i], s.OK, "step %s ok", s.Step)
+				assert.Equal(t, tt.wantSkip[i], s.Skipped, "step %s skipped", s.Step)
+				if !s.OK && !s.Skipped {
+					assert.NotEmpty(t, s.Error)
+				}
+			}
+			assert.Equal(t, tt.wantOK[len(tt.wantOK)-1], row.OK())
+			assert.Equal(t, tt.wantCat, row.Category())
+
+			if tt.factoryErr == nil && tt.dnsErr == nil && tt.tcpErr == nil {
+				assert.True(t, checker.closed, "connection should be closed")
+			}
+		})
+	}
+}
+
+func TestStepResult_DurationMs(t *testing.T) {
+	step := StepResult{Step: StepPing, OK: true, DurationMs: 1500}
+	assert.Equal(t, 1500*time.Millisecond, step.Duration())
+
+	data, err := json.Marshal(step)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"step":"ping","ok":true,"duration_ms":1500}`, string(data))
+}
+
+func TestBuildMatrix_MultipleServers(t *testing.T) {
+	r := NewRunner()
+	r.resolve = func(ctx context.Context, host string) error {
+		if host == "bad-host" {
+			return errors.New("no such host")
+		}
+		return nil
+	}
+	r.dial = func(ctx context.Context, address string, timeout time.Duration) error { return nil }
+	r.dbFactory = func(config.ServerConfig) (Checker, error) { return &fakeChecker{}, nil }
+
+	servers := []config.ServerConfig{
+		{Name: "GOOD", Host: "good-host", Port: 1433},
+		{Name: "BAD", Host: "bad-host", Port: 1433},
+	}
+	m := r.BuildMatrix(context.Background(), servers)
+
+	assert.Len(t, m.Rows, 2)
+	assert.Equal(t, "GOOD", m.Rows[0].Server)
+	assert.True(t, m.Rows[0].OK())
+	assert.Equal(t, "BAD", m.Rows[1].Server)
+	assert.False(t, m.Rows[1].OK())
+}
+
+func TestBuildMatrix_ClockSkew(t *testing.T) {
+	tests := []struct {
+		name     string
+		checker  *fakeChecker
+		wantSkew *time.Duration
+	}{
+		{
+			name:     "measured after login",
+			checker:  &fakeChecker{skew: 90 * time.Second},
+			wantSkew: ptr(90 * time.Second),
+		},
+		{
+			name:     "measured despite missing permissions",
+			checker:  &fakeChecker{permsErr: errors.New("permission denied"), skew: -2 * time.Second},
+			wantSkew: ptr(-2 * time.Second),
+		},
+		{
+			name:    "not measured when login fails",
+			checker: &fakeChecker{pingErr: errors.New("login failed"), skew: time.Hour},
+		},
+		{
+			name:    "unreadable time is unknown",
+			checker: &fakeChecker{skewErr: errors.New("timeout")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRunner()
+			r.resolve = func(ctx context.Context, host string) error { return nil }
+			r.dial = func(ctx context.Context, address string, timeout time.Duration) error { return nil }
+			r.dbFactory = func(config.ServerConfig) (Checker, error) { return tt.checker, nil }
+
+			row := r.BuildMatrix(context.Background(), []config.ServerConfig{{Name: "SQL01", Host: "sql01", Port: 1433}}).Rows[0]
+
+			skew, ok := row.ClockSkew()
+			if tt.wantSkew == nil {
+				assert.False(t, ok)
+				assert.Nil(t, row.ClockSkewMs)
+				return
+			}
+			assert.True(t, ok)
+			assert.Equal(t, *tt.wantSkew, skew)
+		})
+	}
+}
+
+// ptr returns a pointer to d.
+func ptr(d time.Duration) *time.Duration {
+	return &d
+}
@@ -0,0 +1,182 @@
+// Package events keeps a bounded JSONL trace of recent checks.
+// Each line records a check's inputs and outputs so intermittent issues
+// such as a missed failure can be replayed from a bug report.
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/jobs"
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+// DefaultMaxEntries is the number of events kept when no limit is configured.
+const DefaultMaxEntries = 100
+
+// Event is a single check recorded in the event log.
+type Event struct {
+	Time               time.Time         `json:"time"`
+	LookbackHours      int               `json:"lookback_hours"`
+	Servers            []string          `json:"servers"`
+	Status             string            `json:"status,omitempty"`
+	ServersChecked     int               `json:"servers_checked"`
+	ServersAvailable   int               `json:"servers_available"`
+	ServersUnavailable []string          `json:"servers_unavailable,omitempty"`
+	FailedJobs         int               `json:"failed_jobs"`
+	DurationMs         int64             `json:"duration_ms"`
+	QueryLatencyMs     map[string]int64  `json:"query_latency_ms,omitempty"`
+	ServerErrors       map[string]string `json:"server_errors,omitempty"`
+	Error              string            `json:"error,omitempty"`
+}
+
+// NewEvent builds an event from a check's inputs and its result.
+// Either result or err may be nil.
+func NewEvent(at time.Time, lookbackHours int, servers []string, result *jobs.CheckResult, err error) Event {
+	ev := Event{
+		Time:          at,
+		LookbackHours: lookbackHours,
+		Servers:       servers,
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	if result == nil {
+		return ev
+	}
+
+	ev.Status = result.Status
+	ev.ServersChecked = result.ServersChecked
+	ev.ServersAvailable = result.ServersAvailable
+	ev.ServersUnavailable = result.ServersUnavailable
+	ev.FailedJobs = len(result.FailedJobs)
+	ev.DurationMs = result.Duration.Milliseconds()
+	ev.ServerErrors = result.ServerErrors
+	if len(result.ServerQueryLatencyMs) > 0 {
+		ev.QueryLatencyMs = maps.Clone(result.ServerQueryLatencyMs)
+	}
+	return ev
+}
+
+// Log is a ring buffer of events stored as a JSONL file.
+type Log struct {
+	path       string
+	maxEntries int
+	mu         sync.Mutex
+}
+
+// NewLog creates an event log at path that keeps the last maxEntries events.
+// A non-positive maxEntries uses DefaultMaxEntries.
+func NewLog(path string, maxEntries int) *Log {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &Log{path: path, maxEntries: maxEntries}
+}
+
+// DefaultPath returns the default event log path.
+func DefaultPath() string {
+	return filepath.Join(state.DefaultDir(), "events.jsonl")
+}
+
+// Path returns the event log path.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append adds an event and drops the oldest events beyond the limit.
+func (l *Log) Append(ev Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lines, err := l.readLines()
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	lines = append(lines, line)
+	if len(lines) > l.maxEntries {
+		lines = lines[len(lines)-l.maxEntries:]
+	}
+
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o750); err != nil {
+		return fmt.Errorf("failed to create event log directory: %w", err)
+	}
+	if err := os.WriteFile(l.path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write event log: %w", err)
+	}
+	return nil
+}
+
+// Recent returns up to n of the most recent events, oldest first.
+// A non-positive n returns all events. Lines that fail to parse are skipped.
+func (l *Log) Recent(n int) ([]Event, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lines, err := l.readLines()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(lines))
+	for _, line := range lines {
+		var ev Event
+		if err := json.Unmarshal(line, &ev); err != nil {
+			continue
+		}
+		events = append(events, ev)
+	}
+
+	if n > 0 && len(events) > n {
+		events = events[len(events)-n:]
+	}
+	return events, nil
+}
+
+// readLines reads the non-empty lines of the event log without locking.
+// A missing file yields no lines.
+func (l *Log) readLines() ([][]byte, error) {
+	f, err := os.Open(l.path) // #nosec G304 -- path is the configured event log
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		lines = append(lines, bytes.Clone(scanner.Bytes()))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read event log: %w", err)
+	}
+	return lines, nil
+}
@@ -0,0 +1,100 @@
+package events
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+func TestLog_AppendTrimsToMaxEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	l := NewLog(path, 3)
+	base := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, l.Append(Event{Time: base.Add(time.Duration(i) * time.Hour), LookbackHours: i}))
+	}
+
+	evs, err := l.Recent(0)
+	require.NoError(t, err)
+	require.Len(t, evs, 3)
+	assert.Equal(t, []int{2, 3, 4}, []int{evs[0].LookbackHours, evs[1].LookbackHours, evs[2].LookbackHours})
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, countLines(data))
+}
+
+func TestLog_Recent(t *testing.T) {
+	l := NewLog(filepath.Join(t.TempDir(), "events.jsonl"), 10)
+
+	evs, err := l.Recent(0)
+	require.NoError(t, err)
+	assert.Empty(t, evs)
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, l.Append(Event{LookbackHours: i}))
+	}
+
+	evs, err = l.Recent(2)
+	require.NoError(t, err)
+	require.Len(t, evs, 2)
+	assert.Equal(t, 2, evs[0].LookbackHours)
+	assert.Equal(t, 3, evs[1].LookbackHours)
+}
+
+func TestLog_SkipsCorruptLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{\"lookback_hours\":1}\nnot json\n\n"), 0o600))
+
+	evs, err := NewLog(path, 10).Recent(0)
+	require.NoError(t, err)
+	require.Len(t, evs, 1)
+	assert.Equal(t, 1, evs[0].LookbackHours)
+}
+
+func TestNewEvent(t *testing.T) {
+	at := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	result := &jobs.CheckResult{
+		Status:               "partial",
+		ServersChecked:       2,
+		ServersAvailable:     1,
+		ServersUnavailable:   []string{"SQL02"},
+		FailedJobs:           []database.FailedJob{{JobName: "Backup"}},
+		Duration:             1500 * time.Millisecond,
+		ServerQueryLatencyMs: map[string]int64{"SQL01": 250},
+	}
+
+	ev := NewEvent(at, 24, []string{"SQL01", "SQL02"}, result, nil)
+	assert.Equal(t, at, ev.Time)
+	assert.Equal(t, 24, ev.LookbackHours)
+	assert.Equal(t, []string{"SQL01", "SQL02"}, ev.Servers)
+	assert.Equal(t, "partial", ev.Status)
+	assert.Equal(t, 1, ev.FailedJobs)
+	assert.Equal(t, int64(1500), ev.DurationMs)
+	assert.Equal(t, map[string]int64{"SQL01": 250}, ev.QueryLatencyMs)
+	assert.Empty(t, ev.Error)
+
+	ev = NewEvent(at, 24, nil, nil, errors.New("server not found"))
+	assert.Equal(t, "server not found", ev.Error)
+	assert.Zero(t, ev.ServersChecked)
+}
+
+// countLines returns the number of newline-terminated lines in data.
+func countLines(data []byte) int {
+	n := 0
+	for _, b := range data {
+		if b == '\n' {
+			n++
+		}
+	}
+	return n
+}
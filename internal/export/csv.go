@@ -0,0 +1,64 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+// csvHeader lists the CSV columns in output order.
+var csvHeader = []string{
+	"server",
+	"job_name",
+	"step_id",
+	"step_name",
+	"failed_at",
+	"run_date",
+	"run_time",
+	"status",
+	"duration_seconds",
+	"error_message",
+	"owner",
+	"category",
+	"persistence",
+}
+
+// WriteCSV writes failed jobs as CSV with a header row.
+func WriteCSV(w io.Writer, failed []database.FailedJob) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+
+	for _, job := range failed {
+		record := []string{
+			job.ServerName,
+			job.JobName,
+			strconv.Itoa(job.StepID),
+			job.StepName,
+			job.FailedAt.Format(time.RFC3339),
+			strconv.Itoa(job.RunDate),
+			strconv.Itoa(job.RunTime),
+			strconv.Itoa(job.Status),
+			strconv.Itoa(job.Duration),
+			job.ErrorMessage,
+			job.Owner,
+			job.Category,
+			job.Persistence,
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("failed to write csv record: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to flush csv: %w", err)
+	}
+	return nil
+}
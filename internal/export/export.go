@@ -0,0 +1,80 @@
+// Package export writes check results to files for reporting.
+// Unlike check output, exports always include the full failure detail.
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+// Format identifies an export file format.
+type Format string
+
+// Supported export formats.
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+)
+
+// ParseFormat parses a format name (case-insensitive).
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatCSV, FormatJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s (expected csv or json)", s)
+	}
+}
+
+// Checker runs a check across all servers.
+type Checker interface {
+	CheckAll(ctx context.Context) (*jobs.CheckResult, error)
+}
+
+// ToFile runs a check and writes the result to the file at path.
+// The file is removed again if writing fails.
+func ToFile(ctx context.Context, checker Checker, format Format, path string) (*jobs.CheckResult, error) {
+	result, err := checker.CheckAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check servers: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600) // #nosec G304 -- path is chosen by the user
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export file: %w", err)
+	}
+
+	writeErr := Write(f, format, result)
+	closeErr := f.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		_ = os.Remove(path)
+		return nil, fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	return result, nil
+}
+
+// Write writes the check result to w in the given format.
+// CSV contains one row per failed job; JSON contains the full result.
+func Write(w io.Writer, format Format, result *jobs.CheckResult) error {
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, result.FailedJobs)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return fmt.Errorf("failed to encode json: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
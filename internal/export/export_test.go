@@ -0,0 +1,142 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+// MockChecker is a mock implementation of Checker.
+type MockChecker struct {
+	mock.Mock
+}
+
+func (m *MockChecker) CheckAll(ctx context.Context) (*jobs.CheckResult, error) {
+	args := m.Called(ctx)
+	if err := args.Error(1); err != nil {
+		return nil, fmt.Errorf("mock: %w", err)
+	}
+	result, _ := args.Get(0).(*jobs.CheckResult)
+	return result, nil
+}
+
+func sampleResult() *jobs.CheckResult {
+	return &jobs.CheckResult{
+		Status:         "failed_jobs",
+		ServersChecked: 1,
+		FailedJobs: []database.FailedJob{
+			{
+				ServerName:   "PROD-SQL01",
+				JobName:      "Backup_Database",
+				RunDate:      20260203,
+				RunTime:      73000,
+				FailedAt:     time.Date(2026, 2, 3, 7, 30, 0, 0, time.UTC),
+				Status:       0,
+				ErrorMessage: "Timeout expired, \"retry\" failed",
+				Duration:     125,
+				Owner:        "DBA Team",
+				Category:     "Database Maintenance",
+				Persistence:  database.PersistencePersistent,
+			},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Format
+		wantErr bool
+	}{
+		{input: "csv", want: FormatCSV},
+		{input: "JSON", want: FormatJSON},
+		{input: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseFormat(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestToFile_CSV(t *testing.T) {
+	checker := new(MockChecker)
+	checker.On("CheckAll", mock.Anything).Return(sampleResult(), nil)
+
+	path := filepath.Join(t.TempDir(), "report.csv")
+	_, err := ToFile(context.Background(), checker, FormatCSV, path)
+	require.NoError(t, err)
+	checker.AssertExpectations(t)
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	records, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, csvHeader, records[0])
+	assert.Equal(t, []string{
+		"PROD-SQL01",
+		"Backup_Database",
+		"0",
+		"",
+		"2026-02-03T07:30:00Z",
+		"20260203",
+		"73000",
+		"0",
+		"125",
+		"Timeout expired, \"retry\" failed",
+		"DBA Team",
+		"Database Maintenance",
+		"persistent",
+	}, records[1])
+}
+
+func TestToFile_JSON(t *testing.T) {
+	checker := new(MockChecker)
+	checker.On("CheckAll", mock.Anything).Return(sampleResult(), nil)
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	_, err := ToFile(context.Background(), checker, FormatJSON, path)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var got jobs.CheckResult
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, sampleResult().FailedJobs, got.FailedJobs)
+}
+
+func TestToFile_CheckError(t *testing.T) {
+	checker := new(MockChecker)
+	checker.On("CheckAll", mock.Anything).Return(nil, errors.New("no servers"))
+
+	path := filepath.Join(t.TempDir(), "report.csv")
+	_, err := ToFile(context.Background(), checker, FormatCSV, path)
+	assert.Error(t, err)
+
+	_, statErr := os.Stat(path)
+	assert.True(t, os.IsNotExist(statErr), "no file should be created when the check fails")
+}
@@ -0,0 +1,108 @@
+// Package hook runs the post-check hook: a command that receives each check
+// result as JSON on stdin, e.g. to restart a failed job or open a ticket.
+// Like result sinks, hooks are best effort: failures are reported to the
+// caller for logging and never affect checks or notifications.
+package hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+const (
+	// defaultTimeout is used when the hook has no timeout configured.
+	defaultTimeout = 30 * time.Second
+
+	// maxOutput bounds the hook output kept for the logs.
+	maxOutput = 64 * 1024
+
+	// waitDelay bounds how long the output is drained after the hook is
+	// killed, since processes it started may keep the pipes open.
+	waitDelay = 5 * time.Second
+)
+
+// PostCheck runs the configured post-check hook.
+type PostCheck struct {
+	cfg     config.PostCheckHook
+	timeout time.Duration
+}
+
+// NewPostCheck creates a post-check hook, or returns nil if no command is configured.
+func NewPostCheck(cfg config.PostCheckHook) *PostCheck {
+	if cfg.Command == "" {
+		return nil
+	}
+
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &PostCheck{cfg: cfg, timeout: timeout}
+}
+
+// Run runs the hook in the command's directory with result as JSON on stdin
+// and returns its combined stdout and stderr, truncated to maxOutput. The
+// command is started directly, never through a shell, and is killed when
+// the timeout expires. A non-zero exit status is returned as an error.
+func (h *PostCheck) Run(ctx context.Context, result *jobs.CheckResult) (string, error) {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	// The command comes from the configuration, which only administrators can change
+	cmd := exec.CommandContext(ctx, h.cfg.Command, h.cfg.Args...) //nolint:gosec
+	cmd.Dir = filepath.Dir(h.cfg.Command)
+	cmd.Stdin = bytes.NewReader(body)
+	out := &limitedBuffer{max: maxOutput}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.WaitDelay = waitDelay
+
+	err = cmd.Run()
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return out.String(), fmt.Errorf("post-check hook timed out after %s", h.timeout)
+	case err != nil:
+		return out.String(), fmt.Errorf("post-check hook failed: %w", err)
+	}
+	return out.String(), nil
+}
+
+// limitedBuffer keeps the first max bytes written to it and discards the
+// rest, without failing the writes so the hook is not cut short.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+// String returns the output kept, marked if some was discarded.
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "\n[output truncated]"
+	}
+	return b.buf.String()
+}
@@ -0,0 +1,121 @@
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+// helperEnv makes the test binary act as the hook, see TestHelperHook.
+const helperEnv = "WATCHMAN_HOOK_HELPER"
+
+// TestHelperHook is not a real test: it is the fake hook run by the other
+// tests. It saves stdin to the file named by WATCHMAN_HOOK_OUT, prints a
+// line and exits with WATCHMAN_HOOK_EXIT, or sleeps if asked to.
+func TestHelperHook(t *testing.T) {
+	if os.Getenv(helperEnv) != "1" {
+		return
+	}
+	if os.Getenv("WATCHMAN_HOOK_SLEEP") == "1" {
+		time.Sleep(time.Minute)
+	}
+	input, _ := io.ReadAll(os.Stdin)
+	_ = os.WriteFile(os.Getenv("WATCHMAN_HOOK_OUT"), input, 0o600)
+	fmt.Println("ticket opened")
+	fmt.Fprintln(os.Stderr, "hook stderr")
+	code, _ := strconv.Atoi(os.Getenv("WATCHMAN_HOOK_EXIT"))
+	os.Exit(code)
+}
+
+// newHelperHook returns a hook running this test binary as TestHelperHook,
+// which saves its input to the returned path.
+func newHelperHook(t *testing.T) (*PostCheck, string) {
+	t.Helper()
+	exe, err := os.Executable()
+	require.NoError(t, err)
+
+	out := filepath.Join(t.TempDir(), "input.json")
+	t.Setenv(helperEnv, "1")
+	t.Setenv("WATCHMAN_HOOK_OUT", out)
+	return NewPostCheck(config.PostCheckHook{Command: exe, Args: []string{"-test.run=^TestHelperHook$"}}), out
+}
+
+func TestNewPostCheck(t *testing.T) {
+	assert.Nil(t, NewPostCheck(config.PostCheckHook{}), "disabled without a command")
+
+	h := NewPostCheck(config.PostCheckHook{Command: "/opt/hook"})
+	assert.Equal(t, defaultTimeout, h.timeout)
+
+	h = NewPostCheck(config.PostCheckHook{Command: "/opt/hook", Timeout: 5})
+	assert.Equal(t, 5*time.Second, h.timeout)
+}
+
+func TestPostCheck_ReceivesResultJSON(t *testing.T) {
+	h, path := newHelperHook(t)
+	result := &jobs.CheckResult{
+		Status:     "failed_jobs",
+		Summary:    "1 failed job on 1 server",
+		FailedJobs: []database.FailedJob{{ServerName: "SQL01", JobName: "Backup"}},
+	}
+
+	output, err := h.Run(context.Background(), result)
+	require.NoError(t, err)
+	assert.Contains(t, output, "ticket opened")
+	assert.Contains(t, output, "hook stderr")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var got jobs.CheckResult
+	require.NoError(t, json.Unmarshal(data, &got), "input: %s", data)
+	assert.Equal(t, "failed_jobs", got.Status)
+	require.Len(t, got.FailedJobs, 1)
+	assert.Equal(t, "Backup", got.FailedJobs[0].JobName)
+}
+
+func TestPostCheck_Failure(t *testing.T) {
+	h, _ := newHelperHook(t)
+	t.Setenv("WATCHMAN_HOOK_EXIT", "3")
+
+	output, err := h.Run(context.Background(), &jobs.CheckResult{Status: "success"})
+	assert.ErrorContains(t, err, "post-check hook failed")
+	assert.Contains(t, output, "ticket opened", "output is kept for the logs")
+}
+
+func TestPostCheck_Timeout(t *testing.T) {
+	h, _ := newHelperHook(t)
+	t.Setenv("WATCHMAN_HOOK_SLEEP", "1")
+	h.timeout = 200 * time.Millisecond
+
+	start := time.Now()
+	_, err := h.Run(context.Background(), &jobs.CheckResult{Status: "success"})
+	assert.ErrorContains(t, err, "timed out after 200ms")
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{max: 8}
+	n, err := b.Write([]byte("12345"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	n, err = b.Write([]byte("67890"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n, "writes past the limit do not fail")
+	_, _ = b.Write([]byte("more"))
+
+	assert.Equal(t, "12345678\n[output truncated]", b.String())
+	assert.False(t, strings.Contains(b.String(), "9"))
+}
@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Assertion is a comparison of a CheckResult field with a number, such as
+// "servers>=2" or "failures==0".
+type Assertion struct {
+	Field string
+	Op    string
+	Value int
+}
+
+// assertFields maps each field an assertion can compare to its value in a
+// result.
+var assertFields = map[string]func(*CheckResult) int{
+	"servers":     func(r *CheckResult) int { return r.ServersChecked },
+	"available":   func(r *CheckResult) int { return r.ServersAvailable },
+	"unavailable": func(r *CheckResult) int { return len(r.ServersUnavailable) },
+	"skipped":     func(r *CheckResult) int { return len(r.ServersSkipped) },
+	"failures":    func(r *CheckResult) int { return len(r.FailedJobs) },
+	"warnings":    func(r *CheckResult) int { return len(r.WarnJobs) },
+}
+
+// assertOps lists the comparison operators, two-character ones first so
+// ">=" is not read as ">".
+var assertOps = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// AssertFields returns the names of the fields an assertion can compare.
+func AssertFields() []string {
+	names := make([]string, 0, len(assertFields))
+	for name := range assertFields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ParseAssertion parses an assertion of the form <field><op><number>,
+// e.g. "servers>=2". Spaces around the parts are ignored.
+func ParseAssertion(s string) (Assertion, error) {
+	for _, op := range assertOps {
+		field, value, ok := strings.Cut(s, op)
+		if !ok {
+			continue
+		}
+		a := Assertion{Field: strings.TrimSpace(field), Op: op}
+		if _, ok := assertFields[a.Field]; !ok {
+			return Assertion{}, fmt.Errorf("invalid assertion %q: unknown field %q (expected one of %s)",
+				s, a.Field, strings.Join(AssertFields(), ", "))
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 {
+			return Assertion{}, fmt.Errorf("invalid assertion %q: %q is not a non-negative number", s, strings.TrimSpace(value))
+		}
+		a.Value = n
+		return a, nil
+	}
+	return Assertion{}, fmt.Errorf("invalid assertion %q: expected <field><op><number> with op one of %s",
+		s, strings.Join(assertOps, " "))
+}
+
+// ParseAssertions parses each of exprs with ParseAssertion.
+func ParseAssertions(exprs []string) ([]Assertion, error) {
+	assertions := make([]Assertion, 0, len(exprs))
+	for _, expr := range exprs {
+		a, err := ParseAssertion(expr)
+		if err != nil {
+			return nil, err
+		}
+		assertions = append(assertions, a)
+	}
+	return assertions, nil
+}
+
+// String returns the assertion in the form it is parsed from.
+func (a Assertion) String() string {
+	return a.Field + a.Op + strconv.Itoa(a.Value)
+}
+
+// Eval returns whether result satisfies the assertion, and the value of
+// the field it compared.
+func (a Assertion) Eval(result *CheckResult) (bool, int) {
+	got := assertFields[a.Field](result)
+	switch a.Op {
+	case ">=":
+		return got >= a.Value, got
+	case "<=":
+		return got <= a.Value, got
+	case "==":
+		return got == a.Value, got
+	case "!=":
+		return got != a.Value, got
+	case ">":
+		return got > a.Value, got
+	default:
+		return got < a.Value, got
+	}
+}
@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+func TestParseAssertion(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    Assertion
+		wantErr string
+	}{
+		{expr: "servers>=2", want: Assertion{Field: "servers", Op: ">=", Value: 2}},
+		{expr: "failures==0", want: Assertion{Field: "failures", Op: "==", Value: 0}},
+		{expr: " unavailable < 1 ", want: Assertion{Field: "unavailable", Op: "<", Value: 1}},
+		{expr: "available>1", want: Assertion{Field: "available", Op: ">", Value: 1}},
+		{expr: "warnings!=3", want: Assertion{Field: "warnings", Op: "!=", Value: 3}},
+		{expr: "servers", wantErr: "expected <field><op><number>"},
+		{expr: "jobs==0", wantErr: `unknown field "jobs"`},
+		{expr: "failures==none", wantErr: `"none" is not a non-negative number`},
+		{expr: "failures>=-1", wantErr: `"-1" is not a non-negative number`},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAssertion(tt.expr)
+		if tt.wantErr != "" {
+			require.Error(t, err, tt.expr)
+			assert.Contains(t, err.Error(), tt.wantErr, tt.expr)
+			continue
+		}
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.want, got, tt.expr)
+	}
+}
+
+func TestAssertion_Eval(t *testing.T) {
+	result := &CheckResult{
+		ServersChecked:     3,
+		ServersAvailable:   2,
+		ServersUnavailable: []string{"SQL03"},
+		FailedJobs:         []database.FailedJob{{JobName: "Backup"}},
+	}
+
+	passing, err := ParseAssertions([]string{"servers>=3", "available>1", "unavailable<=1", "skipped==0", "failures!=0", "warnings<1"})
+	require.NoError(t, err)
+	for _, a := range passing {
+		ok, _ := a.Eval(result)
+		assert.True(t, ok, a.String())
+	}
+
+	failing, err := ParseAssertions([]string{"servers>3", "available>=3", "unavailable==0", "failures==0", "warnings>0"})
+	require.NoError(t, err)
+	for _, a := range failing {
+		ok, _ := a.Eval(result)
+		assert.False(t, ok, a.String())
+	}
+
+	_, got := failing[3].Eval(result)
+	assert.Equal(t, 1, got, "the compared value is returned")
+}
+
+func TestParseAssertions_StopsAtFirstError(t *testing.T) {
+	_, err := ParseAssertions([]string{"servers>=1", "bogus"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"bogus"`)
+}
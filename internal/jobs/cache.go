@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+// ResultCache stores the last check result so repeated checks within the TTL
+// can skip querying servers.
+type ResultCache struct {
+	path string
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// cacheEntry is the on-disk form of a cached result.
+type cacheEntry struct {
+	Key    string       `json:"key"`
+	Result *CheckResult `json:"result"`
+}
+
+// NewResultCache creates a cache at path whose entries expire after ttl.
+func NewResultCache(path string, ttl time.Duration) *ResultCache {
+	return &ResultCache{path: path, ttl: ttl, now: time.Now}
+}
+
+// DefaultCachePath returns the default result cache path.
+func DefaultCachePath() string {
+	return filepath.Join(state.DefaultDir(), "last_result.json")
+}
+
+// CacheKey identifies the checks a cached result can answer.
+// An empty server means all enabled servers.
+func CacheKey(server string, lookbackHours int) string {
+	if server == "" {
+		server = "*"
+	}
+	return fmt.Sprintf("%s|%d", server, lookbackHours)
+}
+
+// Get returns the cached result for key if it is younger than the TTL.
+// The returned result has Cached set. A missing, stale or unreadable cache is a miss.
+func (c *ResultCache) Get(key string) (*CheckResult, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Result == nil {
+		return nil, false
+	}
+	if entry.Key != key {
+		return nil, false
+	}
+
+	age := c.now().Sub(entry.Result.Timestamp)
+	if age < 0 || age >= c.ttl {
+		return nil, false
+	}
+
+	entry.Result.Cached = true
+	return entry.Result, true
+}
+
+// Put stores result under key.
+func (c *ResultCache) Put(key string, result *CheckResult) error {
+	if c.ttl <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(cacheEntry{Key: key, Result: result})
+	if err != nil {
+		return fmt.Errorf("failed to encode cached result: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o750); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write cached result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+func TestResultCache(t *testing.T) {
+	checkedAt := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	result := &CheckResult{
+		Status:         "failed_jobs",
+		Timestamp:      checkedAt,
+		ServersChecked: 1,
+		FailedJobs:     []database.FailedJob{{ServerName: "SQL01", JobName: "Backup"}},
+		Duration:       2 * time.Second,
+	}
+	key := CacheKey("", 24)
+
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		key     string
+		elapsed time.Duration
+		wantHit bool
+	}{
+		{name: "hit within ttl", ttl: time.Minute, key: key, elapsed: 30 * time.Second, wantHit: true},
+		{name: "miss at ttl", ttl: time.Minute, key: key, elapsed: time.Minute, wantHit: false},
+		{name: "miss after ttl", ttl: time.Minute, key: key, elapsed: 2 * time.Minute, wantHit: false},
+		{name: "miss for other server", ttl: time.Minute, key: CacheKey("SQL01", 24), elapsed: time.Second, wantHit: false},
+		{name: "miss for other lookback", ttl: time.Minute, key: CacheKey("", 48), elapsed: time.Second, wantHit: false},
+		{name: "disabled", ttl: 0, key: key, elapsed: 0, wantHit: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewResultCache(filepath.Join(t.TempDir(), "last_result.json"), tt.ttl)
+			c.now = func() time.Time { return checkedAt.Add(tt.elapsed) }
+
+			require.NoError(t, c.Put(key, result))
+			got, ok := c.Get(tt.key)
+
+			assert.Equal(t, tt.wantHit, ok)
+			if tt.wantHit {
+				assert.True(t, got.Cached)
+				assert.Equal(t, "failed_jobs", got.Status)
+				assert.Equal(t, 2*time.Second, got.Duration)
+				assert.Len(t, got.FailedJobs, 1)
+			}
+		})
+	}
+}
+
+func TestResultCache_MissingFile(t *testing.T) {
+	c := NewResultCache(filepath.Join(t.TempDir(), "last_result.json"), time.Minute)
+
+	_, ok := c.Get(CacheKey("", 24))
+	assert.False(t, ok)
+}
@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"math"
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+// incrementalEnabled returns true if checks should only scan history since the last check.
+func (m *Monitor) incrementalEnabled() bool {
+	return m.cfg.Monitoring.Incremental.Enabled && m.store != nil
+}
+
+// lookbackWindows returns the lookback to use for each server, keyed by name.
+// In incremental mode the window shrinks to the time since the server's last
+// successful check; otherwise every server uses the full lookback. If the
+// last check times cannot be read, a warning is logged and every server
+// uses the full lookback.
+func (m *Monitor) lookbackWindows(now time.Time, servers []config.ServerConfig) map[string]time.Duration {
+	full := time.Duration(m.cfg.Monitoring.LookbackHours) * time.Hour
+	floor := time.Duration(m.cfg.Monitoring.Incremental.MinLookbackHours) * time.Hour
+	windows := make(map[string]time.Duration, len(servers))
+
+	var lastChecks map[string]time.Time
+	if m.incrementalEnabled() {
+		st, err := m.store.Load()
+		if err != nil {
+			m.logger.Warn().Err(err).Msg("failed to load last check times, scanning the full lookback")
+		} else {
+			lastChecks = st.LastChecks
+		}
+	}
+
+	for _, srv := range servers {
+		windows[srv.Name] = incrementalLookback(now, lastChecks[srv.Name], full, floor)
+	}
+	return windows
+}
+
+// recordLastChecks stores startTime as the last check time of every server
+// that was checked successfully. Failed servers keep their previous time so
+// the next check still covers the gap. A failure to save is logged as a
+// warning; the next check then scans a longer window.
+func (m *Monitor) recordLastChecks(startTime time.Time, results []ServerResult) {
+	if !m.incrementalEnabled() {
+		return
+	}
+
+	err := m.store.Update(func(st *state.State) error {
+		if st.LastChecks == nil {
+			st.LastChecks = make(map[string]time.Time)
+		}
+		for _, r := range results {
+			if r.Error == nil {
+				st.LastChecks[r.ServerName] = startTime
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		m.logger.Warn().Err(err).Msg("failed to save last check times")
+	}
+}
+
+// incrementalLookback returns the lookback covering the time since lastCheck,
+// rounded up to whole seconds and clamped between floor and full.
+// A zero or future lastCheck yields the full lookback.
+func incrementalLookback(now, lastCheck time.Time, full, floor time.Duration) time.Duration {
+	if lastCheck.IsZero() || lastCheck.After(now) {
+		return full
+	}
+
+	since := (now.Sub(lastCheck) + time.Second - 1).Truncate(time.Second)
+	return min(max(since, floor), full)
+}
+
+// SinceLastLookback returns the lookback hours covering the time since the
+// oldest last successful check of servers, rounded up to whole hours.
+// Unlike incremental scans the window is not capped, so it can exceed the
+// configured lookback. If any server has no usable last check, fallback is returned.
+func SinceLastLookback(now time.Time, lastChecks map[string]time.Time, servers []string, fallback int) int {
+	if len(servers) == 0 {
+		return fallback
+	}
+
+	var oldest time.Time
+	for _, name := range servers {
+		last, ok := lastChecks[name]
+		if !ok || last.IsZero() || last.After(now) {
+			return fallback
+		}
+		if oldest.IsZero() || last.Before(oldest) {
+			oldest = last
+		}
+	}
+	return max(int(math.Ceil(now.Sub(oldest).Hours())), 1)
+}
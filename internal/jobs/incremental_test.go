@@ -0,0 +1,192 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+func TestIncrementalLookback(t *testing.T) {
+	now := time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		lastCheck time.Time
+		floor     time.Duration
+		want      time.Duration
+	}{
+		{name: "first run uses full lookback", lastCheck: time.Time{}, floor: time.Hour, want: 24 * time.Hour},
+		{name: "shrinks to time since last check", lastCheck: now.Add(-3 * time.Hour), floor: time.Hour, want: 3 * time.Hour},
+		{name: "partial hours are kept", lastCheck: now.Add(-90 * time.Minute), floor: time.Hour, want: 90 * time.Minute},
+		{name: "partial seconds round up", lastCheck: now.Add(-10*time.Minute - time.Millisecond), want: 10*time.Minute + time.Second},
+		{name: "floor applies to short gaps", lastCheck: now.Add(-10 * time.Minute), floor: 2 * time.Hour, want: 2 * time.Hour},
+		{name: "zero floor scans the gap", lastCheck: now.Add(-10 * time.Minute), want: 10 * time.Minute},
+		{name: "capped at full lookback", lastCheck: now.Add(-72 * time.Hour), floor: time.Hour, want: 24 * time.Hour},
+		{name: "future last check uses full lookback", lastCheck: now.Add(time.Hour), floor: time.Hour, want: 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, incrementalLookback(now, tt.lastCheck, 24*time.Hour, tt.floor))
+		})
+	}
+}
+
+func TestSinceLastLookback(t *testing.T) {
+	now := time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC)
+	lastChecks := map[string]time.Time{
+		"SQL01": now.Add(-3 * time.Hour),
+		"SQL02": now.Add(-30 * time.Hour),
+		"SQL03": now.Add(-10 * time.Minute),
+		"SQL04": now.Add(time.Hour),
+	}
+
+	tests := []struct {
+		name    string
+		servers []string
+		want    int
+	}{
+		{name: "time since last check", servers: []string{"SQL01"}, want: 3},
+		{name: "oldest server wins", servers: []string{"SQL01", "SQL02"}, want: 30},
+		{name: "short gaps scan an hour", servers: []string{"SQL03"}, want: 1},
+		{name: "server without state falls back", servers: []string{"SQL01", "NEW"}, want: 24},
+		{name: "future last check falls back", servers: []string{"SQL04"}, want: 24},
+		{name: "no servers falls back", servers: nil, want: 24},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SinceLastLookback(now, lastChecks, tt.servers, 24))
+		})
+	}
+}
+
+func TestCheckAll_Incremental(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{
+			LookbackHours: 24,
+			Incremental:   config.IncrementalConfig{Enabled: true, MinLookbackHours: 1},
+		},
+		Servers: []config.ServerConfig{
+			{Name: "Server1", Enabled: true},
+			{Name: "Server2", Enabled: true},
+		},
+	}
+
+	now := time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC)
+	down := map[string]bool{}
+	lookbacks := map[string][]time.Duration{}
+
+	monitor := NewMonitor(cfg)
+	monitor.now = func() time.Time { return now }
+	monitor.SetStateStore(state.NewStore(filepath.Join(t.TempDir(), "state.json")))
+	monitor.dbFactory = func(s config.ServerConfig) (JobQuerier, error) {
+		m := new(MockJobQuerier)
+		if down[s.Name] {
+			m.On("Ping", mock.Anything).Return(errors.New("connection refused"))
+		} else {
+			m.On("Ping", mock.Anything).Return(nil)
+		}
+		m.On("QueryFailedJobs", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				lookbacks[s.Name] = append(lookbacks[s.Name], args.Get(1).(time.Duration))
+			}).
+			Return([]database.FailedJob{}, nil)
+		m.On("Close").Return(nil)
+		return m, nil
+	}
+
+	// First run scans the full window
+	_, err := monitor.CheckAll(context.Background())
+	require.NoError(t, err)
+
+	// Server2 is down for the second check
+	now = now.Add(30 * time.Minute)
+	down["Server2"] = true
+	_, err = monitor.CheckAll(context.Background())
+	require.NoError(t, err)
+
+	// Server2 is back and catches up since its last successful check
+	now = now.Add(4 * time.Hour)
+	down["Server2"] = false
+	_, err = monitor.CheckAll(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []time.Duration{24 * time.Hour, time.Hour, 4 * time.Hour}, lookbacks["Server1"])
+	assert.Equal(t, []time.Duration{24 * time.Hour, 270 * time.Minute}, lookbacks["Server2"])
+}
+
+func TestCheckAll_IncrementalCorruptState(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{
+			LookbackHours: 24,
+			Incremental:   config.IncrementalConfig{Enabled: true, MinLookbackHours: 1},
+		},
+		Servers: []config.ServerConfig{{Name: "Server1", Enabled: true}},
+	}
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o600))
+
+	var lookbacks []time.Duration
+	var logs bytes.Buffer
+	monitor := NewMonitor(cfg)
+	monitor.SetLogger(zerolog.New(&logs))
+	monitor.SetStateStore(state.NewStore(path))
+	monitor.dbFactory = func(s config.ServerConfig) (JobQuerier, error) {
+		m := new(MockJobQuerier)
+		m.On("Ping", mock.Anything).Return(nil)
+		m.On("QueryFailedJobs", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { lookbacks = append(lookbacks, args.Get(1).(time.Duration)) }).
+			Return([]database.FailedJob{}, nil)
+		m.On("Close").Return(nil)
+		return m, nil
+	}
+
+	// A corrupt state file must not stop monitoring
+	result, err := monitor.CheckAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "success", result.Status)
+	assert.Equal(t, []time.Duration{24 * time.Hour}, lookbacks, "falls back to the full lookback")
+	assert.Contains(t, logs.String(), "failed to load last check times")
+	assert.Contains(t, logs.String(), "failed to save last check times")
+}
+
+func TestCheckAll_IncrementalDisabled(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{LookbackHours: 24},
+		Servers:    []config.ServerConfig{{Name: "Server1", Enabled: true}},
+	}
+
+	var lookbacks []time.Duration
+	monitor := NewMonitor(cfg)
+	monitor.SetStateStore(state.NewStore(filepath.Join(t.TempDir(), "state.json")))
+	monitor.dbFactory = func(s config.ServerConfig) (JobQuerier, error) {
+		m := new(MockJobQuerier)
+		m.On("Ping", mock.Anything).Return(nil)
+		m.On("QueryFailedJobs", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { lookbacks = append(lookbacks, args.Get(1).(time.Duration)) }).
+			Return([]database.FailedJob{}, nil)
+		m.On("Close").Return(nil)
+		return m, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := monitor.CheckAll(context.Background())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []time.Duration{24 * time.Hour, 24 * time.Hour}, lookbacks)
+}
@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+// longRunningJobs returns the jobs of server running past their
+// monitoring.long_running threshold, or nil if detection is off. The
+// executions past the lowest threshold are read once and each is compared
+// with its own job's threshold. A query problem is logged and never fails
+// the server's check.
+func (m *Monitor) longRunningJobs(ctx context.Context, db JobQuerier, server string) []database.LongRunningJob {
+	cfg := m.cfg.Monitoring.LongRunning
+	if !cfg.Enabled {
+		return nil
+	}
+
+	running, err := db.QueryLongRunningJobs(ctx, cfg.MinThreshold())
+	if err != nil {
+		m.logger.Warn().Str("server", server).Err(err).Msg("failed to query long-running jobs")
+		return nil
+	}
+
+	var jobs []database.LongRunningJob
+	for _, job := range running {
+		job.ThresholdMinutes = database.LongRunningThreshold(cfg, job.JobName)
+		if job.RunningFor() >= time.Duration(job.ThresholdMinutes)*time.Minute {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// addLongRunningJobs appends the long-running jobs of server to cr, tagged
+// with the server's configured and display names.
+func addLongRunningJobs(cr *CheckResult, server config.ServerConfig, jobs []database.LongRunningJob) {
+	for _, job := range jobs {
+		job.DisplayName = server.DisplayName
+		job.ConfigName = server.Name
+		cr.LongRunningJobs = append(cr.LongRunningJobs, job)
+	}
+}
+
+// sortLongRunningJobs sorts long-running jobs by server name, then longest
+// running first.
+func sortLongRunningJobs(jobs []database.LongRunningJob) {
+	slices.SortStableFunc(jobs, func(a, b database.LongRunningJob) int {
+		if c := cmp.Compare(a.ServerName, b.ServerName); c != 0 {
+			return c
+		}
+		if c := a.StartedAt.Compare(b.StartedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.JobName, b.JobName)
+	})
+}
@@ -3,13 +3,22 @@
 package jobs
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"os"
+	"runtime/debug"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/rs/zerolog"
+
 	"github.com/hoangtran1411/watchman/internal/config"
 	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/state"
+	"github.com/hoangtran1411/watchman/internal/warnings"
 )
 
 // CheckResult represents the result of checking all servers.
@@ -22,131 +31,476 @@ type CheckResult struct {
 	FailedJobs         []database.FailedJob `json:"failed_jobs"`
 	Summary            string               `json:"summary"`
 	Duration           time.Duration        `json:"duration_ms"`
+
+	// ServerQueryLatencyMs is the failed jobs query time per reachable
+	// server in milliseconds, excluding connect and ping.
+	ServerQueryLatencyMs map[string]int64 `json:"server_query_latency_ms,omitempty"`
+
+	// ServerErrors maps each server that failed to connect or query to its
+	// error message, with credentials redacted.
+	ServerErrors map[string]string `json:"server_errors,omitempty"`
+
+	// WarnJobs are jobs with a status in monitoring.warn_statuses. They are
+	// logged and reported but never notified and do not affect the exit code.
+	WarnJobs []database.FailedJob `json:"warn_jobs,omitempty"`
+
+	// Cached is true when the result was served from the result cache.
+	Cached bool `json:"cached,omitempty"`
+
+	// ServerClockSkewMs is how far each reachable server's clock is ahead
+	// of this host's, in milliseconds. Only preflight measures it.
+	ServerClockSkewMs map[string]int64 `json:"server_clock_skew_ms,omitempty"`
+
+	// ServersSkipped lists the servers not checked because a fail-fast check
+	// stopped at an earlier failure, or because the context deadline was too
+	// close to start them.
+	ServersSkipped []string `json:"servers_skipped,omitempty"`
+
+	// LongRunningJobs are jobs still running past their monitoring.long_running
+	// threshold. They are reported and notified but do not affect the exit code.
+	LongRunningJobs []database.LongRunningJob `json:"long_running_jobs,omitempty"`
 }
 
 // ServerResult represents the result of checking a single server.
 type ServerResult struct {
-	ServerName string
-	Available  bool
-	FailedJobs []database.FailedJob
-	Error      error
-}
+	ServerName   string
+	Available    bool
+	FailedJobs   []database.FailedJob
+	QueryLatency time.Duration // Zero if the query did not run
+	Error        error
 
-// JobQuerier defines the interface for database operations needed by Monitor.
-type JobQuerier interface {
-	Ping(ctx context.Context) error
-	Close() error
-	QueryFailedJobs(ctx context.Context, lookbackHours int) ([]database.FailedJob, error)
+	// ClockSkew is the server clock's offset from this host's, nil if not measured.
+	ClockSkew *time.Duration
+
+	// LongRunningJobs are the jobs running past their threshold.
+	LongRunningJobs []database.LongRunningJob
 }
 
+// JobQuerier defines the database operations needed by Monitor.
+type JobQuerier = database.JobQuerier
+
 // DBFactory is a function that creates a JobQuerier.
-type DBFactory func(config.ServerConfig) (JobQuerier, error)
+type DBFactory = database.Factory
 
 // Monitor handles job monitoring operations.
 type Monitor struct {
 	cfg       *config.Config
 	dbFactory DBFactory
+	store     *state.Store
+	logger    zerolog.Logger
+	now       func() time.Time
+	failFast  bool
+
+	// deadlineMargin is the least time left before the context deadline
+	// for a server check to be started.
+	deadlineMargin time.Duration
 }
 
+// defaultDeadlineMargin leaves time for a started check to finish before
+// the context deadline, so queries are not cancelled midway.
+const defaultDeadlineMargin = 5 * time.Second
+
+// deadlineSkippedError is the server error of servers not checked because
+// the context deadline was too close.
+const deadlineSkippedError = "deadline exceeded: check not started"
+
 // NewMonitor creates a new job monitor.
 func NewMonitor(cfg *config.Config) *Monitor {
 	return &Monitor{
-		cfg: cfg,
-		dbFactory: func(cfg config.ServerConfig) (JobQuerier, error) {
-			return database.New(cfg)
-		},
+		cfg:       cfg,
+		dbFactory: database.NewFactory(cfg.Monitoring.QueryStatuses()),
+		logger:    zerolog.New(os.Stderr).With().Timestamp().Logger(),
+		now:       time.Now,
+
+		deadlineMargin: defaultDeadlineMargin,
 	}
 }
 
+// SetLogger sets the logger for problems that do not fail a check, such as a
+// recovered panic. By default they are written to stderr.
+func (m *Monitor) SetLogger(logger zerolog.Logger) {
+	m.logger = logger
+}
+
+// SetFailFast makes sequential checks stop at the first server that has
+// failed jobs or cannot be checked. The remaining servers are listed in
+// ServersSkipped. Parallel checks are not affected.
+func (m *Monitor) SetFailFast(enabled bool) {
+	m.failFast = enabled
+}
+
+// SetStateStore sets the store used to track last check times for incremental scans.
+// Without a store every check scans the full lookback window.
+func (m *Monitor) SetStateStore(store *state.Store) {
+	m.store = store
+}
+
 // CheckAll checks all enabled servers for failed jobs.
 func (m *Monitor) CheckAll(ctx context.Context) (*CheckResult, error) {
-	startTime := time.Now()
+	startTime := m.now()
 	servers := m.cfg.GetEnabledServers()
 
 	if len(servers) == 0 {
 		return &CheckResult{
-			Status:    "error",
-			Timestamp: startTime,
-			Summary:   "No enabled servers configured",
+			Status:             "no_servers",
+			Timestamp:          startTime,
+			ServersUnavailable: []string{},
+			FailedJobs:         []database.FailedJob{},
+			Summary:            "No enabled servers configured: set enabled: true on at least one server",
 		}, nil
 	}
 
+	windows := m.lookbackWindows(startTime, servers)
+
 	// Check servers (parallel or sequential based on config)
+	groups := m.groupServers(servers)
 	var results []ServerResult
+	var deadlineSkipped []string
 	if m.cfg.Monitoring.Parallel.Enabled {
-		results = m.checkParallel(ctx, servers)
+		results, deadlineSkipped = m.checkParallel(ctx, groups, windows)
 	} else {
-		results = m.checkSequential(ctx, servers)
+		results, deadlineSkipped = m.checkSequential(ctx, groups, windows)
 	}
 
+	m.recordLastChecks(startTime, results)
+
 	// Aggregate results
-	return m.aggregateResults(startTime, results), nil
+	cr := m.aggregateResults(startTime, results)
+	cr.ServersSkipped = skippedServers(servers, results)
+	if cr.ServersChecked == 0 && len(cr.ServersSkipped) > 0 {
+		// Nothing was checked, so the run must not pass as healthy
+		cr.Status = "error"
+		cr.Summary = "No servers checked"
+	}
+	if len(deadlineSkipped) > 0 {
+		m.markDeadlineExceeded(cr, deadlineSkipped)
+	}
+	if n := len(cr.ServersSkipped) - len(deadlineSkipped); n > 0 {
+		cr.Summary += fmt.Sprintf(" (stopped early, %d servers not checked)", n)
+	}
+	if len(cr.ServersSkipped) > 0 {
+		warnings.Add(warnings.SourceMonitor, "servers not checked: %s", strings.Join(cr.ServersSkipped, ", "))
+	}
+	return cr, nil
 }
 
-// CheckServer checks a single server for failed jobs.
-func (m *Monitor) CheckServer(ctx context.Context, serverName string) (*CheckResult, error) {
-	startTime := time.Now()
+// nearDeadline returns true if ctx is done or too close to its deadline to
+// start another server check.
+func (m *Monitor) nearDeadline(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	deadline, ok := ctx.Deadline()
+	return ok && deadline.Sub(m.now()) < m.deadlineMargin
+}
 
-	// Find server config
-	var serverCfg *config.ServerConfig
-	for _, srv := range m.cfg.Servers {
-		if srv.Name == serverName {
-			serverCfg = &srv
-			break
-		}
+// markDeadlineExceeded records servers, skipped because the deadline was
+// too close, in cr as errors and in the summary.
+func (m *Monitor) markDeadlineExceeded(cr *CheckResult, servers []string) {
+	if cr.ServerErrors == nil {
+		cr.ServerErrors = make(map[string]string)
+	}
+	for _, server := range servers {
+		cr.ServerErrors[server] = deadlineSkippedError
 	}
+	cr.Summary += fmt.Sprintf(" (deadline exceeded, %d servers not checked)", len(servers))
+	m.logger.Warn().Strs("servers", servers).Msg("check deadline near, remaining servers not checked")
+}
+
+// CheckServer checks a single server for failed jobs.
+func (m *Monitor) CheckServer(ctx context.Context, serverName string) (*CheckResult, error) {
+	startTime := m.now()
 
-	if serverCfg == nil {
+	serverCfg, ok := m.findServer(serverName)
+	if !ok {
 		return nil, fmt.Errorf("server not found: %s", serverName)
 	}
 
-	result := m.checkSingleServer(ctx, *serverCfg)
-	return m.aggregateResults(startTime, []ServerResult{result}), nil
+	results := m.checkGroup(ctx, []config.ServerConfig{serverCfg}, nil)
+	return m.singleResult(startTime, serverCfg, results[0]), nil
 }
 
-// checkParallel checks servers in parallel with concurrency limit.
-func (m *Monitor) checkParallel(ctx context.Context, servers []config.ServerConfig) []ServerResult {
-	maxConcurrent := m.cfg.Monitoring.Parallel.MaxConcurrent
-	if maxConcurrent <= 0 {
-		maxConcurrent = 5
-	}
-
+// checkParallel checks server groups in parallel with concurrency limit.
+// It also returns the servers not checked because the deadline was too close.
+func (m *Monitor) checkParallel(ctx context.Context, groups [][]config.ServerConfig, windows map[string]time.Duration) ([]ServerResult, []string) {
 	// Semaphore for limiting concurrency
-	sem := make(chan struct{}, maxConcurrent)
-	results := make([]ServerResult, len(servers))
+	sem := make(chan struct{}, m.maxConcurrent())
+	hostLocks := newHostLocks(groups)
+	groupResults := make([][]ServerResult, len(groups))
+	groupSkipped := make([]bool, len(groups))
 	var wg sync.WaitGroup
 
-	for i, srv := range servers {
+	for i, group := range groups {
 		wg.Add(1)
-		go func(idx int, server config.ServerConfig) {
+		go func(idx int, servers []config.ServerConfig) {
 			defer wg.Done()
 
+			// Serialize checks per host before taking a global slot,
+			// so waiting on a busy host does not hold a slot
+			hostLock := hostLocks[serverHost(servers[0])]
+			hostLock.Lock()
+			defer hostLock.Unlock()
+
 			// Acquire semaphore
 			sem <- struct{}{}
 			defer func() { <-sem }()
 
-			results[idx] = m.checkSingleServer(ctx, server)
-		}(i, srv)
+			// Leave the group without a result rather than start a check
+			// that would be cancelled midway
+			if m.nearDeadline(ctx) {
+				groupSkipped[idx] = true
+				return
+			}
+			groupResults[idx] = m.checkGroup(ctx, servers, windows)
+		}(i, group)
 	}
 
 	wg.Wait()
+
+	results := make([]ServerResult, 0, len(groups))
+	var skipped []string
+	for i, r := range groupResults {
+		results = append(results, r...)
+		if groupSkipped[i] {
+			skipped = append(skipped, serverNames(groups[i])...)
+		}
+	}
+	return results, skipped
+}
+
+// serverNames returns the names of servers.
+func serverNames(servers []config.ServerConfig) []string {
+	names := make([]string, len(servers))
+	for i, srv := range servers {
+		names[i] = srv.Name
+	}
+	return names
+}
+
+// maxConcurrent returns the maximum number of servers checked at once.
+func (m *Monitor) maxConcurrent() int {
+	if n := m.cfg.Monitoring.Parallel.MaxConcurrent; n > 0 {
+		return n
+	}
+	return 5
+}
+
+// newHostLocks returns one mutex per normalized host so that at most one
+// check runs against a host at a time, even when configs differ in port or login.
+func newHostLocks(groups [][]config.ServerConfig) map[string]*sync.Mutex {
+	locks := make(map[string]*sync.Mutex)
+	for _, group := range groups {
+		for _, srv := range group {
+			host := serverHost(srv)
+			if _, ok := locks[host]; !ok {
+				locks[host] = &sync.Mutex{}
+			}
+		}
+	}
+	return locks
+}
+
+// checkSequential checks server groups one by one.
+// It also returns the servers not checked because the deadline was too close.
+func (m *Monitor) checkSequential(ctx context.Context, groups [][]config.ServerConfig, windows map[string]time.Duration) ([]ServerResult, []string) {
+	results := make([]ServerResult, 0, len(groups))
+
+	for i, group := range groups {
+		if m.nearDeadline(ctx) {
+			var skipped []string
+			for _, rest := range groups[i:] {
+				skipped = append(skipped, serverNames(rest)...)
+			}
+			return results, skipped
+		}
+		groupResults := m.checkGroup(ctx, group, windows)
+		results = append(results, groupResults...)
+		if m.failFast && slices.ContainsFunc(groupResults, m.failed) {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+// failed returns true if the server could not be checked or has failed
+// jobs to report. Jobs with a warn-only status do not count.
+func (m *Monitor) failed(r ServerResult) bool {
+	if r.Error != nil || !r.Available {
+		return true
+	}
+	return slices.ContainsFunc(r.FailedJobs, func(job database.FailedJob) bool {
+		return !m.cfg.Monitoring.IsWarnStatus(job.Status)
+	})
+}
+
+// skippedServers returns the names of the servers that have no result.
+func skippedServers(servers []config.ServerConfig, results []ServerResult) []string {
+	checked := make(map[string]bool, len(results))
+	for _, r := range results {
+		checked[r.ServerName] = true
+	}
+	var skipped []string
+	for _, srv := range servers {
+		if !checked[srv.Name] {
+			skipped = append(skipped, srv.Name)
+		}
+	}
+	return skipped
+}
+
+// checkGroup checks a group of servers sharing one SQL Server instance.
+// The group is queried with the widest lookback window of its members.
+// A panic, e.g. while scanning a malformed row, fails only this group.
+func (m *Monitor) checkGroup(ctx context.Context, servers []config.ServerConfig, windows map[string]time.Duration) (results []ServerResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			results = m.recoverGroup(servers, r)
+		}
+	}()
+
+	var lookback time.Duration
+	for _, srv := range servers {
+		lookback = max(lookback, windows[srv.Name])
+	}
+	if lookback <= 0 {
+		lookback = time.Duration(m.cfg.Monitoring.LookbackHours) * time.Hour
+	}
+
+	if len(servers) == 1 {
+		return []ServerResult{m.checkSingleServer(ctx, servers[0], lookback)}
+	}
+	return m.checkSharedHost(ctx, servers, lookback)
+}
+
+// recoverGroup logs a panic raised while checking servers and returns an
+// unavailable result for each of them.
+func (m *Monitor) recoverGroup(servers []config.ServerConfig, recovered any) []ServerResult {
+	names := make([]string, len(servers))
+	results := make([]ServerResult, len(servers))
+	for i, srv := range servers {
+		names[i] = srv.Name
+		results[i] = ServerResult{
+			ServerName: srv.Name,
+			Error:      fmt.Errorf("check panicked: %v", recovered),
+		}
+	}
+
+	m.logger.Error().
+		Strs("servers", names).
+		Interface("panic", recovered).
+		Str("stack", string(debug.Stack())).
+		Msg("recovered panic while checking server")
 	return results
 }
 
-// checkSequential checks servers one by one.
-func (m *Monitor) checkSequential(ctx context.Context, servers []config.ServerConfig) []ServerResult {
-	results := make([]ServerResult, 0, len(servers))
+// groupServers groups servers that can share a connection when batching is enabled.
+// Otherwise every server forms its own group. Configured order is preserved.
+func (m *Monitor) groupServers(servers []config.ServerConfig) [][]config.ServerConfig {
+	groups := make([][]config.ServerConfig, 0, len(servers))
+	if !m.cfg.Monitoring.BatchSharedHosts {
+		for _, srv := range servers {
+			groups = append(groups, []config.ServerConfig{srv})
+		}
+		return groups
+	}
 
+	index := make(map[string]int)
 	for _, srv := range servers {
-		result := m.checkSingleServer(ctx, srv)
-		results = append(results, result)
+		key := connectionKey(srv)
+		if i, ok := index[key]; ok {
+			groups[i] = append(groups[i], srv)
+			continue
+		}
+		index[key] = len(groups)
+		groups = append(groups, []config.ServerConfig{srv})
+	}
+	return groups
+}
+
+// connectionKey identifies servers reachable through the same connection:
+// the same connection string, with the host normalized, and the same query
+// timeout. The connection string covers the instance, login, password,
+// database and connection options, so servers differing in any of them get
+// their own connection.
+func connectionKey(server config.ServerConfig) string {
+	if server.DSN == "" {
+		server.Host = normalizeHost(server.Host)
+	}
+	return fmt.Sprintf("%s|%d", database.ConnectionString(server), server.Options.QueryTimeout)
+}
+
+// serverHost returns the normalized host the server connects to.
+func serverHost(server config.ServerConfig) string {
+	host, _ := database.Address(server)
+	return normalizeHost(host)
+}
+
+// normalizeHost normalizes a host name for comparison.
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+}
+
+// checkSharedHost checks several servers on one instance with a single connection and query.
+func (m *Monitor) checkSharedHost(ctx context.Context, servers []config.ServerConfig, lookback time.Duration) []ServerResult {
+	results := make([]ServerResult, len(servers))
+	for i, srv := range servers {
+		results[i].ServerName = srv.Name
+	}
+
+	setError := func(err error) []ServerResult {
+		for i := range results {
+			results[i].Error = err
+		}
+		return results
+	}
+
+	db, err := m.dbFactory(servers[0])
+	if err != nil {
+		return setError(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	if pingErr := db.Ping(ctx); pingErr != nil {
+		return setError(pingErr)
+	}
+
+	for i := range results {
+		results[i].Available = true
 	}
 
+	queryStart := m.now()
+	jobsByServer, err := db.QueryFailedJobsShared(ctx, lookback, servers)
+	latency := m.now().Sub(queryStart)
+	for i := range results {
+		results[i].QueryLatency = latency
+	}
+	if err != nil {
+		return setError(err)
+	}
+
+	failed := 0
+	for _, jobs := range jobsByServer {
+		failed += len(jobs)
+	}
+	// Job names are looked up in the connection's history database, so
+	// servers reading another one have no history and keep every failure
+	outcomes := m.recentOutcomes(ctx, db, servers[0].Name, failed)
+	history := database.HistoryDatabase(servers[0])
+	for i, srv := range servers {
+		var serverOutcomes map[string][]int
+		if strings.EqualFold(database.HistoryDatabase(srv), history) {
+			serverOutcomes = outcomes
+		}
+		results[i].FailedJobs = m.filterAndClassify(jobsByServer[srv.Name], serverOutcomes)
+	}
 	return results
 }
 
 // checkSingleServer checks a single server for failed jobs.
-func (m *Monitor) checkSingleServer(ctx context.Context, server config.ServerConfig) ServerResult {
+func (m *Monitor) checkSingleServer(ctx context.Context, server config.ServerConfig, lookback time.Duration) ServerResult {
 	result := ServerResult{
 		ServerName: server.Name,
 	}
@@ -171,13 +525,17 @@ func (m *Monitor) checkSingleServer(ctx context.Context, server config.ServerCon
 	result.Available = true
 
 	// Query failed jobs
-	jobs, err := db.QueryFailedJobs(ctx, m.cfg.Monitoring.LookbackHours)
+	queryStart := m.now()
+	jobs, err := db.QueryFailedJobs(ctx, lookback)
+	result.QueryLatency = m.now().Sub(queryStart)
 	if err != nil {
 		result.Error = err
 		return result
 	}
 
-	result.FailedJobs = jobs
+	outcomes := m.recentOutcomes(ctx, db, server.Name, len(jobs))
+	result.FailedJobs = m.filterAndClassify(jobs, outcomes)
+	result.LongRunningJobs = m.longRunningJobs(ctx, db, server.Name)
 	return result
 }
 
@@ -192,17 +550,40 @@ func (m *Monitor) aggregateResults(startTime time.Time, results []ServerResult)
 	}
 
 	for _, r := range results {
+		if r.Error != nil {
+			if cr.ServerErrors == nil {
+				cr.ServerErrors = make(map[string]string)
+			}
+			cr.ServerErrors[r.ServerName] = m.redactError(r.Error)
+		}
 		if r.Available {
 			cr.ServersAvailable++
-			cr.FailedJobs = append(cr.FailedJobs, r.FailedJobs...)
+			srv, _ := m.findServer(r.ServerName)
+			m.partitionJobs(cr, srv, r.FailedJobs)
+			addLongRunningJobs(cr, srv, r.LongRunningJobs)
+			if cr.ServerQueryLatencyMs == nil {
+				cr.ServerQueryLatencyMs = make(map[string]int64)
+			}
+			cr.ServerQueryLatencyMs[r.ServerName] = r.QueryLatency.Milliseconds()
+			if r.ClockSkew != nil {
+				if cr.ServerClockSkewMs == nil {
+					cr.ServerClockSkewMs = make(map[string]int64)
+				}
+				cr.ServerClockSkewMs[r.ServerName] = r.ClockSkew.Milliseconds()
+			}
 		} else {
 			cr.ServersUnavailable = append(cr.ServersUnavailable, r.ServerName)
 		}
 	}
 
+	// Parallel checks complete in any order, so sort for stable output
+	sortFailedJobs(cr.FailedJobs)
+	sortFailedJobs(cr.WarnJobs)
+	sortLongRunningJobs(cr.LongRunningJobs)
+
 	// Generate summary
 	cr.Summary = m.generateSummary(cr)
-	cr.Duration = time.Since(startTime)
+	cr.Duration = m.now().Sub(startTime)
 
 	// Set status based on results
 	if cr.ServersAvailable == 0 && cr.ServersChecked > 0 {
@@ -214,6 +595,75 @@ func (m *Monitor) aggregateResults(startTime time.Time, results []ServerResult)
 	return cr
 }
 
+// singleResult builds the CheckResult of a check of server alone. It is
+// equivalent to aggregateResults for one result, but skips the server
+// lookup, allocates the job list once and builds the maps at their final
+// size.
+func (m *Monitor) singleResult(startTime time.Time, server config.ServerConfig, r ServerResult) *CheckResult {
+	cr := &CheckResult{
+		Status:             "success",
+		Timestamp:          startTime,
+		ServersChecked:     1,
+		ServersUnavailable: []string{},
+		FailedJobs:         make([]database.FailedJob, 0, len(r.FailedJobs)),
+	}
+	if r.Error != nil {
+		cr.ServerErrors = map[string]string{r.ServerName: m.redactError(r.Error)}
+	}
+
+	if r.Available {
+		cr.ServersAvailable = 1
+		m.partitionJobs(cr, server, r.FailedJobs)
+		addLongRunningJobs(cr, server, r.LongRunningJobs)
+		cr.ServerQueryLatencyMs = map[string]int64{r.ServerName: r.QueryLatency.Milliseconds()}
+		if r.ClockSkew != nil {
+			cr.ServerClockSkewMs = map[string]int64{r.ServerName: r.ClockSkew.Milliseconds()}
+		}
+		sortFailedJobs(cr.FailedJobs)
+		sortFailedJobs(cr.WarnJobs)
+		sortLongRunningJobs(cr.LongRunningJobs)
+	} else {
+		cr.ServersUnavailable = append(cr.ServersUnavailable, r.ServerName)
+		cr.Status = "error"
+	}
+
+	cr.Summary = m.generateSummary(cr)
+	cr.Duration = m.now().Sub(startTime)
+	if r.Available && len(cr.FailedJobs) > 0 {
+		cr.Status = "failed_jobs"
+	}
+	return cr
+}
+
+// partitionJobs appends the jobs of server to cr, splitting warn-only
+// statuses from alertable ones. Each job is tagged with its severity from
+// monitoring.severities and the server's configured and display names.
+func (m *Monitor) partitionJobs(cr *CheckResult, server config.ServerConfig, jobs []database.FailedJob) {
+	for _, job := range jobs {
+		job.Severity = database.Severity(m.cfg.Monitoring.Severities, job.JobName)
+		job.DisplayName = server.DisplayName
+		job.ConfigName = server.Name
+		if m.cfg.Monitoring.IsWarnStatus(job.Status) {
+			cr.WarnJobs = append(cr.WarnJobs, job)
+		} else {
+			cr.FailedJobs = append(cr.FailedJobs, job)
+		}
+	}
+}
+
+// sortFailedJobs sorts failed jobs by server name, then most recent failure first.
+func sortFailedJobs(jobs []database.FailedJob) {
+	slices.SortStableFunc(jobs, func(a, b database.FailedJob) int {
+		if c := cmp.Compare(a.ServerName, b.ServerName); c != 0 {
+			return c
+		}
+		if c := b.FailedAt.Compare(a.FailedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.JobName, b.JobName)
+	})
+}
+
 // generateSummary generates a human-readable summary.
 func (m *Monitor) generateSummary(cr *CheckResult) string {
 	if cr.ServersAvailable == 0 && cr.ServersChecked > 0 {
@@ -250,13 +700,28 @@ func (cr *CheckResult) HasFailedJobs() bool {
 }
 
 // GetExitCode returns the appropriate exit code based on results.
-func (cr *CheckResult) GetExitCode() int {
+// Only failed jobs whose run_status is in failOn fail the check, so jobs of
+// other reported statuses are informational; nil counts every job.
+func (cr *CheckResult) GetExitCode(failOn []int) int {
 	switch {
+	case cr.Status == "no_servers":
+		return 2 // Config error
 	case cr.Status == "error":
 		return 3 // Connection error
-	case cr.HasFailedJobs():
+	case cr.hasJobsWithStatus(failOn):
 		return 1 // Failed jobs found
 	default:
 		return 0 // Success
 	}
 }
+
+// hasJobsWithStatus returns true if a failed job has one of the run_status
+// values in statuses, or if there is any failed job when statuses is nil.
+func (cr *CheckResult) hasJobsWithStatus(statuses []int) bool {
+	if statuses == nil {
+		return cr.HasFailedJobs()
+	}
+	return slices.ContainsFunc(cr.FailedJobs, func(job database.FailedJob) bool {
+		return slices.Contains(statuses, job.Status)
+	})
+}
@@ -1,17 +1,23 @@
 package jobs
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/hoangtran1411/watchman/internal/config"
 	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/warnings"
 )
 
 // MockJobQuerier is a mock implementation of JobQuerier.
@@ -35,8 +41,8 @@ func (m *MockJobQuerier) Close() error {
 	return nil
 }
 
-func (m *MockJobQuerier) QueryFailedJobs(ctx context.Context, lookbackHours int) ([]database.FailedJob, error) {
-	args := m.Called(ctx, lookbackHours)
+func (m *MockJobQuerier) QueryFailedJobs(ctx context.Context, lookback time.Duration) ([]database.FailedJob, error) {
+	args := m.Called(ctx, lookback)
 	err := args.Error(1)
 	if err != nil {
 		err = fmt.Errorf("mock: %w", err)
@@ -44,6 +50,39 @@ func (m *MockJobQuerier) QueryFailedJobs(ctx context.Context, lookbackHours int)
 	return args.Get(0).([]database.FailedJob), err
 }
 
+func (m *MockJobQuerier) QueryFailedJobsShared(ctx context.Context, lookback time.Duration, servers []config.ServerConfig) (map[string][]database.FailedJob, error) {
+	args := m.Called(ctx, lookback, servers)
+	err := args.Error(1)
+	if err != nil {
+		err = fmt.Errorf("mock: %w", err)
+	}
+	return args.Get(0).(map[string][]database.FailedJob), err
+}
+
+func (m *MockJobQuerier) QueryRecentOutcomes(ctx context.Context, count int) (map[string][]int, error) {
+	args := m.Called(ctx, count)
+	err := args.Error(1)
+	if err != nil {
+		err = fmt.Errorf("mock: %w", err)
+	}
+	return args.Get(0).(map[string][]int), err
+}
+
+func (m *MockJobQuerier) QueryLongRunningJobs(ctx context.Context, thresholdMinutes int) ([]database.LongRunningJob, error) {
+	args := m.Called(ctx, thresholdMinutes)
+	return args.Get(0).([]database.LongRunningJob), args.Error(1)
+}
+
+func (m *MockJobQuerier) GetClockSkew(ctx context.Context) (time.Duration, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func (m *MockJobQuerier) ValidatePermissions(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
 func TestCheckAll(t *testing.T) {
 	// Setup
 	cfg := &config.Config{
@@ -73,7 +112,7 @@ func TestCheckAll(t *testing.T) {
 
 	// Expectations
 	mockDB1.On("Ping", mock.Anything).Return(nil)
-	mockDB1.On("QueryFailedJobs", mock.Anything, 24).Return([]database.FailedJob{}, nil)
+	mockDB1.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{}, nil)
 	mockDB1.On("Close").Return(nil)
 
 	// Server2 has a failed job
@@ -84,7 +123,7 @@ func TestCheckAll(t *testing.T) {
 		FailedAt:   time.Now(),
 	}
 	mockDB2.On("Ping", mock.Anything).Return(nil)
-	mockDB2.On("QueryFailedJobs", mock.Anything, 24).Return([]database.FailedJob{failedJob}, nil)
+	mockDB2.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{failedJob}, nil)
 	mockDB2.On("Close").Return(nil)
 
 	// Execute
@@ -102,6 +141,395 @@ func TestCheckAll(t *testing.T) {
 	mockDB2.AssertExpectations(t)
 }
 
+func TestCheckAll_FailFast(t *testing.T) {
+	newConfig := func() *config.Config {
+		return &config.Config{
+			Monitoring: config.MonitoringConfig{LookbackHours: 24, WarnStatuses: []string{"canceled"}},
+			Servers: []config.ServerConfig{
+				{Name: "Server1", Host: "sql1", Enabled: true},
+				{Name: "Server2", Host: "sql2", Enabled: true},
+				{Name: "Server3", Host: "sql3", Enabled: true},
+			},
+		}
+	}
+
+	t.Run("stops at first failed job", func(t *testing.T) {
+		warnOnly := new(MockJobQuerier)
+		warnOnly.On("Ping", mock.Anything).Return(nil)
+		warnOnly.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{{ServerName: "Server1", JobName: "Cancelled", Status: 3}}, nil)
+		warnOnly.On("Close").Return(nil)
+
+		failing := new(MockJobQuerier)
+		failing.On("Ping", mock.Anything).Return(nil)
+		failing.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{{ServerName: "Server2", JobName: "Backup"}}, nil)
+		failing.On("Close").Return(nil)
+
+		monitor := NewMonitor(newConfig())
+		monitor.SetFailFast(true)
+		monitor.dbFactory = func(s config.ServerConfig) (JobQuerier, error) {
+			switch s.Name {
+			case "Server1":
+				return warnOnly, nil
+			case "Server2":
+				return failing, nil
+			}
+			t.Fatalf("%s must not be queried after a failure", s.Name)
+			return nil, nil
+		}
+
+		result, err := monitor.CheckAll(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "failed_jobs", result.Status)
+		assert.Equal(t, 2, result.ServersChecked, "warn-only jobs do not stop the check")
+		assert.Equal(t, []string{"Server3"}, result.ServersSkipped)
+		assert.Contains(t, result.Summary, "stopped early, 1 servers not checked")
+		assert.Equal(t, 1, result.GetExitCode(nil))
+	})
+
+	t.Run("stops at first unreachable server", func(t *testing.T) {
+		down := new(MockJobQuerier)
+		down.On("Ping", mock.Anything).Return(errors.New("connection refused"))
+		down.On("Close").Return(nil)
+
+		monitor := NewMonitor(newConfig())
+		monitor.SetFailFast(true)
+		calls := 0
+		monitor.dbFactory = func(s config.ServerConfig) (JobQuerier, error) {
+			calls++
+			return down, nil
+		}
+
+		warnings.Take()
+		result, err := monitor.CheckAll(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, []string{"Server1"}, result.ServersUnavailable)
+		assert.Equal(t, []string{"Server2", "Server3"}, result.ServersSkipped)
+		assert.Equal(t, 3, result.GetExitCode(nil))
+		assert.Equal(t, []warnings.Warning{
+			{Source: warnings.SourceMonitor, Message: "servers not checked: Server2, Server3"},
+		}, warnings.Take())
+	})
+
+	t.Run("checks every server without failures", func(t *testing.T) {
+		healthy := new(MockJobQuerier)
+		healthy.On("Ping", mock.Anything).Return(nil)
+		healthy.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{}, nil)
+		healthy.On("Close").Return(nil)
+
+		monitor := NewMonitor(newConfig())
+		monitor.SetFailFast(true)
+		monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) { return healthy, nil }
+
+		result, err := monitor.CheckAll(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.ServersChecked)
+		assert.Empty(t, result.ServersSkipped)
+		assert.Equal(t, "No failed jobs on 3 servers", result.Summary)
+	})
+}
+
+func TestCheckAll_SkipsServersNearDeadline(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		t.Run(fmt.Sprintf("parallel=%v", parallel), func(t *testing.T) {
+			cfg := &config.Config{
+				Monitoring: config.MonitoringConfig{
+					LookbackHours: 24,
+					Parallel:      config.ParallelConfig{Enabled: parallel, MaxConcurrent: 1},
+				},
+				Servers: []config.ServerConfig{
+					{Name: "Server1", Host: "sql1", Enabled: true},
+					{Name: "Server2", Host: "sql2", Enabled: true},
+					{Name: "Server3", Host: "sql3", Enabled: true},
+				},
+			}
+
+			start := time.Now()
+			deadline := start.Add(time.Hour)
+			ctx, cancel := context.WithDeadline(context.Background(), deadline)
+			defer cancel()
+
+			var mu sync.Mutex
+			now := start
+			monitor := NewMonitor(cfg)
+			monitor.SetLogger(zerolog.Nop())
+			monitor.now = func() time.Time {
+				mu.Lock()
+				defer mu.Unlock()
+				return now
+			}
+
+			// The first server checked takes until just before the deadline
+			var started []string
+			monitor.dbFactory = func(s config.ServerConfig) (JobQuerier, error) {
+				started = append(started, s.Name)
+				db := new(MockJobQuerier)
+				db.On("Ping", mock.Anything).Run(func(mock.Arguments) {
+					mu.Lock()
+					now = deadline.Add(-time.Second)
+					mu.Unlock()
+				}).Return(nil)
+				db.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{}, nil)
+				db.On("Close").Return(nil)
+				return db, nil
+			}
+
+			result, err := monitor.CheckAll(ctx)
+			require.NoError(t, err)
+			require.Len(t, started, 1, "no check is started near the deadline")
+			assert.Equal(t, 1, result.ServersChecked)
+			assert.Equal(t, 1, result.ServersAvailable)
+			assert.Empty(t, result.ServersUnavailable, "skipped servers are not reported as down")
+			assert.Len(t, result.ServersSkipped, 2)
+			assert.NotContains(t, result.ServersSkipped, started[0])
+			for _, server := range result.ServersSkipped {
+				assert.Equal(t, deadlineSkippedError, result.ServerErrors[server])
+			}
+			assert.Contains(t, result.Summary, "deadline exceeded, 2 servers not checked")
+		})
+	}
+}
+
+func TestCheckAll_ExpiredContextChecksNothing(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{LookbackHours: 24},
+		Servers:    []config.ServerConfig{{Name: "Server1", Enabled: true}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	monitor := NewMonitor(cfg)
+	monitor.SetLogger(zerolog.Nop())
+	monitor.dbFactory = func(s config.ServerConfig) (JobQuerier, error) {
+		t.Fatalf("%s must not be checked after the context is done", s.Name)
+		return nil, nil
+	}
+
+	result, err := monitor.CheckAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Server1"}, result.ServersSkipped)
+	assert.Empty(t, result.ServersUnavailable)
+	assert.Equal(t, "error", result.Status, "a run that checked nothing is not healthy")
+	assert.Equal(t, 3, result.GetExitCode(nil))
+	assert.Equal(t, "No servers checked (deadline exceeded, 1 servers not checked)", result.Summary)
+	assert.Equal(t, deadlineSkippedError, result.ServerErrors["Server1"])
+}
+
+func TestCheckAll_FailFastNearDeadlineNotLabelledDeadline(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{LookbackHours: 24},
+		Servers: []config.ServerConfig{
+			{Name: "Server1", Host: "sql1", Enabled: true},
+			{Name: "Server2", Host: "sql2", Enabled: true},
+		},
+	}
+
+	start := time.Now()
+	deadline := start.Add(time.Hour)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	now := start
+	monitor := NewMonitor(cfg)
+	monitor.SetLogger(zerolog.Nop())
+	monitor.SetFailFast(true)
+	monitor.now = func() time.Time { return now }
+
+	// Server1 fails and its check ends just before the deadline
+	monitor.dbFactory = func(s config.ServerConfig) (JobQuerier, error) {
+		db := new(MockJobQuerier)
+		db.On("Ping", mock.Anything).Run(func(mock.Arguments) {
+			now = deadline.Add(-time.Second)
+		}).Return(errors.New("connection refused"))
+		db.On("Close").Return(nil)
+		return db, nil
+	}
+
+	result, err := monitor.CheckAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Server2"}, result.ServersSkipped)
+	assert.NotContains(t, result.ServerErrors, "Server2", "skipped by fail-fast, not the deadline")
+	assert.Contains(t, result.Summary, "stopped early, 1 servers not checked")
+	assert.NotContains(t, result.Summary, "deadline")
+}
+
+func TestCheckAll_TagsSeverity(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{
+			LookbackHours: 24,
+			Severities: []config.SeverityRule{
+				{Pattern: "Backup_*", Level: config.SeverityCritical},
+				{Pattern: "*", Level: config.SeverityLow},
+			},
+		},
+		Servers: []config.ServerConfig{{Name: "Server1", Enabled: true}},
+	}
+
+	db := new(MockJobQuerier)
+	db.On("Ping", mock.Anything).Return(nil)
+	db.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{
+		{ServerName: "Server1", JobName: "Backup_Full"},
+		{ServerName: "Server1", JobName: "ETL"},
+	}, nil)
+	db.On("Close").Return(nil)
+
+	monitor := NewMonitor(cfg)
+	monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) { return db, nil }
+
+	result, err := monitor.CheckAll(context.Background())
+	require.NoError(t, err)
+
+	severities := make(map[string]string)
+	for _, job := range result.FailedJobs {
+		severities[job.JobName] = job.Severity
+	}
+	assert.Equal(t, map[string]string{"Backup_Full": "critical", "ETL": "low"}, severities)
+}
+
+func TestCheckAll_TagsDisplayName(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{LookbackHours: 24},
+		Servers: []config.ServerConfig{
+			{Name: "FIN01", Enabled: true, DisplayName: "Finance Prod DB"},
+			{Name: "HR01", Enabled: true},
+		},
+	}
+
+	monitor := NewMonitor(cfg)
+	monitor.dbFactory = func(server config.ServerConfig) (JobQuerier, error) {
+		db := new(MockJobQuerier)
+		db.On("Ping", mock.Anything).Return(nil)
+		db.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{
+			{ServerName: server.Name + `\INST2`, JobName: "Backup"},
+		}, nil)
+		db.On("Close").Return(nil)
+		return db, nil
+	}
+
+	result, err := monitor.CheckAll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, result.FailedJobs, 2)
+	assert.Equal(t, "Finance Prod DB", result.FailedJobs[0].ServerLabel())
+	assert.Equal(t, `FIN01\INST2`, result.FailedJobs[0].ServerName, "the server name is kept")
+	assert.Equal(t, "FIN01", result.FailedJobs[0].ConfiguredServer(), "tagged with the configured server")
+	assert.Equal(t, `HR01\INST2`, result.FailedJobs[1].ServerLabel(), "falls back to @@SERVERNAME")
+}
+
+func TestCheckAll_WarnStatuses(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{
+			LookbackHours:  24,
+			ReportStatuses: []string{"failed"},
+			WarnStatuses:   []string{"canceled"},
+		},
+		Servers: []config.ServerConfig{{Name: "Server1", Enabled: true}},
+	}
+
+	tests := []struct {
+		name       string
+		jobs       []database.FailedJob
+		wantStatus string
+		wantFailed []string
+		wantWarn   []string
+		wantExit   int
+	}{
+		{
+			name: "mixed statuses are partitioned",
+			jobs: []database.FailedJob{
+				{ServerName: "Server1", JobName: "Backup", Status: 0},
+				{ServerName: "Server1", JobName: "Reindex", Status: 3},
+			},
+			wantStatus: "failed_jobs",
+			wantFailed: []string{"Backup"},
+			wantWarn:   []string{"Reindex"},
+			wantExit:   1,
+		},
+		{
+			name: "warn-only statuses do not fail the check",
+			jobs: []database.FailedJob{
+				{ServerName: "Server1", JobName: "Reindex", Status: 3},
+			},
+			wantStatus: "success",
+			wantWarn:   []string{"Reindex"},
+			wantExit:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockJobQuerier)
+			mockDB.On("Ping", mock.Anything).Return(nil)
+			mockDB.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return(tt.jobs, nil)
+			mockDB.On("Close").Return(nil)
+
+			monitor := NewMonitor(cfg)
+			monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) {
+				return mockDB, nil
+			}
+
+			result, err := monitor.CheckAll(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, result.Status)
+			assert.Equal(t, tt.wantFailed, jobNames(result.FailedJobs))
+			assert.Equal(t, tt.wantWarn, jobNames(result.WarnJobs))
+			assert.Equal(t, tt.wantExit, result.GetExitCode(nil))
+		})
+	}
+}
+
+// jobNames returns the job names of jobs, or nil if there are none.
+func jobNames(jobs []database.FailedJob) []string {
+	var names []string
+	for _, job := range jobs {
+		names = append(names, job.JobName)
+	}
+	return names
+}
+
+func TestCheckAll_NoEnabledServers(t *testing.T) {
+	cfg := &config.Config{
+		Servers: []config.ServerConfig{
+			{Name: "Server1", Enabled: false},
+		},
+	}
+
+	result, err := NewMonitor(cfg).CheckAll(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "no_servers", result.Status)
+	assert.Contains(t, result.Summary, "No enabled servers configured")
+	assert.Equal(t, 0, result.ServersChecked)
+	assert.Equal(t, 2, result.GetExitCode(nil))
+}
+
+func TestCheckResult_GetExitCode(t *testing.T) {
+	failed := database.FailedJob{JobName: "Backup", Status: 0}
+	cancelled := database.FailedJob{JobName: "Reindex", Status: 3}
+
+	tests := []struct {
+		name   string
+		result CheckResult
+		failOn []int
+		want   int
+	}{
+		{name: "success", result: CheckResult{Status: "success"}, want: 0},
+		{name: "failed jobs", result: CheckResult{Status: "failed_jobs", FailedJobs: []database.FailedJob{{JobName: "Backup"}}}, want: 1},
+		{name: "no enabled servers", result: CheckResult{Status: "no_servers"}, want: 2},
+		{name: "all servers unavailable", result: CheckResult{Status: "error", ServersChecked: 2}, want: 3},
+		{name: "any status without a list", result: CheckResult{Status: "failed_jobs", FailedJobs: []database.FailedJob{cancelled}}, want: 1},
+		{name: "informational status", result: CheckResult{Status: "failed_jobs", FailedJobs: []database.FailedJob{cancelled}}, failOn: []int{0}, want: 0},
+		{name: "mixed statuses", result: CheckResult{Status: "failed_jobs", FailedJobs: []database.FailedJob{cancelled, failed}}, failOn: []int{0}, want: 1},
+		{name: "empty list", result: CheckResult{Status: "failed_jobs", FailedJobs: []database.FailedJob{failed}}, failOn: []int{}, want: 0},
+		{name: "connection error wins", result: CheckResult{Status: "error", FailedJobs: []database.FailedJob{cancelled}}, failOn: []int{0}, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.result.GetExitCode(tt.failOn))
+		})
+	}
+}
+
 func TestCheckAll_ConnectionError(t *testing.T) {
 	// Setup
 	cfg := &config.Config{
@@ -140,3 +568,547 @@ func TestCheckAll_ConnectionError(t *testing.T) {
 	// QueryFailedJobs should not be called
 	mockDB.AssertNotCalled(t, "QueryFailedJobs", mock.Anything, mock.Anything)
 }
+
+func TestCheckAll_ServerErrors(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{
+			LookbackHours: 24,
+			Parallel:      config.ParallelConfig{Enabled: false},
+		},
+		Servers: []config.ServerConfig{
+			{Name: "Server1", Enabled: true, Auth: config.AuthConfig{Type: "sql", Username: "sa", Password: "S3cret!"}},
+			{Name: "Server2", Enabled: true},
+			{Name: "Server3", Enabled: true},
+		},
+	}
+
+	mockDB1 := new(MockJobQuerier)
+	mockDB2 := new(MockJobQuerier)
+	mockDB3 := new(MockJobQuerier)
+	dbs := map[string]*MockJobQuerier{"Server1": mockDB1, "Server2": mockDB2, "Server3": mockDB3}
+
+	monitor := NewMonitor(cfg)
+	monitor.dbFactory = func(s config.ServerConfig) (JobQuerier, error) {
+		return dbs[s.Name], nil
+	}
+
+	mockDB1.On("Ping", mock.Anything).Return(errors.New("login failed for user 'sa' with password 'S3cret!' (sqlserver://sa:x@host?password=S3cret!&database=msdb)"))
+	mockDB1.On("Close").Return(nil)
+	mockDB2.On("Ping", mock.Anything).Return(nil)
+	mockDB2.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{}, errors.New("query timeout"))
+	mockDB2.On("Close").Return(nil)
+	mockDB3.On("Ping", mock.Anything).Return(nil)
+	mockDB3.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{}, nil)
+	mockDB3.On("Close").Return(nil)
+
+	result, err := monitor.CheckAll(context.Background())
+	assert.NoError(t, err)
+
+	assert.Len(t, result.ServerErrors, 2)
+	assert.Contains(t, result.ServerErrors["Server1"], "login failed")
+	assert.Contains(t, result.ServerErrors["Server1"], "password=***")
+	assert.NotContains(t, result.ServerErrors["Server1"], "S3cret!")
+	assert.Contains(t, result.ServerErrors["Server2"], "query timeout")
+	assert.NotContains(t, result.ServerErrors, "Server3")
+}
+
+func TestCheckAll_RecoversPanic(t *testing.T) {
+	for _, parallel := range []bool{true, false} {
+		t.Run(fmt.Sprintf("parallel=%v", parallel), func(t *testing.T) {
+			cfg := &config.Config{
+				Monitoring: config.MonitoringConfig{
+					LookbackHours: 24,
+					Parallel:      config.ParallelConfig{Enabled: parallel, MaxConcurrent: 2},
+				},
+				Servers: []config.ServerConfig{
+					{Name: "Broken", Enabled: true, Host: "broken"},
+					{Name: "Healthy", Enabled: true, Host: "healthy"},
+				},
+			}
+
+			broken := new(MockJobQuerier)
+			broken.On("Ping", mock.Anything).Return(nil)
+			broken.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Run(func(mock.Arguments) {
+				panic("sql: Scan error on column index 3")
+			}).Return([]database.FailedJob{}, nil)
+			broken.On("Close").Return(nil)
+
+			healthy := new(MockJobQuerier)
+			healthy.On("Ping", mock.Anything).Return(nil)
+			healthy.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{
+				{ServerName: "Healthy", JobName: "Backup", Status: 0},
+			}, nil)
+			healthy.On("Close").Return(nil)
+
+			var logs bytes.Buffer
+			monitor := NewMonitor(cfg)
+			monitor.SetLogger(zerolog.New(&logs))
+			monitor.dbFactory = func(s config.ServerConfig) (JobQuerier, error) {
+				if s.Name == "Broken" {
+					return broken, nil
+				}
+				return healthy, nil
+			}
+
+			result, err := monitor.CheckAll(context.Background())
+			assert.NoError(t, err)
+
+			assert.Equal(t, 1, result.ServersAvailable)
+			assert.Equal(t, []string{"Broken"}, result.ServersUnavailable)
+			assert.Contains(t, result.ServerErrors["Broken"], "check panicked: sql: Scan error")
+			if assert.Len(t, result.FailedJobs, 1) {
+				assert.Equal(t, "Backup", result.FailedJobs[0].JobName)
+			}
+			broken.AssertCalled(t, "Close")
+
+			assert.Contains(t, logs.String(), "recovered panic while checking server")
+			assert.Contains(t, logs.String(), "runtime/debug.Stack")
+		})
+	}
+}
+
+func TestCheckServer_RecoversPanic(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{LookbackHours: 24},
+		Servers:    []config.ServerConfig{{Name: "Broken", Enabled: true}},
+	}
+	broken := new(MockJobQuerier)
+	broken.On("Ping", mock.Anything).Run(func(mock.Arguments) {
+		panic("nil pointer")
+	}).Return(nil)
+	broken.On("Close").Return(nil)
+
+	monitor := NewMonitor(cfg)
+	monitor.SetLogger(zerolog.Nop())
+	monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) {
+		return broken, nil
+	}
+
+	result, err := monitor.CheckServer(context.Background(), "Broken")
+	assert.NoError(t, err)
+	assert.Equal(t, "error", result.Status)
+	assert.Contains(t, result.ServerErrors["Broken"], "check panicked: nil pointer")
+}
+
+// singleServerConfig has one server with a password, severities and a
+// warn-only status, so every part of a result is exercised.
+func singleServerConfig() *config.Config {
+	return &config.Config{
+		Monitoring: config.MonitoringConfig{
+			LookbackHours: 24,
+			WarnStatuses:  []string{"retried"},
+			Severities:    []config.SeverityRule{{Pattern: "Backup_*", Level: config.SeverityCritical}},
+		},
+		Servers: []config.ServerConfig{{
+			Name:        "SQL1",
+			DisplayName: "Production",
+			Enabled:     true,
+			Auth:        config.AuthConfig{Type: "sql", Password: "hunter2"},
+		}},
+	}
+}
+
+// singleServerResult returns a result of SQL1 with count failed jobs, a
+// warn-only one and a long-running one.
+func singleServerResult(count int) ServerResult {
+	base := time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC)
+	jobs := make([]database.FailedJob, 0, count+1)
+	for i := range count {
+		jobs = append(jobs, database.FailedJob{
+			ServerName: "SQL1",
+			JobName:    fmt.Sprintf("Backup_%d", i%3),
+			FailedAt:   base.Add(time.Duration(i%5) * time.Minute),
+		})
+	}
+	jobs = append(jobs, database.FailedJob{ServerName: "SQL1", JobName: "ETL", FailedAt: base, Status: 2})
+	skew := 2 * time.Second
+	return ServerResult{
+		ServerName:   "SQL1",
+		Available:    true,
+		FailedJobs:   jobs,
+		QueryLatency: 40 * time.Millisecond,
+		ClockSkew:    &skew,
+		LongRunningJobs: []database.LongRunningJob{
+			{ServerName: "SQL1", JobName: "Reindex", StartedAt: base, RunningSeconds: 3 * 3600, ThresholdMinutes: 120},
+		},
+	}
+}
+
+func TestSingleResult_MatchesAggregate(t *testing.T) {
+	start := time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC)
+	tests := map[string]ServerResult{
+		"failed jobs":     singleServerResult(7),
+		"no failed jobs":  {ServerName: "SQL1", Available: true, QueryLatency: time.Millisecond},
+		"unavailable":     {ServerName: "SQL1", Error: errors.New("login failed, password=hunter2")},
+		"query failed":    {ServerName: "SQL1", Available: true, Error: errors.New("timeout")},
+		"warn jobs only":  {ServerName: "SQL1", Available: true, FailedJobs: []database.FailedJob{{ServerName: "SQL1", JobName: "ETL", Status: 2}}},
+		"nothing checked": {ServerName: "SQL1"},
+	}
+
+	for name, r := range tests {
+		t.Run(name, func(t *testing.T) {
+			monitor := NewMonitor(singleServerConfig())
+			monitor.now = func() time.Time { return start.Add(time.Second) }
+
+			want := monitor.aggregateResults(start, []ServerResult{r})
+			got := monitor.singleResult(start, monitor.cfg.Servers[0], r)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestCheckServer_SingleResult(t *testing.T) {
+	mockDB := new(MockJobQuerier)
+	mockDB.On("Ping", mock.Anything).Return(nil)
+	mockDB.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return(singleServerResult(3).FailedJobs, nil)
+	mockDB.On("Close").Return(nil)
+
+	monitor := NewMonitor(singleServerConfig())
+	monitor.SetLogger(zerolog.Nop())
+	monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) {
+		return mockDB, nil
+	}
+
+	result, err := monitor.CheckServer(context.Background(), "SQL1")
+	require.NoError(t, err)
+	assert.Equal(t, "failed_jobs", result.Status)
+	require.Len(t, result.FailedJobs, 3)
+	assert.Equal(t, "Backup_2", result.FailedJobs[0].JobName, "most recent failure first")
+	assert.Equal(t, config.SeverityCritical, result.FailedJobs[0].Severity)
+	assert.Equal(t, "Production", result.FailedJobs[0].DisplayName)
+	require.Len(t, result.WarnJobs, 1)
+	assert.Equal(t, "ETL", result.WarnJobs[0].JobName)
+}
+
+func TestCheckAll_LongRunningJobs(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{
+			LookbackHours: 24,
+			Parallel:      config.ParallelConfig{Enabled: false, MaxConcurrent: 1},
+			LongRunning: config.LongRunningConfig{
+				Enabled:          true,
+				ThresholdMinutes: 120,
+				Jobs:             []config.LongRunningRule{{Pattern: "Index_*", ThresholdMinutes: 30}},
+			},
+		},
+		Servers: []config.ServerConfig{
+			{Name: "Server1", DisplayName: "Production", Enabled: true},
+			{Name: "Server2", Enabled: true},
+		},
+	}
+
+	base := time.Date(2026, 2, 3, 1, 0, 0, 0, time.Local)
+	healthy := new(MockJobQuerier)
+	healthy.On("Ping", mock.Anything).Return(nil)
+	healthy.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{}, nil)
+	healthy.On("QueryLongRunningJobs", mock.Anything, 30).Return([]database.LongRunningJob{
+		{ServerName: "Server1", JobName: "ETL_Nightly", StartedAt: base, RunningSeconds: 3 * 3600},
+		{ServerName: "Server1", JobName: "Index_Rebuild", StartedAt: base.Add(time.Hour), RunningSeconds: 45 * 60},
+		{ServerName: "Server1", JobName: "Backup", StartedAt: base.Add(2 * time.Hour), RunningSeconds: 60 * 60},
+	}, nil)
+	healthy.On("Close").Return(nil)
+
+	denied := new(MockJobQuerier)
+	denied.On("Ping", mock.Anything).Return(nil)
+	denied.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{}, nil)
+	denied.On("QueryLongRunningJobs", mock.Anything, 30).Return([]database.LongRunningJob(nil), errors.New("permission denied"))
+	denied.On("Close").Return(nil)
+
+	monitor := NewMonitor(cfg)
+	monitor.SetLogger(zerolog.Nop())
+	monitor.dbFactory = func(s config.ServerConfig) (JobQuerier, error) {
+		if s.Name == "Server1" {
+			return healthy, nil
+		}
+		return denied, nil
+	}
+
+	result, err := monitor.CheckAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "success", result.Status, "a failed long-running query does not fail the check")
+	assert.Equal(t, 2, result.ServersAvailable)
+	assert.Empty(t, result.ServersUnavailable)
+
+	// Backup has run an hour, under the default threshold of two
+	require.Len(t, result.LongRunningJobs, 2)
+	assert.Equal(t, "ETL_Nightly", result.LongRunningJobs[0].JobName)
+	assert.Equal(t, 120, result.LongRunningJobs[0].ThresholdMinutes)
+	assert.Equal(t, "Production", result.LongRunningJobs[0].DisplayName)
+	assert.Equal(t, "Server1", result.LongRunningJobs[0].ConfigName)
+	assert.Equal(t, "Index_Rebuild", result.LongRunningJobs[1].JobName)
+	assert.Equal(t, 30, result.LongRunningJobs[1].ThresholdMinutes)
+}
+
+func TestCheckAll_LongRunningDisabled(t *testing.T) {
+	mockDB := new(MockJobQuerier)
+	mockDB.On("Ping", mock.Anything).Return(nil)
+	mockDB.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{}, nil)
+	mockDB.On("Close").Return(nil)
+
+	monitor := NewMonitor(singleServerConfig())
+	monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) {
+		return mockDB, nil
+	}
+
+	result, err := monitor.CheckAll(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, result.LongRunningJobs)
+	mockDB.AssertNotCalled(t, "QueryLongRunningJobs", mock.Anything, mock.Anything)
+}
+
+func BenchmarkSingleResult(b *testing.B) {
+	monitor := NewMonitor(singleServerConfig())
+	start := time.Now()
+	r := singleServerResult(20)
+
+	b.Run("aggregate", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			monitor.aggregateResults(start, []ServerResult{r})
+		}
+	})
+	b.Run("single", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			monitor.singleResult(start, monitor.cfg.Servers[0], r)
+		}
+	})
+}
+
+func TestCheckAll_StableOrdering(t *testing.T) {
+	base := time.Date(2026, 2, 3, 8, 0, 0, 0, time.Local)
+	serverJobs := map[string][]database.FailedJob{
+		"Server-C": {
+			{ServerName: "Server-C", JobName: "Backup", FailedAt: base},
+		},
+		"Server-A": {
+			{ServerName: "Server-A", JobName: "ETL", FailedAt: base.Add(time.Hour)},
+			{ServerName: "Server-A", JobName: "Backup", FailedAt: base},
+		},
+		"Server-B": {
+			{ServerName: "Server-B", JobName: "Cleanup", FailedAt: base.Add(-time.Hour)},
+		},
+	}
+
+	// Delays make the servers finish in a different order than configured
+	delays := map[string]time.Duration{
+		"Server-C": 0,
+		"Server-A": 20 * time.Millisecond,
+		"Server-B": 10 * time.Millisecond,
+	}
+
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{
+			LookbackHours: 24,
+			Parallel:      config.ParallelConfig{Enabled: true, MaxConcurrent: 3},
+		},
+		Servers: []config.ServerConfig{
+			{Name: "Server-C", Enabled: true},
+			{Name: "Server-A", Enabled: true},
+			{Name: "Server-B", Enabled: true},
+		},
+	}
+
+	monitor := NewMonitor(cfg)
+	monitor.dbFactory = func(s config.ServerConfig) (JobQuerier, error) {
+		m := new(MockJobQuerier)
+		m.On("Ping", mock.Anything).Return(nil)
+		m.On("QueryFailedJobs", mock.Anything, 24*time.Hour).
+			After(delays[s.Name]).
+			Return(serverJobs[s.Name], nil)
+		m.On("Close").Return(nil)
+		return m, nil
+	}
+
+	want := []string{"Server-A/ETL", "Server-A/Backup", "Server-B/Cleanup", "Server-C/Backup"}
+
+	for i := 0; i < 3; i++ {
+		result, err := monitor.CheckAll(context.Background())
+		assert.NoError(t, err)
+
+		got := make([]string, 0, len(result.FailedJobs))
+		for _, job := range result.FailedJobs {
+			got = append(got, job.ServerName+"/"+job.JobName)
+		}
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestCheckAll_SerializesPerHost(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{
+			LookbackHours: 24,
+			Parallel:      config.ParallelConfig{Enabled: true, MaxConcurrent: 5},
+		},
+		Servers: []config.ServerConfig{
+			{Name: "A1", Enabled: true, Host: "sql01.local", Port: 1433},
+			{Name: "A2", Enabled: true, Host: "SQL01.local.", Port: 1434},
+			{Name: "B", Enabled: true, Host: "sql02.local", Port: 1433},
+		},
+	}
+
+	var mu sync.Mutex
+	inFlight := make(map[string]int)
+	maxInFlight := make(map[string]int)
+	track := func(host string, delta int) {
+		mu.Lock()
+		defer mu.Unlock()
+		inFlight[host] += delta
+		if inFlight[host] > maxInFlight[host] {
+			maxInFlight[host] = inFlight[host]
+		}
+	}
+
+	monitor := NewMonitor(cfg)
+	monitor.dbFactory = func(s config.ServerConfig) (JobQuerier, error) {
+		host := normalizeHost(s.Host)
+		track(host, 1)
+
+		m := new(MockJobQuerier)
+		m.On("Ping", mock.Anything).Return(nil)
+		m.On("QueryFailedJobs", mock.Anything, 24*time.Hour).
+			After(20*time.Millisecond).
+			Return([]database.FailedJob{}, nil)
+		m.On("Close").Run(func(mock.Arguments) { track(host, -1) }).Return(nil)
+		return m, nil
+	}
+
+	result, err := monitor.CheckAll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.ServersAvailable)
+
+	assert.Equal(t, 1, maxInFlight["sql01.local"], "checks against the same host must not overlap")
+	assert.Equal(t, 1, maxInFlight["sql02.local"])
+}
+
+func TestCheckAll_QueryLatency(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{
+			LookbackHours: 24,
+			Parallel:      config.ParallelConfig{Enabled: true, MaxConcurrent: 2},
+		},
+		Servers: []config.ServerConfig{
+			{Name: "Up", Enabled: true, Host: "up"},
+			{Name: "Down", Enabled: true, Host: "down"},
+		},
+	}
+
+	monitor := NewMonitor(cfg)
+	monitor.dbFactory = func(s config.ServerConfig) (JobQuerier, error) {
+		m := new(MockJobQuerier)
+		if s.Name == "Down" {
+			m.On("Ping", mock.Anything).Return(errors.New("connection refused"))
+		} else {
+			m.On("Ping", mock.Anything).Return(nil)
+		}
+		m.On("QueryFailedJobs", mock.Anything, 24*time.Hour).
+			After(10*time.Millisecond).
+			Return([]database.FailedJob{}, nil)
+		m.On("Close").Return(nil)
+		return m, nil
+	}
+
+	result, err := monitor.CheckAll(context.Background())
+	assert.NoError(t, err)
+
+	assert.Len(t, result.ServerQueryLatencyMs, 1)
+	assert.GreaterOrEqual(t, result.ServerQueryLatencyMs["Up"], int64(10))
+	assert.NotContains(t, result.ServerQueryLatencyMs, "Down")
+
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), fmt.Sprintf(`"server_query_latency_ms":{"Up":%d}`, result.ServerQueryLatencyMs["Up"]))
+	assert.Less(t, result.ServerQueryLatencyMs["Up"], int64(10000), "serialized in milliseconds")
+}
+
+func TestGroupServers(t *testing.T) {
+	servers := []config.ServerConfig{
+		{Name: "A1", Host: "sql01.local", Port: 1433, Auth: config.AuthConfig{Type: "sql", Username: "svc"}},
+		{Name: "B", Host: "sql02.local", Port: 1433, Auth: config.AuthConfig{Type: "sql", Username: "svc"}},
+		{Name: "A2", Host: " SQL01.local ", Port: 1433, Auth: config.AuthConfig{Type: "sql", Username: "svc"}},
+		{Name: "A-other-login", Host: "sql01.local", Port: 1433, Auth: config.AuthConfig{Type: "sql", Username: "dba"}},
+		{Name: "A-other-port", Host: "sql01.local", Port: 1434, Auth: config.AuthConfig{Type: "sql", Username: "svc"}},
+		{Name: "A-other-password", Host: "sql01.local", Port: 1433, Auth: config.AuthConfig{Type: "sql", Username: "svc", Password: "other"}},
+		{Name: "A-other-database", Host: "sql01.local", Port: 1433, Database: "ops", Auth: config.AuthConfig{Type: "sql", Username: "svc"}},
+		{Name: "A-encrypted", Host: "sql01.local", Port: 1433, Auth: config.AuthConfig{Type: "sql", Username: "svc"}, Options: config.DBOptions{Encrypt: true}},
+		{Name: "A-other-timeout", Host: "sql01.local", Port: 1433, Auth: config.AuthConfig{Type: "sql", Username: "svc"}, Options: config.DBOptions{QueryTimeout: 5}},
+		{Name: "D1", DSN: "sqlserver://svc:pw@sql01.local?database=msdb"},
+		{Name: "D2", DSN: "sqlserver://svc:pw@sql01.local?database=msdb"},
+		{Name: "D-other", DSN: "sqlserver://svc:pw@sql01.local?database=msdb&packet+size=8192"},
+	}
+
+	tests := []struct {
+		name  string
+		batch bool
+		want  [][]string
+	}{
+		{
+			name:  "batching disabled",
+			batch: false,
+			want: [][]string{{"A1"}, {"B"}, {"A2"}, {"A-other-login"}, {"A-other-port"},
+				{"A-other-password"}, {"A-other-database"}, {"A-encrypted"}, {"A-other-timeout"}, {"D1"}, {"D2"}, {"D-other"}},
+		},
+		{
+			name:  "batching enabled",
+			batch: true,
+			want: [][]string{{"A1", "A2"}, {"B"}, {"A-other-login"}, {"A-other-port"},
+				{"A-other-password"}, {"A-other-database"}, {"A-encrypted"}, {"A-other-timeout"}, {"D1", "D2"}, {"D-other"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Monitoring: config.MonitoringConfig{BatchSharedHosts: tt.batch}}
+			groups := NewMonitor(cfg).groupServers(servers)
+
+			got := make([][]string, 0, len(groups))
+			for _, g := range groups {
+				var names []string
+				for _, srv := range g {
+					names = append(names, srv.Name)
+				}
+				got = append(got, names)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCheckAll_BatchSharedHosts(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{
+			LookbackHours:    24,
+			BatchSharedHosts: true,
+			Parallel:         config.ParallelConfig{Enabled: true, MaxConcurrent: 2},
+		},
+		Servers: []config.ServerConfig{
+			{Name: "ETL-VIEW", Enabled: true, Host: "sql01", Port: 1433},
+			{Name: "BACKUP-VIEW", Enabled: true, Host: "sql01", Port: 1433},
+		},
+	}
+
+	mockDB := new(MockJobQuerier)
+	factoryCalls := 0
+	monitor := NewMonitor(cfg)
+	monitor.dbFactory = func(s config.ServerConfig) (JobQuerier, error) {
+		factoryCalls++
+		return mockDB, nil
+	}
+
+	mockDB.On("Ping", mock.Anything).Return(nil).Once()
+	mockDB.On("QueryFailedJobsShared", mock.Anything, 24*time.Hour, mock.Anything).Return(map[string][]database.FailedJob{
+		"ETL-VIEW":    {{ServerName: "SQL01", JobName: "ETL_Daily"}},
+		"BACKUP-VIEW": {},
+	}, nil).Once()
+	mockDB.On("Close").Return(nil).Once()
+
+	result, err := monitor.CheckAll(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, factoryCalls, "shared host should use a single connection")
+	assert.Equal(t, 2, result.ServersChecked)
+	assert.Equal(t, 2, result.ServersAvailable)
+	assert.Len(t, result.FailedJobs, 1)
+	mockDB.AssertExpectations(t)
+	mockDB.AssertNotCalled(t, "QueryFailedJobs", mock.Anything, mock.Anything)
+}
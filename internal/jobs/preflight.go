@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+// Preflight connects to and pings every enabled server once, without querying
+// job history, so connection and login problems surface before the first check.
+// The result has no failed jobs; unreachable servers are listed in
+// ServersUnavailable with their errors in ServerErrors. When
+// monitoring.max_clock_skew_seconds is set, each reachable server's clock
+// skew is recorded in ServerClockSkewMs.
+func (m *Monitor) Preflight(ctx context.Context) *CheckResult {
+	startTime := m.now()
+	servers := m.cfg.GetEnabledServers()
+
+	results := make([]ServerResult, len(servers))
+	sem := make(chan struct{}, m.maxConcurrent())
+	var wg sync.WaitGroup
+	for i, srv := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = m.pingServer(ctx, srv)
+		}()
+	}
+	wg.Wait()
+
+	cr := m.aggregateResults(startTime, results)
+	cr.Summary = fmt.Sprintf("Preflight: %d of %d servers reachable", cr.ServersAvailable, cr.ServersChecked)
+	return cr
+}
+
+// pingServer opens a connection to server and pings it.
+func (m *Monitor) pingServer(ctx context.Context, server config.ServerConfig) ServerResult {
+	result := ServerResult{ServerName: server.Name}
+
+	db, err := m.dbFactory(server)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	if err := db.Ping(ctx); err != nil {
+		result.Error = err
+		return result
+	}
+	result.Available = true
+
+	// A server whose time cannot be read is still reachable
+	if m.cfg.Monitoring.MaxClockSkewSeconds > 0 {
+		if skew, err := db.GetClockSkew(ctx); err == nil {
+			result.ClockSkew = &skew
+		}
+	}
+	return result
+}
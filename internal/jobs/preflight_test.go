@@ -0,0 +1,132 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+func TestPreflight(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{LookbackHours: 24},
+		Servers: []config.ServerConfig{
+			{Name: "UP", Enabled: true},
+			{Name: "DOWN", Enabled: true, Auth: config.AuthConfig{Password: "s3cret"}},
+			{Name: "BROKEN", Enabled: true},
+			{Name: "DISABLED", Enabled: false},
+		},
+	}
+
+	up := new(MockJobQuerier)
+	up.On("Ping", mock.Anything).Return(nil)
+	up.On("Close").Return(nil)
+
+	down := new(MockJobQuerier)
+	down.On("Ping", mock.Anything).Return(errors.New("login failed: password=s3cret"))
+	down.On("Close").Return(nil)
+
+	monitor := NewMonitor(cfg)
+	monitor.dbFactory = func(s config.ServerConfig) (JobQuerier, error) {
+		switch s.Name {
+		case "UP":
+			return up, nil
+		case "DOWN":
+			return down, nil
+		case "BROKEN":
+			return nil, errors.New("invalid connection string")
+		}
+		t.Fatalf("unexpected server %s", s.Name)
+		return nil, nil
+	}
+
+	result := monitor.Preflight(context.Background())
+
+	assert.Equal(t, 3, result.ServersChecked)
+	assert.Equal(t, 1, result.ServersAvailable)
+	assert.ElementsMatch(t, []string{"DOWN", "BROKEN"}, result.ServersUnavailable)
+	assert.Empty(t, result.FailedJobs)
+	assert.Equal(t, "Preflight: 1 of 3 servers reachable", result.Summary)
+	assert.Contains(t, result.ServerErrors["BROKEN"], "invalid connection string")
+	assert.NotContains(t, result.ServerErrors["DOWN"], "s3cret")
+	assert.Contains(t, result.ServerQueryLatencyMs, "UP", "reachable servers clear recorded outages")
+
+	// Preflight only pings: history is never queried
+	up.AssertNotCalled(t, "QueryFailedJobs", mock.Anything, mock.Anything)
+	up.AssertExpectations(t)
+	down.AssertExpectations(t)
+}
+
+func TestPreflight_AllDown(t *testing.T) {
+	cfg := &config.Config{
+		Servers: []config.ServerConfig{{Name: "DOWN", Enabled: true}},
+	}
+	down := new(MockJobQuerier)
+	down.On("Ping", mock.Anything).Return(errors.New("timeout"))
+	down.On("Close").Return(nil)
+
+	monitor := NewMonitor(cfg)
+	monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) {
+		return down, nil
+	}
+
+	result := monitor.Preflight(context.Background())
+	assert.Equal(t, "error", result.Status)
+	assert.Equal(t, []string{"DOWN"}, result.ServersUnavailable)
+}
+
+func TestPreflight_ClockSkew(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{MaxClockSkewSeconds: 60},
+		Servers: []config.ServerConfig{
+			{Name: "SKEWED", Enabled: true},
+			{Name: "UNKNOWN", Enabled: true},
+		},
+	}
+
+	skewed := new(MockJobQuerier)
+	skewed.On("Ping", mock.Anything).Return(nil)
+	skewed.On("GetClockSkew", mock.Anything).Return(-5*time.Minute, nil)
+	skewed.On("Close").Return(nil)
+
+	unknown := new(MockJobQuerier)
+	unknown.On("Ping", mock.Anything).Return(nil)
+	unknown.On("GetClockSkew", mock.Anything).Return(time.Duration(0), errors.New("permission denied"))
+	unknown.On("Close").Return(nil)
+
+	monitor := NewMonitor(cfg)
+	monitor.dbFactory = func(s config.ServerConfig) (JobQuerier, error) {
+		if s.Name == "SKEWED" {
+			return skewed, nil
+		}
+		return unknown, nil
+	}
+
+	result := monitor.Preflight(context.Background())
+	assert.Equal(t, 2, result.ServersAvailable, "an unreadable clock does not make a server unavailable")
+	assert.Equal(t, map[string]int64{"SKEWED": -300000}, result.ServerClockSkewMs, "serialized in milliseconds")
+	assert.True(t, cfg.Monitoring.ClockSkewExceeded(time.Duration(result.ServerClockSkewMs["SKEWED"])*time.Millisecond))
+}
+
+func TestPreflight_ClockSkewDisabled(t *testing.T) {
+	cfg := &config.Config{
+		Servers: []config.ServerConfig{{Name: "UP", Enabled: true}},
+	}
+	up := new(MockJobQuerier)
+	up.On("Ping", mock.Anything).Return(nil)
+	up.On("Close").Return(nil)
+
+	monitor := NewMonitor(cfg)
+	monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) {
+		return up, nil
+	}
+
+	result := monitor.Preflight(context.Background())
+	assert.Nil(t, result.ServerClockSkewMs)
+	up.AssertNotCalled(t, "GetClockSkew", mock.Anything)
+}
@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+// redacted replaces secrets in error messages.
+const redacted = "***"
+
+// passwordParam matches password settings in connection strings and URLs.
+var passwordParam = regexp.MustCompile(`(?i)(password|pwd)=[^;&\s]*`)
+
+// urlUserinfo matches the password in the userinfo of a URL.
+var urlUserinfo = regexp.MustCompile(`(?i)([a-z][a-z0-9+.-]*://[^:/@\s]*):[^@/\s]*@`)
+
+// redactError returns err's message with secrets replaced: the password of
+// each configured server and DSN wherever it appears, URL userinfo
+// passwords and connection string password settings.
+func (m *Monitor) redactError(err error) string {
+	msg := err.Error()
+	for _, srv := range m.cfg.Servers {
+		if srv.DSN != "" {
+			msg = strings.ReplaceAll(msg, srv.DSN, database.RedactDSN(srv.DSN))
+		}
+		for _, password := range []string{srv.Auth.Password, database.DSNPassword(srv.DSN)} {
+			for _, form := range database.PasswordForms(password) {
+				msg = strings.ReplaceAll(msg, form, redacted)
+			}
+		}
+	}
+	msg = urlUserinfo.ReplaceAllString(msg, "${1}:"+redacted+"@")
+	return passwordParam.ReplaceAllString(msg, "${1}="+redacted)
+}
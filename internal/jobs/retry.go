@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+// RetryCheck runs check until at least one server is reachable or the
+// attempts in cfg are used up, waiting cfg.DelaySeconds between attempts.
+// Errors from check are configuration problems and are returned without retrying.
+// The last result is returned when every attempt fails to reach a server.
+func RetryCheck(ctx context.Context, cfg config.RetryConfig, check func(ctx context.Context) (*CheckResult, error)) (*CheckResult, error) {
+	attempts := 1
+	if cfg.Enabled && cfg.MaxAttempts > 1 {
+		attempts = cfg.MaxAttempts
+	}
+	delay := time.Duration(cfg.DelaySeconds) * time.Second
+
+	var result *CheckResult
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return result, nil
+			case <-time.After(delay):
+			}
+		}
+
+		var err error
+		result, err = check(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if result.Status != "error" {
+			return result, nil
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,84 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+func TestRetryCheck_Attempts(t *testing.T) {
+	tests := []struct {
+		name      string
+		retry     config.RetryConfig
+		wantPings int
+	}{
+		{name: "disabled", retry: config.RetryConfig{Enabled: false, MaxAttempts: 3}, wantPings: 1},
+		{name: "three attempts", retry: config.RetryConfig{Enabled: true, MaxAttempts: 3}, wantPings: 3},
+		{name: "five attempts", retry: config.RetryConfig{Enabled: true, MaxAttempts: 5}, wantPings: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Monitoring: config.MonitoringConfig{LookbackHours: 24},
+				Servers:    []config.ServerConfig{{Name: "Server1", Enabled: true}},
+			}
+			mockDB := new(MockJobQuerier)
+			mockDB.On("Ping", mock.Anything).Return(errors.New("connection refused"))
+			mockDB.On("Close").Return(nil)
+
+			monitor := NewMonitor(cfg)
+			monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) {
+				return mockDB, nil
+			}
+
+			result, err := RetryCheck(context.Background(), tt.retry, monitor.CheckAll)
+			require.NoError(t, err)
+			assert.Equal(t, "error", result.Status)
+			mockDB.AssertNumberOfCalls(t, "Ping", tt.wantPings)
+		})
+	}
+}
+
+func TestRetryCheck_StopsOnceReachable(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{LookbackHours: 24},
+		Servers:    []config.ServerConfig{{Name: "Server1", Enabled: true}},
+	}
+	mockDB := new(MockJobQuerier)
+	mockDB.On("Ping", mock.Anything).Return(errors.New("connection refused")).Once()
+	mockDB.On("Ping", mock.Anything).Return(nil)
+	mockDB.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{}, nil)
+	mockDB.On("Close").Return(nil)
+
+	monitor := NewMonitor(cfg)
+	monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) {
+		return mockDB, nil
+	}
+
+	retry := config.RetryConfig{Enabled: true, MaxAttempts: 5}
+	result, err := RetryCheck(context.Background(), retry, monitor.CheckAll)
+	require.NoError(t, err)
+	assert.Equal(t, "success", result.Status)
+	mockDB.AssertNumberOfCalls(t, "Ping", 2)
+}
+
+func TestRetryCheck_ErrorNotRetried(t *testing.T) {
+	calls := 0
+	check := func(context.Context) (*CheckResult, error) {
+		calls++
+		return nil, errors.New("server not found: NOPE")
+	}
+
+	_, err := RetryCheck(context.Background(), config.RetryConfig{Enabled: true, MaxAttempts: 3}, check)
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// DurationStats summarizes a set of durations.
+type DurationStats struct {
+	Count int
+	Min   time.Duration
+	Avg   time.Duration
+	Max   time.Duration
+	P95   time.Duration
+}
+
+// SummarizeDurations returns the min, mean, max and 95th percentile of ds.
+// The percentile uses the nearest-rank method. An empty set yields zero stats.
+func SummarizeDurations(ds []time.Duration) DurationStats {
+	if len(ds) == 0 {
+		return DurationStats{}
+	}
+
+	sorted := slices.Clone(ds)
+	slices.Sort(sorted)
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+
+	return DurationStats{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Avg:   total / time.Duration(len(sorted)),
+		Max:   sorted[len(sorted)-1],
+		P95:   percentile(sorted, 95),
+	}
+}
+
+// percentile returns the p-th percentile of sorted using the nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
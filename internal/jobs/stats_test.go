@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeDurations(t *testing.T) {
+	tests := []struct {
+		name string
+		ds   []time.Duration
+		want DurationStats
+	}{
+		{
+			name: "empty",
+			want: DurationStats{},
+		},
+		{
+			name: "single",
+			ds:   []time.Duration{42 * time.Millisecond},
+			want: DurationStats{Count: 1, Min: 42 * time.Millisecond, Avg: 42 * time.Millisecond, Max: 42 * time.Millisecond, P95: 42 * time.Millisecond},
+		},
+		{
+			name: "one to twenty, unsorted",
+			ds: []time.Duration{
+				20, 1, 19, 2, 18, 3, 17, 4, 16, 5, 15, 6, 14, 7, 13, 8, 12, 9, 11, 10,
+			},
+			// Nearest rank: ceil(0.95 * 20) = 19th value
+			want: DurationStats{Count: 20, Min: 1, Avg: 10, Max: 20, P95: 19},
+		},
+		{
+			name: "outlier dominates p95 of small set",
+			ds:   []time.Duration{100, 100, 100, 100, 900},
+			// ceil(0.95 * 5) = 5th value
+			want: DurationStats{Count: 5, Min: 100, Avg: 260, Max: 900, P95: 900},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SummarizeDurations(tt.ds))
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+// Stage identifies a stage of a traced server check.
+type Stage string
+
+// Trace stages in execution order.
+const (
+	StageConnect     Stage = "connect"
+	StagePing        Stage = "ping"
+	StagePermissions Stage = "permissions"
+	StageQuery       Stage = "query"
+	StageFilter      Stage = "filter"
+)
+
+// TraceStages lists the stages of a trace in the order they run.
+var TraceStages = []Stage{StageConnect, StagePing, StagePermissions, StageQuery, StageFilter}
+
+// StageResult is the outcome of a single trace stage.
+type StageResult struct {
+	Stage      Stage  `json:"stage"`
+	OK         bool   `json:"ok"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Duration returns how long the stage took.
+func (s StageResult) Duration() time.Duration {
+	return time.Duration(s.DurationMs) * time.Millisecond
+}
+
+// ServerTrace is a single server check broken into stages along with the
+// data passed between them.
+type ServerTrace struct {
+	Server        string        `json:"server"`
+	LookbackHours int           `json:"lookback_hours"`
+	Stages        []StageResult `json:"stages"`
+
+	// Rows are the failed jobs returned by the query. Job name filters are
+	// applied by the query itself.
+	Rows []database.FailedJob `json:"rows"`
+
+	// FailedJobs and WarnJobs are the rows left after the consecutive
+	// failures filter, split by monitoring.warn_statuses as a check would.
+	FailedJobs []database.FailedJob `json:"failed_jobs"`
+	WarnJobs   []database.FailedJob `json:"warn_jobs"`
+}
+
+// OK returns true if every stage succeeded.
+func (t *ServerTrace) OK() bool {
+	for _, s := range t.Stages {
+		if !s.OK {
+			return false
+		}
+	}
+	return true
+}
+
+// TraceServer runs a check of one server stage by stage, recording each
+// stage's outcome and timing. Once a stage fails, the remaining stages are
+// marked as skipped. Unlike CheckServer, it also validates msdb permissions
+// and does not apply incremental lookback or record state.
+func (m *Monitor) TraceServer(ctx context.Context, serverName string) (*ServerTrace, error) {
+	server, ok := m.findServer(serverName)
+	if !ok {
+		return nil, fmt.Errorf("server not found: %s", serverName)
+	}
+
+	trace := &ServerTrace{
+		Server:        server.Name,
+		LookbackHours: m.cfg.Monitoring.LookbackHours,
+		Rows:          []database.FailedJob{},
+		FailedJobs:    []database.FailedJob{},
+		WarnJobs:      []database.FailedJob{},
+	}
+
+	var db JobQuerier
+	defer func() {
+		if db != nil {
+			_ = db.Close()
+		}
+	}()
+
+	stages := []struct {
+		stage Stage
+		run   func() error
+	}{
+		{StageConnect, func() error {
+			var err error
+			db, err = m.dbFactory(server)
+			return err
+		}},
+		{StagePing, func() error { return db.Ping(ctx) }},
+		{StagePermissions, func() error { return db.ValidatePermissions(ctx) }},
+		{StageQuery, func() error {
+			rows, err := db.QueryFailedJobs(ctx, time.Duration(trace.LookbackHours)*time.Hour)
+			trace.Rows = append(trace.Rows, rows...)
+			return err
+		}},
+		{StageFilter, func() error {
+			outcomes := m.recentOutcomes(ctx, db, server.Name, len(trace.Rows))
+			cr := &CheckResult{}
+			m.partitionJobs(cr, server, m.filterAndClassify(trace.Rows, outcomes))
+			trace.FailedJobs = append(trace.FailedJobs, cr.FailedJobs...)
+			trace.WarnJobs = append(trace.WarnJobs, cr.WarnJobs...)
+			return nil
+		}},
+	}
+
+	failed := false
+	for _, s := range stages {
+		if failed {
+			trace.Stages = append(trace.Stages, StageResult{Stage: s.stage, Skipped: true})
+			continue
+		}
+		result := m.runStage(s.stage, s.run)
+		failed = !result.OK
+		trace.Stages = append(trace.Stages, result)
+	}
+	return trace, nil
+}
+
+// findServer returns the configuration of the named server.
+func (m *Monitor) findServer(name string) (config.ServerConfig, bool) {
+	for _, srv := range m.cfg.Servers {
+		if srv.Name == name {
+			return srv, true
+		}
+	}
+	return config.ServerConfig{}, false
+}
+
+// runStage runs and times a single trace stage. A panic fails the stage
+// instead of the command, since tracing is used on misbehaving servers.
+func (m *Monitor) runStage(stage Stage, run func() error) (result StageResult) {
+	start := m.now()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result = StageResult{Stage: stage, DurationMs: m.now().Sub(start).Milliseconds(), Error: fmt.Sprintf("panicked: %v", recovered)}
+		}
+	}()
+
+	err := run()
+	result = StageResult{Stage: stage, OK: err == nil, DurationMs: m.now().Sub(start).Milliseconds()}
+	if err != nil {
+		result.Error = m.redactError(err)
+	}
+	return result
+}
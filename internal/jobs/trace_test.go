@@ -0,0 +1,132 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+func traceConfig() *config.Config {
+	return &config.Config{
+		Monitoring: config.MonitoringConfig{
+			LookbackHours:       24,
+			ConsecutiveFailures: 2,
+			WarnStatuses:        []string{"canceled"},
+		},
+		Servers: []config.ServerConfig{{Name: "SQL01", Enabled: true}},
+	}
+}
+
+func TestTraceServer(t *testing.T) {
+	rows := []database.FailedJob{
+		{ServerName: "SQL01", JobName: "Backup", Status: 0},
+		{ServerName: "SQL01", JobName: "Flaky", Status: 0},
+		{ServerName: "SQL01", JobName: "Cancelled", Status: 3},
+	}
+	db := new(MockJobQuerier)
+	db.On("Ping", mock.Anything).Return(nil)
+	db.On("ValidatePermissions", mock.Anything).Return(nil)
+	db.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return(rows, nil)
+	db.On("QueryRecentOutcomes", mock.Anything, 2).Return(map[string][]int{
+		"Backup": {0, 0},
+		"Flaky":  {0, 1},
+	}, nil)
+	db.On("Close").Return(nil)
+
+	monitor := NewMonitor(traceConfig())
+	monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) { return db, nil }
+
+	trace, err := monitor.TraceServer(context.Background(), "SQL01")
+	require.NoError(t, err)
+
+	require.Len(t, trace.Stages, len(TraceStages))
+	for i, stage := range TraceStages {
+		assert.Equal(t, stage, trace.Stages[i].Stage)
+		assert.True(t, trace.Stages[i].OK, "stage %s", stage)
+	}
+	assert.True(t, trace.OK())
+	assert.Equal(t, 24, trace.LookbackHours)
+	assert.Len(t, trace.Rows, 3)
+	require.Len(t, trace.FailedJobs, 1)
+	assert.Equal(t, "Backup", trace.FailedJobs[0].JobName)
+	require.Len(t, trace.WarnJobs, 1)
+	assert.Equal(t, "Cancelled", trace.WarnJobs[0].JobName)
+	db.AssertExpectations(t)
+}
+
+func TestStageResult_DurationMs(t *testing.T) {
+	stage := StageResult{Stage: StageQuery, OK: true, DurationMs: 250}
+	assert.Equal(t, 250*time.Millisecond, stage.Duration())
+
+	data, err := json.Marshal(stage)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"stage":"query","ok":true,"duration_ms":250}`, string(data))
+}
+
+func TestTraceServer_SkipsAfterFailure(t *testing.T) {
+	cfg := traceConfig()
+	cfg.Servers[0].Auth.Password = "s3cret"
+
+	db := new(MockJobQuerier)
+	db.On("Ping", mock.Anything).Return(nil)
+	db.On("ValidatePermissions", mock.Anything).Return(errors.New("denied for password=s3cret"))
+	db.On("Close").Return(nil)
+
+	monitor := NewMonitor(cfg)
+	monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) { return db, nil }
+
+	trace, err := monitor.TraceServer(context.Background(), "SQL01")
+	require.NoError(t, err)
+
+	assert.False(t, trace.OK())
+	assert.True(t, trace.Stages[1].OK)
+	assert.False(t, trace.Stages[2].OK)
+	assert.NotContains(t, trace.Stages[2].Error, "s3cret")
+	for _, s := range trace.Stages[3:] {
+		assert.True(t, s.Skipped, "stage %s", s.Stage)
+	}
+	db.AssertNotCalled(t, "QueryFailedJobs", mock.Anything, mock.Anything)
+	db.AssertCalled(t, "Close")
+}
+
+func TestTraceServer_ConnectFailure(t *testing.T) {
+	monitor := NewMonitor(traceConfig())
+	monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) {
+		return nil, errors.New("invalid connection string")
+	}
+
+	trace, err := monitor.TraceServer(context.Background(), "SQL01")
+	require.NoError(t, err)
+
+	assert.Equal(t, "invalid connection string", trace.Stages[0].Error)
+	assert.True(t, trace.Stages[1].Skipped)
+}
+
+func TestTraceServer_RecoversPanic(t *testing.T) {
+	db := new(MockJobQuerier)
+	db.On("Ping", mock.Anything).Run(func(mock.Arguments) { panic("driver bug") })
+	db.On("Close").Return(nil)
+
+	monitor := NewMonitor(traceConfig())
+	monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) { return db, nil }
+
+	trace, err := monitor.TraceServer(context.Background(), "SQL01")
+	require.NoError(t, err)
+
+	assert.Contains(t, trace.Stages[1].Error, "driver bug")
+	assert.True(t, trace.Stages[2].Skipped)
+}
+
+func TestTraceServer_UnknownServer(t *testing.T) {
+	_, err := NewMonitor(traceConfig()).TraceServer(context.Background(), "NOPE")
+	assert.EqualError(t, err, "server not found: NOPE")
+}
@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"context"
+	"slices"
+
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+// failedStatus is the run_status of a failed run.
+const failedStatus = 0
+
+// persistentRuns is how many failed runs in a row make a failure persistent.
+const persistentRuns = 2
+
+// recentOutcomes returns the latest job outcomes needed to apply
+// monitoring.consecutive_failures and monitoring.classify_failures, or nil
+// if both are off, there is nothing to filter or the history cannot be read.
+// A nil result keeps every failure, so a query problem never hides an alert;
+// it is logged as a warning.
+func (m *Monitor) recentOutcomes(ctx context.Context, db JobQuerier, server string, failed int) map[string][]int {
+	n := m.cfg.Monitoring.ConsecutiveFailures
+	if failed == 0 || (n <= 1 && !m.cfg.Monitoring.ClassifyFailures) {
+		return nil
+	}
+
+	outcomes, err := db.QueryRecentOutcomes(ctx, max(n, persistentRuns))
+	if err != nil {
+		m.logger.Warn().Str("server", server).Err(err).Msg("failed to query job history, reporting every failure")
+		return nil
+	}
+	return outcomes
+}
+
+// filterAndClassify applies monitoring.consecutive_failures to jobs and, if
+// monitoring.classify_failures is set, tags the remaining ones with their
+// persistence. jobs itself is left unchanged.
+func (m *Monitor) filterAndClassify(jobs []database.FailedJob, outcomes map[string][]int) []database.FailedJob {
+	jobs = filterConsecutive(jobs, outcomes, m.cfg.Monitoring.ConsecutiveFailures)
+	if m.cfg.Monitoring.ClassifyFailures && outcomes != nil {
+		jobs = slices.Clone(jobs)
+		classifyPersistence(jobs, outcomes)
+	}
+	return jobs
+}
+
+// filterConsecutive drops failed job outcomes of jobs whose latest runs have
+// not failed at least n times in a row. Step outcomes, statuses other than
+// failed and jobs without history are kept. With nil outcomes, jobs are
+// returned unchanged.
+func filterConsecutive(jobs []database.FailedJob, outcomes map[string][]int, n int) []database.FailedJob {
+	if outcomes == nil || n <= 1 {
+		return jobs
+	}
+
+	var kept []database.FailedJob
+	for _, job := range jobs {
+		if BelowConsecutiveFailures(job, outcomes[job.JobName], n) {
+			continue
+		}
+		kept = append(kept, job)
+	}
+	return kept
+}
+
+// BelowConsecutiveFailures returns true if monitoring.consecutive_failures
+// set to n drops job, given the job's latest outcomes, newest first: a failed
+// job outcome whose latest runs did not fail n times in a row. Step outcomes,
+// statuses other than failed and jobs without history are never dropped.
+func BelowConsecutiveFailures(job database.FailedJob, history []int, n int) bool {
+	return n > 1 && len(history) > 0 && job.StepID == 0 && job.Status == failedStatus && consecutiveFailures(history) < n
+}
+
+// consecutiveFailures returns how many of the outcomes, newest first, failed
+// in a row before the first run that did not fail.
+func consecutiveFailures(outcomes []int) int {
+	count := 0
+	for _, status := range outcomes {
+		if status != failedStatus {
+			break
+		}
+		count++
+	}
+	return count
+}
+
+// classifyPersistence tags failed job outcomes as persistent if the job's
+// latest runs failed persistentRuns times in a row, transient otherwise.
+// Jobs without history, step outcomes and statuses other than failed are
+// left untagged.
+func classifyPersistence(jobs []database.FailedJob, outcomes map[string][]int) {
+	for i := range jobs {
+		history, ok := outcomes[jobs[i].JobName]
+		if !ok || len(history) == 0 || jobs[i].StepID != 0 || jobs[i].Status != failedStatus {
+			continue
+		}
+		jobs[i].Persistence = persistence(history)
+	}
+}
+
+// persistence returns the persistence of a failure given the job's
+// outcomes, newest first.
+func persistence(outcomes []int) string {
+	if consecutiveFailures(outcomes) >= persistentRuns {
+		return database.PersistencePersistent
+	}
+	return database.PersistenceTransient
+}
@@ -0,0 +1,236 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+func TestConsecutiveFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		outcomes []int
+		want     int
+	}{
+		{name: "no history", outcomes: nil, want: 0},
+		{name: "single failure", outcomes: []int{0, 1, 1}, want: 1},
+		{name: "three in a row", outcomes: []int{0, 0, 0}, want: 3},
+		{name: "streak broken by success", outcomes: []int{0, 0, 1, 0, 0}, want: 2},
+		{name: "latest run succeeded", outcomes: []int{1, 0, 0}, want: 0},
+		{name: "cancelled breaks the streak", outcomes: []int{0, 3, 0}, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, consecutiveFailures(tt.outcomes))
+		})
+	}
+}
+
+func TestFilterConsecutive(t *testing.T) {
+	failed := []database.FailedJob{
+		{JobName: "Flaky"},
+		{JobName: "Broken"},
+		{JobName: "Broken"},
+		{JobName: "Flaky", StepID: 2, StepName: "Load"},
+		{JobName: "Flaky", Status: 3},
+		{JobName: "NoHistory"},
+	}
+	outcomes := map[string][]int{
+		"Flaky":  {0, 1, 1},
+		"Broken": {0, 0, 0},
+	}
+
+	got := filterConsecutive(failed, outcomes, 3)
+	assert.Equal(t, []database.FailedJob{
+		{JobName: "Broken"},
+		{JobName: "Broken"},
+		{JobName: "Flaky", StepID: 2, StepName: "Load"},
+		{JobName: "Flaky", Status: 3},
+		{JobName: "NoHistory"},
+	}, got, "a job without history is reported")
+
+	assert.Equal(t, failed, filterConsecutive(failed, nil, 3), "nil outcomes keep every job")
+}
+
+func TestClassifyPersistence(t *testing.T) {
+	failed := []database.FailedJob{
+		{JobName: "Flaky"},
+		{JobName: "Broken"},
+		{JobName: "Recovered"},
+		{JobName: "New"},
+		{JobName: "Broken", StepID: 2, StepName: "Load"},
+		{JobName: "Broken", Status: 3},
+	}
+	outcomes := map[string][]int{
+		"Flaky":     {0, 1, 1},
+		"Broken":    {0, 0, 1},
+		"Recovered": {1, 0, 0},
+	}
+
+	classifyPersistence(failed, outcomes)
+	var got []string
+	for _, job := range failed {
+		got = append(got, job.Persistence)
+	}
+	assert.Equal(t, []string{
+		database.PersistenceTransient,
+		database.PersistencePersistent,
+		database.PersistenceTransient, // Failed within the lookback, then succeeded
+		"",                            // No history
+		"",                            // Step outcome
+		"",                            // Not a failure
+	}, got)
+}
+
+func TestCheckAll_ClassifyFailures(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{LookbackHours: 24, ClassifyFailures: true},
+		Servers:    []config.ServerConfig{{Name: "SQL01", Enabled: true}},
+	}
+	db := new(MockJobQuerier)
+	db.On("Ping", mock.Anything).Return(nil)
+	db.On("Close").Return(nil)
+	db.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{
+		{ServerName: "SQL01", JobName: "Broken"},
+		{ServerName: "SQL01", JobName: "Flaky"},
+	}, nil)
+	db.On("QueryRecentOutcomes", mock.Anything, 2).Return(map[string][]int{"Flaky": {0, 1}, "Broken": {0, 0}}, nil)
+
+	monitor := NewMonitor(cfg)
+	monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) {
+		return db, nil
+	}
+
+	result, err := monitor.CheckAll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Broken", "Flaky"}, jobNames(result.FailedJobs), "classifying never drops a failure")
+	assert.Equal(t, database.PersistencePersistent, result.FailedJobs[0].Persistence)
+	assert.Equal(t, database.PersistenceTransient, result.FailedJobs[1].Persistence)
+	db.AssertExpectations(t)
+}
+
+func TestCheckAll_ConsecutiveFailures(t *testing.T) {
+	tests := []struct {
+		name        string
+		outcomes    map[string][]int
+		outcomesErr error
+		wantJobs    []string
+	}{
+		{
+			name:     "one-off failure is ignored",
+			outcomes: map[string][]int{"Flaky": {0, 1, 1}, "Broken": {0, 0, 0}},
+			wantJobs: []string{"Broken"},
+		},
+		{
+			name:        "history error keeps every failure",
+			outcomes:    map[string][]int{},
+			outcomesErr: errors.New("timeout"),
+			wantJobs:    []string{"Broken", "Flaky"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Monitoring: config.MonitoringConfig{LookbackHours: 24, ConsecutiveFailures: 3},
+				Servers:    []config.ServerConfig{{Name: "SQL01", Enabled: true}},
+			}
+			db := new(MockJobQuerier)
+			db.On("Ping", mock.Anything).Return(nil)
+			db.On("Close").Return(nil)
+			db.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{
+				{ServerName: "SQL01", JobName: "Broken"},
+				{ServerName: "SQL01", JobName: "Flaky"},
+			}, nil)
+			db.On("QueryRecentOutcomes", mock.Anything, 3).Return(tt.outcomes, tt.outcomesErr)
+
+			var logs bytes.Buffer
+			monitor := NewMonitor(cfg)
+			monitor.SetLogger(zerolog.New(&logs))
+			monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) {
+				return db, nil
+			}
+
+			result, err := monitor.CheckAll(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantJobs, jobNames(result.FailedJobs))
+			if tt.outcomesErr != nil {
+				assert.Contains(t, logs.String(), "failed to query job history")
+				assert.Contains(t, logs.String(), `"server":"SQL01"`)
+			} else {
+				assert.Empty(t, logs.String())
+			}
+			db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCheckAll_ConsecutiveFailuresDisabled(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{LookbackHours: 24},
+		Servers:    []config.ServerConfig{{Name: "SQL01", Enabled: true}},
+	}
+	db := new(MockJobQuerier)
+	db.On("Ping", mock.Anything).Return(nil)
+	db.On("Close").Return(nil)
+	db.On("QueryFailedJobs", mock.Anything, 24*time.Hour).Return([]database.FailedJob{{ServerName: "SQL01", JobName: "Flaky"}}, nil)
+
+	monitor := NewMonitor(cfg)
+	monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) {
+		return db, nil
+	}
+
+	result, err := monitor.CheckAll(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, result.FailedJobs, 1)
+	db.AssertNotCalled(t, "QueryRecentOutcomes", mock.Anything, mock.Anything)
+}
+
+func TestCheckAll_SharedHostOtherHistoryDatabase(t *testing.T) {
+	cfg := &config.Config{
+		Monitoring: config.MonitoringConfig{
+			LookbackHours:       24,
+			ConsecutiveFailures: 3,
+			BatchSharedHosts:    true,
+		},
+		Servers: []config.ServerConfig{
+			{Name: "PROD", Enabled: true, Host: "sql01", Port: 1433},
+			{Name: "RESTORED", Enabled: true, Host: "sql01", Port: 1433, MsdbDatabase: "msdb_restored"},
+		},
+	}
+
+	db := new(MockJobQuerier)
+	db.On("Ping", mock.Anything).Return(nil)
+	db.On("Close").Return(nil)
+	db.On("QueryFailedJobsShared", mock.Anything, 24*time.Hour, mock.Anything).Return(map[string][]database.FailedJob{
+		"PROD":     {{ServerName: "PROD", JobName: "Flaky"}},
+		"RESTORED": {{ServerName: "RESTORED", JobName: "Flaky"}, {ServerName: "RESTORED", JobName: "Restored_Only"}},
+	}, nil)
+	// History of the connection's database (msdb) only
+	db.On("QueryRecentOutcomes", mock.Anything, 3).Return(map[string][]int{"Flaky": {0, 1, 1}}, nil)
+
+	monitor := NewMonitor(cfg)
+	monitor.dbFactory = func(config.ServerConfig) (JobQuerier, error) {
+		return db, nil
+	}
+
+	result, err := monitor.CheckAll(context.Background())
+	assert.NoError(t, err)
+	// PROD's one-off failure is ignored; RESTORED has no history in msdb,
+	// so its failures are all reported
+	var got []string
+	for _, job := range result.FailedJobs {
+		got = append(got, job.ServerName+"/"+job.JobName)
+	}
+	assert.ElementsMatch(t, []string{"RESTORED/Flaky", "RESTORED/Restored_Only"}, got)
+}
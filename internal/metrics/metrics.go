@@ -0,0 +1,120 @@
+// Package metrics defines the Prometheus metrics Watchman exports and renders
+// them in the text exposition format, e.g. for node_exporter's textfile
+// collector.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+// Metric describes an exported metric.
+type Metric struct {
+	Name string
+	Help string
+	Type string
+}
+
+// Exported metrics.
+var (
+	FailedJobs = Metric{
+		Name: "watchman_failed_jobs",
+		Help: "Failed SQL Agent jobs within the lookback period, per server.",
+		Type: "gauge",
+	}
+	ServerUp = Metric{
+		Name: "watchman_server_up",
+		Help: "Whether the server was reachable during the last check (1) or not (0).",
+		Type: "gauge",
+	}
+	LastCheckTimestamp = Metric{
+		Name: "watchman_last_check_timestamp_seconds",
+		Help: "Unix time of the last check.",
+		Type: "gauge",
+	}
+)
+
+// labelEscaper escapes label values as required by the exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Write renders the result in the Prometheus text exposition format.
+// servers lists the servers that were checked; servers that were unreachable
+// report server_up 0 and no failed_jobs sample.
+func Write(w io.Writer, result *jobs.CheckResult, servers []string) error {
+	failed := make(map[string]int)
+	for _, job := range result.FailedJobs {
+		failed[job.ConfiguredServer()]++
+	}
+
+	names := slices.Clone(servers)
+	for _, name := range result.ServersUnavailable {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	bw := bufio.NewWriter(w)
+	writeHeader(bw, FailedJobs)
+	for _, name := range names {
+		if !slices.Contains(result.ServersUnavailable, name) {
+			fmt.Fprintf(bw, "%s{server=\"%s\"} %d\n", FailedJobs.Name, labelEscaper.Replace(name), failed[name])
+		}
+	}
+
+	writeHeader(bw, ServerUp)
+	for _, name := range names {
+		up := 1
+		if slices.Contains(result.ServersUnavailable, name) {
+			up = 0
+		}
+		fmt.Fprintf(bw, "%s{server=\"%s\"} %d\n", ServerUp.Name, labelEscaper.Replace(name), up)
+	}
+
+	writeHeader(bw, LastCheckTimestamp)
+	fmt.Fprintf(bw, "%s %d\n", LastCheckTimestamp.Name, result.Timestamp.Unix())
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric.
+func writeHeader(w io.Writer, m Metric) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.Name, m.Help, m.Name, m.Type)
+}
+
+// WriteFile writes the metrics to path atomically, so a collector never reads
+// a partial file. The temp file is created next to path since a rename across
+// directories is not atomic.
+func WriteFile(path string, result *jobs.CheckResult, servers []string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer func() {
+		_ = os.Remove(tmpName) // No-op after a successful rename
+	}()
+
+	if err := Write(tmp, result, servers); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("failed to replace metrics file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+func sampleResult() *jobs.CheckResult {
+	return &jobs.CheckResult{
+		Timestamp:          time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC),
+		ServersUnavailable: []string{"SQL03"},
+		FailedJobs: []database.FailedJob{
+			{ServerName: "SQL01", JobName: "Backup"},
+			{ServerName: "SQL01", JobName: "ETL"},
+		},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, sampleResult(), []string{"SQL02", "SQL01", "SQL03"}))
+
+	want := `# HELP watchman_failed_jobs Failed SQL Agent jobs within the lookback period, per server.
+# TYPE watchman_failed_jobs gauge
+watchman_failed_jobs{server="SQL01"} 2
+watchman_failed_jobs{server="SQL02"} 0
+# HELP watchman_server_up Whether the server was reachable during the last check (1) or not (0).
+# TYPE watchman_server_up gauge
+watchman_server_up{server="SQL01"} 1
+watchman_server_up{server="SQL02"} 1
+watchman_server_up{server="SQL03"} 0
+# HELP watchman_last_check_timestamp_seconds Unix time of the last check.
+# TYPE watchman_last_check_timestamp_seconds gauge
+watchman_last_check_timestamp_seconds 1770105600
+`
+	assert.Equal(t, want, buf.String())
+}
+
+func TestWrite_CountsByConfiguredServer(t *testing.T) {
+	result := &jobs.CheckResult{
+		Timestamp: time.Unix(0, 0),
+		FailedJobs: []database.FailedJob{
+			{ServerName: "SQLNODE1", ConfigName: "PROD-SQL01", JobName: "Backup"},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, result, []string{"PROD-SQL01"}))
+	assert.Contains(t, buf.String(), `watchman_failed_jobs{server="PROD-SQL01"} 1`)
+	assert.NotContains(t, buf.String(), "SQLNODE1", "@@SERVERNAME is not a label")
+}
+
+func TestWrite_EscapesLabels(t *testing.T) {
+	result := &jobs.CheckResult{Timestamp: time.Unix(0, 0)}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, result, []string{`HOST\SQL"01`}))
+	assert.Contains(t, buf.String(), `watchman_server_up{server="HOST\\SQL\"01"} 1`)
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "watchman.prom")
+	require.NoError(t, os.WriteFile(path, []byte("stale"), 0o600))
+
+	require.NoError(t, WriteFile(path, sampleResult(), []string{"SQL01"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `watchman_failed_jobs{server="SQL01"} 2`)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temp file must not be left behind")
+}
+
+func TestWriteFile_MissingDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "watchman.prom")
+	assert.Error(t, WriteFile(path, sampleResult(), nil))
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+// contentType is the media type of the text exposition format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Push sends the metrics to a Prometheus Pushgateway under the given job
+// label. PUT replaces every metric previously pushed for the job, so servers
+// that are no longer checked do not linger.
+func Push(ctx context.Context, client *http.Client, gateway, job string, result *jobs.CheckResult, servers []string) error {
+	var body bytes.Buffer
+	if err := Write(&body, result, servers); err != nil {
+		return err
+	}
+
+	target := strings.TrimRight(gateway, "/") + "/metrics/job/" + url.PathEscape(job)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("pushgateway returned unexpected status: %s", resp.Status)
+	}
+	return nil
+}
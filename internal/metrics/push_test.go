@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPush(t *testing.T) {
+	var method, path, ctype, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, ctype = r.Method, r.URL.EscapedPath(), r.Header.Get("Content-Type")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	err := Push(context.Background(), srv.Client(), srv.URL+"/", "Watchman Prod", sampleResult(), []string{"SQL01", "SQL02", "SQL03"})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/metrics/job/Watchman%20Prod", path)
+	assert.Equal(t, contentType, ctype)
+	assert.Contains(t, body, "# TYPE watchman_failed_jobs gauge\n")
+	assert.Contains(t, body, `watchman_failed_jobs{server="SQL01"} 2`)
+	assert.Contains(t, body, `watchman_server_up{server="SQL03"} 0`)
+	assert.Contains(t, body, "watchman_last_check_timestamp_seconds 1770105600\n")
+}
+
+func TestPush_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "bad metrics", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	err := Push(context.Background(), srv.Client(), srv.URL, "watchman", sampleResult(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400")
+}
+
+func TestPush_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	assert.Error(t, Push(context.Background(), http.DefaultClient, url, "watchman", sampleResult(), nil))
+}
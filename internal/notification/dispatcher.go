@@ -0,0 +1,578 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+// Backend defines a notification channel (toast, email, webhook, ...).
+type Backend interface {
+	// Name returns the backend identifier used in logs and errors.
+	Name() string
+	// Send delivers a notification for the check result.
+	Send(ctx context.Context, result *jobs.CheckResult) error
+}
+
+// ServerDownNotifier is implemented by backends that can alert on unreachable servers.
+type ServerDownNotifier interface {
+	NotifyServerDown(ctx context.Context, servers []string) error
+}
+
+// defaultMaxConcurrentSends is used when no send limit is configured.
+const defaultMaxConcurrentSends = 4
+
+// DefaultMutePath returns the path of the mute file.
+// While the file exists, no notifications are sent.
+func DefaultMutePath() string {
+	return filepath.Join(state.DefaultDir(), "mute")
+}
+
+// Dispatcher fans out check results to all enabled backends.
+type Dispatcher struct {
+	backends    []Backend
+	mutePath    string
+	quietHours  config.QuietHoursConfig
+	digest      bool
+	serverDown  bool
+	maxSends    int
+	minSendGap  time.Duration
+	location    *time.Location
+	store       *state.Store
+	outbox      *Outbox
+	monitorOnly map[string]bool // Servers never notified about
+	now         func() time.Time
+	mu          sync.Mutex
+}
+
+// NewDispatcher creates a dispatcher with the backends enabled in cfg.
+// Notifications deferred during quiet hours are queued in store, and failed
+// sends are queued in an outbox next to it if notification.outbox is enabled.
+func NewDispatcher(cfg *config.Config, store *state.Store) *Dispatcher {
+	loc, err := cfg.GetLocation()
+	if err != nil {
+		loc = time.Local
+	}
+
+	var backends []Backend
+	if cfg.Notification.Toast.Enabled {
+		notifier := NewNotifier(cfg.Notification)
+		notifier.SetDisplayNames(cfg.Servers)
+		backends = append(backends, notifier)
+	}
+	if cfg.Notification.Pipe.Name != "" {
+		backends = append(backends, NewPipeBackend(cfg.Notification.Pipe))
+	}
+
+	monitorOnly := make(map[string]bool)
+	for _, server := range cfg.Servers {
+		if server.MonitorOnly {
+			monitorOnly[server.Name] = true
+		}
+	}
+
+	var outbox *Outbox
+	if o := cfg.Notification.Outbox; o.Enabled && store != nil {
+		path := filepath.Join(filepath.Dir(store.Path()), "outbox.jsonl")
+		outbox = NewOutbox(path, time.Duration(o.MaxAgeHours)*time.Hour)
+	}
+
+	return &Dispatcher{
+		backends:    backends,
+		mutePath:    DefaultMutePath(),
+		quietHours:  cfg.Notification.QuietHours,
+		digest:      cfg.Notification.Mode == config.NotificationModeDigest,
+		serverDown:  cfg.Notification.NotifyServerDown,
+		maxSends:    cfg.Notification.MaxConcurrentSends,
+		minSendGap:  time.Duration(cfg.Notification.MinIntervalBetweenSends) * time.Second,
+		location:    loc,
+		store:       store,
+		outbox:      outbox,
+		monitorOnly: monitorOnly,
+		now:         time.Now,
+	}
+}
+
+// Backends returns the enabled backends.
+func (d *Dispatcher) Backends() []Backend {
+	return d.backends
+}
+
+// Close closes the backends holding resources, such as the named pipe of the
+// pipe backend. Backend errors are joined.
+func (d *Dispatcher) Close() error {
+	var errs []error
+	for _, b := range d.backends {
+		if c, ok := b.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", b.Name(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DigestMode returns true if failed jobs are accumulated for a daily digest
+// instead of being sent after each check.
+func (d *Dispatcher) DigestMode() bool {
+	return d.digest && d.store != nil
+}
+
+// DefersSends returns true if quiet hours or the send throttle may queue
+// failed jobs, so FlushPending must run periodically to send them.
+func (d *Dispatcher) DefersSends() bool {
+	return d.store != nil && (d.quietHours.Enabled || d.minSendGap > 0)
+}
+
+// Deferring returns true if failed jobs dispatched now would be queued
+// because of quiet hours or the send throttle rather than sent.
+func (d *Dispatcher) Deferring() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.inQuietHours() {
+		return true
+	}
+	throttled, err := d.throttled()
+	return err == nil && throttled
+}
+
+// Muted returns true if the mute file exists.
+func (d *Dispatcher) Muted() bool {
+	if d.mutePath == "" {
+		return false
+	}
+	_, err := os.Stat(d.mutePath)
+	return err == nil
+}
+
+// Dispatch sends the check result to every backend.
+// A failing backend does not prevent delivery through the others;
+// all backend errors are joined and returned.
+// During quiet hours the failed jobs are queued instead, and any queued
+// jobs are included with the next result dispatched after the window.
+// In digest mode the failed jobs are always queued for SendDigest.
+// Within notification.min_interval_between_sends of the last notification
+// the failed jobs are queued as well and coalesced into the next one.
+// Failed jobs of monitor-only servers are left out.
+// Nothing is sent or queued while muted.
+func (d *Dispatcher) Dispatch(ctx context.Context, result *jobs.CheckResult) error {
+	if result == nil || d.Muted() {
+		return nil
+	}
+	result = d.withoutMonitorOnly(result)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.DigestMode() || d.inQuietHours() {
+		return d.deferJobs(result.FailedJobs)
+	}
+	throttled, err := d.throttled()
+	if err != nil {
+		return err
+	}
+	if throttled {
+		return d.deferJobs(result.FailedJobs)
+	}
+
+	pending, err := d.peekPending()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		digest := *result
+		digest.FailedJobs = mergeFailedJobs(pending, result.FailedJobs)
+		result = &digest
+	}
+
+	// Warn-only jobs (monitoring.warn_statuses) are never notified
+	if !result.HasFailedJobs() {
+		return nil
+	}
+	return d.sendPending(ctx, result, pending)
+}
+
+// FlushPending sends jobs deferred during quiet hours or by the send
+// throttle as a single digest. It does nothing while quiet hours or the
+// throttle are still in effect, while muted or when nothing is queued.
+// In digest mode the queue is left for SendDigest. The jobs stay queued
+// until they are sent.
+func (d *Dispatcher) FlushPending(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.DigestMode() || d.inQuietHours() || d.Muted() {
+		return nil
+	}
+	if throttled, err := d.throttled(); err != nil || throttled {
+		return err
+	}
+
+	pending, err := d.peekPending()
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	return d.sendPending(ctx, &jobs.CheckResult{
+		Status:     "failed_jobs",
+		Timestamp:  d.now(),
+		FailedJobs: pending,
+		Summary:    fmt.Sprintf("%d deferred failed jobs", len(pending)),
+	}, pending)
+}
+
+// SendDigest sends the failed jobs accumulated since the last digest as a
+// single summary. It does nothing while muted, during quiet hours or when
+// nothing is queued; the jobs stay queued for the next digest until they
+// are sent.
+func (d *Dispatcher) SendDigest(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.inQuietHours() || d.Muted() {
+		return nil
+	}
+
+	pending, err := d.peekPending()
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	servers := make(map[string]struct{})
+	for _, job := range pending {
+		servers[job.ServerName] = struct{}{}
+	}
+	return d.sendPending(ctx, &jobs.CheckResult{
+		Status:     "failed_jobs",
+		Timestamp:  d.now(),
+		FailedJobs: pending,
+		Summary:    fmt.Sprintf("Daily digest: %d failed jobs on %d servers", len(pending), len(servers)),
+	}, pending)
+}
+
+// NotifyServersDown alerts about servers that are unreachable in result, if enabled.
+// Each outage is alerted once: a server is not alerted again until it has been
+// seen reachable. An outage is recorded only once its alert was sent, so a
+// failed send is retried after the next check. Monitor-only servers are never
+// alerted. Nothing is sent while muted or during quiet hours.
+func (d *Dispatcher) NotifyServersDown(ctx context.Context, result *jobs.CheckResult) error {
+	if result == nil || !d.serverDown || d.Muted() {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.inQuietHours() {
+		return nil
+	}
+
+	down := result.ServersUnavailable
+	if d.store != nil {
+		var err error
+		down, err = d.newlyDown(result)
+		if err != nil {
+			return err
+		}
+	}
+	down = slices.DeleteFunc(slices.Clone(down), func(server string) bool {
+		return d.monitorOnly[server]
+	})
+	if len(down) == 0 {
+		return nil
+	}
+
+	err := d.fanOut(func(b Backend) error {
+		if n, ok := b.(ServerDownNotifier); ok {
+			return n.NotifyServerDown(ctx, down)
+		}
+		return nil
+	})
+	if err != nil || d.store == nil {
+		return err
+	}
+	return d.recordDown(down)
+}
+
+// newlyDown returns the servers unreachable in result whose outage was not
+// alerted yet. Servers reachable in result are cleared so a later outage is
+// alerted again.
+func (d *Dispatcher) newlyDown(result *jobs.CheckResult) ([]string, error) {
+	var down []string
+	err := d.store.Update(func(st *state.State) error {
+		for server := range result.ServerQueryLatencyMs {
+			delete(st.DownServers, server)
+		}
+		for _, server := range result.ServersUnavailable {
+			if _, alerted := st.DownServers[server]; !alerted {
+				down = append(down, server)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update server outages: %w", err)
+	}
+	return down, nil
+}
+
+// recordDown records the outages of servers as alerted.
+func (d *Dispatcher) recordDown(servers []string) error {
+	err := d.store.Update(func(st *state.State) error {
+		if st.DownServers == nil {
+			st.DownServers = make(map[string]time.Time)
+		}
+		for _, server := range servers {
+			st.DownServers[server] = d.now()
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record server outages: %w", err)
+	}
+	return nil
+}
+
+// RetryOutbox resends queued sends that failed earlier, keeping the ones that
+// fail again until they expire. Entries for backends that are no longer
+// enabled are dropped. Nothing is sent while muted or during quiet hours.
+// It returns the number of sends delivered.
+func (d *Dispatcher) RetryOutbox(ctx context.Context) (int, error) {
+	if d.outbox == nil || d.Muted() {
+		return 0, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.inQuietHours() {
+		return 0, nil
+	}
+
+	backends := make(map[string]Backend, len(d.backends))
+	for _, b := range d.backends {
+		backends[b.Name()] = b
+	}
+
+	return d.outbox.Drain(d.now(), func(entry OutboxEntry) error {
+		b, ok := backends[entry.Backend]
+		if !ok {
+			return nil
+		}
+		return b.Send(ctx, entry.Result)
+	})
+}
+
+// send delivers the result through every backend.
+// Failed sends of remote backends are queued in the outbox for retry.
+// It reports whether every backend delivered the result or queued it.
+func (d *Dispatcher) send(ctx context.Context, result *jobs.CheckResult) (bool, error) {
+	if err := d.recordSend(); err != nil {
+		return false, err
+	}
+	var lost atomic.Bool
+	err := d.fanOut(func(b Backend) error {
+		err := b.Send(ctx, result)
+		if err == nil {
+			return nil
+		}
+		if d.outbox == nil || isLocal(b) {
+			lost.Store(true)
+			return err
+		}
+
+		entry := OutboxEntry{Backend: b.Name(), EnqueuedAt: d.now(), Result: result}
+		if qErr := d.outbox.Enqueue(entry); qErr != nil {
+			lost.Store(true)
+			return errors.Join(err, qErr)
+		}
+		return fmt.Errorf("%w (queued for retry)", err)
+	})
+	return !lost.Load(), err
+}
+
+// sendPending sends result, which includes the queued jobs pending, and
+// removes them from the queue once every backend delivered or queued the
+// result. Otherwise they stay queued for the next send.
+func (d *Dispatcher) sendPending(ctx context.Context, result *jobs.CheckResult, pending []database.FailedJob) error {
+	sent, err := d.send(ctx, result)
+	if sent && len(pending) > 0 {
+		err = errors.Join(err, d.clearPending(pending))
+	}
+	return err
+}
+
+// throttled returns true if the last notification was sent less than
+// min_interval_between_sends ago.
+func (d *Dispatcher) throttled() (bool, error) {
+	if d.minSendGap <= 0 || d.store == nil {
+		return false, nil
+	}
+	st, err := d.store.Load()
+	if err != nil {
+		return false, fmt.Errorf("failed to load last notification time: %w", err)
+	}
+	return !st.LastNotificationSent.IsZero() && d.now().Sub(st.LastNotificationSent) < d.minSendGap, nil
+}
+
+// recordSend records the time of a notification for the send throttle.
+// A send counts even if a backend fails, since the others may have delivered.
+func (d *Dispatcher) recordSend() error {
+	if d.minSendGap <= 0 || d.store == nil {
+		return nil
+	}
+	err := d.store.Update(func(st *state.State) error {
+		st.LastNotificationSent = d.now()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record notification time: %w", err)
+	}
+	return nil
+}
+
+// withoutMonitorOnly returns result without the failed jobs of monitor-only
+// servers. result itself is left unchanged.
+func (d *Dispatcher) withoutMonitorOnly(result *jobs.CheckResult) *jobs.CheckResult {
+	if len(d.monitorOnly) == 0 {
+		return result
+	}
+	filtered := *result
+	filtered.FailedJobs = slices.DeleteFunc(slices.Clone(result.FailedJobs), func(job database.FailedJob) bool {
+		return d.monitorOnly[job.ConfiguredServer()]
+	})
+	return &filtered
+}
+
+// isLocal returns true if b delivers on this machine.
+func isLocal(b Backend) bool {
+	l, ok := b.(LocalBackend)
+	return ok && l.Local()
+}
+
+// fanOut calls fn for every backend in parallel, running at most maxSends at once.
+// Errors are joined in backend order.
+func (d *Dispatcher) fanOut(fn func(b Backend) error) error {
+	limit := d.maxSends
+	if limit <= 0 {
+		limit = defaultMaxConcurrentSends
+	}
+
+	sem := make(chan struct{}, limit)
+	errs := make([]error, len(d.backends))
+	var wg sync.WaitGroup
+	for i, b := range d.backends {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(b); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", b.Name(), err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// inQuietHours returns true if the current time falls within quiet hours.
+func (d *Dispatcher) inQuietHours() bool {
+	if d.store == nil {
+		return false
+	}
+
+	now := d.now()
+	if d.location != nil {
+		now = now.In(d.location)
+	}
+	return d.quietHours.Covers(now.Format("15:04"))
+}
+
+// deferJobs queues failed jobs in state until quiet hours end.
+func (d *Dispatcher) deferJobs(failed []database.FailedJob) error {
+	if len(failed) == 0 {
+		return nil
+	}
+
+	err := d.store.Update(func(st *state.State) error {
+		st.PendingNotifications = mergeFailedJobs(st.PendingNotifications, failed)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to defer notification: %w", err)
+	}
+	return nil
+}
+
+// peekPending returns the queued failed jobs, leaving them queued.
+func (d *Dispatcher) peekPending() ([]database.FailedJob, error) {
+	if d.store == nil {
+		return nil, nil
+	}
+
+	st, err := d.store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load deferred notifications: %w", err)
+	}
+	return st.PendingNotifications, nil
+}
+
+// clearPending removes sent from the queued failed jobs. Jobs queued since
+// they were read are kept.
+func (d *Dispatcher) clearPending(sent []database.FailedJob) error {
+	keys := make(map[string]bool, len(sent))
+	for _, job := range sent {
+		keys[failedJobKey(job)] = true
+	}
+
+	err := d.store.Update(func(st *state.State) error {
+		st.PendingNotifications = slices.DeleteFunc(st.PendingNotifications, func(job database.FailedJob) bool {
+			return keys[failedJobKey(job)]
+		})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clear deferred notifications: %w", err)
+	}
+	return nil
+}
+
+// mergeFailedJobs appends b to a, skipping jobs already present.
+// A job is identified by server, name, step and failure time.
+func mergeFailedJobs(a, b []database.FailedJob) []database.FailedJob {
+	seen := make(map[string]bool, len(a)+len(b))
+	merged := make([]database.FailedJob, 0, len(a)+len(b))
+
+	for _, list := range [][]database.FailedJob{a, b} {
+		for _, job := range list {
+			key := failedJobKey(job)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, job)
+		}
+	}
+
+	return merged
+}
+
+// failedJobKey identifies a failed job by server, name, step and failure time.
+func failedJobKey(job database.FailedJob) string {
+	return fmt.Sprintf("%s|%s|%d|%s", job.ServerName, job.JobName, job.StepID, job.FailedAt.UTC().Format(time.RFC3339))
+}
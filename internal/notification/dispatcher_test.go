@@ -0,0 +1,795 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+// fakeBackend records the results it receives.
+type fakeBackend struct {
+	name     string
+	err      error
+	received []*jobs.CheckResult
+}
+
+func (f *fakeBackend) Name() string { return f.name }
+
+func (f *fakeBackend) Send(ctx context.Context, result *jobs.CheckResult) error {
+	f.received = append(f.received, result)
+	return f.err
+}
+
+func TestNewDispatcher(t *testing.T) {
+	tests := []struct {
+		name         string
+		toastEnabled bool
+		wantBackends []string
+	}{
+		{name: "toast enabled", toastEnabled: true, wantBackends: []string{"toast"}},
+		{name: "toast disabled", toastEnabled: false, wantBackends: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Notification: config.NotificationConfig{
+				AppID: "TestApp",
+				Toast: config.BackendConfig{Enabled: tt.toastEnabled},
+			}}
+			d := NewDispatcher(cfg, nil)
+
+			var names []string
+			for _, b := range d.Backends() {
+				names = append(names, b.Name())
+			}
+			assert.Equal(t, tt.wantBackends, names)
+		})
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	result := &jobs.CheckResult{
+		FailedJobs: []database.FailedJob{{ServerName: "S1", JobName: "J1"}},
+	}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   bool
+		errSubstr []string
+	}{
+		{
+			name: "all backends succeed",
+			errs: []error{nil, nil, nil},
+		},
+		{
+			name:      "one backend fails",
+			errs:      []error{nil, errors.New("smtp down"), nil},
+			wantErr:   true,
+			errSubstr: []string{"backend1: smtp down"},
+		},
+		{
+			name:      "multiple backends fail",
+			errs:      []error{errors.New("timeout"), nil, errors.New("bad token")},
+			wantErr:   true,
+			errSubstr: []string{"backend0: timeout", "backend2: bad token"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backends := make([]*fakeBackend, len(tt.errs))
+			d := &Dispatcher{}
+			for i, err := range tt.errs {
+				backends[i] = &fakeBackend{name: "backend" + string(rune('0'+i)), err: err}
+				d.backends = append(d.backends, backends[i])
+			}
+
+			err := d.Dispatch(context.Background(), result)
+			if tt.wantErr {
+				assert.Error(t, err)
+				for _, sub := range tt.errSubstr {
+					assert.Contains(t, err.Error(), sub)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			// Every backend receives the result even if another one failed
+			for _, b := range backends {
+				assert.Len(t, b.received, 1)
+				assert.Same(t, result, b.received[0])
+			}
+		})
+	}
+}
+
+func TestDispatch_WarnJobsOnly(t *testing.T) {
+	backend := &fakeBackend{name: "toast"}
+	d := &Dispatcher{backends: []Backend{backend}}
+
+	result := &jobs.CheckResult{
+		Status:     "success",
+		FailedJobs: []database.FailedJob{},
+		WarnJobs:   []database.FailedJob{{ServerName: "S1", JobName: "Reindex", Status: 3}},
+	}
+	require.NoError(t, d.Dispatch(context.Background(), result))
+	assert.Empty(t, backend.received, "warn-only jobs must not notify")
+
+	result.FailedJobs = []database.FailedJob{{ServerName: "S1", JobName: "Backup"}}
+	require.NoError(t, d.Dispatch(context.Background(), result))
+	require.Len(t, backend.received, 1)
+	assert.Len(t, backend.received[0].FailedJobs, 1)
+}
+
+func TestDispatch_MonitorOnly(t *testing.T) {
+	backend := &fakeBackend{name: "toast"}
+	d := NewDispatcher(&config.Config{Servers: []config.ServerConfig{
+		{Name: "PROD", Enabled: true},
+		{Name: "STAGING", Enabled: true, MonitorOnly: true},
+	}}, nil)
+	d.backends = []Backend{backend}
+	d.mutePath = ""
+
+	result := &jobs.CheckResult{FailedJobs: []database.FailedJob{
+		{ServerName: "STAGING", JobName: "Load"},
+		{ServerName: "PROD", JobName: "Backup"},
+	}}
+	require.NoError(t, d.Dispatch(context.Background(), result))
+	require.Len(t, backend.received, 1)
+	assert.Equal(t, []database.FailedJob{{ServerName: "PROD", JobName: "Backup"}}, backend.received[0].FailedJobs)
+	assert.Len(t, result.FailedJobs, 2, "the check result keeps monitor-only failures")
+
+	backend.received = nil
+	result.FailedJobs = result.FailedJobs[:1]
+	require.NoError(t, d.Dispatch(context.Background(), result))
+	assert.Empty(t, backend.received, "monitor-only failures alone send nothing")
+}
+
+func TestDispatch_MonitorOnlyByConfiguredName(t *testing.T) {
+	backend := &fakeBackend{name: "toast"}
+	d := NewDispatcher(&config.Config{Servers: []config.ServerConfig{
+		{Name: "PROD-SQL01", Enabled: true},
+		{Name: "PROD-SQL01-RO", Enabled: true, MonitorOnly: true},
+	}}, nil)
+	d.backends = []Backend{backend}
+	d.mutePath = ""
+
+	// Both configured servers reach the same instance, which reports its own
+	// @@SERVERNAME
+	result := &jobs.CheckResult{FailedJobs: []database.FailedJob{
+		{ServerName: "SQLNODE1", ConfigName: "PROD-SQL01-RO", JobName: "Load"},
+		{ServerName: "SQLNODE1", ConfigName: "PROD-SQL01", JobName: "Backup"},
+	}}
+	require.NoError(t, d.Dispatch(context.Background(), result))
+	require.Len(t, backend.received, 1)
+	assert.Equal(t, []database.FailedJob{{ServerName: "SQLNODE1", ConfigName: "PROD-SQL01", JobName: "Backup"}},
+		backend.received[0].FailedJobs)
+}
+
+// closingBackend is a backend that records being closed.
+type closingBackend struct {
+	fakeBackend
+	closed bool
+}
+
+func (c *closingBackend) Close() error {
+	c.closed = true
+	return c.err
+}
+
+func TestDispatcher_Close(t *testing.T) {
+	pipe := &closingBackend{fakeBackend: fakeBackend{name: "pipe"}}
+	broken := &closingBackend{fakeBackend: fakeBackend{name: "broken", err: errors.New("busy")}}
+	d := &Dispatcher{backends: []Backend{&fakeBackend{name: "toast"}, pipe, broken}}
+
+	err := d.Close()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "broken: busy")
+	assert.True(t, pipe.closed)
+	assert.True(t, broken.closed)
+}
+
+func TestDispatch_Muted(t *testing.T) {
+	mutePath := filepath.Join(t.TempDir(), "mute")
+	b := &fakeBackend{name: "fake"}
+	d := &Dispatcher{backends: []Backend{b}, mutePath: mutePath}
+	result := &jobs.CheckResult{FailedJobs: []database.FailedJob{{ServerName: "S1", JobName: "J1"}}}
+
+	require.NoError(t, os.WriteFile(mutePath, nil, 0o600))
+	assert.True(t, d.Muted())
+	assert.NoError(t, d.Dispatch(context.Background(), result))
+	assert.Empty(t, b.received, "nothing is sent while muted")
+
+	require.NoError(t, os.Remove(mutePath))
+	assert.False(t, d.Muted())
+	assert.NoError(t, d.Dispatch(context.Background(), result))
+	assert.Len(t, b.received, 1)
+}
+
+func TestDispatch_NilResult(t *testing.T) {
+	b := &fakeBackend{name: "fake"}
+	d := &Dispatcher{backends: []Backend{b}}
+
+	assert.NoError(t, d.Dispatch(context.Background(), nil))
+	assert.Empty(t, b.received)
+}
+
+// newQuietDispatcher returns a dispatcher with 22:00-07:00 quiet hours and a controllable clock.
+func newQuietDispatcher(t *testing.T, now *time.Time) (*Dispatcher, *fakeBackend, *state.Store) {
+	t.Helper()
+	b := &fakeBackend{name: "fake"}
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	d := &Dispatcher{
+		backends:   []Backend{b},
+		quietHours: config.QuietHoursConfig{Enabled: true, Start: "22:00", End: "07:00"},
+		location:   time.UTC,
+		store:      store,
+		now:        func() time.Time { return *now },
+	}
+	return d, b, store
+}
+
+func TestInQuietHours(t *testing.T) {
+	tests := []struct {
+		name  string
+		start string
+		end   string
+		at    string
+		want  bool
+	}{
+		{name: "before overnight window", start: "22:00", end: "07:00", at: "21:59", want: false},
+		{name: "start of overnight window", start: "22:00", end: "07:00", at: "22:00", want: true},
+		{name: "after midnight", start: "22:00", end: "07:00", at: "03:30", want: true},
+		{name: "end is exclusive", start: "22:00", end: "07:00", at: "07:00", want: false},
+		{name: "inside daytime window", start: "12:00", end: "13:00", at: "12:30", want: true},
+		{name: "outside daytime window", start: "12:00", end: "13:00", at: "13:30", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock, _ := time.Parse("15:04", tt.at)
+			d := &Dispatcher{
+				quietHours: config.QuietHoursConfig{Enabled: true, Start: tt.start, End: tt.end},
+				location:   time.UTC,
+				store:      state.NewStore(filepath.Join(t.TempDir(), "state.json")),
+				now:        func() time.Time { return clock },
+			}
+			assert.Equal(t, tt.want, d.inQuietHours())
+		})
+	}
+}
+
+func TestDispatch_QuietHoursDefers(t *testing.T) {
+	now := time.Date(2026, 2, 3, 23, 0, 0, 0, time.UTC)
+	d, b, store := newQuietDispatcher(t, &now)
+
+	job1 := database.FailedJob{ServerName: "S1", JobName: "J1", FailedAt: now.Add(-time.Hour)}
+	job2 := database.FailedJob{ServerName: "S1", JobName: "J2", FailedAt: now}
+
+	require.NoError(t, d.Dispatch(context.Background(), &jobs.CheckResult{FailedJobs: []database.FailedJob{job1}}))
+	// The same failure reported again must not be queued twice
+	require.NoError(t, d.Dispatch(context.Background(), &jobs.CheckResult{FailedJobs: []database.FailedJob{job1, job2}}))
+	assert.Empty(t, b.received, "nothing should be sent during quiet hours")
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Len(t, st.PendingNotifications, 2)
+
+	// Still quiet: flushing is a no-op
+	require.NoError(t, d.FlushPending(context.Background()))
+	assert.Empty(t, b.received)
+}
+
+func TestFlushPending_DigestAfterQuietHours(t *testing.T) {
+	now := time.Date(2026, 2, 3, 23, 0, 0, 0, time.UTC)
+	d, b, store := newQuietDispatcher(t, &now)
+
+	jobsDuringNight := []database.FailedJob{
+		{ServerName: "S1", JobName: "J1", FailedAt: now},
+		{ServerName: "S2", JobName: "J2", FailedAt: now.Add(time.Hour)},
+	}
+	for _, job := range jobsDuringNight {
+		require.NoError(t, d.Dispatch(context.Background(), &jobs.CheckResult{FailedJobs: []database.FailedJob{job}}))
+	}
+
+	now = time.Date(2026, 2, 4, 7, 1, 0, 0, time.UTC)
+	require.NoError(t, d.FlushPending(context.Background()))
+
+	require.Len(t, b.received, 1, "deferred jobs are sent as a single digest")
+	assert.Equal(t, jobsDuringNight, b.received[0].FailedJobs)
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Empty(t, st.PendingNotifications)
+
+	// Nothing left to flush
+	require.NoError(t, d.FlushPending(context.Background()))
+	assert.Len(t, b.received, 1)
+}
+
+func TestFlushPending_KeepsQueueWhenSendFails(t *testing.T) {
+	now := time.Date(2026, 2, 3, 23, 0, 0, 0, time.UTC)
+	d, b, store := newQuietDispatcher(t, &now)
+
+	night := database.FailedJob{ServerName: "S1", JobName: "Night", FailedAt: now}
+	require.NoError(t, d.Dispatch(context.Background(), &jobs.CheckResult{FailedJobs: []database.FailedJob{night}}))
+
+	now = time.Date(2026, 2, 4, 7, 1, 0, 0, time.UTC)
+	b.err = errors.New("toast unavailable")
+	require.Error(t, d.FlushPending(context.Background()))
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []database.FailedJob{night}, st.PendingNotifications, "a failed send must not drop deferred jobs")
+
+	// The next flush delivers them and empties the queue
+	b.err = nil
+	require.NoError(t, d.FlushPending(context.Background()))
+	require.Len(t, b.received, 2)
+	assert.Equal(t, []database.FailedJob{night}, b.received[1].FailedJobs)
+
+	st, err = store.Load()
+	require.NoError(t, err)
+	assert.Empty(t, st.PendingNotifications)
+}
+
+func TestDispatch_KeepsPendingWhenSendFails(t *testing.T) {
+	now := time.Date(2026, 2, 3, 23, 0, 0, 0, time.UTC)
+	d, b, store := newQuietDispatcher(t, &now)
+
+	night := database.FailedJob{ServerName: "S1", JobName: "Night", FailedAt: now}
+	require.NoError(t, d.Dispatch(context.Background(), &jobs.CheckResult{FailedJobs: []database.FailedJob{night}}))
+
+	now = time.Date(2026, 2, 4, 8, 0, 0, 0, time.UTC)
+	b.err = errors.New("toast unavailable")
+	morning := database.FailedJob{ServerName: "S1", JobName: "Morning", FailedAt: now}
+	require.Error(t, d.Dispatch(context.Background(), &jobs.CheckResult{FailedJobs: []database.FailedJob{morning}}))
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []database.FailedJob{night}, st.PendingNotifications)
+}
+
+func TestFlushPending_QueuedInOutboxClearsQueue(t *testing.T) {
+	now := time.Date(2026, 2, 4, 7, 1, 0, 0, time.UTC)
+	dir := t.TempDir()
+	store := state.NewStore(filepath.Join(dir, "state.json"))
+	b := &fakeBackend{name: "webhook", err: errors.New("503 Service Unavailable")}
+	d := &Dispatcher{
+		backends: []Backend{b},
+		store:    store,
+		outbox:   NewOutbox(filepath.Join(dir, "outbox.jsonl"), time.Hour),
+		now:      func() time.Time { return now },
+	}
+
+	job := database.FailedJob{ServerName: "S1", JobName: "Night", FailedAt: now.Add(-time.Hour)}
+	require.NoError(t, d.deferJobs([]database.FailedJob{job}))
+	require.Error(t, d.FlushPending(context.Background()))
+
+	// The outbox retries the send, so the jobs are not queued twice
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Empty(t, st.PendingNotifications)
+}
+
+func TestDispatch_IncludesPendingAfterQuietHours(t *testing.T) {
+	now := time.Date(2026, 2, 3, 23, 0, 0, 0, time.UTC)
+	d, b, _ := newQuietDispatcher(t, &now)
+
+	night := database.FailedJob{ServerName: "S1", JobName: "Night", FailedAt: now}
+	require.NoError(t, d.Dispatch(context.Background(), &jobs.CheckResult{FailedJobs: []database.FailedJob{night}}))
+
+	now = time.Date(2026, 2, 4, 8, 0, 0, 0, time.UTC)
+	morning := database.FailedJob{ServerName: "S1", JobName: "Morning", FailedAt: now}
+	result := &jobs.CheckResult{FailedJobs: []database.FailedJob{morning}}
+	require.NoError(t, d.Dispatch(context.Background(), result))
+
+	require.Len(t, b.received, 1)
+	assert.Equal(t, []database.FailedJob{night, morning}, b.received[0].FailedJobs)
+	assert.Len(t, result.FailedJobs, 1, "caller's result must not be modified")
+}
+
+func TestDispatch_MinIntervalCoalesces(t *testing.T) {
+	now := time.Date(2026, 2, 3, 9, 0, 0, 0, time.UTC)
+	b := &fakeBackend{name: "fake"}
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	d := &Dispatcher{
+		backends:   []Backend{b},
+		minSendGap: 5 * time.Minute,
+		store:      store,
+		now:        func() time.Time { return now },
+	}
+	ctx := context.Background()
+
+	assert.True(t, d.DefersSends(), "the throttle alone needs the pending queue flushed")
+	assert.False(t, d.Deferring())
+	first := database.FailedJob{ServerName: "S1", JobName: "J1", FailedAt: now}
+	require.NoError(t, d.Dispatch(ctx, &jobs.CheckResult{FailedJobs: []database.FailedJob{first}}))
+	require.Len(t, b.received, 1, "the first notification is sent immediately")
+	assert.True(t, d.Deferring(), "within the interval")
+
+	// A burst of distinct failures within the interval is held back
+	now = now.Add(time.Minute)
+	second := database.FailedJob{ServerName: "S1", JobName: "J2", FailedAt: now}
+	require.NoError(t, d.Dispatch(ctx, &jobs.CheckResult{FailedJobs: []database.FailedJob{second}}))
+	now = now.Add(time.Minute)
+	third := database.FailedJob{ServerName: "S2", JobName: "J3", FailedAt: now}
+	require.NoError(t, d.Dispatch(ctx, &jobs.CheckResult{FailedJobs: []database.FailedJob{third}}))
+	require.NoError(t, d.FlushPending(ctx))
+	assert.Len(t, b.received, 1)
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Len(t, st.PendingNotifications, 2)
+	assert.Equal(t, now.Add(-2*time.Minute), st.LastNotificationSent)
+
+	// Once the interval has passed they are sent together
+	now = now.Add(3 * time.Minute)
+	require.NoError(t, d.FlushPending(ctx))
+	require.Len(t, b.received, 2)
+	assert.Equal(t, []database.FailedJob{second, third}, b.received[1].FailedJobs)
+
+	st, err = store.Load()
+	require.NoError(t, err)
+	assert.Empty(t, st.PendingNotifications)
+	assert.Equal(t, now, st.LastNotificationSent)
+}
+
+func TestDispatcher_DefersSends(t *testing.T) {
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	assert.False(t, (&Dispatcher{store: store}).DefersSends())
+	assert.True(t, (&Dispatcher{store: store, minSendGap: time.Minute}).DefersSends())
+	assert.True(t, (&Dispatcher{store: store, quietHours: config.QuietHoursConfig{Enabled: true}}).DefersSends())
+	assert.False(t, (&Dispatcher{minSendGap: time.Minute}).DefersSends(), "nothing is queued without a store")
+}
+
+func TestDispatch_MinIntervalIncludesQueuedJobs(t *testing.T) {
+	now := time.Date(2026, 2, 3, 9, 0, 0, 0, time.UTC)
+	b := &fakeBackend{name: "fake"}
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, store.Save(&state.State{LastNotificationSent: now.Add(-time.Minute)}))
+	d := &Dispatcher{
+		backends:   []Backend{b},
+		minSendGap: 2 * time.Minute,
+		store:      store,
+		now:        func() time.Time { return now },
+	}
+	ctx := context.Background()
+
+	held := database.FailedJob{ServerName: "S1", JobName: "J1", FailedAt: now}
+	require.NoError(t, d.Dispatch(ctx, &jobs.CheckResult{FailedJobs: []database.FailedJob{held}}))
+	assert.Empty(t, b.received, "the interval carries over from the last run")
+
+	now = now.Add(2 * time.Minute)
+	next := database.FailedJob{ServerName: "S1", JobName: "J2", FailedAt: now}
+	require.NoError(t, d.Dispatch(ctx, &jobs.CheckResult{FailedJobs: []database.FailedJob{next}}))
+	require.Len(t, b.received, 1)
+	assert.Equal(t, []database.FailedJob{held, next}, b.received[0].FailedJobs)
+}
+
+// fakeServerDownBackend is a backend that also records server down alerts.
+type fakeServerDownBackend struct {
+	fakeBackend
+	down [][]string
+}
+
+func (f *fakeServerDownBackend) NotifyServerDown(ctx context.Context, servers []string) error {
+	f.down = append(f.down, servers)
+	return f.err
+}
+
+func TestDispatch_DigestAccumulatesAcrossRuns(t *testing.T) {
+	now := time.Date(2026, 2, 3, 9, 0, 0, 0, time.UTC)
+	b := &fakeBackend{name: "fake"}
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	d := &Dispatcher{
+		backends: []Backend{b},
+		digest:   true,
+		location: time.UTC,
+		store:    store,
+		now:      func() time.Time { return now },
+	}
+	require.True(t, d.DigestMode())
+
+	morning := database.FailedJob{ServerName: "S1", JobName: "Backup", FailedAt: now}
+	noon := database.FailedJob{ServerName: "S2", JobName: "ETL", FailedAt: now.Add(3 * time.Hour)}
+	require.NoError(t, d.Dispatch(context.Background(), &jobs.CheckResult{FailedJobs: []database.FailedJob{morning}}))
+	now = now.Add(3 * time.Hour)
+	require.NoError(t, d.Dispatch(context.Background(), &jobs.CheckResult{FailedJobs: []database.FailedJob{morning, noon}}))
+
+	// The quiet hours flush must not send the digest queue early
+	require.NoError(t, d.FlushPending(context.Background()))
+	assert.Empty(t, b.received, "digest mode must not send after each check")
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Len(t, st.PendingNotifications, 2)
+
+	// Digest time
+	now = time.Date(2026, 2, 3, 17, 0, 0, 0, time.UTC)
+	require.NoError(t, d.SendDigest(context.Background()))
+	require.Len(t, b.received, 1)
+	digest := b.received[0]
+	assert.Len(t, digest.FailedJobs, 2)
+	assert.Equal(t, "Daily digest: 2 failed jobs on 2 servers", digest.Summary)
+	assert.Equal(t, now, digest.Timestamp)
+
+	// The queue is emptied: a second digest sends nothing
+	require.NoError(t, d.SendDigest(context.Background()))
+	assert.Len(t, b.received, 1)
+}
+
+func TestSendDigest_QuietHoursKeepsQueue(t *testing.T) {
+	now := time.Date(2026, 2, 3, 23, 0, 0, 0, time.UTC)
+	d, b, store := newQuietDispatcher(t, &now)
+	d.digest = true
+
+	job := database.FailedJob{ServerName: "S1", JobName: "Backup", FailedAt: now}
+	require.NoError(t, d.Dispatch(context.Background(), &jobs.CheckResult{FailedJobs: []database.FailedJob{job}}))
+	require.NoError(t, d.SendDigest(context.Background()))
+	assert.Empty(t, b.received)
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Len(t, st.PendingNotifications, 1, "jobs stay queued for the next digest")
+}
+
+func TestSendDigest_KeepsQueueWhenSendFails(t *testing.T) {
+	now := time.Date(2026, 2, 3, 9, 0, 0, 0, time.UTC)
+	b := &fakeBackend{name: "fake", err: errors.New("toast unavailable")}
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	d := &Dispatcher{
+		backends: []Backend{b},
+		digest:   true,
+		store:    store,
+		now:      func() time.Time { return now },
+	}
+
+	job := database.FailedJob{ServerName: "S1", JobName: "Backup", FailedAt: now}
+	require.NoError(t, d.Dispatch(context.Background(), &jobs.CheckResult{FailedJobs: []database.FailedJob{job}}))
+	require.Error(t, d.SendDigest(context.Background()))
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Len(t, st.PendingNotifications, 1, "a failed digest must not drop the day's failures")
+
+	b.err = nil
+	require.NoError(t, d.SendDigest(context.Background()))
+	require.Len(t, b.received, 2)
+	st, err = store.Load()
+	require.NoError(t, err)
+	assert.Empty(t, st.PendingNotifications)
+}
+
+func TestNotifyServersDown(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		wantDown [][]string
+	}{
+		{name: "enabled alerts down server", enabled: true, wantDown: [][]string{{"SQL02"}}},
+		{name: "disabled sends nothing", enabled: false, wantDown: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &fakeServerDownBackend{fakeBackend: fakeBackend{name: "fake"}}
+			d := &Dispatcher{
+				backends:   []Backend{b},
+				serverDown: tt.enabled,
+				store:      state.NewStore(filepath.Join(t.TempDir(), "state.json")),
+				now:        time.Now,
+			}
+
+			result := &jobs.CheckResult{
+				ServersUnavailable:   []string{"SQL02"},
+				ServerQueryLatencyMs: map[string]int64{"SQL01": 1},
+			}
+			require.NoError(t, d.NotifyServersDown(context.Background(), result))
+			assert.Equal(t, tt.wantDown, b.down)
+		})
+	}
+}
+
+func TestNotifyServersDown_MonitorOnly(t *testing.T) {
+	b := &fakeServerDownBackend{fakeBackend: fakeBackend{name: "fake"}}
+	d := &Dispatcher{
+		backends:    []Backend{b},
+		serverDown:  true,
+		monitorOnly: map[string]bool{"STAGING": true},
+		now:         time.Now,
+	}
+
+	result := &jobs.CheckResult{ServersUnavailable: []string{"STAGING", "PROD"}}
+	require.NoError(t, d.NotifyServersDown(context.Background(), result))
+	assert.Equal(t, [][]string{{"PROD"}}, b.down)
+	assert.Equal(t, []string{"STAGING", "PROD"}, result.ServersUnavailable)
+}
+
+func TestNotifyServersDown_OncePerOutage(t *testing.T) {
+	b := &fakeServerDownBackend{fakeBackend: fakeBackend{name: "fake"}}
+	d := &Dispatcher{
+		backends:   []Backend{b},
+		serverDown: true,
+		store:      state.NewStore(filepath.Join(t.TempDir(), "state.json")),
+		now:        time.Now,
+	}
+	down := &jobs.CheckResult{ServersUnavailable: []string{"SQL01"}}
+	up := &jobs.CheckResult{ServerQueryLatencyMs: map[string]int64{"SQL01": 1}}
+
+	for _, result := range []*jobs.CheckResult{down, down, down, up, down} {
+		require.NoError(t, d.NotifyServersDown(context.Background(), result))
+	}
+
+	// Alerted when the outage starts and again after recovery, not on every check
+	assert.Equal(t, [][]string{{"SQL01"}, {"SQL01"}}, b.down)
+}
+
+func TestNotifyServersDown_RetriesFailedAlert(t *testing.T) {
+	b := &fakeServerDownBackend{fakeBackend: fakeBackend{name: "fake", err: errors.New("toast unavailable")}}
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	d := &Dispatcher{
+		backends:   []Backend{b},
+		serverDown: true,
+		store:      store,
+		now:        time.Now,
+	}
+	down := &jobs.CheckResult{ServersUnavailable: []string{"SQL01"}}
+
+	require.Error(t, d.NotifyServersDown(context.Background(), down))
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Empty(t, st.DownServers, "an outage is recorded only once alerted")
+
+	b.err = nil
+	require.NoError(t, d.NotifyServersDown(context.Background(), down))
+	require.NoError(t, d.NotifyServersDown(context.Background(), down))
+	assert.Equal(t, [][]string{{"SQL01"}, {"SQL01"}}, b.down, "alerted again after the failed send, then once")
+}
+
+// slowBackend tracks how many sends run at the same time.
+type slowBackend struct {
+	name    string
+	delay   time.Duration
+	active  *atomic.Int32
+	maxSeen *atomic.Int32
+}
+
+func (s *slowBackend) Name() string { return s.name }
+
+func (s *slowBackend) Send(ctx context.Context, result *jobs.CheckResult) error {
+	n := s.active.Add(1)
+	defer s.active.Add(-1)
+	for {
+		seen := s.maxSeen.Load()
+		if n <= seen || s.maxSeen.CompareAndSwap(seen, n) {
+			break
+		}
+	}
+	time.Sleep(s.delay)
+	return nil
+}
+
+func TestDispatch_MaxConcurrentSends(t *testing.T) {
+	var active, maxSeen atomic.Int32
+	d := &Dispatcher{maxSends: 3}
+	for i := 0; i < 12; i++ {
+		d.backends = append(d.backends, &slowBackend{
+			name:    fmt.Sprintf("webhook%d", i),
+			delay:   20 * time.Millisecond,
+			active:  &active,
+			maxSeen: &maxSeen,
+		})
+	}
+
+	result := &jobs.CheckResult{FailedJobs: []database.FailedJob{{ServerName: "S1", JobName: "J1"}}}
+	require.NoError(t, d.Dispatch(context.Background(), result))
+
+	assert.Equal(t, int32(3), maxSeen.Load())
+	assert.Equal(t, int32(0), active.Load())
+}
+
+// fakeLongRunningBackend is a backend that also records long-running alerts.
+type fakeLongRunningBackend struct {
+	fakeBackend
+	running [][]string
+}
+
+func (f *fakeLongRunningBackend) NotifyLongRunning(ctx context.Context, running []database.LongRunningJob) error {
+	names := make([]string, 0, len(running))
+	for _, job := range running {
+		names = append(names, job.ServerName+"/"+job.JobName)
+	}
+	f.running = append(f.running, names)
+	return f.err
+}
+
+func TestNotifyLongRunning_OncePerExecution(t *testing.T) {
+	b := &fakeLongRunningBackend{fakeBackend: fakeBackend{name: "fake"}}
+	d := &Dispatcher{
+		backends:    []Backend{b},
+		monitorOnly: map[string]bool{"STAGING": true},
+		store:       state.NewStore(filepath.Join(t.TempDir(), "state.json")),
+		now:         time.Now,
+	}
+
+	started := time.Date(2026, 2, 3, 1, 0, 0, 0, time.UTC)
+	first := &jobs.CheckResult{LongRunningJobs: []database.LongRunningJob{
+		{ServerName: "SQL01", JobName: "ETL", StartedAt: started},
+		{ServerName: "STAGING", JobName: "ETL", StartedAt: started},
+	}}
+	next := &jobs.CheckResult{LongRunningJobs: []database.LongRunningJob{
+		{ServerName: "SQL01", JobName: "ETL", StartedAt: started.Add(24 * time.Hour)},
+	}}
+
+	for _, result := range []*jobs.CheckResult{first, first, next, {}} {
+		require.NoError(t, d.NotifyLongRunning(context.Background(), result))
+	}
+
+	// Each execution is alerted once; monitor-only servers never are
+	assert.Equal(t, [][]string{{"SQL01/ETL"}, {"SQL01/ETL"}}, b.running)
+	assert.Empty(t, b.received, "long-running jobs are not sent as a check result")
+}
+
+func TestNotifyLongRunning_ByConfiguredName(t *testing.T) {
+	b := &fakeLongRunningBackend{fakeBackend: fakeBackend{name: "fake"}}
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	d := &Dispatcher{
+		backends:    []Backend{b},
+		monitorOnly: map[string]bool{"PROD-SQL01-RO": true},
+		store:       store,
+		now:         time.Now,
+	}
+
+	// Both configured servers reach the same instance, which reports its own
+	// @@SERVERNAME
+	started := time.Date(2026, 2, 3, 1, 0, 0, 0, time.UTC)
+	result := &jobs.CheckResult{LongRunningJobs: []database.LongRunningJob{
+		{ServerName: "SQLNODE1", ConfigName: "PROD-SQL01", JobName: "ETL", StartedAt: started},
+		{ServerName: "SQLNODE1", ConfigName: "PROD-SQL01-RO", JobName: "ETL", StartedAt: started},
+	}}
+	require.NoError(t, d.NotifyLongRunning(context.Background(), result))
+	assert.Equal(t, [][]string{{"SQLNODE1/ETL"}}, b.running, "the monitor-only server is not alerted")
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []state.LongRunningAlert{{Server: "PROD-SQL01", Job: "ETL", StartedAt: started}}, st.LongRunningAlerts,
+		"alerts are keyed by the configured server")
+}
+
+func TestNotifyLongRunning_RetriesFailedAlert(t *testing.T) {
+	b := &fakeLongRunningBackend{fakeBackend: fakeBackend{name: "fake", err: errors.New("webhook unavailable")}}
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	d := &Dispatcher{
+		backends: []Backend{b},
+		store:    store,
+		now:      time.Now,
+	}
+	result := &jobs.CheckResult{LongRunningJobs: []database.LongRunningJob{
+		{ServerName: "SQL01", JobName: "ETL", StartedAt: time.Date(2026, 2, 3, 1, 0, 0, 0, time.UTC)},
+	}}
+
+	require.Error(t, d.NotifyLongRunning(context.Background(), result))
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Empty(t, st.LongRunningAlerts, "an execution is recorded only once alerted")
+
+	b.err = nil
+	require.NoError(t, d.NotifyLongRunning(context.Background(), result))
+	require.NoError(t, d.NotifyLongRunning(context.Background(), result))
+	assert.Equal(t, [][]string{{"SQL01/ETL"}, {"SQL01/ETL"}}, b.running, "alerted again after the failed send, then once")
+}
@@ -0,0 +1,90 @@
+package notification
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+func TestNewHTTPClient_Proxy(t *testing.T) {
+	target, err := http.NewRequest(http.MethodGet, "https://hooks.example.com/alert", nil)
+	require.NoError(t, err)
+
+	client, err := NewHTTPClient(config.NotificationConfig{ProxyURL: "http://proxy.corp:8080"}, 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, client.Timeout)
+
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	proxy, err := transport.Proxy(target)
+	require.NoError(t, err)
+	assert.Equal(t, &url.URL{Scheme: "http", Host: "proxy.corp:8080"}, proxy)
+	assert.False(t, transport.TLSClientConfig.InsecureSkipVerify)
+}
+
+func TestNewHTTPClient_EnvironmentProxy(t *testing.T) {
+	client, err := NewHTTPClient(config.NotificationConfig{}, time.Second)
+	require.NoError(t, err)
+
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	require.NotNil(t, transport.Proxy, "environment proxies are used by default")
+	assert.NotSame(t, http.DefaultTransport, transport, "the default transport is not modified")
+}
+
+func TestNewHTTPClient_SendsThroughProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	client, err := NewHTTPClient(config.NotificationConfig{ProxyURL: proxy.URL}, 5*time.Second)
+	require.NoError(t, err)
+
+	resp, err := client.Get("http://hooks.example.invalid/alert")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, "http://hooks.example.invalid/alert", proxied)
+}
+
+func TestNewHTTPClient_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	get := func(tlsCfg config.TLSConfig) error {
+		client, err := NewHTTPClient(config.NotificationConfig{TLS: tlsCfg}, 5*time.Second)
+		require.NoError(t, err)
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		return err
+	}
+
+	assert.Error(t, get(config.TLSConfig{}), "an unknown CA is rejected")
+	assert.NoError(t, get(config.TLSConfig{InsecureSkipVerify: true}))
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, cert, 0o600))
+	assert.NoError(t, get(config.TLSConfig{CAFile: caFile}))
+}
+
+func TestNewHTTPClient_InvalidCAFile(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0o600))
+
+	_, err := NewHTTPClient(config.NotificationConfig{TLS: config.TLSConfig{CAFile: caFile}}, time.Second)
+	assert.ErrorContains(t, err, "no certificates found")
+}
@@ -0,0 +1,109 @@
+package notification
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-toast/toast"
+)
+
+// defaultIconPNG is the toast icon used when notification.icon_path is not set.
+//
+//go:embed assets/icon.png
+var defaultIconPNG []byte
+
+// iconFileName is the name of the extracted icon file.
+const iconFileName = "icon.png"
+
+// embeddedIcon extracts the embedded icon to a file in dir on first use,
+// since toasts can only reference icons by path. The file is kept: Windows
+// may load the icon after the process that raised the toast has exited.
+type embeddedIcon struct {
+	dir  string // Empty for the per-user default, resolved on use
+	mu   sync.Mutex
+	path string
+}
+
+// defaultIcon is shared by every notifier of the process.
+var defaultIcon = &embeddedIcon{}
+
+// defaultIconDir returns the per-user directory the icon is extracted to,
+// %LOCALAPPDATA%\watchman on Windows, or the temp directory if there is no
+// user cache directory.
+func defaultIconDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "watchman")
+	}
+	return filepath.Join(dir, "watchman")
+}
+
+// Path returns the path of the extracted icon, extracting it if it is
+// missing or outdated.
+func (e *embeddedIcon) Path() (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	dir := e.dir
+	if dir == "" {
+		dir = defaultIconDir()
+	}
+	path := filepath.Join(dir, iconFileName)
+	if e.path == path {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	if data, err := os.ReadFile(path); err != nil || !bytes.Equal(data, defaultIconPNG) { // #nosec G304 -- fixed file name
+		if err := writeIcon(dir, path); err != nil {
+			return "", err
+		}
+	}
+	e.path = path
+	return path, nil
+}
+
+// writeIcon writes the embedded icon to path in dir through a temp file, so
+// processes extracting it at once never see a partial file.
+func writeIcon(dir, path string) error {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create icon directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(dir, "icon-*.png")
+	if err != nil {
+		return fmt.Errorf("failed to create icon file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(f.Name()) // No-op after a successful rename
+	}()
+	if _, err := f.Write(defaultIconPNG); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write icon file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write icon file: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("failed to write icon file: %w", err)
+	}
+	return nil
+}
+
+// setIcon sets the toast icon to notification.icon_path or, if none is
+// configured, the embedded default icon. Toasts are sent without an icon if
+// it cannot be extracted.
+func (n *Notifier) setIcon(notification *toast.Notification) {
+	if n.cfg.IconPath != "" {
+		notification.Icon = n.cfg.IconPath
+		return
+	}
+	if path, err := n.icon.Path(); err == nil {
+		notification.Icon = path
+	}
+}
@@ -0,0 +1,86 @@
+package notification
+
+import (
+	"bytes"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-toast/toast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+// TestMain extracts the default icon to a temporary directory instead of
+// the user's cache directory.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "watchman-icon")
+	if err != nil {
+		panic(err)
+	}
+	defaultIcon = &embeddedIcon{dir: dir}
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestEmbeddedIcon_Path(t *testing.T) {
+	icon := &embeddedIcon{dir: filepath.Join(t.TempDir(), "watchman")}
+
+	path, err := icon.Path()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(icon.dir, iconFileName), path)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	_, err = png.Decode(bytes.NewReader(data))
+	assert.NoError(t, err, "the embedded icon is a valid PNG")
+
+	again, err := icon.Path()
+	require.NoError(t, err)
+	assert.Equal(t, path, again, "the icon is extracted once")
+
+	require.NoError(t, os.Remove(path))
+	restored, err := icon.Path()
+	require.NoError(t, err)
+	assert.FileExists(t, restored, "a deleted icon is extracted again")
+
+	// Another process starts with the icon of an older version on disk
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0o600))
+	other := &embeddedIcon{dir: icon.dir}
+	_, err = other.Path()
+	require.NoError(t, err)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, defaultIconPNG, data, "an outdated icon is replaced")
+
+	entries, err := os.ReadDir(icon.dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temp files are left behind")
+}
+
+func TestNotifier_DefaultIcon(t *testing.T) {
+	job := database.FailedJob{ServerName: "SQL1", JobName: "Backup"}
+
+	n := NewNotifier(config.NotificationConfig{AppID: "Watchman"})
+	n.icon = &embeddedIcon{dir: t.TempDir()}
+	pusher := new(MockToastPusher)
+	pusher.On("Push", mock.Anything).Return(nil)
+	n.pusher = pusher
+	require.NoError(t, n.NotifyFailedJobs([]database.FailedJob{job}))
+	sent := pusher.Calls[0].Arguments.Get(0).(toast.Notification)
+	assert.Equal(t, n.icon.path, sent.Icon)
+	assert.FileExists(t, sent.Icon)
+
+	configured := NewNotifier(config.NotificationConfig{AppID: "Watchman", IconPath: `C:\branding\icon.png`})
+	configured.icon = &embeddedIcon{dir: t.TempDir()}
+	configured.pusher = pusher
+	require.NoError(t, configured.NotifyFailedJobs([]database.FailedJob{job}))
+	sent = pusher.Calls[1].Arguments.Get(0).(toast.Notification)
+	assert.Equal(t, `C:\branding\icon.png`, sent.Icon)
+	assert.Empty(t, configured.icon.path, "nothing is extracted when an icon is configured")
+}
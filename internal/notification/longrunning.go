@@ -0,0 +1,171 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-toast/toast"
+
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+// LongRunningNotifier is implemented by backends that can alert on jobs
+// running past their monitoring.long_running threshold.
+type LongRunningNotifier interface {
+	NotifyLongRunning(ctx context.Context, jobs []database.LongRunningJob) error
+}
+
+// NotifyLongRunning alerts about the long-running jobs in result.
+// Each execution is alerted once, recorded in the state by its start time
+// once its alert was sent; the next execution of the same job is alerted
+// again. Monitor-only servers are never alerted. Nothing is sent while muted
+// or during quiet hours.
+func (d *Dispatcher) NotifyLongRunning(ctx context.Context, result *jobs.CheckResult) error {
+	if result == nil || len(result.LongRunningJobs) == 0 || d.Muted() {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.inQuietHours() {
+		return nil
+	}
+
+	running := slices.DeleteFunc(slices.Clone(result.LongRunningJobs), func(job database.LongRunningJob) bool {
+		return d.monitorOnly[job.ConfiguredServer()]
+	})
+	if d.store != nil {
+		var err error
+		running, err = d.newlyLongRunning(running)
+		if err != nil {
+			return err
+		}
+	}
+	if len(running) == 0 {
+		return nil
+	}
+
+	err := d.fanOut(func(b Backend) error {
+		if n, ok := b.(LongRunningNotifier); ok {
+			return n.NotifyLongRunning(ctx, running)
+		}
+		return nil
+	})
+	if err != nil || d.store == nil {
+		return err
+	}
+	return d.recordLongRunning(running)
+}
+
+// newlyLongRunning returns the executions in running not alerted yet.
+func (d *Dispatcher) newlyLongRunning(running []database.LongRunningJob) ([]database.LongRunningJob, error) {
+	st, err := d.store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load long-running alerts: %w", err)
+	}
+	return slices.DeleteFunc(running, func(job database.LongRunningJob) bool {
+		return slices.ContainsFunc(st.LongRunningAlerts, longRunningAlert(job).Equal)
+	}), nil
+}
+
+// recordLongRunning records the executions in running as alerted.
+func (d *Dispatcher) recordLongRunning(running []database.LongRunningJob) error {
+	err := d.store.Update(func(st *state.State) error {
+		for _, job := range running {
+			if execution := longRunningAlert(job); !slices.ContainsFunc(st.LongRunningAlerts, execution.Equal) {
+				st.LongRunningAlerts = append(st.LongRunningAlerts, execution)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record long-running alerts: %w", err)
+	}
+	return nil
+}
+
+// longRunningAlert returns the state entry recording the alert of job's execution.
+func longRunningAlert(job database.LongRunningJob) state.LongRunningAlert {
+	return state.LongRunningAlert{Server: job.ConfiguredServer(), Job: job.JobName, StartedAt: job.StartedAt}
+}
+
+// NotifyLongRunning implements LongRunningNotifier by sending a toast
+// listing the jobs running past their threshold.
+func (n *Notifier) NotifyLongRunning(_ context.Context, running []database.LongRunningJob) error {
+	if len(running) == 0 {
+		return nil
+	}
+
+	g := n.glyphs()
+	var title, body string
+	if len(running) == 1 {
+		job := running[0]
+		title = fmt.Sprintf("%s Job running long on %s", g.running, job.ServerLabel())
+		body = fmt.Sprintf("Job: %s\nStarted at: %s\nRunning for %s (threshold %s)",
+			truncateJobName(job.JobName, n.cfg.MaxJobNameLength, g.ellipsis),
+			formatTime(job.StartedAt, n.cfg.TimeFormat, n.now()),
+			formatMinutes(job.RunningFor()),
+			formatMinutes(time.Duration(job.ThresholdMinutes)*time.Minute),
+		)
+	} else {
+		title = fmt.Sprintf("%s %d Jobs running long", g.running, len(running))
+		body = n.buildLongRunningBody(running)
+	}
+
+	notification := toast.Notification{
+		AppID:   n.cfg.AppID,
+		Title:   title,
+		Message: body,
+	}
+
+	n.setIcon(&notification)
+
+	n.setAudio(&notification)
+
+	return n.push(notification)
+}
+
+// buildLongRunningBody lists the long-running jobs, up to
+// grouping.max_jobs_per_notification.
+func (n *Notifier) buildLongRunningBody(running []database.LongRunningJob) string {
+	g := n.glyphs()
+	maxJobs := n.cfg.Grouping.MaxJobsPerNotification
+	if maxJobs <= 0 {
+		maxJobs = 5
+	}
+
+	var lines []string
+	for i, job := range running {
+		if i == maxJobs {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(running)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%s %s: %s (%s, threshold %s)",
+			g.bullet,
+			job.ServerLabel(),
+			truncateJobName(job.JobName, n.cfg.MaxJobNameLength, g.ellipsis),
+			formatMinutes(job.RunningFor()),
+			formatMinutes(time.Duration(job.ThresholdMinutes)*time.Minute),
+		))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatMinutes renders d in whole minutes, e.g. "45m", "2h" or "3h 5m".
+func formatMinutes(d time.Duration) string {
+	minutes := int(d / time.Minute)
+	switch {
+	case minutes < 60:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes%60 == 0:
+		return fmt.Sprintf("%dh", minutes/60)
+	default:
+		return fmt.Sprintf("%dh %dm", minutes/60, minutes%60)
+	}
+}
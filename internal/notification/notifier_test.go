@@ -1,13 +1,17 @@
 package notification
 
 import (
+	"context"
 	"fmt"
+	"os/exec"
 	"testing"
 	"time"
+	"unicode"
 
 	"github.com/go-toast/toast"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/hoangtran1411/watchman/internal/config"
 	"github.com/hoangtran1411/watchman/internal/database"
@@ -39,7 +43,8 @@ func TestNotifyFailedJobs_NoJobs(t *testing.T) {
 
 func TestNotifyFailedJobs_Individual(t *testing.T) {
 	cfg := config.NotificationConfig{
-		AppID: "TestApp",
+		AppID:    "TestApp",
+		UseEmoji: true,
 		Grouping: config.GroupingConfig{
 			Enabled: false,
 		},
@@ -62,9 +67,43 @@ func TestNotifyFailedJobs_Individual(t *testing.T) {
 	pusher.AssertExpectations(t)
 }
 
+func TestNotifyFailedJobs_IndividualRunDuration(t *testing.T) {
+	pusher := new(MockToastPusher)
+	notifier := NewNotifier(config.NotificationConfig{AppID: "TestApp"})
+	notifier.pusher = pusher
+
+	failedAt := time.Date(2026, 2, 3, 8, 30, 15, 0, time.UTC)
+	job := database.FailedJob{ServerName: "S1", JobName: "Backup", FailedAt: failedAt, Duration: 90}
+
+	pusher.On("Push", mock.MatchedBy(func(n toast.Notification) bool {
+		return n.Message == "Job: Backup\nFailed at: 2026-02-03 08:30:15 (after 1m30s)\n"
+	})).Return(nil).Once()
+
+	assert.NoError(t, notifier.NotifyFailedJobs([]database.FailedJob{job}))
+	pusher.AssertExpectations(t)
+}
+
+func TestNotifyFailedJobs_IndividualRelativeTime(t *testing.T) {
+	pusher := new(MockToastPusher)
+	notifier := NewNotifier(config.NotificationConfig{AppID: "TestApp", TimeFormat: config.TimeFormatRelative})
+	notifier.pusher = pusher
+	failedAt := time.Date(2026, 2, 3, 8, 30, 15, 0, time.UTC)
+	notifier.now = func() time.Time { return failedAt.Add(5 * time.Minute) }
+
+	job := database.FailedJob{ServerName: "S1", JobName: "Backup", FailedAt: failedAt}
+
+	pusher.On("Push", mock.MatchedBy(func(n toast.Notification) bool {
+		return n.Message == "Job: Backup\nFailed at: 5 minutes ago\n"
+	})).Return(nil).Once()
+
+	assert.NoError(t, notifier.NotifyFailedJobs([]database.FailedJob{job}))
+	pusher.AssertExpectations(t)
+}
+
 func TestNotifyFailedJobs_Grouped(t *testing.T) {
 	cfg := config.NotificationConfig{
-		AppID: "TestApp",
+		AppID:    "TestApp",
+		UseEmoji: true,
 		Grouping: config.GroupingConfig{
 			Enabled: true,
 		},
@@ -87,8 +126,60 @@ func TestNotifyFailedJobs_Grouped(t *testing.T) {
 	pusher.AssertExpectations(t)
 }
 
+func TestNotifyFailedJobs_AutoGroupsBusyServers(t *testing.T) {
+	cfg := config.NotificationConfig{
+		AppID:    "TestApp",
+		UseEmoji: true,
+		Grouping: config.GroupingConfig{Enabled: false, AutoGroupThreshold: 3},
+	}
+	jobsOn := func(server string, count int) []database.FailedJob {
+		var jobs []database.FailedJob
+		for i := range count {
+			jobs = append(jobs, database.FailedJob{ServerName: server, JobName: fmt.Sprintf("J%d", i), FailedAt: time.Now()})
+		}
+		return jobs
+	}
+
+	tests := []struct {
+		name   string
+		jobs   []database.FailedJob
+		titles []string
+	}{
+		{
+			name:   "at threshold",
+			jobs:   jobsOn("S1", 3),
+			titles: []string{"❌ Job Failed on S1", "❌ Job Failed on S1", "❌ Job Failed on S1"},
+		},
+		{
+			name:   "above threshold",
+			jobs:   jobsOn("S1", 4),
+			titles: []string{"❌ 4 SQL Agent Jobs Failed"},
+		},
+		{
+			name:   "only the busy server is grouped",
+			jobs:   append(jobsOn("S1", 4), jobsOn("S2", 1)...),
+			titles: []string{"❌ 4 SQL Agent Jobs Failed", "❌ Job Failed on S2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var titles []string
+			pusher := new(MockToastPusher)
+			pusher.On("Push", mock.Anything).Run(func(args mock.Arguments) {
+				titles = append(titles, args.Get(0).(toast.Notification).Title)
+			}).Return(nil)
+			notifier := NewNotifier(cfg)
+			notifier.pusher = pusher
+
+			require.NoError(t, notifier.NotifyFailedJobs(tt.jobs))
+			assert.Equal(t, tt.titles, titles)
+		})
+	}
+}
+
 func TestNotifyUpdateAvailable(t *testing.T) {
-	cfg := config.NotificationConfig{AppID: "TestApp"}
+	cfg := config.NotificationConfig{AppID: "TestApp", UseEmoji: true}
 	pusher := new(MockToastPusher)
 	notifier := NewNotifier(cfg)
 	notifier.pusher = pusher
@@ -101,3 +192,343 @@ func TestNotifyUpdateAvailable(t *testing.T) {
 	assert.NoError(t, err)
 	pusher.AssertExpectations(t)
 }
+
+func TestNotifyServerDown(t *testing.T) {
+	cfg := config.NotificationConfig{AppID: "TestApp", UseEmoji: true}
+	pusher := new(MockToastPusher)
+	notifier := NewNotifier(cfg)
+	notifier.pusher = pusher
+
+	pusher.On("Push", mock.MatchedBy(func(n toast.Notification) bool {
+		return n.AppID == "TestApp" && n.Title == "🔌 Server unreachable" && n.Message == "🖥️ SQL01"
+	})).Return(nil).Once()
+
+	assert.NoError(t, notifier.NotifyServerDown(context.Background(), []string{"SQL01"}))
+	assert.NoError(t, notifier.NotifyServerDown(context.Background(), nil))
+	pusher.AssertExpectations(t)
+}
+
+func TestNotifications_ShowDisplayName(t *testing.T) {
+	var pushed []toast.Notification
+	pusher := new(MockToastPusher)
+	pusher.On("Push", mock.Anything).Run(func(args mock.Arguments) {
+		pushed = append(pushed, args.Get(0).(toast.Notification))
+	}).Return(nil)
+
+	job := database.FailedJob{ServerName: `SQLPRDFIN01\INST2`, DisplayName: "Finance Prod DB", JobName: "Backup"}
+	other := database.FailedJob{ServerName: "SQLHR01", JobName: "ETL"}
+	for _, grouping := range []bool{false, true} {
+		notifier := NewNotifier(config.NotificationConfig{AppID: "TestApp", UseEmoji: true, Grouping: config.GroupingConfig{Enabled: grouping}})
+		notifier.pusher = pusher
+		assert.NoError(t, notifier.NotifyFailedJobs([]database.FailedJob{job, other}))
+	}
+
+	notifier := NewNotifier(config.NotificationConfig{AppID: "TestApp", UseEmoji: true})
+	notifier.pusher = pusher
+	notifier.SetDisplayNames([]config.ServerConfig{{Name: "FIN01", DisplayName: "Finance Prod DB"}, {Name: "HR01"}})
+	assert.NoError(t, notifier.NotifyServerDown(context.Background(), []string{"FIN01", "HR01"}))
+
+	require.Len(t, pushed, 4)
+	assert.Equal(t, "❌ Job Failed on Finance Prod DB", pushed[0].Title)
+	assert.Equal(t, "❌ Job Failed on SQLHR01", pushed[1].Title, "falls back to the server name")
+	assert.Contains(t, pushed[2].Message, "🖥️ Finance Prod DB:")
+	assert.Contains(t, pushed[2].Message, "🖥️ SQLHR01:")
+	assert.NotContains(t, pushed[2].Message, "SQLPRDFIN01")
+	assert.Equal(t, "🖥️ Finance Prod DB\n🖥️ HR01", pushed[3].Message)
+}
+
+func TestNotifications_WithoutEmoji(t *testing.T) {
+	at := time.Date(2026, 2, 3, 8, 30, 15, 0, time.UTC)
+	twoServers := []database.FailedJob{
+		{ServerName: "S1", JobName: "J1", FailedAt: at},
+		{ServerName: "S2", JobName: "J2", FailedAt: at},
+	}
+
+	tests := []struct {
+		name      string
+		grouping  bool
+		send      func(n *Notifier) error
+		wantTitle string
+	}{
+		{
+			name:      "single",
+			send:      func(n *Notifier) error { return n.NotifyFailedJobs(twoServers[:1]) },
+			wantTitle: "[FAIL] Job Failed on S1",
+		},
+		{
+			name:      "grouped",
+			grouping:  true,
+			send:      func(n *Notifier) error { return n.NotifyFailedJobs(twoServers) },
+			wantTitle: "[FAIL] 2 Jobs Failed on 2 Servers",
+		},
+		{
+			name:      "server down",
+			send:      func(n *Notifier) error { return n.NotifyServerDown(context.Background(), []string{"S1", "S2"}) },
+			wantTitle: "[DOWN] 2 Servers unreachable",
+		},
+		{
+			name:      "update",
+			send:      func(n *Notifier) error { return n.NotifyUpdateAvailable("v1.0.0", "v1.1.0") },
+			wantTitle: "[UPDATE] Watchman Update Available",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pushed []toast.Notification
+			pusher := new(MockToastPusher)
+			pusher.On("Push", mock.Anything).Run(func(args mock.Arguments) {
+				pushed = append(pushed, args.Get(0).(toast.Notification))
+			}).Return(nil)
+
+			notifier := NewNotifier(config.NotificationConfig{
+				AppID:    "TestApp",
+				Grouping: config.GroupingConfig{Enabled: tt.grouping},
+			})
+			notifier.pusher = pusher
+
+			assert.NoError(t, tt.send(notifier))
+			assert.Len(t, pushed, 1)
+			assert.Equal(t, tt.wantTitle, pushed[0].Title)
+			for _, n := range pushed {
+				assert.True(t, isASCII(n.Title), "title %q", n.Title)
+				assert.True(t, isASCII(n.Message), "message %q", n.Message)
+			}
+		})
+	}
+}
+
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTruncateJobName(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxLen   int
+		ellipsis string
+		want     string
+	}{
+		{"Backup", 10, "…", "Backup"},
+		{"Very_Long_Job_Name", 18, "…", "Very_Long_Job_Name"},
+		{"Very_Long_Job_Name", 0, "…", "Very_Long_Job_Name"},
+		{"Very_Long_Job_Name", 15, "…", "Very_Lo…ob_Name"},
+		{"Very_Long_Job_Name", 10, "…", "Very_…Name"},
+		{"Very_Long_Job_Name", 10, "...", "Very...ame"},
+		{"Very_Long_Job_Name", 2, "…", "V…"},
+		{"Very_Long_Job_Name", 1, "…", "V"},
+		{"Very_Long_Job_Name", 4, "...", "V..."},
+		{"Very_Long_Job_Name", 3, "...", "Ver"},
+		{"Very_Long_Job_Name", 2, "...", "Ve"},
+		{"Sao_lưu_dữ_liệu_hàng_ngày", 9, "…", "Sao_…ngày"},
+	}
+
+	for _, tt := range tests {
+		got := truncateJobName(tt.name, tt.maxLen, tt.ellipsis)
+		assert.Equal(t, tt.want, got, "truncateJobName(%q, %d, %q)", tt.name, tt.maxLen, tt.ellipsis)
+	}
+}
+
+func TestNotifyFailedJobs_TruncatesLongJobNames(t *testing.T) {
+	long := "ETL_Load_3f2504e0-4f89-11d3-9a0c-0305e82c3301_Daily"
+	var pushed []toast.Notification
+	pusher := new(MockToastPusher)
+	pusher.On("Push", mock.Anything).Run(func(args mock.Arguments) {
+		pushed = append(pushed, args.Get(0).(toast.Notification))
+	}).Return(nil)
+
+	for _, grouping := range []bool{false, true} {
+		notifier := NewNotifier(config.NotificationConfig{
+			AppID:            "TestApp",
+			UseEmoji:         true,
+			MaxJobNameLength: 20,
+			Grouping:         config.GroupingConfig{Enabled: grouping},
+		})
+		notifier.pusher = pusher
+		assert.NoError(t, notifier.NotifyFailedJobs([]database.FailedJob{{ServerName: "S1", JobName: long}}))
+	}
+
+	require.Len(t, pushed, 2)
+	for _, n := range pushed {
+		assert.Contains(t, n.Message, "ETL_Load_3…301_Daily")
+		assert.NotContains(t, n.Message, long)
+	}
+}
+
+func TestNotifyFailedJobs_ShowsSeverity(t *testing.T) {
+	var pushed []toast.Notification
+	pusher := new(MockToastPusher)
+	pusher.On("Push", mock.Anything).Run(func(args mock.Arguments) {
+		pushed = append(pushed, args.Get(0).(toast.Notification))
+	}).Return(nil)
+
+	for _, grouping := range []bool{false, true} {
+		notifier := NewNotifier(config.NotificationConfig{AppID: "TestApp", Grouping: config.GroupingConfig{Enabled: grouping}})
+		notifier.pusher = pusher
+		job := database.FailedJob{ServerName: "S1", JobName: "Backup", Severity: config.SeverityCritical}
+		assert.NoError(t, notifier.NotifyFailedJobs([]database.FailedJob{job}))
+	}
+
+	require.Len(t, pushed, 2)
+	for _, n := range pushed {
+		assert.Contains(t, n.Message, "Backup [critical]")
+	}
+}
+
+// flakyPusher fails with a transient error a number of times, then succeeds.
+type flakyPusher struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (p *flakyPusher) Push(toast.Notification) error {
+	p.calls++
+	if p.calls <= p.failures {
+		return p.err
+	}
+	return nil
+}
+
+func TestNotifier_RetriesTransientPushErrors(t *testing.T) {
+	transient := fmt.Errorf("failed to push notification: %w", &exec.ExitError{})
+	job := database.FailedJob{ServerName: "S1", JobName: "Backup"}
+
+	tests := []struct {
+		name      string
+		retries   int
+		failures  int
+		err       error
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "fails once then succeeds", retries: 2, failures: 1, err: transient, wantCalls: 2},
+		{name: "retries exhausted", retries: 2, failures: 5, err: transient, wantCalls: 3, wantErr: true},
+		{name: "retries disabled", retries: 0, failures: 1, err: transient, wantCalls: 1, wantErr: true},
+		{name: "invalid toast is fatal", retries: 2, failures: 1, err: toast.ErrorInvalidAudio, wantCalls: 1, wantErr: true},
+		{name: "missing PowerShell is fatal", retries: 2, failures: 1, err: &exec.Error{Name: "PowerShell", Err: exec.ErrNotFound}, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := NewNotifier(config.NotificationConfig{
+				AppID: "TestApp",
+				Toast: config.BackendConfig{Enabled: true, Retries: tt.retries, RetryDelayMs: 250},
+			})
+			pusher := &flakyPusher{failures: tt.failures, err: tt.err}
+			notifier.SetPusher(pusher)
+			var slept []time.Duration
+			notifier.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+			err := notifier.NotifyFailedJobs([]database.FailedJob{job})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCalls, pusher.calls)
+			assert.Len(t, slept, tt.wantCalls-1)
+			for _, d := range slept {
+				assert.Equal(t, 250*time.Millisecond, d)
+			}
+		})
+	}
+}
+
+func TestBuildTopBody_OrdersBySeverity(t *testing.T) {
+	notifier := NewNotifier(config.NotificationConfig{TopN: 3})
+	jobs := []database.FailedJob{
+		{ServerName: "S1", JobName: "Cleanup", Severity: config.SeverityLow},
+		{ServerName: "S2", JobName: "Reindex"},
+		{ServerName: "S1", JobName: "Backup_QA", Severity: config.SeverityHigh},
+		{ServerName: "S2", JobName: "Backup_Prod", Severity: config.SeverityCritical},
+		{ServerName: "S1", JobName: "Backup_Dev", Severity: config.SeverityHigh},
+	}
+
+	body := notifier.buildTopBody(jobs, 2)
+	assert.Equal(t,
+		"- S2: Backup_Prod [critical]\n"+
+			"- S1: Backup_QA [high]\n"+
+			"- S1: Backup_Dev [high]\n"+
+			"... and 2 more (1 low)", body)
+}
+
+func TestBuildTopBody_SingleServer(t *testing.T) {
+	notifier := NewNotifier(config.NotificationConfig{TopN: 1})
+	jobs := []database.FailedJob{
+		{ServerName: "S1", JobName: "Reindex"},
+		{ServerName: "S1", JobName: "Backup", Severity: config.SeverityCritical},
+	}
+
+	assert.Equal(t, "[SERVER] S1:\n  - Backup [critical]\n... and 1 more", notifier.buildTopBody(jobs, 1))
+}
+
+func TestRemainderSummary(t *testing.T) {
+	rest := []database.FailedJob{
+		{Severity: config.SeverityLow},
+		{},
+		{Severity: config.SeverityHigh},
+		{Severity: config.SeverityLow},
+		{Severity: config.SeverityCritical},
+	}
+	assert.Equal(t, "... and 5 more (1 critical, 1 high, 2 low)", remainderSummary(rest))
+	assert.Equal(t, "... and 1 more", remainderSummary([]database.FailedJob{{}}))
+}
+
+func TestNotifyFailedJobs_TopN(t *testing.T) {
+	var pushed []toast.Notification
+	pusher := new(MockToastPusher)
+	pusher.On("Push", mock.Anything).Run(func(args mock.Arguments) {
+		pushed = append(pushed, args.Get(0).(toast.Notification))
+	}).Return(nil)
+
+	notifier := NewNotifier(config.NotificationConfig{
+		AppID:    "TestApp",
+		TopN:     1,
+		Grouping: config.GroupingConfig{Enabled: true},
+	})
+	notifier.pusher = pusher
+	assert.NoError(t, notifier.NotifyFailedJobs([]database.FailedJob{
+		{ServerName: "S1", JobName: "Cleanup", Severity: config.SeverityLow},
+		{ServerName: "S1", JobName: "Backup", Severity: config.SeverityCritical},
+	}))
+
+	require.Len(t, pushed, 1)
+	assert.Contains(t, pushed[0].Message, "Backup [critical]\n... and 1 more (1 low)")
+	assert.NotContains(t, pushed[0].Message, "Cleanup")
+}
+
+func TestNotifyLongRunning(t *testing.T) {
+	var pushed []toast.Notification
+	pusher := new(MockToastPusher)
+	pusher.On("Push", mock.Anything).Run(func(args mock.Arguments) {
+		pushed = append(pushed, args.Get(0).(toast.Notification))
+	}).Return(nil)
+
+	notifier := NewNotifier(config.NotificationConfig{
+		AppID:      "TestApp",
+		UseEmoji:   true,
+		TimeFormat: config.TimeFormatISO,
+		Grouping:   config.GroupingConfig{MaxJobsPerNotification: 2},
+	})
+	notifier.pusher = pusher
+
+	started := time.Date(2026, 2, 3, 1, 0, 0, 0, time.UTC)
+	etl := database.LongRunningJob{ServerName: "SQL01", DisplayName: "Production", JobName: "ETL_Nightly", StartedAt: started, RunningSeconds: 3*3600 + 5*60, ThresholdMinutes: 120}
+	index := database.LongRunningJob{ServerName: "SQL02", JobName: "Index_Rebuild", StartedAt: started, RunningSeconds: 45 * 60, ThresholdMinutes: 30}
+	backup := database.LongRunningJob{ServerName: "SQL02", JobName: "Backup", StartedAt: started, RunningSeconds: 2 * 3600, ThresholdMinutes: 90}
+
+	require.NoError(t, notifier.NotifyLongRunning(context.Background(), []database.LongRunningJob{etl}))
+	require.NoError(t, notifier.NotifyLongRunning(context.Background(), []database.LongRunningJob{etl, index, backup}))
+	require.NoError(t, notifier.NotifyLongRunning(context.Background(), nil))
+
+	require.Len(t, pushed, 2)
+	assert.Equal(t, "⏳ Job running long on Production", pushed[0].Title)
+	assert.Equal(t, "Job: ETL_Nightly\nStarted at: 2026-02-03T01:00:00Z\nRunning for 3h 5m (threshold 2h)", pushed[0].Message)
+	assert.Equal(t, "⏳ 3 Jobs running long", pushed[1].Title)
+	assert.Equal(t, "• Production: ETL_Nightly (3h 5m, threshold 2h)\n• SQL02: Index_Rebuild (45m, threshold 30m)\n... and 1 more", pushed[1].Message)
+}
@@ -0,0 +1,165 @@
+package notification
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/jobs"
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+// defaultOutboxMaxAge is used when no outbox max age is configured.
+const defaultOutboxMaxAge = 24 * time.Hour
+
+// LocalBackend is implemented by backends that deliver on this machine, such
+// as toast. Their failed sends are not queued in the outbox since retrying
+// later would not help.
+type LocalBackend interface {
+	Local() bool
+}
+
+// OutboxEntry is a failed send waiting for retry.
+type OutboxEntry struct {
+	Backend    string            `json:"backend"`
+	EnqueuedAt time.Time         `json:"enqueued_at"`
+	Result     *jobs.CheckResult `json:"result"`
+}
+
+// Outbox persists failed sends as JSON lines so they survive restarts.
+type Outbox struct {
+	path   string
+	maxAge time.Duration
+	mu     sync.Mutex
+}
+
+// NewOutbox creates an outbox backed by the file at path.
+// Entries older than maxAge are dropped; zero uses 24 hours.
+func NewOutbox(path string, maxAge time.Duration) *Outbox {
+	if maxAge <= 0 {
+		maxAge = defaultOutboxMaxAge
+	}
+	return &Outbox{path: path, maxAge: maxAge}
+}
+
+// Path returns the outbox file path.
+func (o *Outbox) Path() string {
+	return o.path
+}
+
+// Enqueue appends an entry to the outbox.
+func (o *Outbox) Enqueue(entry OutboxEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox entry: %w", err)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(o.path), 0o750); err != nil {
+		return fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+	// #nosec G304 -- path is the configured state directory
+	f, err := os.OpenFile(o.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open outbox: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close outbox: %w", err)
+	}
+	return nil
+}
+
+// Drain calls send for every queued entry and keeps the entries whose send
+// fails. Entries older than the max age at now are dropped without sending.
+// It returns the number of entries delivered. send must not call Enqueue.
+func (o *Outbox) Drain(now time.Time, send func(OutboxEntry) error) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entries, err := o.load()
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+
+	var kept []OutboxEntry
+	sent := 0
+	for _, entry := range entries {
+		if now.Sub(entry.EnqueuedAt) > o.maxAge {
+			continue
+		}
+		if err := send(entry); err != nil {
+			kept = append(kept, entry)
+			continue
+		}
+		sent++
+	}
+
+	return sent, o.save(kept)
+}
+
+// Len returns the number of queued entries.
+func (o *Outbox) Len() (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entries, err := o.load()
+	return len(entries), err
+}
+
+// load reads the queued entries. A missing file yields no entries and
+// lines that cannot be decoded are skipped.
+func (o *Outbox) load() ([]OutboxEntry, error) {
+	data, err := os.ReadFile(o.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	var entries []OutboxEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		var entry OutboxEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Result == nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// save replaces the outbox with entries, removing the file when empty.
+func (o *Outbox) save(entries []OutboxEntry) error {
+	if len(entries) == 0 {
+		if err := os.Remove(o.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to clear outbox: %w", err)
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return fmt.Errorf("failed to encode outbox entry: %w", err)
+		}
+	}
+	if err := state.WriteFileAtomic(o.path, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to save outbox: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+// localBackend is a fakeBackend that delivers on this machine.
+type localBackend struct {
+	fakeBackend
+}
+
+func (l *localBackend) Local() bool { return true }
+
+func TestOutbox_Drain(t *testing.T) {
+	now := time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC)
+	outbox := NewOutbox(filepath.Join(t.TempDir(), "outbox.jsonl"), time.Hour)
+
+	for _, entry := range []OutboxEntry{
+		{Backend: "expired", EnqueuedAt: now.Add(-2 * time.Hour), Result: &jobs.CheckResult{Summary: "old"}},
+		{Backend: "ok", EnqueuedAt: now.Add(-time.Minute), Result: &jobs.CheckResult{Summary: "a"}},
+		{Backend: "down", EnqueuedAt: now.Add(-time.Minute), Result: &jobs.CheckResult{Summary: "b"}},
+	} {
+		require.NoError(t, outbox.Enqueue(entry))
+	}
+
+	var attempted []string
+	sent, err := outbox.Drain(now, func(entry OutboxEntry) error {
+		attempted = append(attempted, entry.Backend)
+		if entry.Backend == "down" {
+			return errors.New("offline")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []string{"ok", "down"}, attempted, "expired entries are dropped without sending")
+
+	n, err := outbox.Len()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	sent, err = outbox.Drain(now, func(OutboxEntry) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	_, err = os.Stat(outbox.Path())
+	assert.True(t, os.IsNotExist(err), "an empty outbox removes its file")
+}
+
+func TestOutbox_SkipsCorruptLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	outbox := NewOutbox(path, 0)
+	require.NoError(t, outbox.Enqueue(OutboxEntry{Backend: "webhook", EnqueuedAt: time.Now(), Result: &jobs.CheckResult{}}))
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString("{truncated\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	n, err := outbox.Len()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func newOutboxDispatcher(t *testing.T, backends ...Backend) *Dispatcher {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := &config.Config{Notification: config.NotificationConfig{
+		Outbox: config.OutboxConfig{Enabled: true, MaxAgeHours: 24},
+	}}
+	d := NewDispatcher(cfg, state.NewStore(filepath.Join(dir, "state.json")))
+	d.mutePath = ""
+	d.backends = backends
+	return d
+}
+
+func TestDispatch_QueuesFailedRemoteSends(t *testing.T) {
+	webhook := &fakeBackend{name: "webhook", err: errors.New("network unreachable")}
+	toast := &localBackend{fakeBackend{name: "toast", err: errors.New("no session")}}
+	d := newOutboxDispatcher(t, webhook, toast)
+	result := &jobs.CheckResult{FailedJobs: []database.FailedJob{{ServerName: "S1", JobName: "J1"}}}
+
+	err := d.Dispatch(context.Background(), result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "webhook: network unreachable (queued for retry)")
+
+	n, err := d.outbox.Len()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "only the remote backend is queued")
+
+	// Still offline: the send stays queued
+	sent, err := d.RetryOutbox(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	n, _ = d.outbox.Len()
+	assert.Equal(t, 1, n)
+
+	// Back online: the queue drains
+	webhook.err = nil
+	sent, err = d.RetryOutbox(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	n, _ = d.outbox.Len()
+	assert.Equal(t, 0, n)
+
+	require.Len(t, webhook.received, 3)
+	assert.Equal(t, "J1", webhook.received[2].FailedJobs[0].JobName)
+	assert.Len(t, toast.received, 1, "toasts are never retried")
+}
+
+func TestRetryOutbox_DropsUnknownBackends(t *testing.T) {
+	d := newOutboxDispatcher(t)
+	require.NoError(t, d.outbox.Enqueue(OutboxEntry{Backend: "email", EnqueuedAt: time.Now(), Result: &jobs.CheckResult{}}))
+
+	sent, err := d.RetryOutbox(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	n, _ := d.outbox.Len()
+	assert.Equal(t, 0, n)
+}
+
+func TestNewDispatcher_OutboxDisabled(t *testing.T) {
+	cfg := &config.Config{}
+	d := NewDispatcher(cfg, state.NewStore(filepath.Join(t.TempDir(), "state.json")))
+	assert.Nil(t, d.outbox)
+
+	sent, err := d.RetryOutbox(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+}
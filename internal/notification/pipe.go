@@ -0,0 +1,206 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+// defaultPipeBufferSize is used when no pipe buffer size is configured.
+const defaultPipeBufferSize = 100
+
+// pipeCloseTimeout bounds how long Close waits for buffered events.
+const pipeCloseTimeout = 2 * time.Second
+
+// Pipe event types.
+const (
+	PipeEventFailedJobs  = "failed_jobs"
+	PipeEventServersDown = "servers_down"
+)
+
+// PipeEvent is one JSON line written to the notification pipe.
+type PipeEvent struct {
+	Type       string               `json:"type"`
+	Timestamp  time.Time            `json:"timestamp"`
+	Summary    string               `json:"summary,omitempty"`
+	FailedJobs []database.FailedJob `json:"failed_jobs,omitempty"`
+	Servers    []string             `json:"servers,omitempty"`
+
+	// Dropped is the number of events dropped since the previous line,
+	// because the buffer was full or no reader was connected.
+	Dropped int64 `json:"dropped,omitempty"`
+}
+
+// PipeDialer opens the pipe for writing, waiting for a reader if needed.
+type PipeDialer func() (io.WriteCloser, error)
+
+// PipeBackend writes notifications as JSON lines to a local named pipe so
+// other processes can subscribe to events. Events are buffered and written
+// in the background; while the buffer is full they are dropped, so a slow
+// or absent reader never blocks a check.
+type PipeBackend struct {
+	events  chan PipeEvent
+	dial    PipeDialer
+	probe   func() error
+	now     func() time.Time
+	dropped atomic.Int64
+	start   sync.Once
+	done    chan struct{}
+	mu      sync.RWMutex
+	closed  bool
+
+	// wake connects to the pipe once to release a dial waiting for a reader.
+	wake         func()
+	abandoned    atomic.Bool // Set by Close when it gives up on buffered events
+	closeTimeout time.Duration
+}
+
+// NewPipeBackend creates a backend writing to the named pipe in cfg.
+func NewPipeBackend(cfg config.PipeConfig) *PipeBackend {
+	size := cfg.BufferSize
+	if size <= 0 {
+		size = defaultPipeBufferSize
+	}
+	return &PipeBackend{
+		events: make(chan PipeEvent, size),
+		dial:   func() (io.WriteCloser, error) { return listenPipe(cfg.Name) },
+		probe:  func() error { return probePipe(cfg.Name) },
+		now:    time.Now,
+		done:   make(chan struct{}),
+
+		wake:         func() { wakePipe(cfg.Name) },
+		closeTimeout: pipeCloseTimeout,
+	}
+}
+
+// SetDialer replaces how the pipe is opened. It must be called before the
+// first event is sent.
+func (p *PipeBackend) SetDialer(dial PipeDialer) {
+	p.dial = dial
+}
+
+// Name implements Backend.
+func (p *PipeBackend) Name() string {
+	return "pipe"
+}
+
+// Local implements LocalBackend: the pipe is on this machine, so failed
+// sends are not queued for retry.
+func (p *PipeBackend) Local() bool {
+	return true
+}
+
+// Send implements Backend by queuing a failed jobs event.
+func (p *PipeBackend) Send(_ context.Context, result *jobs.CheckResult) error {
+	p.enqueue(PipeEvent{
+		Type:       PipeEventFailedJobs,
+		Timestamp:  result.Timestamp,
+		Summary:    result.Summary,
+		FailedJobs: result.FailedJobs,
+	})
+	return nil
+}
+
+// NotifyServerDown implements ServerDownNotifier by queuing a servers down event.
+func (p *PipeBackend) NotifyServerDown(_ context.Context, servers []string) error {
+	if len(servers) == 0 {
+		return nil
+	}
+	p.enqueue(PipeEvent{
+		Type:      PipeEventServersDown,
+		Timestamp: p.now(),
+		Servers:   servers,
+	})
+	return nil
+}
+
+// Close stops accepting events and waits briefly for buffered events to be
+// written. Events still buffered after the wait are lost, and a writer still
+// waiting for a reader is released so the pipe can be created again, e.g. by
+// the backend of a reloaded configuration.
+func (p *PipeBackend) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.events)
+	p.mu.Unlock()
+
+	p.start.Do(func() { go p.run() })
+	select {
+	case <-p.done:
+		return nil
+	case <-time.After(p.closeTimeout):
+	}
+
+	p.abandoned.Store(true)
+	p.wake()
+	select {
+	case <-p.done:
+	case <-time.After(p.closeTimeout):
+	}
+	return nil
+}
+
+// enqueue buffers event without blocking, counting it as dropped if the
+// buffer is full.
+func (p *PipeBackend) enqueue(event PipeEvent) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return
+	}
+
+	p.start.Do(func() { go p.run() })
+	select {
+	case p.events <- event:
+	default:
+		p.dropped.Add(1)
+	}
+}
+
+// run writes buffered events until the backend is closed. The pipe is
+// reopened after a write fails, e.g. when the reader disconnects.
+func (p *PipeBackend) run() {
+	defer close(p.done)
+
+	var w io.WriteCloser
+	for event := range p.events {
+		event.Dropped = p.dropped.Swap(0)
+		line, err := json.Marshal(event)
+		if err != nil {
+			p.dropped.Add(event.Dropped + 1)
+			continue
+		}
+		line = append(line, '\n')
+
+		if w == nil {
+			if p.abandoned.Load() {
+				p.dropped.Add(event.Dropped + 1)
+				continue
+			}
+			if w, err = p.dial(); err != nil {
+				w = nil
+				p.dropped.Add(event.Dropped + 1)
+				continue
+			}
+		}
+		if _, err := w.Write(line); err != nil {
+			_ = w.Close()
+			w = nil
+			p.dropped.Add(event.Dropped + 1)
+		}
+	}
+	if w != nil {
+		_ = w.Close()
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package notification
+
+import (
+	"errors"
+	"io"
+)
+
+// listenPipe reports that named pipes are unavailable on this platform.
+func listenPipe(string) (io.WriteCloser, error) {
+	return nil, errors.New("named pipes are only supported on Windows")
+}
+
+// probePipe reports that named pipes are unavailable on this platform.
+func probePipe(string) error {
+	return errors.New("named pipes are only supported on Windows")
+}
+
+// wakePipe does nothing; named pipes are unavailable on this platform.
+func wakePipe(string) {}
@@ -0,0 +1,192 @@
+package notification
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+// pipeBuffer is an in-memory pipe whose writes can be held back to
+// simulate a slow reader.
+type pipeBuffer struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	writing chan struct{} // receives once per write, if set
+	release chan struct{} // each write waits for a value, if set
+}
+
+func (p *pipeBuffer) Write(b []byte) (int, error) {
+	if p.writing != nil {
+		p.writing <- struct{}{}
+	}
+	if p.release != nil {
+		<-p.release
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.buf.Write(b)
+}
+
+func (p *pipeBuffer) Close() error { return nil }
+
+// events decodes the JSON lines written so far.
+func (p *pipeBuffer) events(t *testing.T) []PipeEvent {
+	t.Helper()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var events []PipeEvent
+	scanner := bufio.NewScanner(bytes.NewReader(p.buf.Bytes()))
+	for scanner.Scan() {
+		var event PipeEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event), "line %q", scanner.Text())
+		events = append(events, event)
+	}
+	return events
+}
+
+func newTestPipe(size int, w io.WriteCloser) *PipeBackend {
+	p := NewPipeBackend(config.PipeConfig{Name: "watchman-test", BufferSize: size})
+	p.SetDialer(func() (io.WriteCloser, error) { return w, nil })
+	p.now = func() time.Time { return time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC) }
+	return p
+}
+
+func TestPipeBackend_WritesJSONLines(t *testing.T) {
+	w := &pipeBuffer{}
+	p := newTestPipe(10, w)
+
+	result := &jobs.CheckResult{
+		Timestamp:  time.Date(2026, 2, 3, 7, 59, 0, 0, time.UTC),
+		Summary:    "1 failed job",
+		FailedJobs: []database.FailedJob{{ServerName: "SQL1", JobName: "Backup"}},
+	}
+	require.NoError(t, p.Send(context.Background(), result))
+	require.NoError(t, p.NotifyServerDown(context.Background(), []string{"SQL2"}))
+	require.NoError(t, p.Close())
+
+	assert.True(t, bytes.HasSuffix(w.buf.Bytes(), []byte("}\n")))
+	events := w.events(t)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, PipeEventFailedJobs, events[0].Type)
+	assert.Equal(t, "1 failed job", events[0].Summary)
+	require.Len(t, events[0].FailedJobs, 1)
+	assert.Equal(t, "Backup", events[0].FailedJobs[0].JobName)
+	assert.Zero(t, events[0].Dropped)
+
+	assert.Equal(t, PipeEventServersDown, events[1].Type)
+	assert.Equal(t, []string{"SQL2"}, events[1].Servers)
+	assert.Equal(t, 8, events[1].Timestamp.Hour())
+}
+
+func TestPipeBackend_DropsWhenBufferFull(t *testing.T) {
+	w := &pipeBuffer{writing: make(chan struct{}, 10), release: make(chan struct{})}
+	p := newTestPipe(2, w)
+	ctx := context.Background()
+	send := func(summary string) {
+		require.NoError(t, p.Send(ctx, &jobs.CheckResult{Summary: summary}))
+	}
+
+	// The first event is taken by the writer, which blocks on the slow reader
+	send("1")
+	<-w.writing
+
+	// Two more fill the buffer and the rest are dropped without blocking
+	done := make(chan struct{})
+	go func() {
+		for _, s := range []string{"2", "3", "4", "5"} {
+			send(s)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Send blocked on a full buffer")
+	}
+
+	close(w.release)
+	require.NoError(t, p.Close())
+
+	events := w.events(t)
+	require.Len(t, events, 3)
+	assert.Equal(t, []string{"1", "2", "3"}, []string{events[0].Summary, events[1].Summary, events[2].Summary})
+	assert.Equal(t, int64(2), events[1].Dropped, "drops are reported on the next line written")
+	assert.Zero(t, events[2].Dropped)
+}
+
+func TestPipeBackend_ReopensAfterWriteError(t *testing.T) {
+	w := &pipeBuffer{}
+	dials := 0
+	p := newTestPipe(10, nil)
+	p.SetDialer(func() (io.WriteCloser, error) {
+		dials++
+		if dials == 1 {
+			return failingWriter{}, nil
+		}
+		return w, nil
+	})
+
+	require.NoError(t, p.Send(context.Background(), &jobs.CheckResult{Summary: "lost"}))
+	require.NoError(t, p.Send(context.Background(), &jobs.CheckResult{Summary: "kept"}))
+	require.NoError(t, p.Close())
+
+	events := w.events(t)
+	require.Len(t, events, 1)
+	assert.Equal(t, "kept", events[0].Summary)
+	assert.Equal(t, int64(1), events[0].Dropped)
+	assert.Equal(t, 2, dials)
+}
+
+// failingWriter is a pipe whose reader has disconnected.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("pipe is being closed") }
+func (failingWriter) Close() error              { return nil }
+
+func TestPipeBackend_CloseReleasesWaitingDial(t *testing.T) {
+	p := NewPipeBackend(config.PipeConfig{Name: "watchman-test"})
+	p.closeTimeout = 50 * time.Millisecond
+
+	// No reader connects: the dial waits until the pipe is woken
+	reader := make(chan struct{})
+	dials := 0
+	p.SetDialer(func() (io.WriteCloser, error) {
+		dials++
+		<-reader
+		return failingWriter{}, nil
+	})
+	var once sync.Once
+	p.wake = func() { once.Do(func() { close(reader) }) }
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, p.Send(context.Background(), &jobs.CheckResult{}))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		_ = p.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not return")
+	}
+	<-p.done
+	assert.Equal(t, 1, dials, "no redial after Close gave up")
+}
@@ -0,0 +1,70 @@
+//go:build windows
+
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// pipeOutBufferSize is the pipe's output buffer size in bytes.
+const pipeOutBufferSize = 64 * 1024
+
+// listenPipe creates the named pipe \\.\pipe\<name> and waits for a local
+// reader to connect. Remote clients are rejected.
+func listenPipe(name string) (io.WriteCloser, error) {
+	path := `\\.\pipe\` + name
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pipe name %q: %w", name, err)
+	}
+
+	h, err := windows.CreateNamedPipe(p,
+		windows.PIPE_ACCESS_OUTBOUND,
+		windows.PIPE_TYPE_BYTE|windows.PIPE_WAIT|windows.PIPE_REJECT_REMOTE_CLIENTS,
+		1, pipeOutBufferSize, 0, 0, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe %s: %w", path, err)
+	}
+
+	if err := windows.ConnectNamedPipe(h, nil); err != nil && !errors.Is(err, windows.ERROR_PIPE_CONNECTED) {
+		_ = windows.CloseHandle(h)
+		return nil, fmt.Errorf("failed to wait for pipe reader: %w", err)
+	}
+	return os.NewFile(uintptr(h), path), nil
+}
+
+// wakePipe connects to \\.\pipe\<name> as a reader and disconnects at once,
+// releasing a listenPipe waiting for a reader.
+func wakePipe(name string) {
+	f, err := os.OpenFile(`\\.\pipe\`+name, os.O_RDONLY, 0)
+	if err == nil {
+		_ = f.Close()
+	}
+}
+
+// probePipe creates and closes the named pipe \\.\pipe\<name> to check the
+// name is valid and not held by another process.
+func probePipe(name string) error {
+	path := `\\.\pipe\` + name
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return fmt.Errorf("invalid pipe name %q: %w", name, err)
+	}
+
+	h, err := windows.CreateNamedPipe(p,
+		windows.PIPE_ACCESS_OUTBOUND,
+		windows.PIPE_TYPE_BYTE|windows.PIPE_WAIT|windows.PIPE_REJECT_REMOTE_CLIENTS,
+		1, pipeOutBufferSize, 0, 0, nil)
+	if errors.Is(err, windows.ERROR_ACCESS_DENIED) || errors.Is(err, windows.ERROR_PIPE_BUSY) {
+		return fmt.Errorf("pipe %s is in use by another process (is the service running?)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create pipe %s: %w", path, err)
+	}
+	return windows.CloseHandle(h)
+}
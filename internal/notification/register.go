@@ -0,0 +1,105 @@
+package notification
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/winshell"
+)
+
+// toastDisplayName is the application name shown on toasts.
+const toastDisplayName = "Watchman"
+
+// aumidRegistryPath is the registry path under which AUMIDs are registered.
+// The entry lets Windows show the app's name and icon instead of "Windows PowerShell".
+const aumidRegistryPath = `Software\Classes\AppUserModelId`
+
+// ToastRegistration describes the registry entry and shortcut binding an
+// App User Model ID (AUMID) to Watchman.
+type ToastRegistration struct {
+	AppID        string `json:"app_id"`
+	DisplayName  string `json:"display_name"`
+	AllUsers     bool   `json:"all_users"`
+	RegistryKey  string `json:"registry_key"`
+	ShortcutPath string `json:"shortcut_path"`
+	Target       string `json:"target"`
+	IconPath     string `json:"icon_path,omitempty"`
+}
+
+// ToastRegistrar registers the notification AUMID with Windows.
+type ToastRegistrar struct {
+	getenv         func(key string) string
+	setValues      func(machine bool, path string, values map[string]string) error
+	createShortcut func(path, target, icon, appID string) error
+}
+
+// NewToastRegistrar creates a registrar that writes to the Windows registry and Start Menu.
+func NewToastRegistrar() *ToastRegistrar {
+	return &ToastRegistrar{
+		getenv:         os.Getenv,
+		setValues:      winshell.SetStringValues,
+		createShortcut: winshell.CreateShortcut,
+	}
+}
+
+// Plan builds the registration for cfg.AppID without changing the system.
+// target is the executable the shortcut points to. With allUsers the entries
+// are machine-wide (HKLM, ProgramData); otherwise per-user (HKCU, AppData).
+func (r *ToastRegistrar) Plan(cfg config.NotificationConfig, target string, allUsers bool) (*ToastRegistration, error) {
+	if err := checkAppID(cfg.AppID); err != nil {
+		return nil, err
+	}
+
+	base := r.getenv("APPDATA")
+	if allUsers {
+		base = r.getenv("ProgramData")
+	}
+	if base == "" {
+		return nil, fmt.Errorf("failed to locate Start Menu folder")
+	}
+
+	return &ToastRegistration{
+		AppID:        cfg.AppID,
+		DisplayName:  toastDisplayName,
+		AllUsers:     allUsers,
+		RegistryKey:  aumidRegistryPath + `\` + cfg.AppID,
+		ShortcutPath: filepath.Join(base, "Microsoft", "Windows", "Start Menu", "Programs", toastDisplayName+".lnk"),
+		Target:       target,
+		IconPath:     cfg.IconPath,
+	}, nil
+}
+
+// Register writes the registry entry and creates the Start Menu shortcut.
+func (r *ToastRegistrar) Register(reg *ToastRegistration) error {
+	values := map[string]string{"DisplayName": reg.DisplayName}
+	if reg.IconPath != "" {
+		values["IconUri"] = reg.IconPath
+	}
+
+	if err := r.setValues(reg.AllUsers, reg.RegistryKey, values); err != nil {
+		return fmt.Errorf("failed to register app id: %w", err)
+	}
+
+	icon := reg.IconPath
+	if icon == "" {
+		icon = reg.Target
+	}
+	if err := r.createShortcut(reg.ShortcutPath, reg.Target, icon, reg.AppID); err != nil {
+		return fmt.Errorf("failed to create start menu shortcut: %w", err)
+	}
+	return nil
+}
+
+// checkAppID returns an error if appID cannot be registered as an AUMID.
+func checkAppID(appID string) error {
+	if appID == "" {
+		return fmt.Errorf("notification app_id is not configured")
+	}
+	if strings.ContainsAny(appID, `\/`) {
+		return fmt.Errorf("invalid notification app_id: %s (must not contain path separators)", appID)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package notification
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+// newTestRegistrar returns a registrar with fake environment and recording system calls.
+func newTestRegistrar(calls *[]string) *ToastRegistrar {
+	env := map[string]string{
+		"APPDATA":     `C:\Users\ops\AppData\Roaming`,
+		"ProgramData": `C:\ProgramData`,
+	}
+	return &ToastRegistrar{
+		getenv: func(key string) string { return env[key] },
+		setValues: func(machine bool, path string, values map[string]string) error {
+			*calls = append(*calls, "registry:"+path)
+			return nil
+		},
+		createShortcut: func(path, target, icon, appID string) error {
+			*calls = append(*calls, "shortcut:"+path)
+			return nil
+		},
+	}
+}
+
+func TestToastRegistrar_Plan(t *testing.T) {
+	tests := []struct {
+		name         string
+		appID        string
+		allUsers     bool
+		wantKey      string
+		wantShortcut string
+		wantErr      bool
+	}{
+		{
+			name:         "per user",
+			appID:        "Watchman.Alerts",
+			wantKey:      `Software\Classes\AppUserModelId\Watchman.Alerts`,
+			wantShortcut: filepath.Join(`C:\Users\ops\AppData\Roaming`, "Microsoft", "Windows", "Start Menu", "Programs", "Watchman.lnk"),
+		},
+		{
+			name:         "all users",
+			appID:        "Watchman.Alerts",
+			allUsers:     true,
+			wantKey:      `Software\Classes\AppUserModelId\Watchman.Alerts`,
+			wantShortcut: filepath.Join(`C:\ProgramData`, "Microsoft", "Windows", "Start Menu", "Programs", "Watchman.lnk"),
+		},
+		{
+			name:    "missing app id",
+			wantErr: true,
+		},
+		{
+			name:    "app id with path separator",
+			appID:   `Watchman\..\Run`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			r := newTestRegistrar(&calls)
+
+			reg, err := r.Plan(config.NotificationConfig{AppID: tt.appID}, `C:\ProgramData\Watchman\watchman.exe`, tt.allUsers)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantKey, reg.RegistryKey)
+			assert.Equal(t, tt.wantShortcut, reg.ShortcutPath)
+			assert.Equal(t, tt.allUsers, reg.AllUsers)
+			assert.Empty(t, calls, "planning must not touch the system")
+		})
+	}
+}
+
+func TestToastRegistrar_Register(t *testing.T) {
+	var calls []string
+	r := newTestRegistrar(&calls)
+
+	var gotValues map[string]string
+	var gotIcon, gotAppID string
+	r.setValues = func(machine bool, path string, values map[string]string) error {
+		gotValues = values
+		calls = append(calls, "registry:"+path)
+		return nil
+	}
+	r.createShortcut = func(path, target, icon, appID string) error {
+		gotIcon, gotAppID = icon, appID
+		calls = append(calls, "shortcut:"+path)
+		return nil
+	}
+
+	reg, err := r.Plan(config.NotificationConfig{AppID: "Watchman"}, `C:\watchman.exe`, false)
+	require.NoError(t, err)
+	require.NoError(t, r.Register(reg))
+
+	assert.Equal(t, []string{"registry:" + reg.RegistryKey, "shortcut:" + reg.ShortcutPath}, calls)
+	assert.Equal(t, map[string]string{"DisplayName": "Watchman"}, gotValues)
+	assert.Equal(t, `C:\watchman.exe`, gotIcon, "shortcut falls back to the executable icon")
+	assert.Equal(t, "Watchman", gotAppID, "shortcut carries the configured app id")
+}
+
+func TestToastRegistrar_RegisterError(t *testing.T) {
+	var calls []string
+	r := newTestRegistrar(&calls)
+	r.setValues = func(bool, string, map[string]string) error { return errors.New("access denied") }
+
+	reg, err := r.Plan(config.NotificationConfig{AppID: "Watchman"}, `C:\watchman.exe`, true)
+	require.NoError(t, err)
+
+	err = r.Register(reg)
+	assert.ErrorContains(t, err, "access denied")
+	assert.Empty(t, calls, "shortcut is not created when registry write fails")
+}
@@ -0,0 +1,243 @@
+package notification
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-toast/toast"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+// Simulation is what the notification pipeline would do with a set of failed jobs.
+type Simulation struct {
+	Input         int                     `json:"input"`
+	Reported      []database.FailedJob    `json:"reported"`
+	Dropped       []SimulatedDrop         `json:"dropped"`
+	Notifications []SimulatedNotification `json:"notifications"`
+
+	// Deferred are the jobs queued by quiet hours, digest mode or the send
+	// throttle instead of being sent.
+	Deferred []database.FailedJob `json:"deferred"`
+
+	// Muted is set if the mute file exists, so nothing would be sent.
+	Muted bool `json:"muted,omitempty"`
+}
+
+// SimulatedDrop is a failed job removed before notification.
+type SimulatedDrop struct {
+	Job    database.FailedJob `json:"job"`
+	Reason string             `json:"reason"`
+}
+
+// SimulatedNotification is a toast or pipe event that would have been sent.
+// Pipe events carry the JSON line as the message.
+type SimulatedNotification struct {
+	Backend string `json:"backend"`
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	Sound   string `json:"sound,omitempty"`
+}
+
+// recordingPusher collects toasts instead of showing them.
+type recordingPusher struct {
+	sent []toast.Notification
+}
+
+// Push records the notification.
+func (p *recordingPusher) Push(notification toast.Notification) error {
+	p.sent = append(p.sent, notification)
+	return nil
+}
+
+// recordingPipe collects the lines written to the notification pipe.
+type recordingPipe struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write records p.
+func (r *recordingPipe) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.Write(p)
+}
+
+// Close implements io.Closer.
+func (r *recordingPipe) Close() error {
+	return nil
+}
+
+// lines returns the recorded lines.
+func (r *recordingPipe) lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(r.buf.Bytes()))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+// Simulate runs failed jobs through the server job filters,
+// monitoring.warn_statuses and monitoring.consecutive_failures, then
+// dispatches them as a check would, without a database or desktop.
+// Dispatching applies monitor-only servers, mute, quiet hours, digest mode
+// and the send throttle against a copy of st kept in memory, so the state
+// file is never changed; st may be nil. Toasts and pipe events are recorded
+// instead of sent, and the outbox is not used.
+//
+// Jobs are matched to servers by name, case-insensitively; jobs for unknown
+// or disabled servers are dropped. The input stands in for the job history
+// of consecutive_failures: a job's job-level outcomes in the input, newest
+// first.
+func Simulate(cfg *config.Config, st *state.State, failed []database.FailedJob) (*Simulation, error) {
+	sim := &Simulation{
+		Input:         len(failed),
+		Reported:      []database.FailedJob{},
+		Dropped:       []SimulatedDrop{},
+		Notifications: []SimulatedNotification{},
+		Deferred:      []database.FailedJob{},
+	}
+	notified := sim.filter(cfg, failed)
+
+	store, err := state.NewMemoryStore(st)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy state: %w", err)
+	}
+	simCfg := *cfg
+	simCfg.Notification.Outbox.Enabled = false
+	dispatcher := NewDispatcher(&simCfg, store)
+	sim.Muted = dispatcher.Muted()
+
+	pusher := &recordingPusher{}
+	pipe := &recordingPipe{}
+	for _, b := range dispatcher.Backends() {
+		switch b := b.(type) {
+		case *Notifier:
+			b.SetPusher(pusher)
+		case *PipeBackend:
+			b.SetDialer(func() (io.WriteCloser, error) { return pipe, nil })
+		}
+	}
+
+	result := &jobs.CheckResult{
+		Status:     "failed_jobs",
+		Timestamp:  time.Now(),
+		FailedJobs: notified,
+		Summary:    fmt.Sprintf("%d failed jobs", len(notified)),
+	}
+	err = dispatcher.Dispatch(context.Background(), result)
+	// Close flushes the recorded pipe events
+	if cErr := dispatcher.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate notifications: %w", err)
+	}
+
+	after, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate notifications: %w", err)
+	}
+	sim.Deferred = append(sim.Deferred, after.PendingNotifications...)
+
+	for _, n := range pusher.sent {
+		sim.Notifications = append(sim.Notifications, SimulatedNotification{
+			Backend: "toast",
+			Title:   n.Title,
+			Message: n.Message,
+			Sound:   string(n.Audio),
+		})
+	}
+	for _, line := range pipe.lines() {
+		sim.Notifications = append(sim.Notifications, SimulatedNotification{
+			Backend: "pipe",
+			Title:   PipeEventFailedJobs,
+			Message: line,
+		})
+	}
+	return sim, nil
+}
+
+// filter records the jobs dropped before dispatch and the reported ones,
+// and returns the jobs a check would dispatch: the reported jobs plus those
+// of monitor-only servers, which the dispatcher leaves out.
+func (sim *Simulation) filter(cfg *config.Config, failed []database.FailedJob) []database.FailedJob {
+	servers := make(map[string]config.ServerConfig, len(cfg.Servers))
+	for _, srv := range cfg.Servers {
+		servers[strings.ToLower(srv.Name)] = srv
+	}
+	history := jobHistory(failed)
+	threshold := cfg.Monitoring.ConsecutiveFailures
+
+	var notified []database.FailedJob
+	for _, job := range failed {
+		reason := ""
+		srv, ok := servers[strings.ToLower(job.ServerName)]
+		switch {
+		case !ok:
+			reason = "unknown server"
+		case !srv.Enabled:
+			reason = "server disabled"
+		default:
+			reason = database.DropReason(srv, job)
+		}
+		if reason == "" {
+			switch {
+			case cfg.Monitoring.IsWarnStatus(job.Status):
+				reason = "warn-only status (warn_statuses)"
+			case jobs.BelowConsecutiveFailures(job, history[historyKey(job)], threshold):
+				reason = fmt.Sprintf("fewer than %d consecutive failures", threshold)
+			}
+		}
+
+		if reason != "" {
+			sim.Dropped = append(sim.Dropped, SimulatedDrop{Job: job, Reason: reason})
+			continue
+		}
+		job.ConfigName = srv.Name
+		job.DisplayName = srv.DisplayName
+		notified = append(notified, job)
+		if srv.MonitorOnly {
+			sim.Dropped = append(sim.Dropped, SimulatedDrop{Job: job, Reason: "monitor-only server"})
+			continue
+		}
+		sim.Reported = append(sim.Reported, job)
+	}
+	return notified
+}
+
+// jobHistory returns the job-level outcomes in failed of each job, newest
+// first, keyed by historyKey.
+func jobHistory(failed []database.FailedJob) map[string][]int {
+	runs := slices.Clone(failed)
+	slices.SortStableFunc(runs, func(a, b database.FailedJob) int {
+		return b.FailedAt.Compare(a.FailedAt)
+	})
+
+	history := make(map[string][]int)
+	for _, job := range runs {
+		if job.StepID == 0 {
+			history[historyKey(job)] = append(history[historyKey(job)], job.Status)
+		}
+	}
+	return history
+}
+
+// historyKey identifies a job across servers.
+func historyKey(job database.FailedJob) string {
+	return strings.ToLower(job.ServerName) + "|" + job.JobName
+}
@@ -0,0 +1,176 @@
+package notification
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+func simulateConfig() *config.Config {
+	return &config.Config{
+		Servers: []config.ServerConfig{
+			{
+				Name:    "SQL01",
+				Enabled: true,
+				Jobs:    config.JobsFilter{Exclude: []string{"*_Test"}},
+			},
+			{Name: "SQL02", Enabled: false},
+		},
+		Notification: config.NotificationConfig{
+			AppID:    "Watchman",
+			UseEmoji: true,
+			Grouping: config.GroupingConfig{Enabled: true, MaxJobsPerNotification: 5},
+			Toast:    config.BackendConfig{Enabled: true},
+		},
+	}
+}
+
+func sampleFailures() []database.FailedJob {
+	at := time.Date(2026, 2, 3, 7, 30, 0, 0, time.UTC)
+	return []database.FailedJob{
+		{ServerName: "SQL01", JobName: "Backup_Full", FailedAt: at},
+		{ServerName: "SQL01", JobName: "ETL_Daily", FailedAt: at},
+		{ServerName: "SQL01", JobName: "ETL_Test", FailedAt: at},
+		{ServerName: "SQL01", JobName: "ETL_Daily", StepID: 2, StepName: "Load", FailedAt: at},
+		{ServerName: "sql02", JobName: "Backup_Full", FailedAt: at},
+		{ServerName: "SQL99", JobName: "Backup_Full", FailedAt: at},
+	}
+}
+
+func TestSimulate_Grouped(t *testing.T) {
+	sim, err := Simulate(simulateConfig(), nil, sampleFailures())
+	require.NoError(t, err)
+
+	assert.Equal(t, 6, sim.Input)
+	require.Len(t, sim.Reported, 2)
+	assert.Equal(t, "Backup_Full", sim.Reported[0].JobName)
+	assert.Equal(t, "ETL_Daily", sim.Reported[1].JobName)
+
+	reasons := make([]string, 0, len(sim.Dropped))
+	for _, d := range sim.Dropped {
+		reasons = append(reasons, d.Reason)
+	}
+	assert.Equal(t, []string{
+		"excluded by job filters",
+		"step outcome (include_steps is off)",
+		"server disabled",
+		"unknown server",
+	}, reasons)
+
+	require.Len(t, sim.Notifications, 1)
+	assert.Equal(t, "❌ 2 SQL Agent Jobs Failed", sim.Notifications[0].Title)
+	assert.Contains(t, sim.Notifications[0].Message, "• Backup_Full")
+	assert.Contains(t, sim.Notifications[0].Message, "• ETL_Daily")
+}
+
+func TestSimulate_Ungrouped(t *testing.T) {
+	cfg := simulateConfig()
+	cfg.Notification.Grouping.Enabled = false
+
+	sim, err := Simulate(cfg, nil, sampleFailures())
+	require.NoError(t, err)
+
+	require.Len(t, sim.Notifications, 2)
+	assert.Equal(t, "❌ Job Failed on SQL01", sim.Notifications[0].Title)
+	assert.Contains(t, sim.Notifications[0].Message, "Job: Backup_Full")
+	assert.Contains(t, sim.Notifications[1].Message, "Job: ETL_Daily")
+}
+
+func TestSimulate_ToastDisabled(t *testing.T) {
+	cfg := simulateConfig()
+	cfg.Notification.Toast.Enabled = false
+
+	sim, err := Simulate(cfg, nil, sampleFailures())
+	require.NoError(t, err)
+
+	assert.Len(t, sim.Reported, 2)
+	assert.Empty(t, sim.Notifications)
+}
+
+func TestSimulate_MonitoringFilters(t *testing.T) {
+	cfg := simulateConfig()
+	cfg.Servers = append(cfg.Servers, config.ServerConfig{Name: "SQL03", Enabled: true, MonitorOnly: true})
+	cfg.Monitoring.WarnStatuses = []string{"canceled"}
+	cfg.Monitoring.ConsecutiveFailures = 2
+
+	at := time.Date(2026, 2, 3, 7, 30, 0, 0, time.UTC)
+	sim, err := Simulate(cfg, nil, []database.FailedJob{
+		{ServerName: "SQL01", JobName: "Backup_Full", FailedAt: at},
+		{ServerName: "SQL01", JobName: "Backup_Full", FailedAt: at.Add(-time.Hour)},
+		{ServerName: "SQL01", JobName: "ETL_Daily", FailedAt: at},
+		{ServerName: "SQL01", JobName: "Index_Rebuild", Status: 3, FailedAt: at},
+		{ServerName: "SQL03", JobName: "Backup_Full", FailedAt: at},
+		{ServerName: "SQL03", JobName: "Backup_Full", FailedAt: at.Add(-time.Hour)},
+	})
+	require.NoError(t, err)
+
+	reasons := make(map[string]string, len(sim.Dropped))
+	for _, d := range sim.Dropped {
+		reasons[d.Job.ServerName+"/"+d.Job.JobName] = d.Reason
+	}
+	assert.Equal(t, map[string]string{
+		"SQL01/ETL_Daily":     "fewer than 2 consecutive failures",
+		"SQL01/Index_Rebuild": "warn-only status (warn_statuses)",
+		"SQL03/Backup_Full":   "monitor-only server",
+	}, reasons)
+
+	require.Len(t, sim.Notifications, 1)
+	assert.Equal(t, "❌ 2 SQL Agent Jobs Failed", sim.Notifications[0].Title)
+	assert.NotContains(t, sim.Notifications[0].Message, "SQL03")
+}
+
+func TestSimulate_Throttled(t *testing.T) {
+	cfg := simulateConfig()
+	cfg.Notification.MinIntervalBetweenSends = 3600
+	st := &state.State{LastNotificationSent: time.Now().Add(-time.Minute)}
+
+	sim, err := Simulate(cfg, st, sampleFailures())
+	require.NoError(t, err)
+
+	assert.Len(t, sim.Reported, 2)
+	assert.Len(t, sim.Deferred, 2)
+	assert.Empty(t, sim.Notifications)
+	assert.True(t, st.PendingNotifications == nil, "the given state is left unchanged")
+}
+
+func TestSimulate_QuietHours(t *testing.T) {
+	cfg := simulateConfig()
+	loc, err := cfg.GetLocation()
+	require.NoError(t, err)
+	now := time.Now().In(loc)
+	cfg.Notification.QuietHours = config.QuietHoursConfig{
+		Enabled: true,
+		Start:   now.Add(-time.Hour).Format("15:04"),
+		End:     now.Add(time.Hour).Format("15:04"),
+	}
+
+	sim, err := Simulate(cfg, nil, sampleFailures())
+	require.NoError(t, err)
+
+	assert.Len(t, sim.Deferred, 2)
+	assert.Empty(t, sim.Notifications)
+}
+
+func TestSimulate_Pipe(t *testing.T) {
+	cfg := simulateConfig()
+	cfg.Notification.Toast.Enabled = false
+	cfg.Notification.Pipe = config.PipeConfig{Name: "watchman-simulate"}
+
+	sim, err := Simulate(cfg, nil, sampleFailures())
+	require.NoError(t, err)
+
+	require.Len(t, sim.Notifications, 1)
+	assert.Equal(t, "pipe", sim.Notifications[0].Backend)
+	assert.Equal(t, PipeEventFailedJobs, sim.Notifications[0].Title)
+
+	var event PipeEvent
+	require.NoError(t, json.Unmarshal([]byte(sim.Notifications[0].Message), &event))
+	assert.Len(t, event.FailedJobs, 2)
+}
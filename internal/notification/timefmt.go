@@ -0,0 +1,54 @@
+package notification
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+// Layouts of the notification.time_format presets.
+const (
+	layoutISO = time.RFC3339
+	layoutUS  = "01/02/2006 3:04:05 PM"
+)
+
+// formatTime renders t according to a notification.time_format value.
+// now is only used by the relative format.
+func formatTime(t time.Time, format string, now time.Time) string {
+	switch format {
+	case "":
+		return t.Format(config.TimeFormatDefault)
+	case config.TimeFormatISO:
+		return t.Format(layoutISO)
+	case config.TimeFormatUS:
+		return t.Format(layoutUS)
+	case config.TimeFormatRelative:
+		return relativeTime(now.Sub(t))
+	default:
+		return t.Format(format)
+	}
+}
+
+// relativeTime renders how long ago something happened, in the largest
+// whole unit. Times in the future, e.g. from clock skew, read as "just now".
+func relativeTime(ago time.Duration) string {
+	switch {
+	case ago < time.Minute:
+		return "just now"
+	case ago < time.Hour:
+		return plural(int(ago/time.Minute), "minute") + " ago"
+	case ago < 24*time.Hour:
+		return plural(int(ago/time.Hour), "hour") + " ago"
+	default:
+		return plural(int(ago/(24*time.Hour)), "day") + " ago"
+	}
+}
+
+// plural formats a count with its unit, e.g. "1 minute" or "5 minutes".
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
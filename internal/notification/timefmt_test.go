@@ -0,0 +1,37 @@
+package notification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatTime(t *testing.T) {
+	at := time.Date(2026, 2, 3, 14, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		format string
+		now    time.Time
+		want   string
+	}{
+		{"", at, "2026-02-03 14:30:15"},
+		{"2006-01-02 15:04:05", at, "2026-02-03 14:30:15"},
+		{"iso", at, "2026-02-03T14:30:15Z"},
+		{"us", at, "02/03/2026 2:30:15 PM"},
+		{"Jan 2 15:04", at, "Feb 3 14:30"},
+		{"relative", at.Add(30 * time.Second), "just now"},
+		{"relative", at.Add(-time.Minute), "just now"},
+		{"relative", at.Add(time.Minute), "1 minute ago"},
+		{"relative", at.Add(5*time.Minute + 59*time.Second), "5 minutes ago"},
+		{"relative", at.Add(time.Hour), "1 hour ago"},
+		{"relative", at.Add(23 * time.Hour), "23 hours ago"},
+		{"relative", at.Add(50 * time.Hour), "2 days ago"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+"/"+tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatTime(at, tt.format, tt.now))
+		})
+	}
+}
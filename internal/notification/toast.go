@@ -2,13 +2,20 @@
 package notification
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os/exec"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/go-toast/toast"
 
 	"github.com/hoangtran1411/watchman/internal/config"
 	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+	"github.com/hoangtran1411/watchman/internal/winshell"
 )
 
 // ToastPusher abstracts the toast notification sending.
@@ -27,10 +34,36 @@ func (p *DefaultToastPusher) Push(notification toast.Notification) error {
 	return nil
 }
 
+// glyphs holds the markers used in notification text.
+type glyphs struct {
+	fail     string
+	server   string
+	bullet   string
+	down     string
+	running  string
+	update   string
+	done     string
+	ellipsis string
+}
+
+var (
+	emojiGlyphs = glyphs{fail: "❌", server: "🖥️", bullet: "•", down: "🔌", running: "⏳", update: "🔄", done: "✅", ellipsis: "…"}
+	asciiGlyphs = glyphs{fail: "[FAIL]", server: "[SERVER]", bullet: "-", down: "[DOWN]", running: "[LONG]", update: "[UPDATE]", done: "[UPDATED]", ellipsis: "..."}
+)
+
 // Notifier handles Windows Toast notifications.
 type Notifier struct {
 	cfg    config.NotificationConfig
 	pusher ToastPusher
+	now    func() time.Time
+	sleep  func(time.Duration)
+	icon   *embeddedIcon
+
+	// displayNames maps server names to their configured display names.
+	displayNames map[string]string
+
+	// appIDRegistered reports whether the AUMID registry key exists.
+	appIDRegistered func(key string) (bool, error)
 }
 
 // NewNotifier creates a new notification handler.
@@ -38,7 +71,88 @@ func NewNotifier(cfg config.NotificationConfig) *Notifier {
 	return &Notifier{
 		cfg:    cfg,
 		pusher: &DefaultToastPusher{},
+		now:    time.Now,
+		sleep:  time.Sleep,
+		icon:   defaultIcon,
+
+		appIDRegistered: winshell.KeyExists,
+	}
+}
+
+// SetDisplayNames sets the display names shown instead of server names in
+// server down toasts, from servers[].display_name.
+func (n *Notifier) SetDisplayNames(servers []config.ServerConfig) {
+	n.displayNames = make(map[string]string)
+	for _, srv := range servers {
+		if srv.DisplayName != "" {
+			n.displayNames[srv.Name] = srv.DisplayName
+		}
+	}
+}
+
+// labels returns the display names of servers, keeping names without one.
+func (n *Notifier) labels(servers []string) []string {
+	labels := make([]string, len(servers))
+	for i, server := range servers {
+		labels[i] = server
+		if name, ok := n.displayNames[server]; ok {
+			labels[i] = name
+		}
+	}
+	return labels
+}
+
+// SetPusher replaces the pusher used to deliver toasts.
+func (n *Notifier) SetPusher(pusher ToastPusher) {
+	n.pusher = pusher
+}
+
+// push delivers a toast, retrying notification.toast.retries times if it
+// fails with an error that may be transient.
+func (n *Notifier) push(notification toast.Notification) error {
+	delay := time.Duration(n.cfg.Toast.RetryDelayMs) * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		err := n.pusher.Push(notification)
+		if err == nil || attempt >= n.cfg.Toast.Retries || !retryablePushError(err) {
+			return err
+		}
+		n.sleep(delay)
+	}
+}
+
+// retryablePushError returns true if err may be transient: PowerShell ran
+// but failed, e.g. on a shell COM hiccup. Invalid toasts and a missing
+// PowerShell fail the same way every time.
+func retryablePushError(err error) bool {
+	if errors.Is(err, toast.ErrorInvalidAudio) || errors.Is(err, toast.ErrorInvalidDuration) || errors.Is(err, exec.ErrNotFound) {
+		return false
 	}
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr)
+}
+
+// glyphs returns the markers to use, honouring notification.use_emoji.
+func (n *Notifier) glyphs() glyphs {
+	if n.cfg.UseEmoji {
+		return emojiGlyphs
+	}
+	return asciiGlyphs
+}
+
+// Name implements Backend.
+func (n *Notifier) Name() string {
+	return "toast"
+}
+
+// Local implements LocalBackend: toasts are shown on this machine, so failed
+// sends are not queued for retry.
+func (n *Notifier) Local() bool {
+	return true
+}
+
+// Send implements Backend by sending a toast for the failed jobs in the result.
+func (n *Notifier) Send(_ context.Context, result *jobs.CheckResult) error {
+	return n.NotifyFailedJobs(result.FailedJobs)
 }
 
 // NotifyFailedJobs sends a notification about failed jobs.
@@ -52,16 +166,40 @@ func (n *Notifier) NotifyFailedJobs(jobs []database.FailedJob) error {
 		return n.sendGroupedNotification(jobs)
 	}
 
-	// Send individual notifications
-	for _, job := range jobs {
-		if err := n.sendSingleNotification(job); err != nil {
-			return err
+	// Send individual notifications, except for servers with too many failures
+	for _, serverJobs := range groupByServer(jobs) {
+		if t := n.cfg.Grouping.AutoGroupThreshold; t > 0 && len(serverJobs) > t {
+			if err := n.sendGroupedNotification(serverJobs); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, job := range serverJobs {
+			if err := n.sendSingleNotification(job); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
+// groupByServer splits jobs by server, in order of each server's first job.
+func groupByServer(jobs []database.FailedJob) [][]database.FailedJob {
+	index := make(map[string]int)
+	var groups [][]database.FailedJob
+	for _, job := range jobs {
+		i, ok := index[job.ServerName]
+		if !ok {
+			i = len(groups)
+			index[job.ServerName] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], job)
+	}
+	return groups
+}
+
 // sendGroupedNotification sends a single notification for multiple failed jobs.
 func (n *Notifier) sendGroupedNotification(jobs []database.FailedJob) error {
 	// Group by server
@@ -80,24 +218,24 @@ func (n *Notifier) sendGroupedNotification(jobs []database.FailedJob) error {
 		Message: body,
 	}
 
-	// Set icon if specified
-	if n.cfg.IconPath != "" {
-		notification.Icon = n.cfg.IconPath
-	}
+	n.setIcon(&notification)
 
-	// Set sound
 	// Set sound
 	n.setAudio(&notification)
 
-	return n.pusher.Push(notification)
+	return n.push(notification)
 }
 
 // sendSingleNotification sends a notification for a single failed job.
 func (n *Notifier) sendSingleNotification(job database.FailedJob) error {
-	title := fmt.Sprintf("❌ Job Failed on %s", job.ServerName)
+	title := fmt.Sprintf("%s Job Failed on %s", n.glyphs().fail, job.ServerLabel())
+	failedAt := formatTime(job.FailedAt, n.cfg.TimeFormat, n.now())
+	if d := job.RunDuration(); d > 0 {
+		failedAt += fmt.Sprintf(" (after %s)", d)
+	}
 	body := fmt.Sprintf("Job: %s\nFailed at: %s\n%s",
-		job.JobName,
-		job.FailedAt.Format("2006-01-02 15:04:05"),
+		n.jobName(job),
+		failedAt,
 		truncateMessage(job.ErrorMessage, 100),
 	)
 
@@ -107,39 +245,43 @@ func (n *Notifier) sendSingleNotification(job database.FailedJob) error {
 		Message: body,
 	}
 
-	if n.cfg.IconPath != "" {
-		notification.Icon = n.cfg.IconPath
-	}
+	n.setIcon(&notification)
 
 	n.setAudio(&notification)
 
-	return n.pusher.Push(notification)
+	return n.push(notification)
 }
 
 // buildTitle builds the notification title.
 func (n *Notifier) buildTitle(jobCount, serverCount int) string {
+	fail := n.glyphs().fail
 	if jobCount == 1 {
-		return "❌ SQL Agent Job Failed"
+		return fail + " SQL Agent Job Failed"
 	}
 
 	if serverCount == 1 {
-		return fmt.Sprintf("❌ %d SQL Agent Jobs Failed", jobCount)
+		return fmt.Sprintf("%s %d SQL Agent Jobs Failed", fail, jobCount)
 	}
 
-	return fmt.Sprintf("❌ %d Jobs Failed on %d Servers", jobCount, serverCount)
+	return fmt.Sprintf("%s %d Jobs Failed on %d Servers", fail, jobCount, serverCount)
 }
 
 // buildBody builds the notification body.
 func (n *Notifier) buildBody(jobs []database.FailedJob, serverJobs map[string][]database.FailedJob) string {
+	if n.cfg.TopN > 0 {
+		return n.buildTopBody(jobs, len(serverJobs))
+	}
+
 	var lines []string
+	g := n.glyphs()
 	maxJobs := n.cfg.Grouping.MaxJobsPerNotification
 	if maxJobs <= 0 {
 		maxJobs = 5
 	}
 
 	shown := 0
-	for server, srvJobs := range serverJobs {
-		lines = append(lines, fmt.Sprintf("🖥️ %s:", server))
+	for _, srvJobs := range serverJobs {
+		lines = append(lines, fmt.Sprintf("%s %s:", g.server, srvJobs[0].ServerLabel()))
 
 		for _, job := range srvJobs {
 			if shown >= maxJobs {
@@ -149,7 +291,7 @@ func (n *Notifier) buildBody(jobs []database.FailedJob, serverJobs map[string][]
 				}
 				break
 			}
-			lines = append(lines, fmt.Sprintf("  • %s", job.JobName))
+			lines = append(lines, fmt.Sprintf("  %s %s", g.bullet, n.jobName(job)))
 			shown++
 		}
 
@@ -161,6 +303,64 @@ func (n *Notifier) buildBody(jobs []database.FailedJob, serverJobs map[string][]
 	return strings.Join(lines, "\n")
 }
 
+// buildTopBody builds the notification body listing the TopN most severe
+// jobs first, then a summary of the rest by severity.
+func (n *Notifier) buildTopBody(jobs []database.FailedJob, serverCount int) string {
+	g := n.glyphs()
+	sorted := slices.Clone(jobs)
+	slices.SortStableFunc(sorted, func(a, b database.FailedJob) int {
+		return severityRank(a.Severity) - severityRank(b.Severity)
+	})
+	top := sorted[:min(n.cfg.TopN, len(sorted))]
+
+	var lines []string
+	if serverCount == 1 {
+		lines = append(lines, fmt.Sprintf("%s %s:", g.server, top[0].ServerLabel()))
+	}
+	for _, job := range top {
+		if serverCount == 1 {
+			lines = append(lines, fmt.Sprintf("  %s %s", g.bullet, n.jobName(job)))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s %s: %s", g.bullet, job.ServerLabel(), n.jobName(job)))
+		}
+	}
+	if rest := sorted[len(top):]; len(rest) > 0 {
+		lines = append(lines, remainderSummary(rest))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// severityOrder lists the severity levels from most to least severe. Jobs
+// without a severity rank between high and low.
+var severityOrder = []string{config.SeverityCritical, config.SeverityHigh, "", config.SeverityLow}
+
+// severityRank returns the position of level in severityOrder.
+func severityRank(level string) int {
+	if i := slices.Index(severityOrder, level); i >= 0 {
+		return i
+	}
+	return slices.Index(severityOrder, "")
+}
+
+// remainderSummary returns the line summarizing the jobs left out of the
+// body, e.g. "... and 4 more (1 high, 3 low)".
+func remainderSummary(rest []database.FailedJob) string {
+	counts := make(map[string]int)
+	for _, job := range rest {
+		counts[job.Severity]++
+	}
+	var parts []string
+	for _, level := range severityOrder {
+		if level != "" && counts[level] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[level], level))
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("... and %d more", len(rest))
+	}
+	return fmt.Sprintf("... and %d more (%s)", len(rest), strings.Join(parts, ", "))
+}
+
 // setAudio sets the audio for the notification based on config.
 func (n *Notifier) setAudio(notification *toast.Notification) {
 	if !n.cfg.Sound.Enabled {
@@ -185,19 +385,84 @@ func (n *Notifier) setAudio(notification *toast.Notification) {
 	}
 }
 
+// NotifyServerDown implements ServerDownNotifier by sending a toast listing unreachable servers.
+func (n *Notifier) NotifyServerDown(_ context.Context, servers []string) error {
+	if len(servers) == 0 {
+		return nil
+	}
+
+	g := n.glyphs()
+	title := g.down + " Server unreachable"
+	if len(servers) > 1 {
+		title = fmt.Sprintf("%s %d Servers unreachable", g.down, len(servers))
+	}
+
+	notification := toast.Notification{
+		AppID:   n.cfg.AppID,
+		Title:   title,
+		Message: g.server + " " + strings.Join(n.labels(servers), "\n"+g.server+" "),
+	}
+
+	n.setIcon(&notification)
+
+	n.setAudio(&notification)
+
+	return n.push(notification)
+}
+
 // NotifyUpdateAvailable sends a notification about available update.
 func (n *Notifier) NotifyUpdateAvailable(currentVersion, newVersion string) error {
 	notification := toast.Notification{
 		AppID:   n.cfg.AppID,
-		Title:   "🔄 Watchman Update Available",
+		Title:   n.glyphs().update + " Watchman Update Available",
 		Message: fmt.Sprintf("Version %s is available (current: %s)\nRun 'watchman update' to upgrade.", newVersion, currentVersion),
 	}
 
-	if n.cfg.IconPath != "" {
-		notification.Icon = n.cfg.IconPath
+	n.setIcon(&notification)
+
+	return n.push(notification)
+}
+
+// NotifyUpdateApplied sends a notification confirming an applied update.
+func (n *Notifier) NotifyUpdateApplied(previousVersion, currentVersion string) error {
+	notification := toast.Notification{
+		AppID:   n.cfg.AppID,
+		Title:   n.glyphs().done + " Watchman Updated",
+		Message: fmt.Sprintf("Updated to %s (was %s)", currentVersion, previousVersion),
+	}
+
+	n.setIcon(&notification)
+
+	return n.push(notification)
+}
+
+// jobName returns the job's name as shown in notification text,
+// honouring notification.max_job_name_length, followed by its severity.
+func (n *Notifier) jobName(job database.FailedJob) string {
+	name := truncateJobName(job.JobName, n.cfg.MaxJobNameLength, n.glyphs().ellipsis)
+	if job.Severity != "" {
+		name += " [" + job.Severity + "]"
 	}
+	return name
+}
 
-	return n.pusher.Push(notification)
+// truncateJobName shortens a job name to at most maxLen characters by
+// replacing its middle with ellipsis, since generated names often differ
+// only at the end. Without room for the ellipsis and a character of the
+// name, the name is cut to maxLen characters. A maxLen of 0 or less keeps
+// the name.
+func truncateJobName(name string, maxLen int, ellipsis string) string {
+	runes := []rune(name)
+	if maxLen <= 0 || len(runes) <= maxLen {
+		return name
+	}
+	keep := maxLen - len([]rune(ellipsis))
+	if keep < 1 {
+		return string(runes[:maxLen])
+	}
+	tail := keep / 2
+	head := keep - tail
+	return string(runes[:head]) + ellipsis + string(runes[len(runes)-tail:])
 }
 
 // truncateMessage truncates a message to max length.
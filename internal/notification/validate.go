@@ -0,0 +1,66 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Validator is implemented by backends that can check their settings and
+// connectivity without delivering a notification.
+type Validator interface {
+	Validate(ctx context.Context) error
+}
+
+// BackendValidation is the validation result of one backend.
+type BackendValidation struct {
+	Backend string `json:"backend"`
+	OK      bool   `json:"ok"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ValidateBackends checks each backend without sending anything.
+// Backends that do not implement Validator are reported as skipped.
+func ValidateBackends(ctx context.Context, backends []Backend) []BackendValidation {
+	results := make([]BackendValidation, 0, len(backends))
+	for _, backend := range backends {
+		result := BackendValidation{Backend: backend.Name()}
+		if v, ok := backend.(Validator); !ok {
+			result.OK, result.Skipped = true, true
+		} else if err := v.Validate(ctx); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.OK = true
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Validate implements Validator: the app ID must be registered with Windows
+// and the icon, if any, must exist.
+func (n *Notifier) Validate(_ context.Context) error {
+	if err := checkAppID(n.cfg.AppID); err != nil {
+		return err
+	}
+	registered, err := n.appIDRegistered(aumidRegistryPath + `\` + n.cfg.AppID)
+	if err != nil {
+		return fmt.Errorf("failed to look up app id: %w", err)
+	}
+	if !registered {
+		return fmt.Errorf("app id %q is not registered, run 'watchmen register-toast'", n.cfg.AppID)
+	}
+	if n.cfg.IconPath != "" {
+		if _, err := os.Stat(n.cfg.IconPath); err != nil {
+			return fmt.Errorf("icon not found: %w", err)
+		}
+	}
+	return nil
+}
+
+// Validate implements Validator by creating the pipe without waiting for a
+// reader. It fails if the name is in use by another process.
+func (p *PipeBackend) Validate(_ context.Context) error {
+	return p.probe()
+}
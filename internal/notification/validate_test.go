@@ -0,0 +1,85 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+// fakeValidatingBackend is a backend whose credential check returns err.
+type fakeValidatingBackend struct {
+	fakeBackend
+	validated bool
+}
+
+func (f *fakeValidatingBackend) Validate(ctx context.Context) error {
+	f.validated = true
+	return f.err
+}
+
+func TestValidateBackends(t *testing.T) {
+	ok := &fakeValidatingBackend{fakeBackend: fakeBackend{name: "webhook"}}
+	denied := &fakeValidatingBackend{fakeBackend: fakeBackend{name: "telegram", err: errors.New("401 Unauthorized")}}
+	unchecked := &fakeBackend{name: "legacy"}
+
+	results := ValidateBackends(context.Background(), []Backend{ok, denied, unchecked})
+
+	assert.Equal(t, []BackendValidation{
+		{Backend: "webhook", OK: true},
+		{Backend: "telegram", Error: "401 Unauthorized"},
+		{Backend: "legacy", OK: true, Skipped: true},
+	}, results)
+	assert.True(t, ok.validated)
+	assert.True(t, denied.validated)
+	assert.Empty(t, ok.received, "validating never sends")
+	assert.Empty(t, denied.received, "validating never sends")
+}
+
+func TestNotifier_Validate(t *testing.T) {
+	icon := filepath.Join(t.TempDir(), "icon.png")
+	registered := map[string]bool{`Software\Classes\AppUserModelId\Watchman`: true}
+
+	tests := []struct {
+		name    string
+		cfg     config.NotificationConfig
+		lookup  error
+		wantErr string
+	}{
+		{name: "registered", cfg: config.NotificationConfig{AppID: "Watchman"}},
+		{name: "not configured", cfg: config.NotificationConfig{}, wantErr: "not configured"},
+		{name: "not registered", cfg: config.NotificationConfig{AppID: "Contoso.Watchman"}, wantErr: "register-toast"},
+		{name: "lookup fails", cfg: config.NotificationConfig{AppID: "Watchman"}, lookup: errors.New("access denied"), wantErr: "access denied"},
+		{name: "missing icon", cfg: config.NotificationConfig{AppID: "Watchman", IconPath: icon}, wantErr: "icon not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := NewNotifier(tt.cfg)
+			n.appIDRegistered = func(key string) (bool, error) {
+				return registered[key], tt.lookup
+			}
+
+			err := n.Validate(context.Background())
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestPipeBackend_Validate(t *testing.T) {
+	p := NewPipeBackend(config.PipeConfig{Name: "watchman-test"})
+	p.probe = func() error { return errors.New("pipe is in use") }
+	assert.EqualError(t, p.Validate(context.Background()), "pipe is in use")
+
+	p.probe = func() error { return nil }
+	assert.NoError(t, p.Validate(context.Background()))
+}
@@ -0,0 +1,58 @@
+// Package perfcounter publishes check results as a Windows performance
+// counter ("Watchman\Failed Jobs") for PerfMon based dashboards.
+// The counter must be registered with lodctr using configs/perfcounter.man.
+// On other platforms the counter is a no-op.
+package perfcounter
+
+import (
+	"fmt"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+// Sink receives counter values.
+type Sink interface {
+	SetFailedJobs(n int) error
+	Close() error
+}
+
+// Counter updates the failed jobs counter after each check.
+// A nil Counter is valid and does nothing.
+type Counter struct {
+	sink Sink
+}
+
+// New opens the performance counter. It returns nil if the counter is disabled.
+func New(cfg config.PerfCounterConfig) (*Counter, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	sink, err := openSink()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open performance counter: %w", err)
+	}
+	return &Counter{sink: sink}, nil
+}
+
+// Update sets the counter to the number of failed jobs in result.
+// Results where no server could be checked leave the last value in place,
+// since the real count is unknown.
+func (c *Counter) Update(result *jobs.CheckResult) error {
+	if c == nil || result == nil || result.Status == "error" {
+		return nil
+	}
+	if err := c.sink.SetFailedJobs(len(result.FailedJobs)); err != nil {
+		return fmt.Errorf("failed to update performance counter: %w", err)
+	}
+	return nil
+}
+
+// Close removes the counter instance.
+func (c *Counter) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.sink.Close()
+}
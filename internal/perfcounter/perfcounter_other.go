@@ -0,0 +1,14 @@
+//go:build !windows
+
+package perfcounter
+
+// noopSink discards counter values on platforms without performance counters.
+type noopSink struct{}
+
+func (noopSink) SetFailedJobs(int) error { return nil }
+func (noopSink) Close() error            { return nil }
+
+// openSink returns a no-op sink; performance counters are Windows only.
+func openSink() (Sink, error) {
+	return noopSink{}, nil
+}
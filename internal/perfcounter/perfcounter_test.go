@@ -0,0 +1,98 @@
+package perfcounter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+// fakeSink records the values it receives.
+type fakeSink struct {
+	values []int
+	err    error
+	closed bool
+}
+
+func (f *fakeSink) SetFailedJobs(n int) error {
+	f.values = append(f.values, n)
+	return f.err
+}
+
+func (f *fakeSink) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestNew_Disabled(t *testing.T) {
+	counter, err := New(config.PerfCounterConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, counter)
+}
+
+func TestCounter_Update(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     *jobs.CheckResult
+		wantValues []int
+	}{
+		{
+			name: "failed jobs are counted",
+			result: &jobs.CheckResult{
+				Status:     "failed_jobs",
+				FailedJobs: []database.FailedJob{{JobName: "Backup"}, {JobName: "ETL"}},
+			},
+			wantValues: []int{2},
+		},
+		{
+			name: "warn-only jobs are not counted",
+			result: &jobs.CheckResult{
+				Status:   "success",
+				WarnJobs: []database.FailedJob{{JobName: "Reindex", Status: 3}},
+			},
+			wantValues: []int{0},
+		},
+		{
+			name:   "unreachable servers keep the last value",
+			result: &jobs.CheckResult{Status: "error"},
+		},
+		{
+			name: "nil result is ignored",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &fakeSink{}
+			counter := &Counter{sink: sink}
+
+			require.NoError(t, counter.Update(tt.result))
+			assert.Equal(t, tt.wantValues, sink.values)
+		})
+	}
+}
+
+func TestCounter_UpdateError(t *testing.T) {
+	counter := &Counter{sink: &fakeSink{err: errors.New("access denied")}}
+
+	err := counter.Update(&jobs.CheckResult{Status: "success"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "access denied")
+}
+
+func TestCounter_Nil(t *testing.T) {
+	var counter *Counter
+	assert.NoError(t, counter.Update(&jobs.CheckResult{Status: "success"}))
+	assert.NoError(t, counter.Close())
+}
+
+func TestCounter_Close(t *testing.T) {
+	sink := &fakeSink{}
+	require.NoError(t, (&Counter{sink: sink}).Close())
+	assert.True(t, sink.closed)
+}
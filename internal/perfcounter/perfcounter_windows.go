@@ -0,0 +1,129 @@
+//go:build windows
+
+package perfcounter
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Provider and counter set GUIDs; they must match configs/perfcounter.man.
+var (
+	providerGUID   = windows.GUID{Data1: 0x50cb0450, Data2: 0x02ac, Data3: 0x4fe5, Data4: [8]byte{0x98, 0x7a, 0x72, 0x36, 0xeb, 0x94, 0x11, 0xb0}}
+	counterSetGUID = windows.GUID{Data1: 0x10967dc3, Data2: 0x1f32, Data3: 0x4138, Data4: [8]byte{0xbe, 0x7f, 0xa9, 0x39, 0xe9, 0x06, 0xda, 0xe7}}
+)
+
+// PerfLib V2 constants from perflib.h and winperf.h.
+const (
+	failedJobsCounterID          = 1
+	perfCountersetSingleInstance = 0
+	perfCounterLargeRawcount     = 0x00010100
+	perfDetailNovice             = 100
+)
+
+var (
+	advapi32                         = windows.NewLazySystemDLL("advapi32.dll")
+	procPerfStartProvider            = advapi32.NewProc("PerfStartProvider")
+	procPerfStopProvider             = advapi32.NewProc("PerfStopProvider")
+	procPerfSetCounterSetInfo        = advapi32.NewProc("PerfSetCounterSetInfo")
+	procPerfCreateInstance           = advapi32.NewProc("PerfCreateInstance")
+	procPerfDeleteInstance           = advapi32.NewProc("PerfDeleteInstance")
+	procPerfSetULongLongCounterValue = advapi32.NewProc("PerfSetULongLongCounterValue")
+)
+
+// counterSetTemplate mirrors PERF_COUNTERSET_INFO followed by one PERF_COUNTER_INFO.
+type counterSetTemplate struct {
+	CounterSetGUID windows.GUID
+	ProviderGUID   windows.GUID
+	NumCounters    uint32
+	InstanceType   uint32
+
+	CounterID   uint32
+	Type        uint32
+	Attrib      uint64
+	Size        uint32
+	DetailLevel uint32
+	Scale       int32
+	Offset      uint32
+}
+
+// perfSink publishes counter values through PerfLib V2.
+type perfSink struct {
+	provider windows.Handle
+	instance uintptr
+}
+
+// openSink starts the counter provider and creates the single counter instance.
+func openSink() (Sink, error) {
+	if err := procPerfStartProvider.Find(); err != nil {
+		return nil, err
+	}
+
+	var provider windows.Handle
+	// #nosec G103 -- PerfLib API call with pointers to live Go values
+	r, _, _ := procPerfStartProvider.Call(uintptr(unsafe.Pointer(&providerGUID)), 0, uintptr(unsafe.Pointer(&provider)))
+	if r != 0 {
+		return nil, fmt.Errorf("PerfStartProvider: %w", windows.Errno(r))
+	}
+	s := &perfSink{provider: provider}
+
+	tmpl := counterSetTemplate{
+		CounterSetGUID: counterSetGUID,
+		ProviderGUID:   providerGUID,
+		NumCounters:    1,
+		InstanceType:   perfCountersetSingleInstance,
+		CounterID:      failedJobsCounterID,
+		Type:           perfCounterLargeRawcount,
+		Size:           8,
+		DetailLevel:    perfDetailNovice,
+	}
+	// #nosec G103 -- PerfLib API call with a pointer to a live Go value
+	r, _, _ = procPerfSetCounterSetInfo.Call(uintptr(provider), uintptr(unsafe.Pointer(&tmpl)), unsafe.Sizeof(tmpl))
+	if r != 0 {
+		_ = s.Close()
+		return nil, fmt.Errorf("PerfSetCounterSetInfo: %w", windows.Errno(r))
+	}
+
+	name, err := windows.UTF16PtrFromString("Watchman")
+	if err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	// #nosec G103 -- PerfLib API call with pointers to live Go values
+	instance, _, err := procPerfCreateInstance.Call(uintptr(provider), uintptr(unsafe.Pointer(&counterSetGUID)), uintptr(unsafe.Pointer(name)), 0)
+	if instance == 0 {
+		_ = s.Close()
+		return nil, fmt.Errorf("PerfCreateInstance: %w", err)
+	}
+	s.instance = instance
+	return s, nil
+}
+
+// SetFailedJobs sets the Failed Jobs counter.
+func (s *perfSink) SetFailedJobs(n int) error {
+	value := uint64(n) // #nosec G115 -- n is a job count and never negative
+	args := []uintptr{uintptr(s.provider), s.instance, failedJobsCounterID, uintptr(value)}
+	if unsafe.Sizeof(uintptr(0)) == 4 {
+		// 32-bit stdcall passes the ULONGLONG in two slots, low word first
+		args = append(args, uintptr(value>>32))
+	}
+	r, _, _ := procPerfSetULongLongCounterValue.Call(args...)
+	if r != 0 {
+		return fmt.Errorf("PerfSetULongLongCounterValue: %w", windows.Errno(r))
+	}
+	return nil
+}
+
+// Close deletes the counter instance and stops the provider.
+func (s *perfSink) Close() error {
+	if s.instance != 0 {
+		_, _, _ = procPerfDeleteInstance.Call(uintptr(s.provider), s.instance)
+		s.instance = 0
+	}
+	if r, _, _ := procPerfStopProvider.Call(uintptr(s.provider)); r != 0 {
+		return fmt.Errorf("PerfStopProvider: %w", windows.Errno(r))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+// backoffBase is how long checks are skipped once the backoff starts.
+// It doubles with each further failed check.
+const backoffBase = time.Hour
+
+// maxBackoffShift bounds the doubling so the delay cannot overflow before
+// it is capped at max_hours.
+const maxBackoffShift = 16
+
+// backoffDelay returns how long to skip scheduled checks after streak
+// consecutive failed checks, or 0 if checks should not be skipped.
+func backoffDelay(streak int, cfg config.BackoffConfig) time.Duration {
+	if cfg.AfterFailures <= 0 || streak < cfg.AfterFailures {
+		return 0
+	}
+	delay := backoffBase << min(streak-cfg.AfterFailures, maxBackoffShift)
+	return min(delay, time.Duration(cfg.MaxHours)*time.Hour)
+}
+
+// SetStateStore sets the store recording the failed check streak, which
+// enables scheduler.backoff.
+func (s *Scheduler) SetStateStore(store *state.Store) {
+	s.store = store
+}
+
+// backoffEnabled returns true if checks are skipped after repeated failures.
+func (s *Scheduler) backoffEnabled() bool {
+	return s.cfg.Scheduler.Backoff.AfterFailures > 0 && s.store != nil
+}
+
+// backingOff returns true if the scheduled check should be skipped because
+// the previous checks all failed.
+func (s *Scheduler) backingOff() bool {
+	if !s.backoffEnabled() {
+		return false
+	}
+	st, err := s.store.Load()
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("failed to load check backoff, checking anyway")
+		return false
+	}
+	if !s.now().Before(st.CheckBackoffUntil) {
+		return false
+	}
+	s.logger.Warn().
+		Int("failed_checks", st.CheckFailureStreak).
+		Time("until", st.CheckBackoffUntil).
+		Msg("scheduled check skipped, backing off after repeated failures")
+	return true
+}
+
+// recordOutcome updates the failed check streak after a check. A failure
+// may start or lengthen the backoff; a success resets it.
+func (s *Scheduler) recordOutcome(failed bool) {
+	if !s.backoffEnabled() {
+		return
+	}
+
+	var streak int
+	var until time.Time
+	var recovered bool
+	err := s.store.Update(func(st *state.State) error {
+		if !failed {
+			recovered = !st.CheckBackoffUntil.IsZero()
+			st.CheckFailureStreak = 0
+			st.CheckBackoffUntil = time.Time{}
+			return nil
+		}
+		st.CheckFailureStreak++
+		if delay := backoffDelay(st.CheckFailureStreak, s.cfg.Scheduler.Backoff); delay > 0 {
+			st.CheckBackoffUntil = s.now().Add(delay)
+		}
+		streak, until = st.CheckFailureStreak, st.CheckBackoffUntil
+		return nil
+	})
+	switch {
+	case err != nil:
+		s.logger.Warn().Err(err).Msg("failed to record check outcome for backoff")
+	case recovered:
+		s.logger.Info().Msg("scheduled check succeeded, backoff reset")
+	case !until.IsZero():
+		s.logger.Warn().Int("failed_checks", streak).Time("until", until).Msg("scheduled checks backing off")
+	}
+}
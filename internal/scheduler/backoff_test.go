@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/state"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	cfg := config.BackoffConfig{AfterFailures: 3, MaxHours: 6}
+
+	tests := []struct {
+		streak int
+		want   time.Duration
+	}{
+		{streak: 0, want: 0},
+		{streak: 2, want: 0},
+		{streak: 3, want: time.Hour},
+		{streak: 4, want: 2 * time.Hour},
+		{streak: 5, want: 4 * time.Hour},
+		{streak: 6, want: 6 * time.Hour},
+		{streak: 100, want: 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, backoffDelay(tt.streak, cfg), "streak %d", tt.streak)
+	}
+
+	assert.Zero(t, backoffDelay(100, config.BackoffConfig{MaxHours: 6}), "disabled")
+}
+
+func TestRunCheck_BackoffEscalatesAndResets(t *testing.T) {
+	cfg := &config.Config{Scheduler: config.SchedulerConfig{
+		Timezone: "UTC",
+		Backoff:  config.BackoffConfig{AfterFailures: 2, MaxHours: 3},
+	}}
+
+	checkErr := errors.New("all 2 servers unavailable")
+	calls := 0
+	s, err := NewScheduler(cfg, func(ctx context.Context) error {
+		calls++
+		return checkErr
+	}, testLogger())
+	require.NoError(t, err)
+
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	s.SetStateStore(store)
+	now := time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	// run advances the clock by d and runs a scheduled check
+	run := func(d time.Duration) {
+		now = now.Add(d)
+		s.runCheck(context.Background())
+	}
+	loadState := func() *state.State {
+		st, err := store.Load()
+		require.NoError(t, err)
+		return st
+	}
+
+	run(0)
+	assert.Zero(t, loadState().CheckBackoffUntil, "one failure does not back off")
+
+	run(time.Minute)
+	st := loadState()
+	assert.Equal(t, 2, st.CheckFailureStreak)
+	assert.Equal(t, now.Add(time.Hour), st.CheckBackoffUntil)
+
+	run(30 * time.Minute)
+	assert.Equal(t, 2, calls, "checks are skipped while backing off")
+
+	run(30 * time.Minute)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, now.Add(2*time.Hour), loadState().CheckBackoffUntil, "the backoff doubles")
+
+	run(2 * time.Hour)
+	assert.Equal(t, now.Add(3*time.Hour), loadState().CheckBackoffUntil, "the backoff is capped")
+
+	checkErr = nil
+	run(3 * time.Hour)
+	st = loadState()
+	assert.Equal(t, 5, calls)
+	assert.Zero(t, st.CheckFailureStreak, "a successful check resets the streak")
+	assert.Zero(t, st.CheckBackoffUntil)
+
+	run(time.Minute)
+	assert.Equal(t, 6, calls, "checks run on schedule again")
+}
+
+func TestRunCheck_BackoffDisabledWithoutStore(t *testing.T) {
+	cfg := &config.Config{Scheduler: config.SchedulerConfig{
+		Timezone: "UTC",
+		Backoff:  config.BackoffConfig{AfterFailures: 1, MaxHours: 3},
+	}}
+	calls := 0
+	s, err := NewScheduler(cfg, func(ctx context.Context) error {
+		calls++
+		return errors.New("down")
+	}, testLogger())
+	require.NoError(t, err)
+
+	s.runCheck(context.Background())
+	s.runCheck(context.Background())
+	assert.Equal(t, 2, calls)
+}
@@ -4,15 +4,22 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/go-co-op/gocron/v2"
 	"github.com/rs/zerolog"
 
 	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/state"
 )
 
+// defaultStopTimeout bounds how long Stop waits for an in-flight check.
+const defaultStopTimeout = 30 * time.Second
+
 // Scheduler handles scheduled job checks.
 type Scheduler struct {
 	scheduler gocron.Scheduler
@@ -20,37 +27,71 @@ type Scheduler struct {
 	location  *time.Location
 	handler   func(ctx context.Context) error
 	logger    zerolog.Logger
+
+	// running tracks in-flight checks so Stop can wait for them.
+	running sync.WaitGroup
+	// stopping is closed by Stop to cut short pending retry delays.
+	stopping    chan struct{}
+	stopOnce    sync.Once
+	stopTimeout time.Duration
+
+	// store records the failed check streak for the backoff, if set.
+	store *state.Store
+	now   func() time.Time
 }
 
 // NewScheduler creates a new scheduler.
 func NewScheduler(cfg *config.Config, handler func(ctx context.Context) error, logger zerolog.Logger) (*Scheduler, error) {
+	return newScheduler(cfg, handler, logger, defaultStopTimeout)
+}
+
+// newScheduler creates a scheduler whose Stop waits up to stopTimeout for
+// running jobs.
+func newScheduler(cfg *config.Config, handler func(ctx context.Context) error, logger zerolog.Logger, stopTimeout time.Duration) (*Scheduler, error) {
 	// Get timezone location
 	loc, err := cfg.GetLocation()
 	if err != nil {
 		return nil, fmt.Errorf("invalid timezone: %w", err)
 	}
 
-	// Create gocron scheduler
+	// Create gocron scheduler. Its shutdown waits for running jobs, so it
+	// gets the same bound as Stop
 	s, err := gocron.NewScheduler(
 		gocron.WithLocation(loc),
+		gocron.WithStopTimeout(stopTimeout),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create scheduler: %w", err)
 	}
 
 	return &Scheduler{
-		scheduler: s,
-		cfg:       cfg,
-		location:  loc,
-		handler:   handler,
-		logger:    logger,
+		scheduler:   s,
+		cfg:         cfg,
+		location:    loc,
+		handler:     handler,
+		logger:      logger,
+		stopping:    make(chan struct{}),
+		stopTimeout: stopTimeout,
+		now:         time.Now,
 	}, nil
 }
 
 // Start starts the scheduler.
+// Check times naming the same minute, e.g. "08:00" and "8:00", are scheduled once.
 func (s *Scheduler) Start(ctx context.Context) error {
+	checkTimes, duplicates, err := uniqueCheckTimes(s.cfg.Scheduler.CheckTimes)
+	if err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		s.logger.Warn().
+			Strs("duplicates", duplicates).
+			Strs("check_times", checkTimes).
+			Msg("duplicate check times ignored")
+	}
+
 	// Schedule jobs for each check time
-	for _, checkTime := range s.cfg.Scheduler.CheckTimes {
+	for _, checkTime := range checkTimes {
 		hour, minute, err := parseTime(checkTime)
 		if err != nil {
 			return fmt.Errorf("invalid check time %s: %w", checkTime, err)
@@ -60,9 +101,7 @@ func (s *Scheduler) Start(ctx context.Context) error {
 		}
 
 		_, err = s.scheduler.NewJob(
-			gocron.DailyJob(1, gocron.NewAtTimes(
-				gocron.NewAtTime(uint(hour), uint(minute), 0),
-			)),
+			dailyAt(hour, minute),
 			gocron.NewTask(s.runCheck, ctx),
 			gocron.WithName(fmt.Sprintf("check_%s", checkTime)),
 		)
@@ -76,16 +115,73 @@ func (s *Scheduler) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop stops the scheduler.
+// ScheduleDaily runs task every day at the HH:MM time at, in the configured
+// timezone. Task errors are logged.
+func (s *Scheduler) ScheduleDaily(ctx context.Context, name, at string, task func(ctx context.Context) error) error {
+	hour, minute, err := parseTime(at)
+	if err != nil {
+		return fmt.Errorf("invalid time %s for %s: %w", at, name, err)
+	}
+
+	_, err = s.scheduler.NewJob(
+		dailyAt(hour, minute),
+		gocron.NewTask(func(ctx context.Context) {
+			if err := task(ctx); err != nil {
+				s.logger.Error().Err(err).Str("task", name).Msg("scheduled task failed")
+			}
+		}, ctx),
+		gocron.WithName(name),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to schedule %s: %w", name, err)
+	}
+	return nil
+}
+
+// Stop stops the scheduler and waits, up to a bound, for an in-flight check
+// to finish. Pending retries are abandoned rather than waited out.
 func (s *Scheduler) Stop() error {
+	s.stopOnce.Do(func() { close(s.stopping) })
+	deadline := time.Now().Add(s.stopTimeout)
+
 	if err := s.scheduler.Shutdown(); err != nil {
+		if errors.Is(err, gocron.ErrStopJobsTimedOut) {
+			return s.errStopTimedOut()
+		}
 		return fmt.Errorf("failed to shutdown scheduler: %w", err)
 	}
-	return nil
+
+	// Shutdown waited for the scheduled jobs; this covers checks run directly
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(time.Until(deadline)):
+		return s.errStopTimedOut()
+	}
+}
+
+// errStopTimedOut returns the error of a Stop that gave up waiting for an
+// in-flight check.
+func (s *Scheduler) errStopTimedOut() error {
+	return fmt.Errorf("timed out after %s waiting for in-flight check", s.stopTimeout)
 }
 
-// runCheck runs the handler with retry logic.
+// runCheck runs the handler with retry logic. The check is skipped while
+// backing off after repeated failures.
 func (s *Scheduler) runCheck(ctx context.Context) {
+	s.running.Add(1)
+	defer s.running.Done()
+
+	if s.backingOff() {
+		return
+	}
+
 	cfg := s.cfg.Scheduler.Retry
 
 	var lastErr error
@@ -95,14 +191,19 @@ func (s *Scheduler) runCheck(ctx context.Context) {
 	}
 
 	for i := 0; i < attempts; i++ {
-		if err := s.handler(ctx); err != nil {
-			lastErr = err
-			if cfg.Enabled && i < attempts-1 {
-				time.Sleep(time.Duration(cfg.DelaySeconds) * time.Second)
-				continue
-			}
+		err := s.handler(ctx)
+		if err == nil {
+			s.recordOutcome(false)
+			return // Success
+		}
+		lastErr = err
+		if i < attempts-1 && !s.waitRetry(time.Duration(cfg.DelaySeconds)*time.Second) {
+			s.logger.Warn().
+				Err(lastErr).
+				Int("attempt", i+1).
+				Msg("scheduler stopping, remaining retries abandoned")
+			return
 		}
-		return // Success
 	}
 
 	// Log error after all retries failed
@@ -111,6 +212,21 @@ func (s *Scheduler) runCheck(ctx context.Context) {
 			Err(lastErr).
 			Int("attempts", attempts).
 			Msg("scheduled check failed after all retry attempts")
+		s.recordOutcome(true)
+	}
+}
+
+// waitRetry sleeps for delay and reports whether the check should retry. It
+// returns false as soon as Stop is called.
+func (s *Scheduler) waitRetry(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-s.stopping:
+		return false
 	}
 }
 
@@ -139,6 +255,37 @@ func (s *Scheduler) NextRun() (time.Time, error) {
 	return nextRun, nil
 }
 
+// dailyAt returns the definition of a job running every day at hour:minute
+// in the scheduler's location.
+func dailyAt(hour, minute int) gocron.JobDefinition {
+	return gocron.DailyJob(1, gocron.NewAtTimes(
+		gocron.NewAtTime(uint(hour), uint(minute), 0),
+	))
+}
+
+// uniqueCheckTimes returns the check times normalized to HH:MM, sorted and
+// without duplicates, along with the configured entries that were dropped.
+func uniqueCheckTimes(times []string) (unique, duplicates []string, err error) {
+	seen := make(map[string]bool, len(times))
+	for _, checkTime := range times {
+		hour, minute, err := parseTime(checkTime)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid check time %s: %w", checkTime, err)
+		}
+
+		normalized := fmt.Sprintf("%02d:%02d", hour, minute)
+		if seen[normalized] {
+			duplicates = append(duplicates, checkTime)
+			continue
+		}
+		seen[normalized] = true
+		unique = append(unique, normalized)
+	}
+
+	slices.Sort(unique)
+	return unique, duplicates, nil
+}
+
 // parseTime parses a time string in HH:MM format.
 func parseTime(s string) (hour, minute int, err error) {
 	t, err := time.Parse("15:04", s)
@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/hoangtran1411/watchman/internal/config"
 )
@@ -61,6 +63,52 @@ func TestStart_InvalidTime(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestStart_DuplicateCheckTimes(t *testing.T) {
+	cfg := &config.Config{
+		Scheduler: config.SchedulerConfig{
+			CheckTimes: []string{"17:00", "08:00", "8:00", "17:00"},
+			Timezone:   "UTC",
+		},
+	}
+	handler := func(ctx context.Context) error { return nil }
+
+	s, err := NewScheduler(cfg, handler, testLogger())
+	assert.NoError(t, err)
+	assert.NoError(t, s.Start(context.Background()))
+	t.Cleanup(func() { _ = s.Stop() })
+
+	var names []string
+	for _, job := range s.scheduler.Jobs() {
+		names = append(names, job.Name())
+	}
+	assert.ElementsMatch(t, []string{"check_08:00", "check_17:00"}, names)
+}
+
+func TestScheduleDaily(t *testing.T) {
+	cfg := &config.Config{Scheduler: config.SchedulerConfig{Timezone: "UTC"}}
+	s, err := NewScheduler(cfg, func(ctx context.Context) error { return nil }, testLogger())
+	assert.NoError(t, err)
+
+	task := func(ctx context.Context) error { return nil }
+	assert.NoError(t, s.ScheduleDaily(context.Background(), "notification_digest", "17:00", task))
+	assert.Error(t, s.ScheduleDaily(context.Background(), "bad", "5pm", task))
+
+	jobs := s.scheduler.Jobs()
+	if assert.Len(t, jobs, 1) {
+		assert.Equal(t, "notification_digest", jobs[0].Name())
+	}
+}
+
+func TestUniqueCheckTimes(t *testing.T) {
+	unique, duplicates, err := uniqueCheckTimes([]string{"17:00", "08:00", "8:00", "17:00", "12:30"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"08:00", "12:30", "17:00"}, unique)
+	assert.Equal(t, []string{"8:00", "17:00"}, duplicates)
+
+	_, _, err = uniqueCheckTimes([]string{"08:00", "25:00"})
+	assert.Error(t, err)
+}
+
 // Mocking function execution for retry test.
 type MockHandler struct {
 	mock.Mock
@@ -120,6 +168,150 @@ func TestRunCheck_NoRetry(t *testing.T) {
 	mockHandler.AssertNumberOfCalls(t, "Handle", 1)
 }
 
+func TestStop_WaitsForInFlightCheck(t *testing.T) {
+	cfg := &config.Config{Scheduler: config.SchedulerConfig{Timezone: "UTC"}}
+
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	handler := func(ctx context.Context) error {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		close(finished)
+		return nil
+	}
+
+	s, err := NewScheduler(cfg, handler, testLogger())
+	assert.NoError(t, err)
+	s.stopTimeout = 5 * time.Second
+
+	go s.runCheck(context.Background())
+	<-started
+
+	assert.NoError(t, s.Stop())
+	select {
+	case <-finished:
+	default:
+		t.Fatal("Stop returned before the in-flight check completed")
+	}
+}
+
+func TestStop_BoundedWait(t *testing.T) {
+	cfg := &config.Config{Scheduler: config.SchedulerConfig{Timezone: "UTC"}}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler := func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}
+
+	s, err := NewScheduler(cfg, handler, testLogger())
+	assert.NoError(t, err)
+	s.stopTimeout = 50 * time.Millisecond
+
+	go s.runCheck(context.Background())
+	<-started
+
+	start := time.Now()
+	err = s.Stop()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out")
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+// runScheduledCheck starts s with one check time and runs that check now
+// through gocron.
+func runScheduledCheck(t *testing.T, s *Scheduler) {
+	t.Helper()
+	require.NoError(t, s.Start(context.Background()))
+	jobs := s.scheduler.Jobs()
+	require.Len(t, jobs, 1)
+	require.NoError(t, jobs[0].RunNow())
+}
+
+func TestStop_WaitsForScheduledCheck(t *testing.T) {
+	cfg := &config.Config{Scheduler: config.SchedulerConfig{CheckTimes: []string{"08:00"}, Timezone: "UTC"}}
+
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	handler := func(ctx context.Context) error {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		close(finished)
+		return nil
+	}
+
+	s, err := newScheduler(cfg, handler, testLogger(), 5*time.Second)
+	require.NoError(t, err)
+	runScheduledCheck(t, s)
+	<-started
+
+	assert.NoError(t, s.Stop())
+	select {
+	case <-finished:
+	default:
+		t.Fatal("Stop returned before the scheduled check completed")
+	}
+}
+
+func TestStop_BoundsScheduledCheck(t *testing.T) {
+	cfg := &config.Config{Scheduler: config.SchedulerConfig{CheckTimes: []string{"08:00"}, Timezone: "UTC"}}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler := func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}
+
+	s, err := newScheduler(cfg, handler, testLogger(), 100*time.Millisecond)
+	require.NoError(t, err)
+	runScheduledCheck(t, s)
+	<-started
+
+	// gocron's own default would wait 10s
+	start := time.Now()
+	err = s.Stop()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out after 100ms")
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestStop_AbandonsPendingRetry(t *testing.T) {
+	cfg := &config.Config{
+		Scheduler: config.SchedulerConfig{
+			Retry: config.RetryConfig{
+				Enabled:      true,
+				MaxAttempts:  3,
+				DelaySeconds: 60,
+			},
+			Timezone: "UTC",
+		},
+	}
+
+	calls := make(chan struct{}, 3)
+	handler := func(ctx context.Context) error {
+		calls <- struct{}{}
+		return errors.New("fail")
+	}
+
+	s, err := NewScheduler(cfg, handler, testLogger())
+	assert.NoError(t, err)
+	s.stopTimeout = 5 * time.Second
+
+	go s.runCheck(context.Background())
+	<-calls
+
+	start := time.Now()
+	assert.NoError(t, s.Stop())
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.Len(t, calls, 0, "no retry after Stop")
+}
+
 func TestParseTime(t *testing.T) {
 	h, m, err := parseTime("08:30")
 	assert.NoError(t, err)
@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/jonboulle/clockwork"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+// CheckRuns lists the upcoming runs of one check time.
+type CheckRuns struct {
+	CheckTime string
+	Runs      []time.Time
+}
+
+// UpcomingRuns returns the next count runs after from of every configured
+// check time, in HH:MM order. They are computed by gocron in the configured
+// timezone with the job definitions the service schedules, so the instants
+// checks fire at, e.g. across a DST change, can be verified.
+func UpcomingRuns(cfg *config.Config, from time.Time, count int) ([]CheckRuns, error) {
+	loc, err := cfg.GetLocation()
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+	checkTimes, _, err := uniqueCheckTimes(cfg.Scheduler.CheckTimes)
+	if err != nil {
+		return nil, err
+	}
+
+	// A fake clock starts the schedule at from; nothing runs
+	s, err := gocron.NewScheduler(
+		gocron.WithLocation(loc),
+		gocron.WithClock(clockwork.NewFakeClockAt(from)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scheduler: %w", err)
+	}
+	defer func() {
+		_ = s.Shutdown()
+	}()
+
+	jobs := make([]gocron.Job, 0, len(checkTimes))
+	for _, checkTime := range checkTimes {
+		hour, minute, err := parseTime(checkTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid check time %s: %w", checkTime, err)
+		}
+		job, err := s.NewJob(dailyAt(hour, minute), gocron.NewTask(func() {}))
+		if err != nil {
+			return nil, fmt.Errorf("failed to schedule job for %s: %w", checkTime, err)
+		}
+		jobs = append(jobs, job)
+	}
+	s.Start()
+
+	runs := make([]CheckRuns, 0, len(jobs))
+	for i, job := range jobs {
+		next, err := job.NextRuns(count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute runs for %s: %w", checkTimes[i], err)
+		}
+		runs = append(runs, CheckRuns{CheckTime: checkTimes[i], Runs: next})
+	}
+	return runs, nil
+}
+
+// maxOffsetSearch is how far NextOffsetChange looks ahead.
+const maxOffsetSearch = 366 * 24 * time.Hour
+
+// NextOffsetChange returns the first hour after from at which loc's UTC
+// offset differs from the offset at from, e.g. a DST change, and false if
+// the offset does not change within a year.
+func NextOffsetChange(loc *time.Location, from time.Time) (time.Time, bool) {
+	start := from.Truncate(time.Hour)
+	_, offset := start.In(loc).Zone()
+	for t := start.Add(time.Hour); t.Sub(start) <= maxOffsetSearch; t = t.Add(time.Hour) {
+		if _, o := t.In(loc).Zone(); o != offset {
+			return t.In(loc), true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+func utc(month time.Month, day, hour, minute int) time.Time {
+	return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestUpcomingRuns_AcrossDSTChange(t *testing.T) {
+	tests := []struct {
+		name       string
+		timezone   string
+		checkTimes []string
+		from       time.Time
+		want       []CheckRuns
+	}{
+		{
+			// Clocks go forward on 8 March 2026 at 02:00 EST
+			name:       "spring forward",
+			timezone:   "America/New_York",
+			checkTimes: []string{"18:30", "08:00"},
+			from:       utc(time.March, 7, 0, 0),
+			want: []CheckRuns{
+				{CheckTime: "08:00", Runs: []time.Time{utc(time.March, 7, 13, 0), utc(time.March, 8, 12, 0), utc(time.March, 9, 12, 0)}},
+				{CheckTime: "18:30", Runs: []time.Time{utc(time.March, 7, 23, 30), utc(time.March, 8, 22, 30), utc(time.March, 9, 22, 30)}},
+			},
+		},
+		{
+			// Clocks go back on 25 October 2026 at 03:00 CEST
+			name:       "fall back",
+			timezone:   "Europe/Berlin",
+			checkTimes: []string{"08:00"},
+			from:       utc(time.October, 24, 0, 0),
+			want: []CheckRuns{
+				{CheckTime: "08:00", Runs: []time.Time{utc(time.October, 24, 6, 0), utc(time.October, 25, 7, 0), utc(time.October, 26, 7, 0)}},
+			},
+		},
+		{
+			name:       "no DST",
+			timezone:   "Asia/Ho_Chi_Minh",
+			checkTimes: []string{"08:00"},
+			from:       utc(time.March, 7, 0, 0),
+			want: []CheckRuns{
+				{CheckTime: "08:00", Runs: []time.Time{utc(time.March, 7, 1, 0), utc(time.March, 8, 1, 0), utc(time.March, 9, 1, 0)}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Scheduler: config.SchedulerConfig{Timezone: tt.timezone, CheckTimes: tt.checkTimes}}
+
+			got, err := UpcomingRuns(cfg, tt.from, 3)
+			require.NoError(t, err)
+			require.Len(t, got, len(tt.want))
+			for i, want := range tt.want {
+				assert.Equal(t, want.CheckTime, got[i].CheckTime)
+				require.Len(t, got[i].Runs, len(want.Runs), want.CheckTime)
+				for j, run := range want.Runs {
+					assert.True(t, run.Equal(got[i].Runs[j]), "%s run %d: got %s, want %s", want.CheckTime, j, got[i].Runs[j].UTC(), run)
+				}
+			}
+		})
+	}
+}
+
+func TestUpcomingRuns_InvalidConfig(t *testing.T) {
+	_, err := UpcomingRuns(&config.Config{Scheduler: config.SchedulerConfig{Timezone: "Mars/Olympus"}}, time.Now(), 3)
+	assert.ErrorContains(t, err, "invalid timezone")
+
+	_, err = UpcomingRuns(&config.Config{Scheduler: config.SchedulerConfig{Timezone: "UTC", CheckTimes: []string{"25:00"}}}, time.Now(), 3)
+	assert.ErrorContains(t, err, "invalid check time")
+}
+
+func TestNextOffsetChange(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	change, ok := NextOffsetChange(newYork, utc(time.January, 10, 0, 0))
+	require.True(t, ok)
+	assert.True(t, utc(time.March, 8, 7, 0).Equal(change), "got %s", change.UTC())
+	_, offset := change.Zone()
+	assert.Equal(t, -4*3600, offset)
+
+	change, ok = NextOffsetChange(newYork, utc(time.March, 8, 7, 0))
+	require.True(t, ok)
+	assert.True(t, utc(time.November, 1, 6, 0).Equal(change), "got %s", change.UTC())
+
+	_, ok = NextOffsetChange(time.UTC, utc(time.January, 10, 0, 0))
+	assert.False(t, ok)
+}
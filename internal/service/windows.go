@@ -9,6 +9,7 @@ import (
 	"github.com/rs/zerolog"
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/debug"
+	"golang.org/x/sys/windows/svc/mgr"
 
 	"github.com/hoangtran1411/watchman/internal/config"
 )
@@ -30,6 +31,10 @@ type Service struct {
 	startHandler func(ctx context.Context) error
 	stopHandler  func() error
 	logger       zerolog.Logger
+
+	// reloadHandler reloads the configuration on the paramchange control;
+	// nil means the control is not accepted.
+	reloadHandler func() error
 }
 
 // NewService creates a new Windows service handler.
@@ -42,6 +47,12 @@ func NewService(cfg *config.Config, start func(ctx context.Context) error, stop
 	}
 }
 
+// SetReloadHandler sets the function called when the service is asked to
+// reload its configuration, e.g. by 'watchman reload'.
+func (s *Service) SetReloadHandler(reload func() error) {
+	s.reloadHandler = reload
+}
+
 // Run runs the service.
 func (s *Service) Run(isDebug bool) error {
 	var err error
@@ -77,9 +88,13 @@ func (s *Service) Execute(args []string, r <-chan svc.ChangeRequest, changes cha
 	}()
 
 	// Report running status
+	accepts := svc.AcceptStop | svc.AcceptShutdown
+	if s.reloadHandler != nil {
+		accepts |= svc.AcceptParamChange
+	}
 	changes <- svc.Status{
 		State:   svc.Running,
-		Accepts: svc.AcceptStop | svc.AcceptShutdown,
+		Accepts: accepts,
 	}
 
 	// Main service loop
@@ -99,6 +114,16 @@ func (s *Service) Execute(args []string, r <-chan svc.ChangeRequest, changes cha
 			case svc.Interrogate:
 				changes <- c.CurrentStatus
 
+			case svc.ParamChange:
+				changes <- c.CurrentStatus
+				if s.reloadHandler != nil {
+					if err := s.reloadHandler(); err != nil {
+						s.logger.Error().
+							Err(err).
+							Msg("configuration reload failed")
+					}
+				}
+
 			case svc.Stop, svc.Shutdown:
 				changes <- svc.Status{State: svc.StopPending}
 
@@ -126,6 +151,26 @@ func (s *Service) Execute(args []string, r <-chan svc.ChangeRequest, changes cha
 	}
 }
 
+// Reload asks the running service to reload its configuration.
+func Reload() error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to service manager: %w", err)
+	}
+	defer func() { _ = m.Disconnect() }()
+
+	s, err := m.OpenService(ServiceName)
+	if err != nil {
+		return fmt.Errorf("failed to open service: %w", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	if _, err := s.Control(svc.ParamChange); err != nil {
+		return fmt.Errorf("failed to send reload request: %w", err)
+	}
+	return nil
+}
+
 // IsInteractive checks if running interactively (not as service).
 func IsInteractive() (bool, error) {
 	isService, err := svc.IsWindowsService()
@@ -83,3 +83,41 @@ func TestExecute_Lifecycle(t *testing.T) {
 	<-done
 	assert.True(t, stopCalled.Load())
 }
+
+func TestExecute_ParamChangeReloads(t *testing.T) {
+	reqChan := make(chan svc.ChangeRequest)
+	statusChan := make(chan svc.Status, 5)
+
+	start := func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}
+	stop := func() error { return nil }
+
+	s := NewService(&config.Config{}, start, stop, testLogger())
+	var reloads atomic.Int32
+	s.SetReloadHandler(func() error {
+		reloads.Add(1)
+		return nil
+	})
+
+	done := make(chan bool)
+	go func() {
+		s.Execute([]string{}, reqChan, statusChan)
+		done <- true
+	}()
+
+	<-statusChan // StartPending
+	status := <-statusChan
+	assert.Equal(t, svc.Running, status.State)
+	assert.NotZero(t, status.Accepts&svc.AcceptParamChange, "paramchange accepted with a reload handler")
+
+	reqChan <- svc.ChangeRequest{Cmd: svc.ParamChange, CurrentStatus: status}
+	assert.Equal(t, svc.Running, (<-statusChan).State)
+
+	reqChan <- svc.ChangeRequest{Cmd: svc.Stop, CurrentStatus: status}
+	<-statusChan // StopPending
+	<-statusChan // Stopped
+	<-done
+	assert.Equal(t, int32(1), reloads.Load())
+}
@@ -0,0 +1,117 @@
+// Package sink publishes check results to external systems.
+// Sinks are best effort: failures are reported to the caller for logging
+// and never affect checks or notifications.
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+	"github.com/hoangtran1411/watchman/internal/notification"
+)
+
+const (
+	// defaultTimeout is used when the sink has no timeout configured.
+	defaultTimeout = 10 * time.Second
+
+	// defaultBackoff is the delay before the first retry; it doubles on each retry.
+	defaultBackoff = time.Second
+)
+
+// HTTPSink sends check results as JSON to an HTTP endpoint.
+type HTTPSink struct {
+	cfg     config.ResultSinkConfig
+	client  *http.Client
+	backoff time.Duration
+}
+
+// NewHTTPSink creates an HTTP sink, or returns nil if no URL is configured.
+// Requests go through notification.proxy_url and notification.tls, like
+// those of the HTTP-based notification backends.
+func NewHTTPSink(cfg config.ResultSinkConfig, notify config.NotificationConfig) (*HTTPSink, error) {
+	if cfg.URL == "" {
+		return nil, nil
+	}
+
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	client, err := notification.NewHTTPClient(notify, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("result sink: %w", err)
+	}
+
+	return &HTTPSink{
+		cfg:     cfg,
+		client:  client,
+		backoff: defaultBackoff,
+	}, nil
+}
+
+// Post sends the result, retrying with exponential backoff on network
+// errors and 5xx/429 responses. Other 4xx responses are not retried.
+func (s *HTTPSink) Post(ctx context.Context, result *jobs.CheckResult) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	backoff := s.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.send(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= s.cfg.Retries {
+			return fmt.Errorf("result sink failed after %d attempts: %w", attempt+1, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("result sink cancelled: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// send performs a single request and reports whether a failure is worth retrying.
+func (s *HTTPSink) send(ctx context.Context, body []byte) (bool, error) {
+	method := strings.ToUpper(s.cfg.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range s.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("unexpected status: %s", resp.Status)
+}
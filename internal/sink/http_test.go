@@ -0,0 +1,144 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+	"github.com/hoangtran1411/watchman/internal/jobs"
+)
+
+// newTestSink creates a sink for cfg without proxy or TLS settings.
+func newTestSink(t *testing.T, cfg config.ResultSinkConfig) *HTTPSink {
+	t.Helper()
+	s, err := NewHTTPSink(cfg, config.NotificationConfig{})
+	require.NoError(t, err)
+	return s
+}
+
+func TestNewHTTPSink_Disabled(t *testing.T) {
+	s, err := NewHTTPSink(config.ResultSinkConfig{}, config.NotificationConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, s)
+}
+
+func TestHTTPSink_UsesProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	s, err := NewHTTPSink(
+		config.ResultSinkConfig{URL: "http://collector.example.invalid/results"},
+		config.NotificationConfig{ProxyURL: proxy.URL},
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Post(context.Background(), &jobs.CheckResult{Status: "success"}))
+	assert.Equal(t, "http://collector.example.invalid/results", proxied, "notification.proxy_url is honored")
+}
+
+func TestNewHTTPSink_InvalidTLS(t *testing.T) {
+	_, err := NewHTTPSink(
+		config.ResultSinkConfig{URL: "https://collector.example.com"},
+		config.NotificationConfig{TLS: config.TLSConfig{CAFile: "does-not-exist.pem"}},
+	)
+	assert.ErrorContains(t, err, "result sink: failed to read tls ca_file")
+}
+
+func TestHTTPSink_Post(t *testing.T) {
+	var gotMethod, gotAuth, gotType string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotAuth = r.Header.Get("Authorization")
+		gotType = r.Header.Get("Content-Type")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s := newTestSink(t, config.ResultSinkConfig{
+		URL:     srv.URL,
+		Method:  "put",
+		Headers: map[string]string{"authorization": "Bearer token"},
+	})
+
+	result := &jobs.CheckResult{
+		Status:     "failed_jobs",
+		FailedJobs: []database.FailedJob{{ServerName: "SQL01", JobName: "Backup"}},
+		Summary:    "1 failed job on 1 server",
+	}
+	require.NoError(t, s.Post(context.Background(), result))
+
+	assert.Equal(t, http.MethodPut, gotMethod)
+	assert.Equal(t, "Bearer token", gotAuth)
+	assert.Equal(t, "application/json", gotType)
+
+	var posted jobs.CheckResult
+	require.NoError(t, json.Unmarshal(gotBody, &posted))
+	assert.Equal(t, result.Status, posted.Status)
+	assert.Equal(t, result.FailedJobs, posted.FailedJobs)
+}
+
+func TestHTTPSink_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		retries      int
+		wantErr      bool
+		wantAttempts int32
+	}{
+		{name: "recovers after server error", statuses: []int{500, 503, 200}, retries: 2, wantAttempts: 3},
+		{name: "gives up after retries", statuses: []int{500, 500, 500}, retries: 1, wantErr: true, wantAttempts: 2},
+		{name: "client error is not retried", statuses: []int{400, 200}, retries: 2, wantErr: true, wantAttempts: 1},
+		{name: "rate limit is retried", statuses: []int{429, 200}, retries: 2, wantAttempts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := attempts.Add(1)
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer srv.Close()
+
+			s := newTestSink(t, config.ResultSinkConfig{URL: srv.URL, Retries: tt.retries})
+			s.backoff = time.Millisecond
+
+			err := s.Post(context.Background(), &jobs.CheckResult{Status: "success"})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantAttempts, attempts.Load())
+		})
+	}
+}
+
+func TestHTTPSink_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	s := newTestSink(t, config.ResultSinkConfig{URL: srv.URL})
+	s.client.Timeout = 20 * time.Millisecond
+
+	err := s.Post(context.Background(), &jobs.CheckResult{Status: "success"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package state
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+// digestWindow is how long failed jobs may wait for the daily digest or the
+// end of quiet hours.
+const digestWindow = 24 * time.Hour
+
+// RetentionWindow returns how long state entries are kept by Compact: the
+// longest window cfg uses them for.
+func RetentionWindow(cfg *config.Config) time.Duration {
+	window := max(
+		time.Duration(cfg.Monitoring.LookbackHours)*time.Hour,
+		time.Duration(cfg.Notification.MinIntervalBetweenSends)*time.Second,
+	)
+	if cfg.Notification.Mode == config.NotificationModeDigest || cfg.Notification.QuietHours.Enabled {
+		window = max(window, digestWindow)
+	}
+	return window
+}
+
+// Compact removes stale entries and returns how many were removed:
+//   - last check times older than maxAge or of servers not in servers
+//   - outages of servers not in servers; ongoing outages of configured
+//     servers are kept so they are not alerted again
+//   - deferred failed jobs that failed more than maxAge ago
+//   - alerts of long-running executions started more than maxAge ago; a
+//     job still running is alerted again
+func (st *State) Compact(now time.Time, maxAge time.Duration, servers []string) int {
+	cutoff := now.Add(-maxAge)
+	removed := 0
+
+	for server, at := range st.LastChecks {
+		if at.Before(cutoff) || !slices.Contains(servers, server) {
+			delete(st.LastChecks, server)
+			removed++
+		}
+	}
+	for server := range st.DownServers {
+		if !slices.Contains(servers, server) {
+			delete(st.DownServers, server)
+			removed++
+		}
+	}
+
+	before := len(st.PendingNotifications)
+	st.PendingNotifications = slices.DeleteFunc(st.PendingNotifications, func(job database.FailedJob) bool {
+		return !job.FailedAt.IsZero() && job.FailedAt.Before(cutoff)
+	})
+	removed += before - len(st.PendingNotifications)
+
+	before = len(st.LongRunningAlerts)
+	st.LongRunningAlerts = slices.DeleteFunc(st.LongRunningAlerts, func(alert LongRunningAlert) bool {
+		return alert.StartedAt.Before(cutoff)
+	})
+	removed += before - len(st.LongRunningAlerts)
+
+	return removed
+}
+
+// Compact removes stale entries from the state file, see State.Compact.
+// The file is rewritten atomically only if something was removed.
+func (s *Store) Compact(now time.Time, maxAge time.Duration, servers []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+	removed := st.Compact(now, maxAge, servers)
+	if removed == 0 {
+		return 0, nil
+	}
+	if err := s.save(st); err != nil {
+		return 0, fmt.Errorf("failed to compact state: %w", err)
+	}
+	return removed, nil
+}
@@ -0,0 +1,88 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+func TestStore_Compact(t *testing.T) {
+	now := time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC)
+	store := NewStore(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, store.Save(&State{
+		LastChecks: map[string]time.Time{
+			"SQL1":    now.Add(-time.Hour),
+			"SQL2":    now.Add(-48 * time.Hour),
+			"Retired": now.Add(-time.Hour),
+		},
+		DownServers: map[string]time.Time{
+			"SQL1":    now.Add(-72 * time.Hour),
+			"Retired": now.Add(-time.Hour),
+		},
+		PendingNotifications: []database.FailedJob{
+			{ServerName: "SQL1", JobName: "Recent", FailedAt: now.Add(-time.Hour)},
+			{ServerName: "SQL1", JobName: "Old", FailedAt: now.Add(-30 * time.Hour)},
+		},
+		LongRunningAlerts: []LongRunningAlert{
+			{Server: "SQL1", Job: "ETL", StartedAt: now.Add(-3 * time.Hour)},
+			{Server: "SQL1", Job: "ETL", StartedAt: now.Add(-27 * time.Hour)},
+		},
+		LastVersion: "1.2.0",
+	}))
+
+	removed, err := store.Compact(now, 24*time.Hour, []string{"SQL1", "SQL2"})
+	require.NoError(t, err)
+	assert.Equal(t, 5, removed)
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SQL1"}, keys(st.LastChecks))
+	assert.Equal(t, []string{"SQL1"}, keys(st.DownServers), "ongoing outages are kept")
+	require.Len(t, st.PendingNotifications, 1)
+	assert.Equal(t, "Recent", st.PendingNotifications[0].JobName)
+	require.Len(t, st.LongRunningAlerts, 1)
+	assert.Equal(t, now.Add(-3*time.Hour), st.LongRunningAlerts[0].StartedAt)
+	assert.Equal(t, "1.2.0", st.LastVersion)
+
+	// A second run finds nothing to remove
+	removed, err = store.Compact(now, 24*time.Hour, []string{"SQL1", "SQL2"})
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+}
+
+func TestStore_CompactMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	removed, err := NewStore(path).Compact(time.Now(), time.Hour, nil)
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+	assert.NoFileExists(t, path)
+}
+
+func TestRetentionWindow(t *testing.T) {
+	cfg := &config.Config{Monitoring: config.MonitoringConfig{LookbackHours: 6}}
+	assert.Equal(t, 6*time.Hour, RetentionWindow(cfg))
+
+	cfg.Notification.MinIntervalBetweenSends = 8 * 3600
+	assert.Equal(t, 8*time.Hour, RetentionWindow(cfg))
+
+	cfg.Notification.Mode = config.NotificationModeDigest
+	assert.Equal(t, 24*time.Hour, RetentionWindow(cfg))
+
+	cfg.Monitoring.LookbackHours = 168
+	assert.Equal(t, 168*time.Hour, RetentionWindow(cfg))
+}
+
+func keys(m map[string]time.Time) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
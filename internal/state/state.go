@@ -0,0 +1,241 @@
+// Package state provides persistent runtime state for Watchman.
+// State is stored as a JSON file next to the configuration and survives
+// service restarts and updates.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+	"github.com/hoangtran1411/watchman/internal/database"
+)
+
+// State represents the persisted runtime state.
+type State struct {
+	// DismissedUpdates maps a dismissed release version to the dismissal time.
+	DismissedUpdates map[string]time.Time `json:"dismissed_updates,omitempty"`
+
+	// PendingNotifications holds failed jobs whose notification was deferred.
+	PendingNotifications []database.FailedJob `json:"pending_notifications,omitempty"`
+
+	// LastChecks maps a server name to the start time of its last successful check.
+	LastChecks map[string]time.Time `json:"last_checks,omitempty"`
+
+	// DownServers maps an unreachable server name to the time its outage was alerted.
+	DownServers map[string]time.Time `json:"down_servers,omitempty"`
+
+	// LongRunningAlerts are the long-running job executions already alerted.
+	LongRunningAlerts []LongRunningAlert `json:"long_running_alerts,omitempty"`
+
+	// LastNotificationSent is when failed jobs were last notified, for
+	// notification.min_interval_between_sends.
+	LastNotificationSent time.Time `json:"last_notification_sent,omitzero"`
+
+	// CheckFailureStreak counts consecutive scheduled checks that reached no
+	// server. Scheduled checks are skipped until CheckBackoffUntil.
+	CheckFailureStreak int       `json:"check_failure_streak,omitempty"`
+	CheckBackoffUntil  time.Time `json:"check_backoff_until,omitzero"`
+
+	// LastVersion is the Watchman version that last started the service.
+	LastVersion string `json:"last_version,omitempty"`
+
+	// AppliedConfig is the configuration the service last started or reloaded with,
+	// with secrets masked. AppliedAt is when it was applied.
+	AppliedConfig *config.Config `json:"applied_config,omitempty"`
+	AppliedAt     time.Time      `json:"applied_at,omitzero"`
+}
+
+// LongRunningAlert identifies an alerted execution of a long-running job.
+type LongRunningAlert struct {
+	Server    string    `json:"server"`
+	Job       string    `json:"job"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Equal returns true if a and other identify the same execution.
+func (a LongRunningAlert) Equal(other LongRunningAlert) bool {
+	return a.Server == other.Server && a.Job == other.Job && a.StartedAt.Equal(other.StartedAt)
+}
+
+// Store reads and writes the state file.
+type Store struct {
+	path string
+	mu   sync.Mutex
+
+	// memory keeps the encoded state in data instead of a file.
+	memory bool
+	data   []byte
+}
+
+// NewStore creates a store backed by the file at path.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// NewMemoryStore creates a store that starts with a copy of st and keeps
+// changes in memory, leaving the state file untouched. A nil st yields an
+// empty state.
+func NewMemoryStore(st *State) (*Store, error) {
+	s := &Store{memory: true}
+	if st == nil {
+		return s, nil
+	}
+	if err := s.save(st); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// DefaultDir returns the default state directory.
+func DefaultDir() string {
+	if programData := os.Getenv("ProgramData"); programData != "" {
+		return filepath.Join(programData, "Watchman")
+	}
+	return "."
+}
+
+// DefaultPath returns the default state file path.
+func DefaultPath() string {
+	return filepath.Join(DefaultDir(), "state.json")
+}
+
+// Path returns the state file path.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load reads the state file. A missing file yields an empty state.
+func (s *Store) Load() (*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Save writes the state file atomically.
+func (s *Store) Save(st *State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save(st)
+}
+
+// Update loads the state, applies fn and saves the result.
+// The state is not saved if fn returns an error.
+func (s *Store) Update(fn func(st *State) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(st); err != nil {
+		return err
+	}
+	return s.save(st)
+}
+
+// load reads the state file without locking.
+func (s *Store) load() (*State, error) {
+	data, err := s.read()
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("failed to parse state: %w", err)
+	}
+	return &st, nil
+}
+
+// read returns the encoded state from memory or the state file.
+func (s *Store) read() ([]byte, error) {
+	if !s.memory {
+		return os.ReadFile(s.path)
+	}
+	if s.data == nil {
+		return nil, os.ErrNotExist
+	}
+	return s.data, nil
+}
+
+// save writes the state file without locking.
+// It writes to a temp file and renames it so a crash never leaves a partial file.
+func (s *Store) save(st *State) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	if s.memory {
+		s.data = data
+		return nil
+	}
+	return WriteFileAtomic(s.path, data)
+}
+
+// WriteFileAtomic writes data to a temp file in the target directory and renames it into place.
+func WriteFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer func() {
+		_ = os.Remove(tmpName) // No-op after a successful rename
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+	return nil
+}
+
+// IsUpdateDismissed returns true if notifications for version were dismissed.
+func (st *State) IsUpdateDismissed(version string) bool {
+	_, ok := st.DismissedUpdates[version]
+	return ok
+}
+
+// RecordVersion records version as the running version and returns the
+// previously recorded one if it differs. The first recorded version is not
+// a change.
+func (st *State) RecordVersion(version string) (previous string, changed bool) {
+	previous = st.LastVersion
+	st.LastVersion = version
+	return previous, previous != "" && previous != version
+}
+
+// RecordAppliedConfig records cfg, masked, as the configuration in effect since at.
+func (st *State) RecordAppliedConfig(cfg *config.Config, at time.Time) {
+	st.AppliedConfig = cfg.Masked()
+	st.AppliedAt = at
+}
+
+// DismissUpdate records that notifications for version should be suppressed.
+// Older dismissals are dropped since a newer release supersedes them.
+func (st *State) DismissUpdate(version string, at time.Time) {
+	st.DismissedUpdates = map[string]time.Time{version: at}
+}
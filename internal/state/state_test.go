@@ -0,0 +1,189 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+func TestStore_LoadMissingFile(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "state.json"))
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.NotNil(t, st)
+	assert.Empty(t, st.DismissedUpdates)
+}
+
+func TestStore_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	store := NewStore(path)
+
+	at := time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC)
+	st := &State{}
+	st.DismissUpdate("1.2.0", at)
+	require.NoError(t, store.Save(st))
+
+	loaded, err := store.Load()
+	require.NoError(t, err)
+	assert.True(t, loaded.IsUpdateDismissed("1.2.0"))
+	assert.True(t, loaded.DismissedUpdates["1.2.0"].Equal(at))
+
+	// No temp files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestStore_LoadCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o600))
+
+	_, err := NewStore(path).Load()
+	assert.Error(t, err)
+}
+
+func TestStore_Update(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "state.json"))
+
+	err := store.Update(func(st *State) error {
+		st.DismissUpdate("1.0.0", time.Now())
+		return nil
+	})
+	require.NoError(t, err)
+
+	// A failing update must not be saved
+	err = store.Update(func(st *State) error {
+		st.DismissUpdate("2.0.0", time.Now())
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.True(t, st.IsUpdateDismissed("1.0.0"))
+	assert.False(t, st.IsUpdateDismissed("2.0.0"))
+}
+
+func TestMemoryStore(t *testing.T) {
+	seed := &State{LastVersion: "1.0.0"}
+	store, err := NewMemoryStore(seed)
+	require.NoError(t, err)
+	assert.Empty(t, store.Path())
+
+	err = store.Update(func(st *State) error {
+		st.DismissUpdate("2.0.0", time.Now())
+		return nil
+	})
+	require.NoError(t, err)
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", st.LastVersion)
+	assert.True(t, st.IsUpdateDismissed("2.0.0"))
+	assert.False(t, seed.IsUpdateDismissed("2.0.0"), "the seed state is copied")
+
+	empty, err := NewMemoryStore(nil)
+	require.NoError(t, err)
+	st, err = empty.Load()
+	require.NoError(t, err)
+	assert.Empty(t, st.LastVersion)
+}
+
+func TestUpdateDismissal(t *testing.T) {
+	tests := []struct {
+		name      string
+		dismissed []string
+		version   string
+		want      bool
+	}{
+		{
+			name:    "nothing dismissed",
+			version: "1.1.0",
+			want:    false,
+		},
+		{
+			name:      "same version dismissed",
+			dismissed: []string{"1.1.0"},
+			version:   "1.1.0",
+			want:      true,
+		},
+		{
+			name:      "newer version after dismissal",
+			dismissed: []string{"1.1.0"},
+			version:   "1.2.0",
+			want:      false,
+		},
+		{
+			name:      "only latest dismissal is kept",
+			dismissed: []string{"1.1.0", "1.2.0"},
+			version:   "1.1.0",
+			want:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := &State{}
+			for _, v := range tt.dismissed {
+				st.DismissUpdate(v, time.Now())
+			}
+			assert.Equal(t, tt.want, st.IsUpdateDismissed(tt.version))
+		})
+	}
+}
+
+func TestRecordVersion(t *testing.T) {
+	tests := []struct {
+		name         string
+		last         string
+		version      string
+		wantPrevious string
+		wantChanged  bool
+	}{
+		{name: "first start", version: "v1.2.0"},
+		{name: "same version", last: "v1.2.0", version: "v1.2.0", wantPrevious: "v1.2.0"},
+		{name: "updated", last: "v1.1.0", version: "v1.2.0", wantPrevious: "v1.1.0", wantChanged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := &State{LastVersion: tt.last}
+			previous, changed := st.RecordVersion(tt.version)
+			assert.Equal(t, tt.wantPrevious, previous)
+			assert.Equal(t, tt.wantChanged, changed)
+			assert.Equal(t, tt.version, st.LastVersion)
+		})
+	}
+}
+
+func TestRecordAppliedConfig(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "state.json"))
+
+	cfg := config.DefaultConfig()
+	cfg.Servers = []config.ServerConfig{
+		{Name: "PROD", Host: "prod", Port: 1433, Auth: config.AuthConfig{Type: "sql", Password: "hunter2"}},
+	}
+	at := time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC)
+
+	require.NoError(t, store.Update(func(st *State) error {
+		st.RecordAppliedConfig(cfg, at)
+		return nil
+	}))
+
+	data, err := os.ReadFile(store.Path())
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2", "secrets must not be stored")
+
+	loaded, err := store.Load()
+	require.NoError(t, err)
+	require.NotNil(t, loaded.AppliedConfig)
+	assert.True(t, loaded.AppliedAt.Equal(at))
+	assert.Empty(t, config.Diff(loaded.AppliedConfig, cfg.Masked()), "round trip keeps the configuration")
+}
@@ -0,0 +1,77 @@
+// Package warnings collects problems that do not stop a command, such as an
+// unknown configuration key, so they are reported with its output instead of
+// being ignored silently.
+package warnings
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Sources of warnings.
+const (
+	SourceConfig  = "config"
+	SourceFilter  = "filter"
+	SourceMonitor = "monitor"
+)
+
+// maxWarnings bounds the collector in the long-running service, which never
+// takes the warnings.
+const maxWarnings = 100
+
+// Warning is a problem found while running a command.
+type Warning struct {
+	Source  string `json:"source"`
+	Message string `json:"message"`
+}
+
+// String returns the warning as printed in text output.
+func (w Warning) String() string {
+	return w.Source + ": " + w.Message
+}
+
+// Collector gathers warnings. It is safe for concurrent use.
+type Collector struct {
+	mu       sync.Mutex
+	warnings []Warning
+}
+
+// Add records a warning from source. A warning already recorded is not
+// added again, and warnings beyond maxWarnings are dropped.
+func (c *Collector) Add(source, format string, args ...interface{}) {
+	w := Warning{Source: source, Message: fmt.Sprintf(format, args...)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.warnings) >= maxWarnings {
+		return
+	}
+	for _, existing := range c.warnings {
+		if existing == w {
+			return
+		}
+	}
+	c.warnings = append(c.warnings, w)
+}
+
+// Take returns the warnings recorded so far, in order, and clears them.
+func (c *Collector) Take() []Warning {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	warnings := c.warnings
+	c.warnings = nil
+	return warnings
+}
+
+// collector is the process-wide collector used by Add and Take.
+var collector = &Collector{}
+
+// Add records a warning in the process-wide collector.
+func Add(source, format string, args ...interface{}) {
+	collector.Add(source, format, args...)
+}
+
+// Take returns and clears the warnings of the process-wide collector.
+func Take() []Warning {
+	return collector.Take()
+}
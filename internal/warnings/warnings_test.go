@@ -0,0 +1,42 @@
+package warnings
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	var c Collector
+	c.Add(SourceConfig, "unknown key %q is ignored", "monitoring.lookback")
+	c.Add(SourceMonitor, "1 server not checked: %s", "SQL02")
+	c.Add(SourceConfig, "unknown key %q is ignored", "monitoring.lookback")
+
+	assert.Equal(t, []Warning{
+		{Source: SourceConfig, Message: `unknown key "monitoring.lookback" is ignored`},
+		{Source: SourceMonitor, Message: "1 server not checked: SQL02"},
+	}, c.Take(), "duplicates are recorded once")
+	assert.Empty(t, c.Take(), "Take clears the warnings")
+}
+
+func TestCollector_Bounded(t *testing.T) {
+	var c Collector
+	var wg sync.WaitGroup
+	for i := 0; i < 2*maxWarnings; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Add(SourceMonitor, "warning %d", i)
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, c.Take(), maxWarnings)
+}
+
+func TestWarning_String(t *testing.T) {
+	w := Warning{Source: SourceFilter, Message: fmt.Sprintf("pattern %q", "a*b")}
+	assert.Equal(t, `filter: pattern "a*b"`, w.String())
+}
@@ -0,0 +1,196 @@
+package winshell
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// COM class, interface and property identifiers used to write shortcuts.
+var (
+	clsidShellLink    = windows.GUID{Data1: 0x00021401, Data4: [8]byte{0xC0, 0, 0, 0, 0, 0, 0, 0x46}}
+	iidIShellLinkW    = windows.GUID{Data1: 0x000214F9, Data4: [8]byte{0xC0, 0, 0, 0, 0, 0, 0, 0x46}}
+	iidIPersistFile   = windows.GUID{Data1: 0x0000010B, Data4: [8]byte{0xC0, 0, 0, 0, 0, 0, 0, 0x46}}
+	iidIPropertyStore = windows.GUID{
+		Data1: 0x886D8EEB, Data2: 0x8CF2, Data3: 0x4446,
+		Data4: [8]byte{0x8D, 0x02, 0xCD, 0xBA, 0x1D, 0xBD, 0xCF, 0x99},
+	}
+
+	// pkeyAppUserModelID is System.AppUserModel.ID.
+	pkeyAppUserModelID = propertyKey{
+		fmtid: windows.GUID{
+			Data1: 0x9F4C2855, Data2: 0x9F79, Data3: 0x4B39,
+			Data4: [8]byte{0xA8, 0xD0, 0xE1, 0xD4, 0x2D, 0xE1, 0xD5, 0xF3},
+		},
+		pid: 5,
+	}
+)
+
+var procCoCreateInstance = windows.NewLazySystemDLL("ole32.dll").NewProc("CoCreateInstance")
+
+// COM constants.
+const (
+	clsctxInprocServer = 0x1
+	vtLPWStr           = 31
+)
+
+// Vtable slots of the methods called, counted from IUnknown's QueryInterface.
+const (
+	slotQueryInterface = 0
+	slotRelease        = 2
+
+	slotShellLinkSetIconLocation = 17
+	slotShellLinkSetPath         = 20
+
+	slotPersistFileSave = 6
+
+	slotPropertyStoreSetValue = 6
+	slotPropertyStoreCommit   = 7
+)
+
+// propertyKey is a PROPERTYKEY.
+type propertyKey struct {
+	fmtid windows.GUID
+	pid   uint32
+}
+
+// propVariant is a PROPVARIANT holding a pointer-sized value.
+type propVariant struct {
+	vt       uint16
+	reserved [3]uint16
+	val      uintptr
+	_        uintptr
+}
+
+// maxSlots bounds the vtable slots addressed.
+const maxSlots = 32
+
+// comObject is the layout of a COM object: a pointer to its vtable.
+type comObject struct {
+	vtbl *[maxSlots]uintptr
+}
+
+// call invokes the method in vtable slot with args, returning an error for
+// a failed HRESULT.
+func (o *comObject) call(slot int, args ...uintptr) error {
+	this := uintptr(unsafe.Pointer(o)) // #nosec G103 -- COM this pointer
+	hr, _, _ := syscall.SyscallN(o.vtbl[slot], append([]uintptr{this}, args...)...)
+	if int32(hr) < 0 { // #nosec G115 -- HRESULT is a signed 32-bit value
+		return syscall.Errno(hr)
+	}
+	return nil
+}
+
+// query returns the interface iid of o.
+func (o *comObject) query(iid *windows.GUID) (*comObject, error) {
+	var out *comObject
+	if err := o.call(slotQueryInterface, uintptr(unsafe.Pointer(iid)), uintptr(unsafe.Pointer(&out))); err != nil { // #nosec G103 -- COM out parameter
+		return nil, err
+	}
+	return out, nil
+}
+
+// release releases o.
+func (o *comObject) release() {
+	_ = o.call(slotRelease)
+}
+
+// CreateShortcut creates a .lnk shortcut at path pointing to target, with
+// its System.AppUserModel.ID property set to appID so toasts raised with
+// that App User Model ID are attributed to the shortcut. icon may be empty.
+func CreateShortcut(path, target, icon, appID string) error {
+	// COM apartments are per thread
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	// S_FALSE means COM was already initialized on this thread
+	err := windows.CoInitializeEx(0, windows.COINIT_APARTMENTTHREADED)
+	if err != nil && !errors.Is(err, syscall.Errno(windows.S_FALSE)) {
+		return fmt.Errorf("failed to initialize COM: %w", err)
+	}
+	defer windows.CoUninitialize()
+
+	if err := writeShortcut(path, target, icon, appID); err != nil {
+		return fmt.Errorf("failed to create shortcut: %w", err)
+	}
+	return nil
+}
+
+// writeShortcut writes the shortcut through IShellLinkW, IPropertyStore and
+// IPersistFile. COM must be initialized on the calling thread.
+func writeShortcut(path, target, icon, appID string) error {
+	var link *comObject
+	hr, _, _ := procCoCreateInstance.Call(
+		uintptr(unsafe.Pointer(&clsidShellLink)), // #nosec G103 -- COM in parameter
+		0,
+		clsctxInprocServer,
+		uintptr(unsafe.Pointer(&iidIShellLinkW)), // #nosec G103 -- COM in parameter
+		uintptr(unsafe.Pointer(&link)),           // #nosec G103 -- COM out parameter
+	)
+	if int32(hr) < 0 { // #nosec G115 -- HRESULT is a signed 32-bit value
+		return fmt.Errorf("shell link: %w", syscall.Errno(hr))
+	}
+	defer link.release()
+
+	if err := callString(link, slotShellLinkSetPath, target); err != nil {
+		return fmt.Errorf("set target: %w", err)
+	}
+	if icon != "" {
+		if err := callString(link, slotShellLinkSetIconLocation, icon, 0); err != nil {
+			return fmt.Errorf("set icon: %w", err)
+		}
+	}
+	if err := setAppUserModelID(link, appID); err != nil {
+		return fmt.Errorf("set app user model id: %w", err)
+	}
+
+	file, err := link.query(&iidIPersistFile)
+	if err != nil {
+		return fmt.Errorf("persist file: %w", err)
+	}
+	defer file.release()
+	if err := callString(file, slotPersistFileSave, path, 1); err != nil {
+		return fmt.Errorf("save %s: %w", path, err)
+	}
+	return nil
+}
+
+// setAppUserModelID sets System.AppUserModel.ID on the shell link.
+func setAppUserModelID(link *comObject, appID string) error {
+	store, err := link.query(&iidIPropertyStore)
+	if err != nil {
+		return err
+	}
+	defer store.release()
+
+	value, err := windows.UTF16PtrFromString(appID)
+	if err != nil {
+		return err
+	}
+	pv := propVariant{vt: vtLPWStr, val: uintptr(unsafe.Pointer(value))} // #nosec G103 -- PROPVARIANT string pointer
+	err = store.call(slotPropertyStoreSetValue,
+		uintptr(unsafe.Pointer(&pkeyAppUserModelID)), // #nosec G103 -- COM in parameter
+		uintptr(unsafe.Pointer(&pv)),                 // #nosec G103 -- COM in parameter
+	)
+	runtime.KeepAlive(value)
+	if err != nil {
+		return err
+	}
+	return store.call(slotPropertyStoreCommit)
+}
+
+// callString invokes the method in slot with s as its first argument,
+// followed by args.
+func callString(o *comObject, slot int, s string, args ...uintptr) error {
+	p, err := windows.UTF16PtrFromString(s)
+	if err != nil {
+		return err
+	}
+	err = o.call(slot, append([]uintptr{uintptr(unsafe.Pointer(p))}, args...)...) // #nosec G103 -- COM string parameter
+	runtime.KeepAlive(p)
+	return err
+}
@@ -0,0 +1,50 @@
+// Package winshell provides Windows shell integration helpers
+// (registry entries and Start Menu shortcuts).
+package winshell
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// SetStringValues creates the registry key and sets its string values.
+// The key is created under HKLM when machine is true, otherwise under HKCU.
+func SetStringValues(machine bool, path string, values map[string]string) error {
+	root := registry.CURRENT_USER
+	if machine {
+		root = registry.LOCAL_MACHINE
+	}
+
+	key, _, err := registry.CreateKey(root, path, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to create registry key %s: %w", path, err)
+	}
+	defer func() {
+		_ = key.Close()
+	}()
+
+	for name, value := range values {
+		if err := key.SetStringValue(name, value); err != nil {
+			return fmt.Errorf("failed to set registry value %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// KeyExists returns true if the registry key exists under HKCU or HKLM.
+func KeyExists(path string) (bool, error) {
+	for _, root := range []registry.Key{registry.CURRENT_USER, registry.LOCAL_MACHINE} {
+		key, err := registry.OpenKey(root, path, registry.QUERY_VALUE)
+		if errors.Is(err, registry.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to open registry key %s: %w", path, err)
+		}
+		_ = key.Close()
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package logger
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// freeDiskSpace returns the bytes available to unprivileged users on the filesystem holding dir.
+func freeDiskSpace(dir string) (uint64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return 0, fmt.Errorf("failed to read free disk space: %w", err)
+	}
+	return st.Bavail * uint64(st.Bsize), nil // #nosec G115 -- block size is positive
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package logger
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// freeDiskSpace returns the bytes available to the caller on the volume holding dir.
+func freeDiskSpace(dir string) (uint64, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, fmt.Errorf("invalid path %s: %w", dir, err)
+	}
+
+	var free uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &free, nil, nil); err != nil {
+		return 0, fmt.Errorf("failed to read free disk space: %w", err)
+	}
+	return free, nil
+}
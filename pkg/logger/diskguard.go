@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"os"
+	"sync"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+// Retention applied to the file log while free disk space is below
+// logging.file.min_free_mb.
+const (
+	lowDiskMaxBackups = 1
+	lowDiskMaxAgeDays = 1
+)
+
+// diskGuard reports how the file log settings were tightened for low disk space.
+type diskGuard struct {
+	FreeMB    uint64
+	MinFreeMB int
+}
+
+// guardDiskSpace returns cfg with compression forced and retention reduced
+// when the free space in the log directory is below cfg.MinFreeMB. The guard
+// is nil when the settings are unchanged, including when the free space
+// cannot be determined.
+func guardDiskSpace(cfg config.FileLogConfig, dir string, freeSpace func(dir string) (uint64, error)) (config.FileLogConfig, *diskGuard) {
+	if cfg.MinFreeMB <= 0 {
+		return cfg, nil
+	}
+
+	free, err := freeSpace(dir)
+	if err != nil {
+		return cfg, nil
+	}
+	freeMB := free / (1024 * 1024)
+	if freeMB >= uint64(cfg.MinFreeMB) {
+		return cfg, nil
+	}
+
+	cfg.Compress = true
+	if cfg.MaxBackups == 0 || cfg.MaxBackups > lowDiskMaxBackups {
+		cfg.MaxBackups = lowDiskMaxBackups
+	}
+	if cfg.MaxAgeDays == 0 || cfg.MaxAgeDays > lowDiskMaxAgeDays {
+		cfg.MaxAgeDays = lowDiskMaxAgeDays
+	}
+	return cfg, &diskGuard{FreeMB: freeMB, MinFreeMB: cfg.MinFreeMB}
+}
+
+// defaultMaxSizeMB is lumberjack's rotation size when none is configured.
+const defaultMaxSizeMB = 100
+
+// guardedWriter is a rotating file writer that checks the free disk space
+// again before each size-triggered rotation, so retention is reduced when
+// the volume fills up while running and restored once space is freed.
+type guardedWriter struct {
+	mu        sync.Mutex
+	cfg       config.FileLogConfig // As configured, before guarding
+	dir       string
+	freeSpace func(dir string) (uint64, error)
+	file      *lumberjack.Logger
+	guarded   bool
+	size      int64 // Bytes in the current file
+	maxSize   int64
+}
+
+// newGuardedWriter opens the rotating file writer for cfg, guarding its
+// retention for the free space in dir. The guard is non-nil if retention
+// was reduced.
+func newGuardedWriter(cfg config.FileLogConfig, dir string, freeSpace func(dir string) (uint64, error)) (*guardedWriter, *diskGuard) {
+	maxSizeMB := cfg.MaxSizeMB
+	if maxSizeMB <= 0 {
+		maxSizeMB = defaultMaxSizeMB
+	}
+	w := &guardedWriter{
+		cfg:       cfg,
+		dir:       dir,
+		freeSpace: freeSpace,
+		maxSize:   int64(maxSizeMB) * 1024 * 1024,
+	}
+	if info, err := os.Stat(cfg.Path); err == nil {
+		w.size = info.Size()
+	}
+	return w, w.guard()
+}
+
+// Write writes p to the log file. When p would make the file rotate, the
+// free disk space is checked first.
+func (w *guardedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.size+int64(len(p)) > w.maxSize {
+		w.guard()
+		w.size = 0
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// guard applies the retention for the current free disk space, reopening the
+// file writer if it changed. It returns the guard in effect, nil if none.
+func (w *guardedWriter) guard() *diskGuard {
+	cfg, guard := guardDiskSpace(w.cfg, w.dir, w.freeSpace)
+	if w.file != nil && (guard != nil) == w.guarded {
+		return guard
+	}
+
+	if w.file != nil {
+		_ = w.file.Close()
+	}
+	w.guarded = guard != nil
+	w.file = &lumberjack.Logger{
+		Filename:   cfg.Path,
+		MaxSize:    cfg.MaxSizeMB,
+		MaxBackups: cfg.MaxBackups,
+		MaxAge:     cfg.MaxAgeDays,
+		Compress:   cfg.Compress,
+	}
+	return guard
+}
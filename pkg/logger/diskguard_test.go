@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoangtran1411/watchman/internal/config"
+)
+
+// freeMB returns a disk-space function reporting mb megabytes free.
+func freeMB(mb uint64) func(string) (uint64, error) {
+	return func(string) (uint64, error) { return mb * 1024 * 1024, nil }
+}
+
+func TestGuardDiskSpace(t *testing.T) {
+	base := config.FileLogConfig{
+		Path:       "logs/watchman.log",
+		MaxSizeMB:  10,
+		MaxBackups: 5,
+		MaxAgeDays: 30,
+		Compress:   false,
+		MinFreeMB:  100,
+	}
+
+	tests := []struct {
+		name      string
+		cfg       func(c config.FileLogConfig) config.FileLogConfig
+		freeSpace func(string) (uint64, error)
+		guarded   bool
+	}{
+		{
+			name:      "enough space",
+			freeSpace: freeMB(500),
+		},
+		{
+			name:      "exactly at threshold",
+			freeSpace: freeMB(100),
+		},
+		{
+			name:      "below threshold",
+			freeSpace: freeMB(99),
+			guarded:   true,
+		},
+		{
+			name: "disabled",
+			cfg: func(c config.FileLogConfig) config.FileLogConfig {
+				c.MinFreeMB = 0
+				return c
+			},
+			freeSpace: freeMB(1),
+		},
+		{
+			name: "free space unknown",
+			freeSpace: func(string) (uint64, error) {
+				return 0, errors.New("access denied")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			if tt.cfg != nil {
+				cfg = tt.cfg(cfg)
+			}
+
+			got, guard := guardDiskSpace(cfg, "logs", tt.freeSpace)
+			if !tt.guarded {
+				assert.Nil(t, guard)
+				assert.Equal(t, cfg, got)
+				return
+			}
+
+			require.NotNil(t, guard)
+			assert.Equal(t, uint64(99), guard.FreeMB)
+			assert.Equal(t, 100, guard.MinFreeMB)
+			assert.True(t, got.Compress)
+			assert.Equal(t, lowDiskMaxBackups, got.MaxBackups)
+			assert.Equal(t, lowDiskMaxAgeDays, got.MaxAgeDays)
+			assert.Equal(t, cfg.MaxSizeMB, got.MaxSizeMB)
+		})
+	}
+}
+
+func TestGuardDiskSpace_UnlimitedRetentionIsCapped(t *testing.T) {
+	cfg := config.FileLogConfig{MinFreeMB: 100}
+
+	got, guard := guardDiskSpace(cfg, "logs", freeMB(10))
+	require.NotNil(t, guard)
+	assert.Equal(t, lowDiskMaxBackups, got.MaxBackups)
+	assert.Equal(t, lowDiskMaxAgeDays, got.MaxAgeDays)
+}
+
+func TestNewFileWriter_AppliesGuard(t *testing.T) {
+	cfg := config.FileLogConfig{
+		Path:       filepath.Join(t.TempDir(), "logs", "watchman.log"),
+		MaxBackups: 5,
+		MaxAgeDays: 30,
+		MinFreeMB:  100,
+	}
+
+	var checked string
+	w, guard, err := newFileWriter(cfg, func(dir string) (uint64, error) {
+		checked = dir
+		return 0, nil
+	})
+	require.NoError(t, err)
+	require.NotNil(t, guard)
+	assert.Equal(t, filepath.Dir(cfg.Path), checked)
+
+	gw, ok := w.(*guardedWriter)
+	require.True(t, ok)
+	lj := gw.file
+	assert.True(t, lj.Compress)
+	assert.Equal(t, lowDiskMaxBackups, lj.MaxBackups)
+	assert.Equal(t, lowDiskMaxAgeDays, lj.MaxAge)
+}
+
+func TestNewFileWriter_GuardsRotation(t *testing.T) {
+	cfg := config.FileLogConfig{
+		Path:       filepath.Join(t.TempDir(), "watchman.log"),
+		MaxSizeMB:  1,
+		MaxBackups: 5,
+		MaxAgeDays: 30,
+		MinFreeMB:  100,
+	}
+
+	free, checks := uint64(500), 0
+	w, guard, err := newFileWriter(cfg, func(string) (uint64, error) {
+		checks++
+		return free * 1024 * 1024, nil
+	})
+	require.NoError(t, err)
+	assert.Nil(t, guard)
+	gw := w.(*guardedWriter)
+	assert.Equal(t, 5, gw.file.MaxBackups)
+
+	// Writes that do not rotate do not check the disk
+	line := bytes.Repeat([]byte("x"), 600*1024)
+	_, err = w.Write(line)
+	require.NoError(t, err)
+	assert.Equal(t, 1, checks)
+
+	// The volume fills up before the file rotates
+	free = 10
+	_, err = w.Write(line)
+	require.NoError(t, err)
+	assert.Equal(t, 2, checks)
+	assert.True(t, gw.file.Compress)
+	assert.Equal(t, lowDiskMaxBackups, gw.file.MaxBackups)
+	assert.Equal(t, lowDiskMaxAgeDays, gw.file.MaxAge)
+
+	// The full file is rotated and compressed in the background
+	assert.Eventually(t, func() bool {
+		backups, _ := filepath.Glob(filepath.Join(filepath.Dir(cfg.Path), "watchman-*.log*"))
+		return len(backups) == 1 && filepath.Ext(backups[0]) == ".gz"
+	}, 5*time.Second, 10*time.Millisecond, "the full file was rotated and compressed")
+
+	// Retention is restored once space is freed
+	free = 500
+	_, err = w.Write(line)
+	require.NoError(t, err)
+	assert.Equal(t, 3, checks)
+	assert.Equal(t, 5, gw.file.MaxBackups)
+	require.NoError(t, gw.file.Close())
+}
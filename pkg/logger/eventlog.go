@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// eventID is the Event Log event ID used for all Watchman entries.
+const eventID = 1
+
+// Reopen backoff bounds. The delay doubles after each failed reopen.
+const (
+	minReopenBackoff = time.Second
+	maxReopenBackoff = 5 * time.Minute
+)
+
+// eventSink is an open Windows Event Log handle.
+type eventSink interface {
+	Info(eid uint32, msg string) error
+	Warning(eid uint32, msg string) error
+	Error(eid uint32, msg string) error
+	Close() error
+}
+
+// eventLogWriter writes info and higher log entries to the Windows Event Log.
+// When a write fails, for example after the source was deleted or the
+// Event Log service restarted, it re-registers the source and reopens the
+// handle, backing off between failed attempts. Entries that cannot be
+// written are dropped so the other log outputs are never blocked.
+type eventLogWriter struct {
+	source string
+	open   func(source string) (eventSink, error)
+	now    func() time.Time
+
+	mu       sync.Mutex
+	sink     eventSink
+	failures int
+	retryAt  time.Time
+}
+
+// newEventLogWriter creates an Event Log writer for source. The handle is opened on first write.
+func newEventLogWriter(source string) *eventLogWriter {
+	return &eventLogWriter{
+		source: source,
+		open:   openEventLog,
+		now:    time.Now,
+	}
+}
+
+// Write implements io.Writer for entries without a level.
+func (w *eventLogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter.
+// It always reports success; failures only trigger a reopen.
+func (w *eventLogWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < zerolog.InfoLevel {
+		return len(p), nil
+	}
+	msg := strings.TrimSpace(string(p))
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.sink == nil && !w.reopen() {
+		return len(p), nil
+	}
+	if err := report(w.sink, level, msg); err == nil {
+		return len(p), nil
+	}
+
+	// The handle is stale; reopen it and retry once
+	_ = w.sink.Close()
+	w.sink = nil
+	if w.reopen() {
+		_ = report(w.sink, level, msg)
+	}
+	return len(p), nil
+}
+
+// Close closes the Event Log handle.
+func (w *eventLogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.sink == nil {
+		return nil
+	}
+	err := w.sink.Close()
+	w.sink = nil
+	return err
+}
+
+// reopen opens the Event Log handle unless a previous failure is still backing off.
+// It must be called with w.mu held.
+func (w *eventLogWriter) reopen() bool {
+	now := w.now()
+	if now.Before(w.retryAt) {
+		return false
+	}
+
+	sink, err := w.open(w.source)
+	if err != nil {
+		w.failures++
+		w.retryAt = now.Add(reopenBackoff(w.failures))
+		return false
+	}
+
+	w.sink = sink
+	w.failures = 0
+	w.retryAt = time.Time{}
+	return true
+}
+
+// reopenBackoff returns the delay after the given number of consecutive failed reopens.
+func reopenBackoff(failures int) time.Duration {
+	backoff := minReopenBackoff
+	for i := 1; i < failures && backoff < maxReopenBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxReopenBackoff)
+}
+
+// report writes msg to sink with the Event Log type matching level.
+func report(sink eventSink, level zerolog.Level, msg string) error {
+	switch {
+	case level == zerolog.NoLevel:
+		return sink.Info(eventID, msg)
+	case level >= zerolog.ErrorLevel:
+		return sink.Error(eventID, msg)
+	case level == zerolog.WarnLevel:
+		return sink.Warning(eventID, msg)
+	default:
+		return sink.Info(eventID, msg)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package logger
+
+import "errors"
+
+// openEventLog reports that the Windows Event Log is unavailable on this platform.
+func openEventLog(source string) (eventSink, error) {
+	return nil, errors.New("event log is only supported on Windows")
+}
@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSink records entries and fails writes while broken is set.
+type fakeSink struct {
+	entries []string
+	broken  bool
+	closed  bool
+}
+
+func (s *fakeSink) write(kind, msg string) error {
+	if s.broken {
+		return errors.New("the handle is invalid")
+	}
+	s.entries = append(s.entries, kind+":"+msg)
+	return nil
+}
+
+func (s *fakeSink) Info(_ uint32, msg string) error    { return s.write("info", msg) }
+func (s *fakeSink) Warning(_ uint32, msg string) error { return s.write("warning", msg) }
+func (s *fakeSink) Error(_ uint32, msg string) error   { return s.write("error", msg) }
+func (s *fakeSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+// fakeOpener hands out sinks and counts opens.
+type fakeOpener struct {
+	sinks []*fakeSink
+	fail  bool
+	opens int
+}
+
+func (o *fakeOpener) open(string) (eventSink, error) {
+	o.opens++
+	if o.fail {
+		return nil, errors.New("access denied")
+	}
+	s := &fakeSink{}
+	o.sinks = append(o.sinks, s)
+	return s, nil
+}
+
+func newTestEventLogWriter(o *fakeOpener, now *time.Time) *eventLogWriter {
+	w := newEventLogWriter("Watchman")
+	w.open = o.open
+	w.now = func() time.Time { return *now }
+	return w
+}
+
+func TestEventLogWriter_Levels(t *testing.T) {
+	now := time.Now()
+	o := &fakeOpener{}
+	w := newTestEventLogWriter(o, &now)
+
+	for _, level := range []zerolog.Level{zerolog.DebugLevel, zerolog.InfoLevel, zerolog.WarnLevel, zerolog.ErrorLevel, zerolog.NoLevel} {
+		n, err := w.WriteLevel(level, []byte(level.String()+"\n"))
+		require.NoError(t, err)
+		assert.Equal(t, len(level.String())+1, n)
+	}
+
+	require.Len(t, o.sinks, 1)
+	assert.Equal(t, []string{"info:info", "warning:warn", "error:error", "info:"}, o.sinks[0].entries)
+}
+
+func TestEventLogWriter_ReopensOnWriteFailure(t *testing.T) {
+	now := time.Now()
+	o := &fakeOpener{}
+	w := newTestEventLogWriter(o, &now)
+
+	_, _ = w.WriteLevel(zerolog.InfoLevel, []byte("first"))
+	require.Len(t, o.sinks, 1)
+
+	// The source was deleted or the Event Log service restarted
+	o.sinks[0].broken = true
+	_, err := w.WriteLevel(zerolog.WarnLevel, []byte("second"))
+	require.NoError(t, err)
+
+	assert.True(t, o.sinks[0].closed)
+	require.Len(t, o.sinks, 2)
+	assert.Equal(t, []string{"warning:second"}, o.sinks[1].entries)
+}
+
+func TestEventLogWriter_BacksOffAfterFailedReopen(t *testing.T) {
+	now := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	o := &fakeOpener{fail: true}
+	w := newTestEventLogWriter(o, &now)
+
+	_, err := w.WriteLevel(zerolog.InfoLevel, []byte("dropped"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, o.opens)
+
+	// Within the backoff no reopen is attempted
+	now = now.Add(500 * time.Millisecond)
+	_, _ = w.WriteLevel(zerolog.InfoLevel, []byte("dropped"))
+	assert.Equal(t, 1, o.opens)
+
+	// After the backoff it retries, and the next delay doubles
+	now = now.Add(time.Second)
+	_, _ = w.WriteLevel(zerolog.InfoLevel, []byte("dropped"))
+	assert.Equal(t, 2, o.opens)
+	assert.Equal(t, now.Add(2*time.Second), w.retryAt)
+
+	// Once the Event Log is back, writes resume
+	o.fail = false
+	now = now.Add(2 * time.Second)
+	_, _ = w.WriteLevel(zerolog.InfoLevel, []byte("healed"))
+	assert.Equal(t, 3, o.opens)
+	require.Len(t, o.sinks, 1)
+	assert.Equal(t, []string{"info:healed"}, o.sinks[0].entries)
+	assert.Zero(t, w.failures)
+}
+
+func TestReopenBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, reopenBackoff(1))
+	assert.Equal(t, 2*time.Second, reopenBackoff(2))
+	assert.Equal(t, 8*time.Second, reopenBackoff(4))
+	assert.Equal(t, maxReopenBackoff, reopenBackoff(20))
+}
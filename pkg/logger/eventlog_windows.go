@@ -0,0 +1,22 @@
+//go:build windows
+
+package logger
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// openEventLog registers source, if needed, and opens an Event Log handle for it.
+// Registration fails harmlessly when the source already exists or the
+// process lacks rights to create it.
+func openEventLog(source string) (eventSink, error) {
+	_ = eventlog.InstallAsEventCreate(source, eventlog.Error|eventlog.Warning|eventlog.Info)
+
+	log, err := eventlog.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+	return log, nil
+}
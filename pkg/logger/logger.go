@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/rs/zerolog"
-	"gopkg.in/natefinch/lumberjack.v2"
 
 	"github.com/hoangtran1411/watchman/internal/config"
 )
@@ -44,44 +43,56 @@ func New(cfg config.LoggingConfig) (*Logger, error) {
 	}
 
 	// File output
+	var guard *diskGuard
 	if cfg.File.Enabled {
-		fileWriter, err := newFileWriter(cfg.File)
+		fileWriter, g, err := newFileWriter(cfg.File, freeDiskSpace)
 		if err != nil {
 			return nil, err
 		}
 		writers = append(writers, fileWriter)
+		guard = g
 	}
 
-	// Create multi-writer
-	multi := io.MultiWriter(writers...)
+	// Windows Event Log output
+	if cfg.EventLog.Enabled {
+		writers = append(writers, newEventLogWriter(cfg.EventLog.Source))
+	}
+
+	// Create multi-writer; level-aware writers receive the entry level
+	multi := zerolog.MultiLevelWriter(writers...)
+	if len(cfg.RedactFields) > 0 {
+		multi = newRedactWriter(multi, cfg.RedactFields)
+	}
 
 	// Create logger
 	logger := zerolog.New(multi).With().Timestamp().Logger()
 
+	if guard != nil {
+		logger.Warn().
+			Uint64("free_mb", guard.FreeMB).
+			Int("min_free_mb", guard.MinFreeMB).
+			Msg("low disk space, log files compressed and retention reduced")
+	}
+
 	return &Logger{
 		Logger:  logger,
 		writers: writers,
 	}, nil
 }
 
-// newFileWriter creates a file writer with rotation.
-func newFileWriter(cfg config.FileLogConfig) (io.Writer, error) {
+// newFileWriter creates a file writer with rotation. Retention is reduced
+// while the log volume is low on space, checked at open and before each
+// rotation; the returned guard is non-nil if it is reduced at open.
+func newFileWriter(cfg config.FileLogConfig, freeSpace func(dir string) (uint64, error)) (io.Writer, *diskGuard, error) {
 	// Ensure log directory exists
 	dir := filepath.Dir(cfg.Path)
 	if err := os.MkdirAll(dir, 0o750); err != nil {
-		return nil, fmt.Errorf("failed to create log directory: %w", err)
+		return nil, nil, fmt.Errorf("failed to create log directory: %w", err)
 	}
 
 	// Create rotating file writer
-	writer := &lumberjack.Logger{
-		Filename:   cfg.Path,
-		MaxSize:    cfg.MaxSizeMB,
-		MaxBackups: cfg.MaxBackups,
-		MaxAge:     cfg.MaxAgeDays,
-		Compress:   cfg.Compress,
-	}
-
-	return writer, nil
+	writer, guard := newGuardedWriter(cfg, dir, freeSpace)
+	return writer, guard, nil
 }
 
 // parseLevel parses log level string to zerolog.Level.
@@ -128,6 +139,14 @@ func (l *Logger) LogCheckResult(serversChecked, serversAvailable, failedJobs int
 		Msg("check completed")
 }
 
+// LogQueryLatency logs the failed jobs query time for a server.
+func (l *Logger) LogQueryLatency(serverName string, latency time.Duration) {
+	l.Debug().
+		Str("server", serverName).
+		Dur("query_latency", latency).
+		Msg("msdb query completed")
+}
+
 // LogServerUnavailable logs a server connection failure.
 func (l *Logger) LogServerUnavailable(serverName string, err error) {
 	l.Warn().
@@ -145,6 +164,26 @@ func (l *Logger) LogFailedJob(serverName, jobName string, failedAt time.Time) {
 		Msg("job failed")
 }
 
+// LogWarnJob logs a job whose status is warn-only and is not notified.
+func (l *Logger) LogWarnJob(serverName, jobName string, status int, finishedAt time.Time) {
+	l.Warn().
+		Str("server", serverName).
+		Str("job", jobName).
+		Int("run_status", status).
+		Time("finished_at", finishedAt).
+		Msg("job finished with warn-only status")
+}
+
+// LogLongRunningJob logs a job still running past its long-running threshold.
+func (l *Logger) LogLongRunningJob(serverName, jobName string, startedAt time.Time, runningFor time.Duration) {
+	l.Warn().
+		Str("server", serverName).
+		Str("job", jobName).
+		Time("started_at", startedAt).
+		Dur("running_for", runningFor).
+		Msg("job running long")
+}
+
 // LogNotificationSent logs a notification being sent.
 func (l *Logger) LogNotificationSent(jobCount int) {
 	l.Info().
@@ -169,6 +208,14 @@ func (l *Logger) LogConfigReload() {
 	l.Info().Msg("configuration reloaded")
 }
 
+// LogUpdateApplied logs the first start after the version changed.
+func (l *Logger) LogUpdateApplied(previousVersion, currentVersion string) {
+	l.Info().
+		Str("previous_version", previousVersion).
+		Str("current_version", currentVersion).
+		Msg("update applied")
+}
+
 // LogUpdateAvailable logs available update.
 func (l *Logger) LogUpdateAvailable(currentVersion, newVersion string) {
 	l.Info().
@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// redactedValue replaces the value of a redacted field.
+var redactedValue = json.RawMessage(`"***"`)
+
+// redactWriter replaces the values of the configured top-level fields of
+// each JSON log entry before passing it on, so every output, including the
+// text console, sees the redacted entry. A zerolog hook cannot do this, as
+// hooks only add fields to an entry.
+type redactWriter struct {
+	next   zerolog.LevelWriter
+	fields []string // lower case
+}
+
+// newRedactWriter returns a writer redacting fields, compared
+// case-insensitively, before writing to next.
+func newRedactWriter(next zerolog.LevelWriter, fields []string) *redactWriter {
+	lower := make([]string, 0, len(fields))
+	for _, field := range fields {
+		lower = append(lower, strings.ToLower(field))
+	}
+	return &redactWriter{next: next, fields: lower}
+}
+
+// Write implements io.Writer for entries without a level.
+func (w *redactWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter. It reports the length of the
+// original entry, since zerolog treats a shorter count as a failed write.
+func (w *redactWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if _, err := w.next.WriteLevel(level, w.redact(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// redact returns the entry with the values of the redacted fields replaced,
+// keeping the field order. Entries that are not a JSON object are returned
+// unchanged.
+func (w *redactWriter) redact(p []byte) []byte {
+	if !w.mayContainField(p) {
+		return p
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(p))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return p
+	}
+	var out bytes.Buffer
+	out.WriteByte('{')
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return p
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return p
+		}
+		if w.redacted(key) {
+			value = redactedValue
+		}
+		if out.Len() > 1 {
+			out.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		out.Write(name)
+		out.WriteByte(':')
+		out.Write(value)
+	}
+	out.WriteString("}\n")
+	return out.Bytes()
+}
+
+// mayContainField is a quick check that the entry mentions a redacted
+// field, so most entries are passed on without being parsed.
+func (w *redactWriter) mayContainField(p []byte) bool {
+	lower := bytes.ToLower(p)
+	for _, field := range w.fields {
+		if bytes.Contains(lower, []byte(field)) {
+			return true
+		}
+	}
+	return false
+}
+
+// redacted returns true if the value of field is redacted.
+func (w *redactWriter) redacted(field string) bool {
+	return slices.Contains(w.fields, strings.ToLower(field))
+}
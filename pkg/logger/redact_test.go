@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactWriter_RedactsConfiguredFields(t *testing.T) {
+	var buf bytes.Buffer
+	w := newRedactWriter(zerolog.MultiLevelWriter(&buf), []string{"password", "Host", "username"})
+	log := zerolog.New(w)
+
+	log.Warn().
+		Str("host", "prod-sql01.corp").
+		Str("USERNAME", "watchman_svc").
+		Str("password", "s3cret").
+		Str("server", "PROD").
+		Dict("auth", zerolog.Dict().Str("type", "sql")).
+		Msg("server unavailable")
+
+	line := buf.String()
+	assert.Equal(t, `{"level":"warn","host":"***","USERNAME":"***","password":"***","server":"PROD",`+
+		`"auth":{"type":"sql"},"message":"server unavailable"}`+"\n", line)
+	for _, secret := range []string{"prod-sql01", "watchman_svc", "s3cret"} {
+		assert.NotContains(t, line, secret)
+	}
+}
+
+func TestRedactWriter_LeavesOtherEntriesUnchanged(t *testing.T) {
+	var buf bytes.Buffer
+	w := newRedactWriter(zerolog.MultiLevelWriter(&buf), []string{"password"})
+
+	entry := `{"level":"info","job":"password_rotation","message":"check completed"}` + "\n"
+	n, err := w.Write([]byte(entry))
+	require.NoError(t, err)
+	assert.Equal(t, len(entry), n)
+	assert.Equal(t, entry, buf.String(), "only field names are matched, not values")
+
+	buf.Reset()
+	_, err = w.Write([]byte("password=not json\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "password=not json\n", buf.String())
+}
+
+func TestRedactWriter_TextConsole(t *testing.T) {
+	var buf bytes.Buffer
+	console := zerolog.ConsoleWriter{Out: &buf, NoColor: true}
+	log := zerolog.New(newRedactWriter(zerolog.MultiLevelWriter(console), []string{"token"}))
+
+	log.Info().Str("token", "abc123").Msg("sent")
+
+	assert.Contains(t, buf.String(), "token=***")
+	assert.NotContains(t, buf.String(), "abc123")
+}
+
+// errWriter fails every write.
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestRedactWriter_ReportsWriteErrors(t *testing.T) {
+	w := newRedactWriter(zerolog.MultiLevelWriter(errWriter{}), []string{"password"})
+
+	_, err := w.WriteLevel(zerolog.InfoLevel, []byte(`{"password":"x"}`+"\n"))
+	assert.ErrorContains(t, err, "disk full")
+}
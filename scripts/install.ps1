@@ -186,6 +186,17 @@ function Register-WindowsService {
     }
 }
 
+function Register-ToastAppId {
+    Write-Step "Registering notification app ID..."
+    
+    & "$InstallDir\$ExeName" register-toast --all-users --quiet
+    if ($LASTEXITCODE -eq 0) {
+        Write-Step "Notification app ID registered" "OK"
+    } else {
+        Write-Step "Failed to register notification app ID (toasts may show as PowerShell)" "WARN"
+    }
+}
+
 function Start-WatchmenService {
     Write-Step "Starting service..."
     
@@ -270,6 +281,9 @@ if (-not (Register-WindowsService)) {
     exit 1
 }
 
+# Register toast app ID
+Register-ToastAppId
+
 # Start service
 $started = Start-WatchmenService
 